@@ -12,7 +12,9 @@ https://vuln.go.dev. Requests to the vulnerability database contain only module
 paths with vulnerabilities already known to the database, not code or other
 properties of your program. See https://vuln.go.dev/privacy.html for more.
 Use the -db flag to specify a different database, which must implement the
-specification at https://go.dev/security/vuln/database.
+specification at https://go.dev/security/vuln/database. For scans in
+air-gapped environments, use the -osv-dir flag to read vulnerabilities from a
+local directory of OSV JSON files instead.
 
 Govulncheck looks for vulnerabilities in Go programs using a specific build
 configuration. For analyzing source code, that configuration is the Go version
@@ -44,6 +46,27 @@ print the full call stack for each entry.
 
 To include progress messages and more details on findings, pass '-show verbose'.
 
+To include when vulnerability reports were published and last modified, whom
+they credit, and links to the commits fixing the vulnerabilities, pass
+'-show advisory'.
+
+To present findings by descending severity, pass '-sort-by-severity', and to
+override the severity of vulnerabilities according to your policy, pass a
+comma-separated list of ID=RATING pairs with '-severity-overrides', such as
+'-severity-overrides GO-2021-0113=LOW'. The text output can instead be
+presented as a plan of module upgrades with '-upgrade-plan', or rendered with
+a Go template given by '-template'. To list every advisory considered by the
+scan on standard error, pass '-list-advisories'.
+
+To avoid leaking the layout of your machine, pass a comma-separated list of
+path prefixes, such as your home directory, with '-redact': absolute paths
+of the positions of findings under those prefixes are made relative to them.
+
+To keep the logs of long scans readable, pass '-progress-interval' with a
+duration, such as '-progress-interval 10s', to forward at most one progress
+message per interval. The last of the coalesced messages is forwarded at the
+end of the scan.
+
 To run govulncheck on a compiled binary, pass it the path to the binary file
 with the '-mode binary' flag:
 
@@ -55,6 +78,14 @@ also the main module of the binary. The latter functions are checked for only
 when the precise version of the binary module is known. Govulncheck output on
 binaries omits call stacks, which require source code analysis.
 
+To scan several binaries at once, such as all binaries of a release, pass a
+directory or a quoted glob pattern instead of a file:
+
+	$ govulncheck -mode binary 'dist/*-linux-*'
+
+The output then covers all binaries. Identical findings of several binaries are
+reported once, listing the paths of the binaries in which they were detected.
+
 Govulncheck also supports '-mode extract' on a Go binary for extraction of minimal
 information needed to analyze the binary. This will produce a blob, typically much
 smaller than the binary, that can also be passed to govulncheck as an argument with
@@ -68,16 +99,95 @@ Govulncheck also supports Static Analysis Results Interchange Format (SARIF) out
 format, following the specification at https://www.oasis-open.org/committees/tc_home.php?wg_abbrev=sarif.
 For more details, please see [golang.org/x/vuln/internal/sarif].
 
+The SARIF output is tuned by the flags prefixed with '-sarif-', such as
+'-sarif-called-only' to only report called vulnerabilities, '-sarif-snippets'
+to include the source lines of call sites, and '-sarif-max-bytes' to keep the
+output within a size accepted by its consumer. The output is written to a
+file, which is only replaced once the output is complete, with '-sarif-output',
+or split by the level of results into files with '-sarif-split', whose path
+template contains {level}, as in '-sarif-split govulncheck-{level}.sarif'. For modules in a subdirectory
+of a repository, '-module-root' makes the paths of files in the SARIF and
+GitHub outputs relative to the root of the repository. Non-streaming outputs
+are written without indentation with '-compact'. See 'govulncheck -help' for
+the list of flags.
+
+To combine the SARIF output of several scans, such as those of the modules of a
+repository, into a single log, pass the files to merge with '-sarif-merge':
+
+	$ govulncheck -format sarif -sarif-merge a.sarif b.sarif > all.sarif
+
 Govulncheck supports the Vulnerability EXchange (VEX) output format, following
 the specification at https://github.com/openvex/spec.
+Vulnerabilities that are called are reported as affected, and those that
+are imported but not called as not_affected with the justification
+vulnerable_code_not_in_execute_path. Vulnerable modules detected by a
+module level scan are reported as under_investigation.
 For more details, please see [golang.org/x/vuln/internal/openvex].
 
+Govulncheck can also write findings as comma-separated values with
+'-format csv', one row per finding, for triage in spreadsheets.
+For more details, please see [golang.org/x/vuln/internal/csv].
+
+Govulncheck supports the security profile of SPDX 3.0 with '-format spdx',
+following the specification at https://spdx.github.io/spdx-spec/v3.0.1/.
+Each vulnerable module is a package associated with its vulnerabilities,
+which are assessed as in the VEX output. Fixed versions of modules are
+reported as fixed packages of the vulnerabilities.
+For more details, please see [golang.org/x/vuln/internal/spdx].
+
+For monitoring, '-format metrics' writes the number of vulnerabilities and
+findings, by the level at which they were found, as gauges in the Prometheus
+text exposition format, suitable for the textfile collector of node_exporter.
+For more details, please see [golang.org/x/vuln/internal/metrics].
+
+To unify Go and container scanning, '-format grype' writes the vulnerabilities
+found at the scan level as a document of matches, following the JSON output
+of Grype at https://github.com/anchore/grype. Each vulnerable module is the
+artifact of a match, found by "govulncheck reachability".
+For more details, please see [golang.org/x/vuln/internal/grype].
+
+In GitHub Actions workflows that do not upload SARIF, '-format github' writes
+each finding as an ::error or ::warning workflow command, which GitHub shows as
+an annotation of the code. Vulnerabilities found at the scan level are errors,
+and other ones are warnings. Called vulnerabilities are annotated at the call
+in the scanned code that leads to them.
+For more details, please see [golang.org/x/vuln/internal/github].
+
+For dashboards, '-format sqlite=path' stores the vulnerabilities and the
+findings of the scan in the SQLite database at path, which is created if
+needed. Each scan is added to the database, next to the previous ones.
+For more details, please see [golang.org/x/vuln/internal/sqlite].
+
+To notify other services, '-webhook url' posts the called findings of the scan
+as JSON to url at the end of the scan. The value of the Authorization header of
+the request, if needed, is read from the GOVULNCHECK_WEBHOOK_AUTH environment
+variable.
+
+If the scan fails partway, for instance because a package does not
+type-check, the vulnerabilities found before the failure are still
+reported as partial results. The JSON output then ends with a failure
+message, introduced in protocol version v1.1.0, and the SARIF output
+records the error as a notification of an unsuccessful invocation.
+Warnings about the scan, such as out of date vulnerability data, are
+recorded as warning notifications of the invocation.
+
 # Exit codes
 
 Govulncheck exits successfully (exit code 0) if there are no vulnerabilities,
 and exits unsuccessfully if there are. It also exits successfully if the
-'format -json' ('-json'), '-format sarif', or '-format openvex' is provided,
-regardless of the number of detected vulnerabilities.
+'format -json' ('-json'), '-format sarif', '-format openvex', '-format csv',
+'-format spdx', '-format metrics', '-format grype', '-format github', or
+'-format sqlite=path' is provided, regardless of the number of detected
+vulnerabilities.
+
+The exit status of the text output can be adjusted to the policy of the user.
+With '-fail-on-called', only called vulnerabilities fail the scan, and with
+'-fail-on-newly-fixable', only vulnerabilities that became fixable since the
+scan whose JSON output is given by '-baseline'. The status of failing scans can
+be set by severity with '-severity-exit-codes', such as
+'-severity-exit-codes CRITICAL=4,HIGH=4'. The status is the one of the highest
+listed rating among the vulnerabilities failing the scan, and 3 if none is
+listed. Statuses must be between 3 and 125.
 
 # Limitations
 
@@ -6,6 +6,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -23,10 +24,20 @@ func main() {
 	if err == nil {
 		err = cmd.Wait()
 	}
-	switch err := err.(type) {
-	case nil:
-	case interface{ ExitCode() int }:
-		os.Exit(err.ExitCode())
+	var exit interface {
+		error
+		ExitCode() int
+	}
+	switch {
+	case err == nil:
+	case errors.As(err, &exit):
+		// Errors wrapping an exit code, like those of
+		// failing to post findings to a webhook, are
+		// reported, but keep the exit code.
+		if err != exit {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(exit.ExitCode())
 	default:
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -0,0 +1,99 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package csv implements a govulncheck handler writing findings
+// as comma-separated values, for triage in spreadsheets.
+//
+// The output starts with a header row, followed by a row per finding
+// with the columns of Header. As findings are streamed at increasing
+// levels of precision, there may be several rows for the same
+// vulnerability and module.
+package csv
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/vuln/internal/cvss"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// Header holds the names of the columns of the output.
+var Header = []string{"osv", "aliases", "severity", "module", "package", "symbol", "called", "fixed_version"}
+
+type handler struct {
+	w    *csv.Writer
+	osvs map[string]*osv.Entry
+	// overrides are the severity overrides of the config.
+	overrides map[string]string
+}
+
+// NewHandler returns a handler that writes findings to w as CSV.
+func NewHandler(w io.Writer) *handler {
+	return &handler{
+		w:    csv.NewWriter(w),
+		osvs: make(map[string]*osv.Entry),
+	}
+}
+
+// Config writes the header row.
+func (h *handler) Config(cfg *govulncheck.Config) error {
+	h.overrides = cfg.SeverityOverrides
+	return h.write(Header)
+}
+
+func (h *handler) Progress(progress *govulncheck.Progress) error {
+	return nil
+}
+
+func (h *handler) SBOM(s *govulncheck.SBOM) error {
+	return nil
+}
+
+func (h *handler) OSV(e *osv.Entry) error {
+	h.osvs[e.ID] = e
+	return nil
+}
+
+// Finding writes the row of f.
+func (h *handler) Finding(f *govulncheck.Finding) error {
+	frame := f.Trace[0]
+	var aliases, severity string
+	if e := h.osvs[f.OSV]; e != nil {
+		aliases = strings.Join(e.Aliases, " ")
+		if score, ok := cvss.OverriddenEntryScore(e, h.overrides); ok {
+			severity = cvss.Rating(score)
+		}
+	}
+	var symbol string
+	if frame.Function != "" {
+		symbol = frame.Function
+		if frame.Receiver != "" {
+			symbol = strings.TrimPrefix(frame.Receiver, "*") + "." + symbol
+		}
+	}
+	return h.write([]string{
+		f.OSV,
+		aliases,
+		severity,
+		frame.Module,
+		frame.Package,
+		symbol,
+		strconv.FormatBool(frame.Function != ""),
+		f.FixedVersion,
+	})
+}
+
+// write writes row and flushes it, so that
+// rows are streamed as findings arrive.
+func (h *handler) write(row []string) error {
+	if err := h.w.Write(row); err != nil {
+		return err
+	}
+	h.w.Flush()
+	return h.w.Error()
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	if err := h.Config(&govulncheck.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "osv,aliases,severity,module,package,symbol,called,fixed_version\n" {
+		t.Errorf("want header written on Config; got %q", got)
+	}
+	for _, e := range []*osv.Entry{
+		{
+			ID:       "GO-0000-0001",
+			Aliases:  []string{"CVE-0000-0001", "GHSA-xxxx-yyyy-zzzz"},
+			Severity: []osv.Severity{{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}},
+		},
+		{ID: "GO-0000-0002"},
+	} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []*govulncheck.Finding{
+		{OSV: "GO-0000-0001", FixedVersion: "v1.2.0", Trace: []*govulncheck.Frame{{Module: "example.com/m", Version: "v1.0.0"}}},
+		{OSV: "GO-0000-0001", FixedVersion: "v1.2.0", Trace: []*govulncheck.Frame{{Module: "example.com/m", Version: "v1.0.0", Package: "example.com/m/p", Function: "F", Receiver: "*T"}}},
+		// Fields with commas and quotes are escaped.
+		{OSV: "GO-0000-0002", Trace: []*govulncheck.Frame{{Module: `example.com/"odd",module`, Package: "example.com/q"}}},
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		Header,
+		{"GO-0000-0001", "CVE-0000-0001 GHSA-xxxx-yyyy-zzzz", "CRITICAL", "example.com/m", "", "", "false", "v1.2.0"},
+		{"GO-0000-0001", "CVE-0000-0001 GHSA-xxxx-yyyy-zzzz", "CRITICAL", "example.com/m", "example.com/m/p", "T.F", "true", "v1.2.0"},
+		{"GO-0000-0002", "", "", `example.com/"odd",module`, "example.com/q", "", "false", ""},
+	}
+	if diff := cmp.Diff(want, rows); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cvss computes severity scores of vulnerabilities from
+// Common Vulnerability Scoring System (CVSS) vectors.
+//
+// Only the base score of CVSS v3.0 and v3.1 vectors is supported.
+// See https://www.first.org/cvss/v3.1/specification-document.
+package cvss
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/vuln/internal/osv"
+)
+
+// Severity ratings of CVSS scores.
+const (
+	RatingNone     = "NONE"
+	RatingLow      = "LOW"
+	RatingMedium   = "MEDIUM"
+	RatingHigh     = "HIGH"
+	RatingCritical = "CRITICAL"
+)
+
+// weights of base metric values.
+var weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// Score computes the base score of a CVSS v3 vector, for
+// instance "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H".
+func Score(vector string) (float64, error) {
+	parts := strings.Split(vector, "/")
+	if len(parts) == 0 || (parts[0] != "CVSS:3.0" && parts[0] != "CVSS:3.1") {
+		return 0, fmt.Errorf("unsupported CVSS vector %q", vector)
+	}
+
+	metrics := make(map[string]string)
+	for _, p := range parts[1:] {
+		m, v, ok := strings.Cut(p, ":")
+		if !ok {
+			return 0, fmt.Errorf("invalid CVSS metric %q in %q", p, vector)
+		}
+		metrics[m] = v
+	}
+
+	w := make(map[string]float64)
+	for m, vals := range weights {
+		val, ok := vals[metrics[m]]
+		if !ok {
+			return 0, fmt.Errorf("missing or invalid CVSS metric %s in %q", m, vector)
+		}
+		w[m] = val
+	}
+	var changed bool
+	switch metrics["S"] {
+	case "U":
+	case "C":
+		changed = true
+		// Privileges required are weighted higher
+		// when the scope is changed.
+		switch metrics["PR"] {
+		case "L":
+			w["PR"] = 0.68
+		case "H":
+			w["PR"] = 0.5
+		}
+	default:
+		return 0, fmt.Errorf("missing or invalid CVSS metric S in %q", vector)
+	}
+
+	iss := 1 - (1-w["C"])*(1-w["I"])*(1-w["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, nil
+	}
+	exploitability := 8.22 * w["AV"] * w["AC"] * w["PR"] * w["UI"]
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// roundUp returns the smallest number, specified to one decimal
+// place, that is equal to or higher than x. It follows the CVSS
+// v3.1 definition that avoids floating point inaccuracies.
+func roundUp(x float64) float64 {
+	i := int(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
+
+// Rating returns the qualitative severity rating of score.
+func Rating(score float64) string {
+	switch {
+	case score >= 9:
+		return RatingCritical
+	case score >= 7:
+		return RatingHigh
+	case score >= 4:
+		return RatingMedium
+	case score > 0:
+		return RatingLow
+	default:
+		return RatingNone
+	}
+}
+
+// EntryScore returns the highest base score among the CVSS v3
+// severities of e. It returns false if e has no valid CVSS v3
+// severity.
+func EntryScore(e *osv.Entry) (float64, bool) {
+	var score float64
+	var found bool
+	for _, s := range e.Severity {
+		if s.Type != osv.SeverityTypeCVSSV3 {
+			continue
+		}
+		sc, err := Score(s.Score)
+		if err != nil {
+			continue
+		}
+		if !found || sc > score {
+			score = sc
+		}
+		found = true
+	}
+	return score, found
+}
+
+// ratingScores are the highest scores of each rating.
+var ratingScores = map[string]float64{
+	RatingNone:     0,
+	RatingLow:      3.9,
+	RatingMedium:   6.9,
+	RatingHigh:     8.9,
+	RatingCritical: 10,
+}
+
+// OverriddenRating returns the rating of e in overrides, which map
+// OSV IDs and aliases to ratings, if any. Ratings are case-insensitive,
+// and unknown ratings are ignored.
+func OverriddenRating(e *osv.Entry, overrides map[string]string) (string, bool) {
+	for _, id := range append([]string{e.ID}, e.Aliases...) {
+		if r, ok := overrides[id]; ok {
+			r = strings.ToUpper(r)
+			if _, ok := ratingScores[r]; ok {
+				return r, true
+			}
+		}
+	}
+	return "", false
+}
+
+// OverriddenEntryScore returns the score of e as EntryScore does,
+// unless overrides rate e, see OverriddenRating, in which case it
+// returns the highest score of that rating.
+func OverriddenEntryScore(e *osv.Entry, overrides map[string]string) (float64, bool) {
+	if r, ok := OverriddenRating(e, overrides); ok {
+		return ratingScores[r], true
+	}
+	return EntryScore(e)
+}
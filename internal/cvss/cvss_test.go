@@ -0,0 +1,105 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cvss
+
+import (
+	"testing"
+
+	"golang.org/x/vuln/internal/osv"
+)
+
+func TestScore(t *testing.T) {
+	for _, tc := range []struct {
+		vector string
+		want   float64
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", 7.5},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:R/S:C/C:L/I:L/A:N", 5.4},
+		{"CVSS:3.0/AV:L/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N", 1.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0},
+	} {
+		got, err := Score(tc.vector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: want %v; got %v", tc.vector, tc.want, got)
+		}
+	}
+}
+
+func TestScoreInvalid(t *testing.T) {
+	for _, vector := range []string{
+		"",
+		"CVSS:2.0/AV:N/AC:L/Au:N/C:P/I:P/A:P",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/C:H/I:H/A:H", // no scope
+		"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+	} {
+		if _, err := Score(vector); err == nil {
+			t.Errorf("%q: want error; got none", vector)
+		}
+	}
+}
+
+func TestRating(t *testing.T) {
+	for _, tc := range []struct {
+		score float64
+		want  string
+	}{
+		{0, RatingNone},
+		{0.1, RatingLow},
+		{3.9, RatingLow},
+		{4.0, RatingMedium},
+		{7.0, RatingHigh},
+		{8.9, RatingHigh},
+		{9.0, RatingCritical},
+		{10, RatingCritical},
+	} {
+		if got := Rating(tc.score); got != tc.want {
+			t.Errorf("%v: want %s; got %s", tc.score, tc.want, got)
+		}
+	}
+}
+
+func TestEntryScore(t *testing.T) {
+	e := &osv.Entry{Severity: []osv.Severity{
+		{Type: "CVSS_V4", Score: "CVSS:4.0/AV:N"},
+		{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.1/AV:N/AC:L/PR:L/UI:R/S:C/C:L/I:L/A:N"},
+		{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H"},
+	}}
+	if got, ok := EntryScore(e); !ok || got != 7.5 {
+		t.Errorf("want 7.5; got %v (%t)", got, ok)
+	}
+	if _, ok := EntryScore(&osv.Entry{}); ok {
+		t.Error("want no score for entry without severity")
+	}
+}
+
+func TestOverriddenEntryScore(t *testing.T) {
+	e := &osv.Entry{
+		ID:       "GO-0000-0001",
+		Aliases:  []string{"CVE-0000-0001"},
+		Severity: []osv.Severity{{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.1/AV:N/AC:L/PR:L/UI:R/S:C/C:L/I:L/A:N"}},
+	}
+	for _, tc := range []struct {
+		overrides map[string]string
+		want      float64
+	}{
+		{nil, 5.4},
+		{map[string]string{"GO-0000-0001": "critical"}, 10},
+		{map[string]string{"CVE-0000-0001": RatingLow}, 3.9},
+		{map[string]string{"CVE-0000-0001": "unknown"}, 5.4},
+		{map[string]string{"GO-0000-0002": RatingHigh}, 5.4},
+	} {
+		if got, ok := OverriddenEntryScore(e, tc.overrides); !ok || got != tc.want {
+			t.Errorf("%v: want %v; got %v (%t)", tc.overrides, tc.want, got, ok)
+		}
+	}
+	if got, ok := OverriddenEntryScore(&osv.Entry{ID: "GO-0000-0002"}, map[string]string{"GO-0000-0002": RatingHigh}); !ok || Rating(got) != RatingHigh {
+		t.Errorf("want a high score for an entry without severity; got %v (%t)", got, ok)
+	}
+}
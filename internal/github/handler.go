@@ -0,0 +1,228 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package github implements a govulncheck handler writing findings as
+// GitHub Actions workflow commands, which annotate the code of pull
+// requests and workflow runs without uploading SARIF.
+//
+// Each finding at its most precise level yields a command of the form
+//
+//	::error file=FILE,line=LINE,col=COL,title=OSV::MESSAGE
+//
+// Vulnerabilities found at the scan level, such as called ones in a
+// symbol level scan, are errors, and other ones, such as imported but
+// not called ones, are warnings. Called findings are located at the
+// call of their trace in the scanned code, and other source findings
+// at the go.mod file, on the require directive of their module if
+// known, see Finding.GoModLocation. Files are relative to the module
+// root, see Config.ModuleRoot, which is the root of the repository
+// when the module is in a subdirectory. Binary findings have no
+// location.
+package github
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/sarif"
+)
+
+// Commands of annotations.
+const (
+	ErrorCommand   = "error"
+	WarningCommand = "warning"
+)
+
+type handler struct {
+	w    io.Writer
+	cfg  *govulncheck.Config
+	osvs map[string]*osv.Entry
+	// rootPrefix is the path of the module directory
+	// relative to the module root of the config, if any.
+	rootPrefix string
+	// findings contains same-level findings for an
+	// OSV at the most precise level of granularity
+	// available.
+	findings map[string][]*govulncheck.Finding
+}
+
+// NewHandler returns a handler that writes findings to w as
+// GitHub Actions workflow commands once all of them are known.
+func NewHandler(w io.Writer) *handler {
+	return &handler{
+		w:        w,
+		osvs:     make(map[string]*osv.Entry),
+		findings: make(map[string][]*govulncheck.Finding),
+	}
+}
+
+// Config records cfg. It fails if cfg has a module root
+// that does not contain the module directory.
+func (h *handler) Config(cfg *govulncheck.Config) error {
+	h.cfg = cfg
+	if cfg.ModuleRoot == "" {
+		return nil
+	}
+	prefix, err := govulncheck.RootPrefix(cfg.ModuleRoot, cfg.SarifURIRoots[sarif.SrcRootID])
+	if err != nil {
+		return err
+	}
+	h.rootPrefix = prefix
+	return nil
+}
+
+func (h *handler) Progress(progress *govulncheck.Progress) error {
+	return nil
+}
+
+func (h *handler) SBOM(s *govulncheck.SBOM) error {
+	return nil
+}
+
+func (h *handler) OSV(e *osv.Entry) error {
+	h.osvs[e.ID] = e
+	return nil
+}
+
+// Finding keeps f if it is at least as precise
+// as the findings of its OSV kept so far.
+func (h *handler) Finding(f *govulncheck.Finding) error {
+	h.findings[f.OSV] = govulncheck.MostPrecise(h.findings[f.OSV], f)
+	return nil
+}
+
+// Streaming returns false as only the findings at the most
+// precise level of each vulnerability are annotated, which
+// are known once all findings are.
+func (h *handler) Streaming() bool {
+	return false
+}
+
+// Flush writes the annotations of the findings to w, ordered
+// by OSV ID. Identical annotations are written once.
+func (h *handler) Flush() error {
+	var ids []string
+	for id := range h.findings {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	seen := make(map[string]bool)
+	var b strings.Builder
+	for _, id := range ids {
+		for _, f := range h.findings[id] {
+			a := annotation(h, f)
+			if !seen[a] {
+				seen[a] = true
+				b.WriteString(a)
+				b.WriteString("\n")
+			}
+		}
+	}
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// annotation returns the workflow command annotating f.
+func annotation(h *handler, f *govulncheck.Finding) string {
+	cmd := WarningCommand
+	if govulncheck.Level(f).Precision() >= h.cfg.ScanLevel.Precision() {
+		cmd = ErrorCommand
+	}
+	var props []string
+	if file, pos := location(h, f); file != "" {
+		props = append(props, "file="+escapeProperty(file))
+		if pos != nil && pos.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", pos.Line))
+			if pos.Column > 0 {
+				props = append(props, fmt.Sprintf("col=%d", pos.Column))
+			}
+		}
+	}
+	props = append(props, "title="+escapeProperty(f.OSV))
+	return fmt.Sprintf("::%s %s::%s", cmd, strings.Join(props, ","), escapeData(message(h.osvs[f.OSV], f)))
+}
+
+// location returns the file and position annotated for f: the position
+// of the call of the entry point of called findings, and the require
+// directive of the module, in go.mod, otherwise. The go.mod file has no
+// position if the require directive is unknown. There is no location in
+// binary scans.
+func location(h *handler, f *govulncheck.Finding) (string, *govulncheck.Position) {
+	if h.cfg.ScanMode == govulncheck.ScanModeBinary || len(f.Trace) == 0 {
+		return "", nil
+	}
+	if govulncheck.IsCalled(f) {
+		if pos := f.Trace[len(f.Trace)-1].Position; pos != nil && pos.Filename != "" {
+			return h.path(pos.Filename), pos
+		}
+	}
+	if pos := f.GoModLocation; pos != nil && pos.Filename != "" {
+		return h.path(pos.Filename), pos
+	}
+	return h.path("go.mod"), nil
+}
+
+// path returns file, a path relative to the module directory, relative
+// to the module root instead, if any. Files outside of the module root
+// keep their paths.
+func (h *handler) path(file string) string {
+	p, _ := govulncheck.RootRelative(h.rootPrefix, filepath.ToSlash(file))
+	return p
+}
+
+// message returns the message of the annotation of f, of the
+// vulnerability e, which describes the vulnerability and its fix.
+func message(e *osv.Entry, f *govulncheck.Finding) string {
+	var b strings.Builder
+	fr := f.Trace[0]
+	switch {
+	case govulncheck.IsCalled(f):
+		sym := fr.Function
+		if fr.Receiver != "" {
+			sym = strings.TrimPrefix(fr.Receiver, "*") + "." + sym
+		}
+		fmt.Fprintf(&b, "Your code calls %s.%s, which is vulnerable to %s", fr.Package, sym, f.OSV)
+	case govulncheck.IsImported(f):
+		fmt.Fprintf(&b, "Your code imports %s, which is vulnerable to %s", fr.Package, f.OSV)
+	default:
+		fmt.Fprintf(&b, "Your code depends on %s, which is vulnerable to %s", moduleVersion(fr.Module, fr.Version), f.OSV)
+	}
+	if e != nil && e.Summary != "" {
+		fmt.Fprintf(&b, ": %s", e.Summary)
+	}
+	b.WriteString(".")
+	if f.FixedVersion != "" {
+		fmt.Fprintf(&b, " Fixed in %s.", moduleVersion(fr.Module, f.FixedVersion))
+	} else {
+		b.WriteString(" No fixed version is available.")
+	}
+	if e != nil {
+		fmt.Fprintf(&b, " See https://pkg.go.dev/vuln/%s.", e.ID)
+	}
+	return b.String()
+}
+
+// moduleVersion returns path@version, or path if version is unknown.
+func moduleVersion(path, version string) string {
+	if version == "" {
+		return path
+	}
+	return path + "@" + version
+}
+
+// escapeData escapes the message of a workflow command.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes the value of a property of a workflow command.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
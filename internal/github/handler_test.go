@@ -0,0 +1,125 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package github
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/sarif"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol, ScanMode: govulncheck.ScanModeSource}); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []*osv.Entry{
+		{ID: "GO-2021-0265", Summary: "Panic in gjson"},
+		{ID: "GO-2021-0001", Summary: "100% broken, on purpose\nreally"},
+	} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []*govulncheck.Finding{
+		// Less precise findings are superseded.
+		{OSV: "GO-2021-0265", FixedVersion: "v1.9.3", Trace: []*govulncheck.Frame{{Module: "github.com/tidwall/gjson", Version: "v1.6.5"}}},
+		{OSV: "GO-2021-0265", FixedVersion: "v1.9.3", Trace: []*govulncheck.Frame{
+			{Module: "github.com/tidwall/gjson", Version: "v1.6.5", Package: "github.com/tidwall/gjson", Function: "Get"},
+			{
+				Module:   "example.com/main",
+				Package:  "example.com/main",
+				Function: "main",
+				Position: &govulncheck.Position{Filename: "main.go", Line: 10, Column: 5},
+			},
+		}},
+		// Vulnerabilities imported but not called
+		// are warnings of a symbol level scan.
+		{OSV: "GO-2021-0001", Trace: []*govulncheck.Frame{{Module: "example.com/m", Version: "v1.0.0", Package: "example.com/m/p"}}},
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "::warning file=go.mod,title=GO-2021-0001::Your code imports example.com/m/p, which is vulnerable to GO-2021-0001: 100%25 broken, on purpose%0Areally. No fixed version is available. See https://pkg.go.dev/vuln/GO-2021-0001.\n" +
+		"::error file=main.go,line=10,col=5,title=GO-2021-0265::Your code calls github.com/tidwall/gjson.Get, which is vulnerable to GO-2021-0265: Panic in gjson. Fixed in github.com/tidwall/gjson@v1.9.3. See https://pkg.go.dev/vuln/GO-2021-0265.\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestEscapeProperty(t *testing.T) {
+	if got, want := escapeProperty("a:b,c%d\n"), "a%3Ab%2Cc%25d%0A"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestModuleRoot(t *testing.T) {
+	root := t.TempDir()
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	cfg := &govulncheck.Config{
+		ScanLevel:     govulncheck.ScanLevelSymbol,
+		ScanMode:      govulncheck.ScanModeSource,
+		ModuleRoot:    root,
+		SarifURIRoots: map[string]string{sarif.SrcRootID: filepath.Join(root, "sub")},
+	}
+	if err := h.Config(cfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []*govulncheck.Finding{
+		{
+			OSV:           "GO-0000-0001",
+			GoModLocation: &govulncheck.Position{Filename: "go.mod", Line: 7, Column: 2},
+			Trace:         []*govulncheck.Frame{{Module: "example.com/m", Version: "v1.0.0", Package: "example.com/m/p"}},
+		},
+		{
+			OSV: "GO-0000-0002",
+			Trace: []*govulncheck.Frame{
+				{Module: "example.com/m", Version: "v1.0.0", Package: "example.com/m/p", Function: "F"},
+				{Module: "example.com/main", Package: "example.com/main", Function: "main", Position: &govulncheck.Position{Filename: "cmd/main.go", Line: 3, Column: 4}},
+			},
+		},
+		// The require directive is unknown.
+		{OSV: "GO-0000-0003", Trace: []*govulncheck.Frame{{Module: "example.com/n", Version: "v1.0.0"}}},
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		props, _, _ := strings.Cut(line, ",title=")
+		got = append(got, props)
+	}
+	want := []string{
+		"::warning file=sub/go.mod,line=7,col=2",
+		"::error file=sub/cmd/main.go,line=3,col=4",
+		"::warning file=sub/go.mod",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The module directory must be in the module root.
+	cfg.SarifURIRoots[sarif.SrcRootID] = t.TempDir()
+	if err := NewHandler(&buf).Config(cfg); err == nil {
+		t.Error("want error for a module directory outside of the module root")
+	}
+}
@@ -30,8 +30,19 @@ import (
 )
 
 const (
-	// ProtocolVersion is the current protocol version this file implements
-	ProtocolVersion = "v1.0.0"
+	// ProtocolVersion is the current protocol version this file implements.
+	//
+	// It is reported in the Config message, which is always the first
+	// message of a stream. The version must be bumped whenever the
+	// structure of Message, or any of the types it refers to, changes
+	// in a way that is not backwards compatible, so that clients can
+	// detect format changes across govulncheck releases. Adding new
+	// optional fields is considered backwards compatible, while new
+	// kinds of messages bump the minor version.
+	//
+	// v1.1.0 adds the Failure message, which ends the stream of a scan
+	// that stopped early, so the preceding findings are partial.
+	ProtocolVersion = "v1.1.0"
 )
 
 // Message is an entry in the output stream. It will always have exactly one
@@ -47,6 +58,9 @@ type Message struct {
 	// and the desired scan level.
 	OSV     *osv.Entry `json:"osv,omitempty"`
 	Finding *Finding   `json:"finding,omitempty"`
+	// Failure is emitted at most once, as the last message of the
+	// stream, when the scan stopped because of an error.
+	Failure *Failure `json:"failure,omitempty"`
 }
 
 // Config must occur as the first message of a stream and informs the client
@@ -72,6 +86,18 @@ type Config struct {
 	// LastModified is the last modified time of the data source.
 	DBLastModified *time.Time `json:"db_last_modified,omitempty"`
 
+	// OSVDir is a local directory of OSV JSON files. When set, the
+	// vulnerabilities are read from this directory instead of DB,
+	// which is useful for scans in air-gapped environments.
+	OSVDir string `json:"osv_dir,omitempty"`
+
+	// MaxDataAgeDays, if positive, is the number of days after which
+	// the vulnerability data is considered stale, as scans with stale
+	// data may miss recent vulnerabilities. A warning is reported when
+	// the data, see StaleDataWarning, is older: as a progress message
+	// in JSON and as a warning notification in SARIF.
+	MaxDataAgeDays int `json:"max_data_age_days,omitempty"`
+
 	// GoVersion is the version of Go used for analyzing standard library
 	// vulnerabilities.
 	GoVersion string `json:"go_version,omitempty"`
@@ -84,6 +110,321 @@ type Config struct {
 	// what to do with it. Valid values are source, binary, query,
 	// and extract.
 	ScanMode ScanMode `json:"scan_mode,omitempty"`
+
+	// ExcludeTestOnly instructs govulncheck not to emit call-level
+	// findings that are reachable only from test code.
+	ExcludeTestOnly bool `json:"exclude_test_only,omitempty"`
+
+	// MaxTraceDepth, if positive, limits the traces of findings to
+	// the MaxTraceDepth frames nearest to the vulnerable symbol and
+	// the entry point. The frames in between are replaced by a single
+	// marker frame, whose Function is ElidedFunction.
+	MaxTraceDepth int `json:"max_trace_depth,omitempty"`
+
+	// CollapseStdlib instructs govulncheck to replace each run of
+	// consecutive standard library frames in the traces of findings,
+	// other than the vulnerable symbol, by a single marker frame, whose
+	// Function is "[N stdlib frames]".
+	CollapseStdlib bool `json:"collapse_stdlib,omitempty"`
+
+	// EmbedOSV instructs handlers to include the full OSV entry of
+	// each finding in their output, so that clients do not need to
+	// look it up separately. In JSON, the entry is set as the OSVEntry
+	// of findings.
+	EmbedOSV bool `json:"embed_osv,omitempty"`
+
+	// IncludeGoModLocation instructs govulncheck to set the GoModLocation
+	// of source findings, pointing to the require directive of the
+	// vulnerable module in go.mod.
+	IncludeGoModLocation bool `json:"include_go_mod_location,omitempty"`
+
+	// IncludePathCount instructs govulncheck to set the PathCount of
+	// call-level source findings.
+	IncludePathCount bool `json:"include_path_count,omitempty"`
+
+	// IncludeLatestVersion instructs govulncheck to set the
+	// LatestVersion of findings by looking up the latest version of
+	// vulnerable modules on the first module proxy of GOPROXY, once
+	// per module. The lookup requires network access, unless the proxy
+	// is a file URL, and is skipped if GOPROXY is off or direct, and
+	// for modules matching GONOPROXY or GOPRIVATE.
+	IncludeLatestVersion bool `json:"include_latest_version,omitempty"`
+
+	// IncludeReplaced instructs govulncheck to set the ReplacedModule
+	// and ReplacedVersion of the frames of findings whose module is a
+	// replacement, so that both the required module and its replacement
+	// are reported.
+	IncludeReplaced bool `json:"include_replaced,omitempty"`
+
+	// IncludeTools instructs govulncheck to also analyze the modules
+	// providing the tools listed in the tool directives of go.mod in
+	// source mode. Findings for modules only needed by tools are
+	// reported at module level, with BuildTime set.
+	IncludeTools bool `json:"include_tools,omitempty"`
+
+	// GoFixedVersions instructs govulncheck to report the FixedVersion
+	// of findings on the standard library as the earliest Go release
+	// containing the fix, such as go1.21.5, rather than as a module
+	// version, such as v1.21.5.
+	GoFixedVersions bool `json:"go_fixed_versions,omitempty"`
+
+	// IncludeModulePrefixes and ExcludeModulePrefixes scope the
+	// findings to vulnerable modules whose path has one of the
+	// include prefixes, if any, and none of the exclude prefixes.
+	// Prefixes match whole path elements, so "example.com/a"
+	// matches "example.com/a/b" but not "example.com/ab". Excludes
+	// take precedence over includes.
+	IncludeModulePrefixes []string `json:"include_module_prefixes,omitempty"`
+	ExcludeModulePrefixes []string `json:"exclude_module_prefixes,omitempty"`
+
+	// PackageScanLevels maps package patterns to the scan levels at
+	// which findings for vulnerable packages matching them are reported,
+	// so that, say, security-critical packages are analyzed at symbol
+	// level while others are only reported at module level. Patterns
+	// are package paths in which "..." matches any string, as in
+	// "golang.org/x/crypto/...". The longest matching pattern wins, and
+	// packages matching none are reported at ScanLevel. Levels above
+	// ScanLevel have no effect, as ScanLevel bounds the analysis.
+	PackageScanLevels map[string]ScanLevel `json:"package_scan_levels,omitempty"`
+
+	// MaxFindings, if positive, instructs govulncheck to stop emitting
+	// findings of each level, module, package, and symbol, after the
+	// first MaxFindings ones, which is useful for quick smoke tests.
+	// Findings of each level are emitted in a deterministic order, and
+	// levels are limited separately so that the most precise findings,
+	// which decide the exit status, are always reported if there are
+	// any. A progress message is emitted when findings are dropped.
+	MaxFindings int `json:"max_findings,omitempty"`
+
+	// RemediationDays maps severity ratings, one of "CRITICAL", "HIGH",
+	// "MEDIUM", "LOW", and "NONE", to the number of days within which
+	// findings of vulnerabilities of that severity must be remediated,
+	// according to the policy of the user. The severity is derived from
+	// the CVSS score of OSV entries, subject to SeverityOverrides. If
+	// set, findings of vulnerabilities whose rating is listed have their
+	// RemediateBy deadline set.
+	RemediationDays map[string]int `json:"remediation_days,omitempty"`
+
+	// Baseline, if not empty, is the path of the JSON output of a prior
+	// scan. Findings of vulnerabilities that have a fixed version for
+	// their module, while they had none in the baseline scan, have
+	// NewlyFixable set.
+	Baseline string `json:"baseline,omitempty"`
+
+	// The fields below are not part of the JSON protocol, as they only
+	// affect the presentation of the output, the exit status, or other
+	// effects of the scan. ScannerNameOverride, ScannerVersionOverride,
+	// FindingWorkers, FindingHook, and SarifURIRoots are only set by
+	// programs embedding govulncheck, WebhookAuth is read from the
+	// environment, and the others have command-line flags.
+
+	// ScannerNameOverride and ScannerVersionOverride, if set, take
+	// precedence over ScannerName and ScannerVersion when presenting
+	// the tool producing the output, for instance in SARIF. They are
+	// meant for tools embedding govulncheck that want to brand the
+	// output as their own. ScannerName and ScannerVersion keep on
+	// describing the underlying govulncheck engine.
+	ScannerNameOverride    string `json:"-"`
+	ScannerVersionOverride string `json:"-"`
+
+	// FindingWorkers, if greater than one, is the number of goroutines
+	// delivering findings to handlers that declare themselves safe for
+	// concurrent use, see Concurrent. This speeds up scans with many
+	// findings when the handler does I/O. Other handlers receive the
+	// findings one at a time, in a deterministic order.
+	FindingWorkers int `json:"-"`
+
+	// FindingHook, if not nil, is called on each finding of source and
+	// binary scans before it is emitted to the handler, so that embedders
+	// can enrich findings, say, with Annotations. The hook may modify the
+	// finding or return another one, and returning nil drops it.
+	//
+	// The hook is called from a single goroutine, in the order in which
+	// findings are emitted: module, package, and then symbol findings.
+	// It sees findings once govulncheck has set all of their fields, and
+	// before MaxFindings applies, so that dropped findings do not count
+	// towards the limit.
+	FindingHook func(*Finding) *Finding `json:"-"`
+
+	// CompactOutput instructs non-streaming output formats, such
+	// as SARIF, to be written without indentation.
+	CompactOutput bool `json:"-"`
+
+	// MaxSarifBytes, if positive, limits the size of the SARIF output,
+	// as some consumers reject large files. When the output is larger,
+	// the results of the least important vulnerabilities, those only
+	// required, then those only imported, are omitted until it fits,
+	// and the number of omitted vulnerabilities is recorded in a
+	// warning notification.
+	MaxSarifBytes int `json:"-"`
+
+	// SarifCalledOnly instructs the SARIF output to only contain
+	// results for vulnerabilities that are called, omitting module
+	// and package level results. Other output formats are complete.
+	SarifCalledOnly bool `json:"-"`
+
+	// FailOnImport instructs the SARIF output to report package level
+	// results at error level even for symbol level scans, where results
+	// for vulnerable packages that are imported but not called are
+	// otherwise warnings.
+	FailOnImport bool `json:"-"`
+
+	// ResultPerStack instructs the SARIF output to report each call
+	// stack of a called vulnerability as its own result, sharing the
+	// rule of the vulnerability, so that each vulnerable path can be
+	// tracked as its own alert. The results have a fingerprint derived
+	// from the functions of their stack. By default, a vulnerability
+	// has a single result holding all of its call stacks.
+	ResultPerStack bool `json:"-"`
+
+	// SarifMaxListed, if positive, is the number of vulnerable packages
+	// or modules listed in the messages of SARIF results, which then end
+	// with "and N more" for the others. It defaults to 5, and negative
+	// values list all of them.
+	SarifMaxListed int `json:"-"`
+
+	// PreferAliasPrefix, if not empty, instructs the SARIF output to
+	// identify rules, and hence results, by the first alias of their
+	// OSV entry with this prefix, such as "CVE-", for organizations
+	// that standardize on those IDs. Rules of entries without such an
+	// alias, or sharing it with other entries, keep their OSV ID, so
+	// that rules are not ambiguous. The OSV ID of rules and results
+	// is then kept as their osvId property.
+	PreferAliasPrefix string `json:"-"`
+
+	// PackageWeights maps package paths to the criticality of the
+	// package, so that SARIF results of findings in critical packages
+	// are ranked above others regardless of their CVSS scores. The
+	// weight of a finding is the highest weight of the packages of its
+	// trace, and 1.0 if none of them are listed. Weights scale the rank
+	// of results, which is then normalized by the highest weight so as
+	// to stay between 0 and 100. Weights must be positive.
+	PackageWeights map[string]float64 `json:"-"`
+
+	// SortStacksByDepth instructs the SARIF output to order the call
+	// stacks of a result by depth first, listing the shortest stacks
+	// first, and then by symbol name. By default, stacks are ordered
+	// by symbol name only.
+	SortStacksByDepth bool `json:"-"`
+
+	// SarifAutomationID, if not empty, is set as the automation
+	// details ID of the SARIF run, which lets consumers correlate
+	// related runs, such as the jobs of a build matrix. GitHub code
+	// scanning, for instance, uses it to categorize uploads.
+	SarifAutomationID string `json:"-"`
+
+	// SarifURIBase controls how the SARIF output refers to files. With
+	// SarifURIRelative, the default, file paths are relative to the
+	// %SRCROOT%, %GOROOT%, and %GOMODCACHE% base IDs, which are listed
+	// in the originalUriBaseIds of the run. With SarifURIAbsolute, they
+	// are absolute file URIs, resolved against SarifURIRoots.
+	SarifURIBase SarifURIBase `json:"-"`
+
+	// SarifURIRoots maps the base IDs of SARIF file paths to the
+	// absolute directories they stand for. It is used when SarifURIBase
+	// is SarifURIAbsolute; paths whose base ID has no root stay relative.
+	// The root of %SRCROOT% is also where SarifSnippets reads files. In
+	// source mode, govulncheck sets it to the module directory if it is
+	// needed but unset.
+	SarifURIRoots map[string]string `json:"-"`
+
+	// ModuleRoot, if not empty, is the directory relative to which the
+	// SARIF output expresses the paths of the files of the module
+	// analyzed, such as the root of a repository containing the module
+	// in a subdirectory. By default, the paths are relative to the
+	// module directory, which is the root of %SRCROOT% in SarifURIRoots,
+	// if any, and the current directory otherwise. The module directory
+	// must be in ModuleRoot, and files outside of ModuleRoot keep paths
+	// relative to the module directory. ModuleRoot is either absolute or
+	// relative to the current directory. Absolute URIs are unaffected.
+	ModuleRoot string `json:"-"`
+
+	// SarifSnippets instructs the SARIF output to include the source
+	// line of the call sites in the code of the module analyzed, as the
+	// snippets of their regions. Files are read relative to the root of
+	// %SRCROOT% in SarifURIRoots, if any, and to the current directory
+	// otherwise. Files that cannot be read, are large, or are not text
+	// are skipped, as are the frames of other modules.
+	SarifSnippets bool `json:"-"`
+
+	// SarifPackageResults instructs the SARIF output to report a module
+	// level finding as one result per affected package of its module,
+	// when its OSV lists several of them, as is common for standard
+	// library advisories. Each result refers to its package as a logical
+	// location.
+	SarifPackageResults bool `json:"-"`
+
+	// SortBySeverity instructs the text output, and streaming outputs
+	// such as JSON, to present findings by descending CVSS score of
+	// their vulnerabilities, then by reachability and OSV ID. Streaming
+	// outputs then hold findings until the end of the scan.
+	SortBySeverity bool `json:"-"`
+
+	// SeverityOverrides maps OSV IDs and aliases, such as CVE IDs, to
+	// the severity rating of the vulnerability, one of "CRITICAL",
+	// "HIGH", "MEDIUM", "LOW", and "NONE", according to the policy of
+	// the user. The ratings take precedence over those derived from
+	// the CVSS scores of OSV entries and, in SARIF, determine the level
+	// of results: error for critical and high, warning for medium, and
+	// note otherwise.
+	SeverityOverrides map[string]string `json:"-"`
+
+	// FailOnNewlyFixable instructs the text output to only report that
+	// vulnerabilities were found, with exit status 3, if some of them
+	// became fixable since the Baseline scan. Vulnerabilities that stay
+	// unfixable, or were already fixable, then do not fail the scan.
+	FailOnNewlyFixable bool `json:"-"`
+
+	// FailOnCalled instructs the text output to only report that
+	// vulnerabilities were found, with exit status 3, if some of them
+	// are called, whatever the scan level. All findings are still
+	// presented, so that vulnerable modules and imported packages are
+	// reported without failing the scan. FailOnNewlyFixable, if set,
+	// takes precedence.
+	FailOnCalled bool `json:"-"`
+
+	// SeverityExitCodes maps severity ratings, one of "CRITICAL",
+	// "HIGH", "MEDIUM", "LOW", and "NONE", to the exit status of the
+	// text output when vulnerabilities are found, so that CI can tell
+	// apart, say, critical vulnerabilities, with {"CRITICAL": 4}, from
+	// others. The status is the one of the highest listed rating among
+	// the vulnerabilities failing the scan, derived from the CVSS scores
+	// of OSV entries subject to SeverityOverrides, and 3 if none of their
+	// ratings is listed. Statuses are between 3 and 125, as 0, 1, and 2
+	// report success, errors, and invalid usage.
+	SeverityExitCodes map[string]int `json:"-"`
+
+	// UpgradePlan instructs the text output to present vulnerabilities
+	// as an upgrade plan, grouped by vulnerable module and by the fixed
+	// version of the module, as in "Upgrade MODULE to VERSION to fix
+	// OSV-1, OSV-2.", instead of presenting each vulnerability with its
+	// findings. Vulnerabilities without a fix are listed per module.
+	UpgradePlan bool `json:"-"`
+
+	// TextTemplate, if not empty, is a text/template used by the text
+	// output to render each finding instead of the default format.
+	// See scan.TemplateFinding for the data passed to the template and
+	// scan.DefaultTextTemplate for a template to start from. The exit
+	// status is that of the default format.
+	TextTemplate string `json:"-"`
+
+	// ListAdvisories instructs govulncheck to list, for debugging, every
+	// OSV entry considered by the scan, with its affected modules and
+	// whether it matched the scanned modules, that is, whether any
+	// finding was reported for it. The list is written at the end of the
+	// scan to the debug output, which is standard error for the command.
+	ListAdvisories bool `json:"-"`
+
+	// WebhookURL is the URL to which the called findings of the scan
+	// are posted, as JSON, at the end of the scan. The findings are
+	// not posted if it is empty.
+	WebhookURL string `json:"-"`
+
+	// WebhookAuth, if not empty, is sent as the value of the
+	// Authorization header of requests to WebhookURL. Govulncheck
+	// reads it from the GOVULNCHECK_WEBHOOK_AUTH environment variable,
+	// so that the credentials do not show in process listings.
+	WebhookAuth string `json:"-"`
 }
 
 // SBOM contains minimal information about the artifacts govulncheck is scanning.
@@ -119,6 +460,24 @@ type Progress struct {
 
 	// Message is the progress message.
 	Message string `json:"message,omitempty"`
+
+	// Warning reports whether the message warns about the results of
+	// the scan, which may be incomplete or out of date, rather than
+	// only reporting progress. Outputs like SARIF record warnings
+	// among the problems of the scan.
+	Warning bool `json:"warning,omitempty"`
+
+	// Truncated reports that findings were dropped because
+	// there were more than Config.MaxFindings of a level.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Failure reports an error that stopped the scan before it completed.
+// The findings reported before the failure are valid, but there may
+// be vulnerabilities that the scan did not get to report.
+type Failure struct {
+	// Message describes the error.
+	Message string `json:"message,omitempty"`
 }
 
 // Finding contains information on a discovered vulnerability. Each vulnerability
@@ -133,6 +492,10 @@ type Finding struct {
 	// OSV is the id of the detected vulnerability.
 	OSV string `json:"osv,omitempty"`
 
+	// OSVEntry is the full entry of the detected vulnerability. It is
+	// only set when Config.EmbedOSV is true.
+	OSVEntry *osv.Entry `json:"osv_entry,omitempty"`
+
 	// FixedVersion is the module version where the vulnerability was
 	// fixed. This is empty if a fix is not available.
 	//
@@ -146,15 +509,152 @@ type Finding struct {
 	// For the stdlib, we will show the fixed version closest to the
 	// Go version that is used. For example, if a fix is available in 1.17.5 and
 	// 1.18.5, and the GOVERSION is 1.17.3, 1.17.5 will be returned as the
-	// fixed version.
+	// fixed version. If Config.GoFixedVersions is true, it is the Go
+	// release instead, go1.17.5 in this example.
 	FixedVersion string `json:"fixed_version,omitempty"`
 
+	// FixedRevision describes FixedVersion in a readable form when it
+	// is a pseudo-version, such as "commit abcdef123456 from 2023-01-01",
+	// naming the commit and the date of the fix. It is empty otherwise.
+	FixedRevision string `json:"fixed_revision,omitempty"`
+
+	// FixedInAdvisory is true if FixedVersion is empty but the OSV
+	// report records a fix in a range that is not in terms of semantic
+	// versions, such as an ECOSYSTEM or GIT range. The fix cannot be
+	// mapped to a module version, so clients should consult the report.
+	FixedInAdvisory bool `json:"fixed_in_advisory,omitempty"`
+
+	// LatestVersion is the latest available version of the vulnerable
+	// module, according to the module proxy, when it is newer than the
+	// version in use. It tells how far the module can be upgraded, past
+	// FixedVersion. It is only set if Config.IncludeLatestVersion is
+	// true and the proxy is reachable, and never for the standard
+	// library.
+	LatestVersion string `json:"latest_version,omitempty"`
+
+	// NewlyFixable is true if FixedVersion is set while the finding
+	// had no fixed version in the baseline scan, that is, if a fix
+	// became available since then. It is only set if Config.Baseline
+	// is set.
+	NewlyFixable bool `json:"newly_fixable,omitempty"`
+
+	// IntroducedVersion is the module version where the vulnerability
+	// was introduced. This is empty if the vulnerability exists since
+	// the first version of the module.
+	//
+	// If there are multiple introduced versions in the OSV report, this
+	// will be the one introducing the range of affected versions that
+	// contains the module version being used.
+	IntroducedVersion string `json:"introduced_version,omitempty"`
+
+	// LastAffectedVersion is the last module version affected by the
+	// vulnerability, for OSV reports that specify a last affected
+	// version instead of a fixed version. Any version after it is not
+	// affected.
+	//
+	// This is empty if FixedVersion is set.
+	LastAffectedVersion string `json:"last_affected_version,omitempty"`
+
+	// AffectedRanges are the ranges of versions of the vulnerable module
+	// affected by the vulnerability, according to the SEMVER ranges of
+	// the OSV entry, ordered by their introduced versions. They tell
+	// whether another version, older or newer, would remediate the
+	// vulnerability.
+	AffectedRanges []*AffectedRange `json:"affected_ranges,omitempty"`
+
+	// Direct is true if the vulnerable module is a direct requirement
+	// of the main module, that is, one not marked as indirect in its
+	// go.mod file. It is only set for source findings.
+	Direct bool `json:"direct,omitempty"`
+
+	// TestOnly is true if the vulnerable symbol is reachable only from
+	// test code, that is, if the entry point of Trace is in a test
+	// package or a _test.go file. It is only set for call-level
+	// findings.
+	TestOnly bool `json:"test_only,omitempty"`
+
+	// ThroughInit is true if the vulnerable symbol is reached through a
+	// package initializer, that is, an init function or a function
+	// literal within one, which runs whenever the package is imported.
+	// It is only set for call-level source findings.
+	ThroughInit bool `json:"through_init,omitempty"`
+
+	// ThroughReflection is true if the vulnerable symbol is reached
+	// through package reflect, whose dynamic calls the call graph may
+	// only approximate. It is only set for call-level source findings.
+	//
+	// Both kinds of paths warrant extra scrutiny.
+	ThroughReflection bool `json:"through_reflection,omitempty"`
+
+	// BuildTime is true if the vulnerable module is only needed by
+	// tools of the main module, such as go:generate dependencies listed
+	// in the tool directives of go.mod, and hence does not end up in
+	// the built binaries. It is only set for source findings when
+	// Config.IncludeTools is true.
+	BuildTime bool `json:"build_time,omitempty"`
+
+	// WorkspaceModule is the module of the go.work workspace from which
+	// the finding originates, when scanning a workspace of several
+	// modules: the module of the entry point of Trace for call-level
+	// findings, and otherwise the only workspace module whose packages
+	// import the vulnerable package or module. It is empty if several
+	// workspace modules import them. It is only set for source findings.
+	WorkspaceModule string `json:"workspace_module,omitempty"`
+
+	// Annotations are properties of the finding added by embedders of
+	// govulncheck, such as internal ticket IDs, see Config.FindingHook.
+	// Govulncheck itself does not set any.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Confidence describes how certain govulncheck is that the finding
+	// is real. Findings of binary scans are less certain than findings of
+	// source scans: symbols are recovered from the binary rather than from
+	// a call graph, and package and module level findings may be based on
+	// incomplete symbol information, as with stripped binaries.
+	//
+	// It is empty for source findings, which are of ConfidenceHigh.
+	Confidence Confidence `json:"confidence,omitempty"`
+
+	// PathCount is the number of call stacks found reaching the
+	// vulnerable symbol, of which Trace is a representative one. Only
+	// the shortest call stacks are counted, each visiting a function
+	// at most once, so it is an estimate of how widely the symbol is
+	// reachable: a single path may be an obscure one, while many paths
+	// suggest the symbol is called throughout the code.
+	//
+	// It is only set for call-level source findings, and only if
+	// Config.IncludePathCount is true.
+	PathCount int `json:"path_count,omitempty"`
+
+	// RemediateBy is the deadline for remediating the vulnerability:
+	// the time of the scan plus the number of days that
+	// Config.RemediationDays allows for its severity. It is only set if
+	// the severity of the vulnerability is listed there.
+	RemediateBy *time.Time `json:"remediate_by,omitempty"`
+
+	// Binaries are the paths of the binaries in which the finding was
+	// detected, when scanning several binaries matching a directory or
+	// glob pattern in binary mode. Identical findings of several
+	// binaries are reported once.
+	Binaries []string `json:"binaries,omitempty"`
+
+	// GoModLocation is the position of the require directive of the
+	// vulnerable module in the go.mod file of the main module, whose
+	// file name is "go.mod". It is only set for source findings when
+	// Config.IncludeGoModLocation is true and the module is required
+	// directly or listed in go.mod.
+	GoModLocation *Position `json:"go_mod_location,omitempty"`
+
 	// Trace contains an entry for each frame in the trace.
 	//
 	// Frames are sorted starting from the imported vulnerable symbol
 	// until the entry point. The first frame in Frames should match
 	// Symbol.
 	//
+	// If Config.MaxTraceDepth is set, frames in the middle of a long
+	// trace are replaced by a single frame with no module and whose
+	// function is ElidedFunction.
+	//
 	// In binary mode, trace will contain a single-frame with no position
 	// information.
 	//
@@ -165,6 +665,21 @@ type Finding struct {
 	Trace []*Frame `json:"trace,omitempty"`
 }
 
+// AffectedRange is a range of affected module versions.
+type AffectedRange struct {
+	// Introduced is the first affected version. It is empty if all
+	// versions up to Fixed or LastAffected are affected.
+	Introduced string `json:"introduced,omitempty"`
+
+	// Fixed is the first version after Introduced that is not affected.
+	// It is empty if the range has no fix.
+	Fixed string `json:"fixed,omitempty"`
+
+	// LastAffected is the last affected version after Introduced, for
+	// ranges that record it instead of a fix.
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
 // Frame represents an entry in a finding trace.
 type Frame struct {
 	// Module is the module path of the module containing this symbol.
@@ -175,6 +690,13 @@ type Frame struct {
 	// Version is the module version from the build graph.
 	Version string `json:"version,omitempty"`
 
+	// ReplacedModule and ReplacedVersion are the path and version of
+	// the module required by the main modules when it is replaced by
+	// Module, whose version is then the version of the replacement.
+	// They are only set if Config.IncludeReplaced is true.
+	ReplacedModule  string `json:"replaced_module,omitempty"`
+	ReplacedVersion string `json:"replaced_version,omitempty"`
+
 	// Package is the import path.
 	Package string `json:"package,omitempty"`
 
@@ -226,6 +748,36 @@ func (l ScanLevel) WantSymbols() bool { return l == ScanLevelSymbol }
 // to generate package-level findings.
 func (l ScanLevel) WantPackages() bool { return l == ScanLevelPackage || l == ScanLevelSymbol }
 
+// Precision orders scan levels by the precision of their findings,
+// from 0 for module to 2 for symbol. Unknown levels have precision 0.
+func (l ScanLevel) Precision() int {
+	switch {
+	case l.WantSymbols():
+		return 2
+	case l.WantPackages():
+		return 1
+	default:
+		return 0
+	}
+}
+
+// SarifURIBase describes how the SARIF output refers to files.
+type SarifURIBase string
+
+const (
+	SarifURIRelative = "relative"
+	SarifURIAbsolute = "absolute"
+)
+
+// Confidence is the level of certainty of a finding.
+type Confidence string
+
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
 // ScanMode represents the mode in which a scan occurred. This can
 // be necessary to correctly to interpret findings. For instance,
 // a binary can be checked for vulnerabilities or the user just wants
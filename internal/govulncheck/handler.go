@@ -30,8 +30,72 @@ type Handler interface {
 	Finding(finding *Finding) error
 }
 
+// Collector is implemented by buffering handlers that can
+// report the findings they have collected so far.
+type Collector interface {
+	// Findings returns a copy of the collected findings,
+	// keyed by OSV ID.
+	Findings() map[string][]*Finding
+}
+
+// Streaming reports whether h presents messages as soon as they
+// are received, rather than holding them in memory until the end
+// of the scan. Drivers can rely on streaming handlers for memory
+// bounded scanning.
+//
+// Handlers report their capability by implementing
+//
+//	Streaming() bool
+//
+// Handlers that do not are considered streaming unless they
+// implement Flush, which is used by buffering handlers to
+// present their output.
+func Streaming(h Handler) bool {
+	if s, ok := h.(interface{ Streaming() bool }); ok {
+		return s.Streaming()
+	}
+	_, flushes := h.(interface{ Flush() error })
+	return !flushes
+}
+
+// Concurrent reports whether h declares its Finding method safe for
+// concurrent use by multiple goroutines. Drivers may then deliver
+// findings concurrently, see Config.FindingWorkers, in which case
+// the order in which h receives them is not deterministic: h must
+// order findings itself before presenting them. Other methods are
+// never called concurrently, neither with each other nor with Finding.
+//
+// Handlers wrapping another handler are concurrent only if their own
+// Finding is safe for concurrent use and the wrapped handler is.
+//
+// Handlers declare it by implementing
+//
+//	Concurrent() bool
+func Concurrent(h Handler) bool {
+	c, ok := h.(interface{ Concurrent() bool })
+	return ok && c.Concurrent()
+}
+
+// Fail reports the failure of the scan to h, if h presents failures,
+// so that the findings reported so far can be presented as partial
+// results. Drivers call it before flushing h.
+//
+// Handlers present failures by implementing
+//
+//	Failure(failure *Failure) error
+func Fail(h Handler, failure *Failure) error {
+	if f, ok := h.(interface{ Failure(*Failure) error }); ok {
+		return f.Failure(failure)
+	}
+	return nil
+}
+
 // HandleJSON reads the json from the supplied stream and hands the decoded
 // output to the handler.
+//
+// It replays a stream written by the JSON handler into any handler, so
+// that a saved scan can be presented in another format, such as SARIF,
+// without scanning again. This is what govulncheck -mode convert does.
 func HandleJSON(from io.Reader, to Handler) error {
 	dec := json.NewDecoder(from)
 	for dec.More() {
@@ -57,6 +121,9 @@ func HandleJSON(from io.Reader, to Handler) error {
 		if msg.Finding != nil {
 			err = to.Finding(msg.Finding)
 		}
+		if msg.Failure != nil {
+			err = Fail(to, msg.Failure)
+		}
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,87 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+func TestHandleJSONRoundTrip(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := &test.MockHandler{
+		ConfigMessages: []*govulncheck.Config{{
+			ProtocolVersion: govulncheck.ProtocolVersion,
+			ScannerName:     "govulncheck",
+			ScanLevel:       govulncheck.ScanLevelSymbol,
+		}},
+		SBOMMessages: []*govulncheck.SBOM{{
+			GoVersion: "go1.22.0",
+			Modules:   []*govulncheck.Module{{Path: "example.com/m", Version: "v1.0.0"}},
+		}},
+		ProgressMessages: []*govulncheck.Progress{{Timestamp: &now, Message: "Scanning..."}},
+		OSVMessages: []*osv.Entry{{
+			ID:       "GO-0000-0001",
+			Modified: now,
+			Affected: []osv.Affected{{Module: osv.Module{Path: "example.com/m"}}},
+		}},
+		FindingMessages: []*govulncheck.Finding{{
+			OSV:          "GO-0000-0001",
+			FixedVersion: "v1.0.1",
+			Trace: []*govulncheck.Frame{
+				{Module: "example.com/m", Version: "v1.0.0", Package: "example.com/m/p", Function: "F"},
+				{Module: "main", Package: "main", Function: "main", Position: &govulncheck.Position{Filename: "main.go", Line: 7, Column: 3}},
+			},
+		}},
+		FailureMessages: []*govulncheck.Failure{{Message: "loading packages: boom"}},
+	}
+
+	var buf bytes.Buffer
+	jh := govulncheck.NewJSONHandler(&buf)
+	for _, c := range want.ConfigMessages {
+		if err := jh.Config(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, s := range want.SBOMMessages {
+		if err := jh.SBOM(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range want.ProgressMessages {
+		if err := jh.Progress(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range want.OSVMessages {
+		if err := jh.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range want.FindingMessages {
+		if err := jh.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range want.FailureMessages {
+		if err := govulncheck.Fail(jh, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := test.NewMockHandler()
+	if err := govulncheck.HandleJSON(&buf, got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("replayed messages mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import "strings"
+
+// Identity returns a key identifying the finding f across scans, for
+// use by baselines, suppression files, and external tooling that must
+// recognize findings reported by earlier runs.
+//
+// The key consists of the OSV ID of f and of the path of its vulnerable
+// module, followed, down to the granularity given by level, by the path
+// of the vulnerable package and the vulnerable symbol, in the form
+//
+//	OSV|MODULE[|PACKAGE[|SYMBOL]]
+//
+// where SYMBOL is the function name, qualified by its receiver type for
+// methods. Elements that f lacks are omitted, so that the key of a
+// module level finding is the same at all levels.
+//
+// The key is stable: it only depends on the elements above and is hence
+// unaffected by the versions of modules, the positions of the vulnerable
+// code or of its call sites, the call stacks leading to it, and the fixed
+// versions of f. Its format will not change across govulncheck releases.
+// The key of a finding without a trace is its OSV ID.
+func Identity(f *Finding, level ScanLevel) string {
+	if len(f.Trace) == 0 {
+		return f.OSV
+	}
+	fr := f.Trace[0]
+	key := []string{f.OSV, fr.Module}
+	if level.WantPackages() && fr.Package != "" {
+		key = append(key, fr.Package)
+		if level.WantSymbols() && fr.Function != "" {
+			// Closures, such as Function$1, are
+			// identified with their enclosing symbol.
+			sym, _, _ := strings.Cut(fr.Function, "$")
+			if fr.Receiver != "" {
+				sym = fr.Receiver + "." + sym
+			}
+			key = append(key, sym)
+		}
+	}
+	return strings.Join(key, "|")
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import "testing"
+
+func TestIdentity(t *testing.T) {
+	symbol := &Finding{
+		OSV: "GO-2021-0265",
+		Trace: []*Frame{
+			{Module: "github.com/tidwall/gjson", Version: "v1.6.5", Package: "github.com/tidwall/gjson", Receiver: "Result", Function: "Get$1"},
+			{Module: "m", Package: "m", Function: "main"},
+		},
+	}
+	for _, test := range []struct {
+		name  string
+		f     *Finding
+		level ScanLevel
+		want  string
+	}{
+		{"no trace", &Finding{OSV: "GO-2021-0265"}, ScanLevelSymbol, "GO-2021-0265"},
+		{"module", &Finding{OSV: "GO-2021-0265", Trace: []*Frame{{Module: "github.com/tidwall/gjson", Version: "v1.6.5"}}},
+			ScanLevelSymbol, "GO-2021-0265|github.com/tidwall/gjson"},
+		{"symbol", symbol, ScanLevelSymbol, "GO-2021-0265|github.com/tidwall/gjson|github.com/tidwall/gjson|Result.Get"},
+		{"symbol at package level", symbol, ScanLevelPackage, "GO-2021-0265|github.com/tidwall/gjson|github.com/tidwall/gjson"},
+		{"symbol at module level", symbol, ScanLevelModule, "GO-2021-0265|github.com/tidwall/gjson"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Identity(test.f, test.level); got != test.want {
+				t.Errorf("got %q; want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIdentityStable(t *testing.T) {
+	finding := func(version string, line int, caller string) *Finding {
+		return &Finding{
+			OSV:          "GO-2021-0265",
+			FixedVersion: "v1.9.3",
+			Trace: []*Frame{
+				{
+					Module: "github.com/tidwall/gjson", Version: version, Package: "github.com/tidwall/gjson", Function: "Get",
+					Position: &Position{Filename: "gjson.go", Line: line, Column: 6},
+				},
+				{
+					Module: "m", Package: "m", Function: caller,
+					Position: &Position{Filename: "main.go", Line: line + 10, Column: 2},
+				},
+			},
+		}
+	}
+	want := Identity(finding("v1.6.5", 10, "main"), ScanLevelSymbol)
+	for _, f := range []*Finding{
+		finding("v1.6.5", 42, "main"), // moved lines
+		finding("v1.6.6", 10, "main"), // another version
+		finding("v1.6.5", 10, "run"),  // another call stack
+	} {
+		if got := Identity(f, ScanLevelSymbol); got != want {
+			t.Errorf("got %q; want %q", got, want)
+		}
+	}
+}
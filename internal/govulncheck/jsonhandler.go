@@ -6,7 +6,6 @@ package govulncheck
 
 import (
 	"encoding/json"
-
 	"io"
 
 	"golang.org/x/vuln/internal/osv"
@@ -14,6 +13,11 @@ import (
 
 type jsonHandler struct {
 	enc *json.Encoder
+	// configured is set once a Config message has been written.
+	configured bool
+	// osvs holds the entries to embed in findings, if
+	// requested by the config.
+	osvs map[string]*osv.Entry
 }
 
 // NewJSONHandler returns a handler that writes govulncheck output as json.
@@ -23,27 +27,85 @@ func NewJSONHandler(w io.Writer) Handler {
 	return &jsonHandler{enc: enc}
 }
 
+// Streaming returns true as messages are written
+// to the underlying writer as they are received.
+func (h *jsonHandler) Streaming() bool {
+	return true
+}
+
 // Config writes config block in JSON to the underlying writer.
+// If config does not specify a protocol version, the current
+// ProtocolVersion is used.
 func (h *jsonHandler) Config(config *Config) error {
+	if config.ProtocolVersion == "" {
+		c := *config
+		c.ProtocolVersion = ProtocolVersion
+		config = &c
+	}
+	h.configured = true
+	if config.EmbedOSV {
+		h.osvs = make(map[string]*osv.Entry)
+	}
 	return h.enc.Encode(Message{Config: config})
 }
 
+// ensureConfig writes a minimal config block with the
+// protocol version if no config has been written so far.
+// This guarantees that the version is always the first
+// message in the stream.
+func (h *jsonHandler) ensureConfig() error {
+	if h.configured {
+		return nil
+	}
+	return h.Config(&Config{ProtocolVersion: ProtocolVersion})
+}
+
 // Progress writes a progress message in JSON to the underlying writer.
 func (h *jsonHandler) Progress(progress *Progress) error {
+	if err := h.ensureConfig(); err != nil {
+		return err
+	}
 	return h.enc.Encode(Message{Progress: progress})
 }
 
 // SBOM writes the SBOM block in JSON to the underlying writer.
 func (h *jsonHandler) SBOM(sbom *SBOM) error {
+	if err := h.ensureConfig(); err != nil {
+		return err
+	}
 	return h.enc.Encode(Message{SBOM: sbom})
 }
 
 // OSV writes an osv entry in JSON to the underlying writer.
 func (h *jsonHandler) OSV(entry *osv.Entry) error {
+	if err := h.ensureConfig(); err != nil {
+		return err
+	}
+	if h.osvs != nil {
+		h.osvs[entry.ID] = entry
+	}
 	return h.enc.Encode(Message{OSV: entry})
 }
 
 // Finding writes a finding in JSON to the underlying writer.
+// If the config requests embedding OSV entries, the finding is
+// written with the entry of its vulnerability.
 func (h *jsonHandler) Finding(finding *Finding) error {
+	if err := h.ensureConfig(); err != nil {
+		return err
+	}
+	if e := h.osvs[finding.OSV]; e != nil && finding.OSVEntry == nil {
+		f := *finding
+		f.OSVEntry = e
+		finding = &f
+	}
 	return h.enc.Encode(Message{Finding: finding})
 }
+
+// Failure writes a failure message in JSON to the underlying writer.
+func (h *jsonHandler) Failure(failure *Failure) error {
+	if err := h.ensureConfig(); err != nil {
+		return err
+	}
+	return h.enc.Encode(Message{Failure: failure})
+}
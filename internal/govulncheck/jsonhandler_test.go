@@ -0,0 +1,141 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+func TestJSONHandlerVersionFirst(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config bool
+	}{
+		{"with-config", true},
+		{"without-config", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := govulncheck.NewJSONHandler(&buf)
+			if tc.config {
+				if err := h.Config(&govulncheck.Config{ScannerName: "govulncheck"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := h.OSV(&osv.Entry{ID: "GO-0000-0001"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+				t.Fatal(err)
+			}
+
+			dec := json.NewDecoder(&buf)
+			var msgs []govulncheck.Message
+			for dec.More() {
+				var msg govulncheck.Message
+				if err := dec.Decode(&msg); err != nil {
+					t.Fatal(err)
+				}
+				msgs = append(msgs, msg)
+			}
+			if len(msgs) != 3 {
+				t.Fatalf("want 3 messages; got %d", len(msgs))
+			}
+			if msgs[0].Config == nil {
+				t.Fatalf("want config as the first message; got %+v", msgs[0])
+			}
+			if got := msgs[0].Config.ProtocolVersion; got != govulncheck.ProtocolVersion {
+				t.Errorf("want protocol version %s; got %s", govulncheck.ProtocolVersion, got)
+			}
+			if msgs[1].OSV == nil || msgs[2].Finding == nil {
+				t.Errorf("want osv and finding after config; got %+v and %+v", msgs[1], msgs[2])
+			}
+		})
+	}
+}
+
+// handler is a Handler that neither
+// streams nor flushes explicitly.
+type handler struct{}
+
+func (handler) Config(*govulncheck.Config) error     { return nil }
+func (handler) SBOM(*govulncheck.SBOM) error         { return nil }
+func (handler) Progress(*govulncheck.Progress) error { return nil }
+func (handler) OSV(*osv.Entry) error                 { return nil }
+func (handler) Finding(*govulncheck.Finding) error   { return nil }
+
+// flushHandler is a buffering Handler.
+type flushHandler struct{ handler }
+
+func (flushHandler) Flush() error { return nil }
+
+func TestStreaming(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		h    govulncheck.Handler
+		want bool
+	}{
+		{"json", govulncheck.NewJSONHandler(&bytes.Buffer{}), true},
+		{"default", handler{}, true},
+		{"flush", flushHandler{}, false},
+		{"redact-json", govulncheck.NewRedactHandler(govulncheck.NewJSONHandler(&bytes.Buffer{})), true},
+		{"redact-flush", govulncheck.NewRedactHandler(flushHandler{}), false},
+	} {
+		if got := govulncheck.Streaming(tc.h); got != tc.want {
+			t.Errorf("%s: want %t; got %t", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestJSONHandlerEmbedOSV(t *testing.T) {
+	entry := &osv.Entry{
+		ID:       "GO-0000-0001",
+		Modified: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Summary:  "summary",
+		Details:  "details",
+		Affected: []osv.Affected{{Module: osv.Module{Path: "m"}}},
+	}
+	for _, embed := range []bool{false, true} {
+		var buf bytes.Buffer
+		h := govulncheck.NewJSONHandler(&buf)
+		if err := h.Config(&govulncheck.Config{EmbedOSV: embed}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.OSV(entry); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(&govulncheck.Finding{OSV: entry.ID, Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+			t.Fatal(err)
+		}
+
+		var got *osv.Entry
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var msg govulncheck.Message
+			if err := dec.Decode(&msg); err != nil {
+				t.Fatal(err)
+			}
+			if msg.Finding != nil {
+				got = msg.Finding.OSVEntry
+			}
+		}
+		if !embed {
+			if got != nil {
+				t.Errorf("want no embedded entry; got %+v", got)
+			}
+			continue
+		}
+		if diff := cmp.Diff(entry, got); diff != "" {
+			t.Errorf("embedded entry mismatch (-want, +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import (
+	"net/url"
+	"strings"
+)
+
+// PURL returns the package URL of the module at path with the given
+// version, for outputs identifying vulnerable modules, such as VEX and
+// SBOM documents. It is of the form
+//
+//	pkg:golang/MODULE_PATH@VERSION
+//
+// where the module path is escaped, without the version if empty.
+// Conceptually there is no namespace and the name is entirely defined
+// by the module path. See https://github.com/package-url/purl-spec/issues/63
+// for further discussion.
+func PURL(path, version string) string {
+	var b strings.Builder
+	b.WriteString("pkg:golang/")
+	b.WriteString(url.PathEscape(path))
+	if version != "" {
+		b.WriteString("@")
+		b.WriteString(version)
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+// The reachability of a finding is given by the top frame of its
+// trace, which holds the vulnerable symbol, package, or module.
+// The predicates below allow to select findings by reachability
+// after the fact, for instance when replaying a saved stream.
+// They all report false for findings without a trace.
+
+// IsCalled reports whether f is a symbol level finding, that is,
+// whether the vulnerable symbol is called by the code analyzed.
+func IsCalled(f *Finding) bool {
+	return len(f.Trace) > 0 && f.Trace[0].Function != ""
+}
+
+// IsImported reports whether f is a package level finding, that is,
+// whether the vulnerable package is imported by the code analyzed
+// but none of its vulnerable symbols is known to be called.
+func IsImported(f *Finding) bool {
+	return len(f.Trace) > 0 && f.Trace[0].Function == "" && f.Trace[0].Package != ""
+}
+
+// IsModuleOnly reports whether f is a module level finding, that is,
+// whether the vulnerable module is required by the code analyzed but
+// none of its vulnerable packages is known to be imported.
+func IsModuleOnly(f *Finding) bool {
+	return len(f.Trace) > 0 && f.Trace[0].Function == "" && f.Trace[0].Package == ""
+}
+
+// Level returns the level at which f was found: ScanLevelSymbol if it
+// is called, ScanLevelPackage if it is imported, and ScanLevelModule
+// otherwise.
+func Level(f *Finding) ScanLevel {
+	switch {
+	case IsCalled(f):
+		return ScanLevelSymbol
+	case IsImported(f):
+		return ScanLevelPackage
+	default:
+		return ScanLevelModule
+	}
+}
+
+// MostPrecise returns fs, findings of a vulnerability found at the same
+// level, updated with the finding f of the same vulnerability, for
+// outputs reporting vulnerabilities at the most precise level at which
+// they were found: f replaces fs if found at a more precise level, is
+// appended to fs if found at the same level, and is dropped otherwise.
+func MostPrecise(fs []*Finding, f *Finding) []*Finding {
+	switch p := Level(f).Precision(); {
+	case len(fs) == 0 || p > Level(fs[0]).Precision():
+		return []*Finding{f}
+	case p == Level(fs[0]).Precision():
+		return append(fs, f)
+	default:
+		return fs
+	}
+}
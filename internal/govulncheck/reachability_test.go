@@ -0,0 +1,92 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import "testing"
+
+func TestReachability(t *testing.T) {
+	for _, test := range []struct {
+		name                         string
+		trace                        []*Frame
+		called, imported, moduleOnly bool
+	}{
+		{
+			name: "no trace",
+		},
+		{
+			name:       "module",
+			trace:      []*Frame{{Module: "m", Version: "v1.0.0"}},
+			moduleOnly: true,
+		},
+		{
+			name:     "package",
+			trace:    []*Frame{{Module: "m", Version: "v1.0.0", Package: "m/p"}},
+			imported: true,
+		},
+		{
+			name:   "symbol",
+			trace:  []*Frame{{Module: "m", Version: "v1.0.0", Package: "m/p", Function: "F"}},
+			called: true,
+		},
+		{
+			name:   "method",
+			trace:  []*Frame{{Module: "m", Package: "m/p", Receiver: "T", Function: "M"}},
+			called: true,
+		},
+		{
+			name: "call stack",
+			trace: []*Frame{
+				{Module: "m", Package: "m/p", Function: "F"},
+				{Module: "main", Package: "main", Function: "main"},
+			},
+			called: true,
+		},
+		{
+			// Only the top frame matters.
+			name: "module under main",
+			trace: []*Frame{
+				{Module: "m"},
+				{Module: "main", Package: "main", Function: "main"},
+			},
+			moduleOnly: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := &Finding{OSV: "GO-0000-0001", Trace: test.trace}
+			if got := IsCalled(f); got != test.called {
+				t.Errorf("IsCalled: got %t; want %t", got, test.called)
+			}
+			if got := IsImported(f); got != test.imported {
+				t.Errorf("IsImported: got %t; want %t", got, test.imported)
+			}
+			if got := IsModuleOnly(f); got != test.moduleOnly {
+				t.Errorf("IsModuleOnly: got %t; want %t", got, test.moduleOnly)
+			}
+		})
+	}
+}
+
+func TestMostPrecise(t *testing.T) {
+	module := &Finding{OSV: "GO-0000-0001", Trace: []*Frame{{Module: "m"}}}
+	pkg1 := &Finding{OSV: "GO-0000-0001", Trace: []*Frame{{Module: "m", Package: "m/p"}}}
+	pkg2 := &Finding{OSV: "GO-0000-0001", Trace: []*Frame{{Module: "m", Package: "m/q"}}}
+	symbol := &Finding{OSV: "GO-0000-0001", Trace: []*Frame{{Module: "m", Package: "m/p", Function: "F"}}}
+
+	var fs []*Finding
+	for _, f := range []*Finding{module, pkg1, module, pkg2} {
+		fs = MostPrecise(fs, f)
+	}
+	if len(fs) != 2 || fs[0] != pkg1 || fs[1] != pkg2 {
+		t.Errorf("want the package findings; got %v", fs)
+	}
+	if fs = MostPrecise(fs, symbol); len(fs) != 1 || fs[0] != symbol {
+		t.Errorf("want the symbol finding; got %v", fs)
+	}
+	for f, want := range map[*Finding]ScanLevel{module: ScanLevelModule, pkg1: ScanLevelPackage, symbol: ScanLevelSymbol} {
+		if got := Level(f); got != want {
+			t.Errorf("Level(%v): got %s; want %s", f.Trace[0], got, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import (
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/vuln/internal/osv"
+)
+
+// redactHandler is a Handler that removes local filesystem
+// prefixes from positions of findings before passing them
+// to the underlying handler.
+type redactHandler struct {
+	h        Handler
+	prefixes []string
+}
+
+// NewRedactHandler returns a handler that forwards messages to h,
+// rewriting the filenames of finding trace positions that start
+// with any of prefixes, such as a module root, GOPATH, or the home
+// directory, to be relative to that prefix. The first matching
+// prefix is used, so more specific prefixes should come first.
+//
+// This avoids leaking absolute paths of the developer machine in
+// the output.
+func NewRedactHandler(h Handler, prefixes ...string) Handler {
+	var ps []string
+	for _, p := range prefixes {
+		if p != "" {
+			ps = append(ps, filepath.Clean(p))
+		}
+	}
+	return &redactHandler{h: h, prefixes: ps}
+}
+
+func (r *redactHandler) Config(config *Config) error {
+	return r.h.Config(config)
+}
+
+func (r *redactHandler) SBOM(sbom *SBOM) error {
+	return r.h.SBOM(sbom)
+}
+
+func (r *redactHandler) Progress(progress *Progress) error {
+	return r.h.Progress(progress)
+}
+
+func (r *redactHandler) OSV(entry *osv.Entry) error {
+	return r.h.OSV(entry)
+}
+
+// Finding passes a copy of finding with redacted
+// positions to the underlying handler.
+func (r *redactHandler) Finding(finding *Finding) error {
+	f := *finding
+	if finding.GoModLocation != nil {
+		p := *finding.GoModLocation
+		p.Filename = r.redact(p.Filename)
+		f.GoModLocation = &p
+	}
+	f.Trace = make([]*Frame, len(finding.Trace))
+	for i, fr := range finding.Trace {
+		c := *fr
+		if fr.Position != nil {
+			p := *fr.Position
+			p.Filename = r.redact(p.Filename)
+			c.Position = &p
+		}
+		f.Trace[i] = &c
+	}
+	return r.h.Finding(&f)
+}
+
+// Streaming reports whether the underlying handler is streaming.
+func (r *redactHandler) Streaming() bool {
+	return Streaming(r.h)
+}
+
+// Concurrent reports whether the underlying handler is concurrent,
+// as findings are redacted in copies.
+func (r *redactHandler) Concurrent() bool {
+	return Concurrent(r.h)
+}
+
+// Flush flushes the underlying handler, if it supports flushing.
+func (r *redactHandler) Flush() error {
+	if f, ok := r.h.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (r *redactHandler) Failure(f *Failure) error {
+	return Fail(r.h, f)
+}
+
+// redact returns filename relative to the first prefix
+// containing it. Otherwise, filename is returned unchanged.
+func (r *redactHandler) redact(filename string) string {
+	if !filepath.IsAbs(filename) {
+		return filename
+	}
+	for _, p := range r.prefixes {
+		rel, err := filepath.Rel(p, filename)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return filepath.ToSlash(rel)
+	}
+	return filename
+}
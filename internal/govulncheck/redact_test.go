@@ -0,0 +1,56 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/test"
+)
+
+func TestRedactHandler(t *testing.T) {
+	root := filepath.FromSlash("/home/alice/src/mymod")
+	home := filepath.FromSlash("/home/alice")
+	if !filepath.IsAbs(root) {
+		t.Skip("test requires Unix-like absolute paths")
+	}
+
+	mock := test.NewMockHandler()
+	h := govulncheck.NewRedactHandler(mock, root, home)
+
+	pos := func(name string) *govulncheck.Position {
+		return &govulncheck.Position{Filename: name, Line: 1}
+	}
+	finding := &govulncheck.Finding{
+		OSV: "GO-0000-0001",
+		Trace: []*govulncheck.Frame{
+			{Module: "golang.org/vmod", Position: pos(filepath.Join(home, "go", "pkg", "mod", "vmod.go"))},
+			{Module: "example.com/mymod", Position: pos(filepath.Join(root, "internal", "main.go"))},
+			{Module: "example.com/mymod", Position: pos("main.go")},
+			{Module: "other", Position: pos(filepath.FromSlash("/tmp/other.go"))},
+			{Module: "nopos"},
+		},
+	}
+	if err := h.Finding(finding); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"go/pkg/mod/vmod.go", "internal/main.go", "main.go", filepath.FromSlash("/tmp/other.go")}
+	got := mock.FindingMessages[0].Trace
+	for i, w := range want {
+		if got[i].Position.Filename != w {
+			t.Errorf("frame %d: want %s; got %s", i, w, got[i].Position.Filename)
+		}
+	}
+	if got[4].Position != nil {
+		t.Errorf("want no position for frame without one; got %v", got[4].Position)
+	}
+	// The original finding must not be modified.
+	if f := finding.Trace[1].Position.Filename; f != filepath.Join(root, "internal", "main.go") {
+		t.Errorf("original finding modified: %s", f)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// RootPrefix returns the slash-separated path of the module directory
+// dir, or the current directory if dir is empty, relative to root, for
+// outputs expressing paths relative to Config.ModuleRoot. It fails if
+// dir is not in root.
+func RootPrefix(root, dir string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absDir)
+	if err != nil || !isLocal(filepath.ToSlash(rel)) {
+		return "", fmt.Errorf("module directory %s is not in module root %s", absDir, absRoot)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// RootRelative returns file, a slash-separated path relative to the
+// module directory, relative to the module root instead, given the
+// prefix of the module directory returned by RootPrefix. Files outside
+// of the module root keep their paths, in which case RootRelative
+// reports false.
+func RootRelative(prefix, file string) (string, bool) {
+	p := path.Join(prefix, file)
+	if !isLocal(p) {
+		return file, false
+	}
+	return p, true
+}
+
+// isLocal reports whether the slash-separated
+// path p does not escape its base directory.
+func isLocal(p string) bool {
+	p = path.Clean(p)
+	return p != ".." && !strings.HasPrefix(p, "../") && !path.IsAbs(p)
+}
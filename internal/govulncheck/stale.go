@@ -0,0 +1,28 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import (
+	"fmt"
+	"time"
+)
+
+// StaleDataWarning returns a warning if the vulnerability data of
+// the scan is older than cfg.MaxDataAgeDays days at now, and the
+// empty string otherwise. The age of the data is that of the last
+// modification of the database, cfg.DBLastModified. There is no
+// warning if it is unknown, as for scans of a local OSVDir: the
+// modification times of entries do not tell how recent the data is.
+func StaleDataWarning(cfg *Config, now time.Time) string {
+	if cfg.MaxDataAgeDays <= 0 || cfg.DBLastModified == nil {
+		return ""
+	}
+	modified := *cfg.DBLastModified
+	if modified.IsZero() || !modified.AddDate(0, 0, cfg.MaxDataAgeDays).Before(now) {
+		return ""
+	}
+	return fmt.Sprintf("The vulnerability data was last modified on %s, more than %d days ago, so recent vulnerabilities may be missed. Consider updating the data.",
+		modified.UTC().Format("2006-01-02"), cfg.MaxDataAgeDays)
+}
@@ -0,0 +1,31 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleDataWarning(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old, recent := now.AddDate(0, 0, -30), now.AddDate(0, 0, -1)
+	for _, tc := range []struct {
+		name string
+		cfg  *Config
+		want bool
+	}{
+		{"no maximum age", &Config{DBLastModified: &old}, false},
+		{"stale database", &Config{MaxDataAgeDays: 7, DBLastModified: &old}, true},
+		{"fresh database", &Config{MaxDataAgeDays: 7, DBLastModified: &recent}, false},
+		{"unknown age", &Config{MaxDataAgeDays: 7}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := StaleDataWarning(tc.cfg, now) != ""; got != tc.want {
+				t.Errorf("got warning %t; want %t", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import (
+	"time"
+
+	"golang.org/x/vuln/internal/osv"
+)
+
+// throttleHandler is a Handler that forwards at most one
+// progress message per interval to the underlying handler.
+type throttleHandler struct {
+	h        Handler
+	interval time.Duration
+	now      func() time.Time // for testing
+
+	last    time.Time // time of the last forwarded progress
+	pending *Progress // latest progress not forwarded, if any
+}
+
+// NewThrottleHandler returns a handler that forwards messages to h,
+// except that progress messages received less than interval after
+// the last forwarded one are coalesced: only the latest of them is
+// forwarded, when h is flushed.
+//
+// This keeps logs of long scans, such as in CI, readable.
+func NewThrottleHandler(h Handler, interval time.Duration) Handler {
+	return &throttleHandler{h: h, interval: interval, now: time.Now}
+}
+
+func (t *throttleHandler) Config(config *Config) error {
+	return t.h.Config(config)
+}
+
+func (t *throttleHandler) SBOM(sbom *SBOM) error {
+	return t.h.SBOM(sbom)
+}
+
+// Progress forwards progress if it is a warning, or if at least the
+// throttling interval has passed since the last forwarded progress
+// message. Otherwise, progress is kept as pending.
+func (t *throttleHandler) Progress(progress *Progress) error {
+	if progress.Warning {
+		return t.h.Progress(progress)
+	}
+	now := t.now()
+	if !t.last.IsZero() && now.Sub(t.last) < t.interval {
+		t.pending = progress
+		return nil
+	}
+	t.last = now
+	t.pending = nil
+	return t.h.Progress(progress)
+}
+
+func (t *throttleHandler) OSV(entry *osv.Entry) error {
+	return t.h.OSV(entry)
+}
+
+func (t *throttleHandler) Finding(finding *Finding) error {
+	return t.h.Finding(finding)
+}
+
+// Streaming reports whether the underlying handler is streaming.
+func (t *throttleHandler) Streaming() bool {
+	return Streaming(t.h)
+}
+
+// Concurrent reports whether the underlying handler is concurrent,
+// as findings are forwarded as is.
+func (t *throttleHandler) Concurrent() bool {
+	return Concurrent(t.h)
+}
+
+// Flush forwards the pending progress message, if any, and
+// flushes the underlying handler, if it supports flushing.
+func (t *throttleHandler) Flush() error {
+	if t.pending != nil {
+		p := t.pending
+		t.pending = nil
+		if err := t.h.Progress(p); err != nil {
+			return err
+		}
+	}
+	if f, ok := t.h.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (t *throttleHandler) Failure(f *Failure) error {
+	return Fail(t.h, f)
+}
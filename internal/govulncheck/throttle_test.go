@@ -0,0 +1,92 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// progressRecorder is a Handler recording progress messages.
+type progressRecorder struct {
+	messages []string
+	flushed  bool
+}
+
+func (r *progressRecorder) Config(*Config) error   { return nil }
+func (r *progressRecorder) SBOM(*SBOM) error       { return nil }
+func (r *progressRecorder) OSV(*osv.Entry) error   { return nil }
+func (r *progressRecorder) Finding(*Finding) error { return nil }
+
+func (r *progressRecorder) Progress(p *Progress) error {
+	r.messages = append(r.messages, p.Message)
+	return nil
+}
+
+func (r *progressRecorder) Flush() error {
+	r.flushed = true
+	return nil
+}
+
+func TestThrottleHandler(t *testing.T) {
+	rec := &progressRecorder{}
+	h := NewThrottleHandler(rec, time.Second).(*throttleHandler)
+	var clock time.Time
+	h.now = func() time.Time { return clock }
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, e := range []struct {
+		after time.Duration
+		msg   string
+	}{
+		{0, "a"},
+		{100 * time.Millisecond, "b"}, // coalesced
+		{900 * time.Millisecond, "c"}, // coalesced
+		{time.Second, "d"},
+		{1500 * time.Millisecond, "e"}, // coalesced
+		{1800 * time.Millisecond, "f"}, // coalesced, forwarded on flush
+	} {
+		clock = start.Add(e.after)
+		if err := h.Progress(&Progress{Message: e.msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff([]string{"a", "d"}, rec.messages); diff != "" {
+		t.Errorf("before flush (-want, +got):\n%s", diff)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"a", "d", "f"}, rec.messages); diff != "" {
+		t.Errorf("after flush (-want, +got):\n%s", diff)
+	}
+	if !rec.flushed {
+		t.Error("want underlying handler flushed")
+	}
+}
+
+func TestThrottleHandlerWarning(t *testing.T) {
+	rec := &progressRecorder{}
+	h := NewThrottleHandler(rec, time.Second).(*throttleHandler)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return clock }
+
+	// Warnings are never coalesced.
+	for _, p := range []*Progress{
+		{Message: "a"},
+		{Message: "w1", Warning: true},
+		{Message: "w2", Warning: true},
+	} {
+		if err := h.Progress(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff([]string{"a", "w1", "w2"}, rec.messages); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/vuln/internal/osv"
+)
+
+// ElidedFunction is the Function of the marker Frame that stands
+// for the frames removed from a trace by TruncateTrace.
+const ElidedFunction = "..."
+
+// Elided reports whether f is the marker of elided frames.
+func (f *Frame) Elided() bool {
+	return f.Module == "" && f.Function == ElidedFunction
+}
+
+// TruncateTrace returns trace truncated to its max frames nearest
+// to the vulnerable symbol followed by the entry point, with a
+// marker Frame standing for the frames in between. The vulnerable
+// symbol and the entry point are always retained.
+//
+// If max is not positive, or trace is already short enough or
+// truncated, trace is returned as is.
+func TruncateTrace(trace []*Frame, max int) []*Frame {
+	if max <= 0 || len(trace) <= max+1 {
+		return trace
+	}
+	for _, f := range trace {
+		if f.Elided() {
+			return trace
+		}
+	}
+	truncated := make([]*Frame, 0, max+2)
+	truncated = append(truncated, trace[:max]...)
+	truncated = append(truncated, &Frame{Function: ElidedFunction})
+	return append(truncated, trace[len(trace)-1])
+}
+
+// collapsedSuffix ends the Function of the marker Frame that
+// stands for the frames removed from a trace by CollapseStdlib.
+const collapsedSuffix = " stdlib frames]"
+
+// Collapsed reports whether f is the marker of collapsed
+// standard library frames.
+func (f *Frame) Collapsed() bool {
+	return f.Module == "" && strings.HasPrefix(f.Function, "[") && strings.HasSuffix(f.Function, collapsedSuffix)
+}
+
+// CollapseStdlib returns trace with each run of two or more
+// consecutive standard library frames replaced by a single marker
+// Frame, whose Function is "[N stdlib frames]". The vulnerable
+// symbol is always retained, even if it is in the standard library.
+//
+// If trace has no such run, it is returned as is.
+func CollapseStdlib(trace []*Frame) []*Frame {
+	if len(trace) == 0 {
+		return trace
+	}
+	collapsed := []*Frame{trace[0]}
+	for i := 1; i < len(trace); {
+		j := i
+		for j < len(trace) && trace[j].Module == osv.GoStdModulePath {
+			j++
+		}
+		switch n := j - i; {
+		case n >= 2:
+			collapsed = append(collapsed, &Frame{Function: fmt.Sprintf("[%d%s", n, collapsedSuffix)})
+			i = j
+		default:
+			collapsed = append(collapsed, trace[i])
+			i++
+		}
+	}
+	if len(collapsed) == len(trace) {
+		return trace
+	}
+	return collapsed
+}
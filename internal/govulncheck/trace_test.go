@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTruncateTrace(t *testing.T) {
+	var trace []*Frame
+	for i := 0; i < 50; i++ {
+		trace = append(trace, &Frame{Module: "m", Package: "m/p", Function: fmt.Sprintf("F%d", i)})
+	}
+
+	got := TruncateTrace(trace, 10)
+	if len(got) != 12 {
+		t.Fatalf("want 12 frames; got %d", len(got))
+	}
+	for i := 0; i < 10; i++ {
+		if got[i] != trace[i] {
+			t.Errorf("frame %d: want %s; got %s", i, trace[i].Function, got[i].Function)
+		}
+	}
+	if !got[10].Elided() {
+		t.Errorf("want elided frame; got %s", got[10].Function)
+	}
+	if got[11] != trace[49] {
+		t.Errorf("want entry point %s; got %s", trace[49].Function, got[11].Function)
+	}
+
+	// Truncation is idempotent.
+	if again := TruncateTrace(got, 5); len(again) != len(got) {
+		t.Errorf("want truncated trace unchanged; got %d frames", len(again))
+	}
+	for _, max := range []int{0, 49, 50} {
+		if got := TruncateTrace(trace, max); len(got) != len(trace) {
+			t.Errorf("max %d: want trace unchanged; got %d frames", max, len(got))
+		}
+	}
+}
+
+func TestCollapseStdlib(t *testing.T) {
+	std := func(f string) *Frame { return &Frame{Module: "stdlib", Package: "net/http", Function: f} }
+	user := func(f string) *Frame { return &Frame{Module: "m", Package: "m/p", Function: f} }
+	trace := []*Frame{
+		std("V"), std("S1"), std("S2"), user("U1"), std("S3"), user("U2"), std("S4"), std("S5"), std("S6"), user("main"),
+	}
+	var got []string
+	for _, f := range CollapseStdlib(trace) {
+		got = append(got, f.Function)
+	}
+	// The vulnerable symbol and a single stdlib frame are kept.
+	want := []string{"V", "[2 stdlib frames]", "U1", "S3", "U2", "[3 stdlib frames]", "main"}
+	if len(got) != len(want) {
+		t.Fatalf("want %v; got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("frame %d: want %s; got %s", i, want[i], got[i])
+		}
+	}
+	collapsed := CollapseStdlib(trace)
+	if !collapsed[1].Collapsed() || collapsed[1].Elided() || trace[1].Collapsed() {
+		t.Error("want only the markers to be collapsed frames")
+	}
+	if err := ValidateFinding(&Finding{OSV: "GO-0000-0001", Trace: collapsed}); err != nil {
+		t.Errorf("want collapsed trace to be valid; got %v", err)
+	}
+
+	// Collapsing is idempotent.
+	if again := CollapseStdlib(collapsed); len(again) != len(collapsed) {
+		t.Errorf("want collapsed trace unchanged; got %d frames", len(again))
+	}
+}
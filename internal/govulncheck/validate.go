@@ -0,0 +1,116 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck
+
+import (
+	"fmt"
+
+	"golang.org/x/vuln/internal/osv"
+)
+
+// ValidateFinding checks that f conforms to the documented
+// shape of findings in the JSON protocol.
+func ValidateFinding(f *Finding) error {
+	if f.OSV == "" {
+		return fmt.Errorf("invalid finding: all findings must have an associated OSV")
+	}
+	if len(f.Trace) < 1 {
+		return fmt.Errorf("invalid finding: all callstacks must have at least one frame")
+	}
+	if f.Trace[0].Module == "" {
+		return fmt.Errorf("invalid finding: the first frame must have the vulnerable module")
+	}
+	for _, frame := range f.Trace {
+		if frame.Elided() || frame.Collapsed() {
+			continue
+		}
+		if frame.Version != "" && frame.Module == "" {
+			return fmt.Errorf("invalid finding: if Frame.Version (%s) is set, Frame.Module must also be", frame.Version)
+		}
+		if frame.Package != "" && frame.Module == "" {
+			return fmt.Errorf("invalid finding: if Frame.Package (%s) is set, Frame.Module must also be", frame.Package)
+		}
+		if frame.Function != "" && frame.Package == "" {
+			return fmt.Errorf("invalid finding: if Frame.Function (%s) is set, Frame.Package must also be", frame.Function)
+		}
+	}
+	return nil
+}
+
+// ValidateOSV checks that e has the fields required by
+// the JSON protocol.
+func ValidateOSV(e *osv.Entry) error {
+	if e.ID == "" {
+		return fmt.Errorf("invalid osv: all entries must have an ID")
+	}
+	for _, a := range e.Affected {
+		if a.Module.Path == "" {
+			return fmt.Errorf("invalid osv %s: all affected entries must have a module path", e.ID)
+		}
+	}
+	return nil
+}
+
+// validateHandler is a Handler that validates OSV entries
+// and findings before passing them to the underlying handler.
+type validateHandler struct {
+	h Handler
+}
+
+// NewValidateHandler returns a handler that forwards messages to h,
+// failing on the first OSV entry or finding that is not valid per
+// ValidateOSV and ValidateFinding. It is meant for catching
+// regressions in tests and debugging.
+func NewValidateHandler(h Handler) Handler {
+	return &validateHandler{h: h}
+}
+
+func (v *validateHandler) Config(config *Config) error {
+	return v.h.Config(config)
+}
+
+func (v *validateHandler) SBOM(sbom *SBOM) error {
+	return v.h.SBOM(sbom)
+}
+
+func (v *validateHandler) Progress(progress *Progress) error {
+	return v.h.Progress(progress)
+}
+
+func (v *validateHandler) OSV(entry *osv.Entry) error {
+	if err := ValidateOSV(entry); err != nil {
+		return err
+	}
+	return v.h.OSV(entry)
+}
+
+func (v *validateHandler) Finding(finding *Finding) error {
+	if err := ValidateFinding(finding); err != nil {
+		return err
+	}
+	return v.h.Finding(finding)
+}
+
+// Streaming reports whether the underlying handler is streaming.
+func (v *validateHandler) Streaming() bool {
+	return Streaming(v.h)
+}
+
+// Concurrent reports whether the underlying handler is concurrent.
+func (v *validateHandler) Concurrent() bool {
+	return Concurrent(v.h)
+}
+
+// Flush flushes the underlying handler, if it supports flushing.
+func (v *validateHandler) Flush() error {
+	if f, ok := v.h.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (v *validateHandler) Failure(f *Failure) error {
+	return Fail(v.h, f)
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package govulncheck_test
+
+import (
+	"testing"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+func TestValidateFinding(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		finding *govulncheck.Finding
+		valid   bool
+	}{
+		{"module", &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m", Version: "v1.0.0"}}}, true},
+		{"call", &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{
+			{Module: "m", Package: "m/p", Function: "F"},
+			{Function: govulncheck.ElidedFunction},
+			{Module: "main", Package: "main", Function: "main"},
+		}}, true},
+		{"no-osv", &govulncheck.Finding{Trace: []*govulncheck.Frame{{Module: "m"}}}, false},
+		{"no-trace", &govulncheck.Finding{OSV: "GO-0000-0001"}, false},
+		{"no-vulnerable-module", &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{}}}, false},
+		{"version-without-module", &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m"}, {Version: "v1.0.0"}}}, false},
+		{"package-without-module", &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m"}, {Package: "p"}}}, false},
+		{"function-without-package", &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m", Function: "F"}}}, false},
+	} {
+		err := govulncheck.ValidateFinding(tc.finding)
+		if tc.valid && err != nil {
+			t.Errorf("%s: want valid; got %v", tc.name, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%s: want validation error; got none", tc.name)
+		}
+	}
+}
+
+func TestValidateOSV(t *testing.T) {
+	if err := govulncheck.ValidateOSV(&osv.Entry{ID: "GO-0000-0001", Affected: []osv.Affected{{Module: osv.Module{Path: "m"}}}}); err != nil {
+		t.Errorf("want valid; got %v", err)
+	}
+	if err := govulncheck.ValidateOSV(&osv.Entry{}); err == nil {
+		t.Error("want error for entry without ID; got none")
+	}
+	if err := govulncheck.ValidateOSV(&osv.Entry{ID: "GO-0000-0001", Affected: []osv.Affected{{}}}); err == nil {
+		t.Error("want error for affected without module path; got none")
+	}
+}
+
+func TestValidateHandler(t *testing.T) {
+	mock := test.NewMockHandler()
+	h := govulncheck.NewValidateHandler(mock)
+	if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001"}); err == nil {
+		t.Error("want error for finding without trace; got none")
+	}
+	if err := h.OSV(&osv.Entry{}); err == nil {
+		t.Error("want error for entry without ID; got none")
+	}
+	if got := len(mock.FindingMessages); got != 1 {
+		t.Errorf("want only the valid finding forwarded; got %d", got)
+	}
+	if got := len(mock.OSVMessages); got != 0 {
+		t.Errorf("want no invalid entry forwarded; got %d", got)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package grype defines the types of the JSON output of Grype, the
+// vulnerability scanner of Anchore, supported by govulncheck.
+//
+// The output is a document of matches, following the JSON output of
+// Grype at https://github.com/anchore/grype. Each vulnerability found
+// at the scan level, that is, called in a symbol level scan, imported
+// in a package level scan, or required in a module level scan, yields
+// a match per vulnerable module. The artifact of the match is the
+// module, as a Syft "go-module" package found by FoundBy, and its match
+// details tell the level at which govulncheck found the vulnerability.
+//
+// This is intended to be the minimal amount of information required
+// for Anchore tooling to consume the findings of govulncheck alongside
+// those of Grype.
+package grype
+
+const (
+	// FoundBy is the note of the artifacts of matches,
+	// telling that they were found by govulncheck.
+	FoundBy = "govulncheck reachability"
+	// Namespace is the namespace of the vulnerabilities
+	// of the Go vulnerability database.
+	Namespace = "go:language:go"
+
+	ArtifactType = "go-module"
+	Language     = "go"
+	MatchType    = "exact-direct-match"
+	Matcher      = "go-module-matcher"
+
+	// The following are the fix states of Grype.
+	FixStateFixed    = "fixed"
+	FixStateNotFixed = "not-fixed"
+)
+
+// Document is the top-level Grype JSON object.
+type Document struct {
+	Matches    []Match    `json:"matches"`
+	Descriptor Descriptor `json:"descriptor"`
+}
+
+// Match is a vulnerability found in an artifact.
+type Match struct {
+	Vulnerability Vulnerability `json:"vulnerability"`
+	// RelatedVulnerabilities are the aliases of the vulnerability.
+	RelatedVulnerabilities []VulnerabilityMetadata `json:"relatedVulnerabilities"`
+	MatchDetails           []MatchDetails          `json:"matchDetails"`
+	Artifact               Package                 `json:"artifact"`
+}
+
+// VulnerabilityMetadata identifies a vulnerability.
+type VulnerabilityMetadata struct {
+	ID         string `json:"id"`
+	DataSource string `json:"dataSource"`
+	Namespace  string `json:"namespace,omitempty"`
+	// Severity is one of "Critical", "High", "Medium",
+	// "Low", "Negligible", and "Unknown".
+	Severity    string   `json:"severity,omitempty"`
+	URLs        []string `json:"urls"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Vulnerability is a vulnerability with its fix.
+type Vulnerability struct {
+	VulnerabilityMetadata
+	Fix Fix `json:"fix"`
+}
+
+// Fix describes the versions fixing a vulnerability.
+type Fix struct {
+	Versions []string `json:"versions"`
+	// State is FixStateFixed or FixStateNotFixed.
+	State string `json:"state"`
+}
+
+// MatchDetails tells how a match was found.
+type MatchDetails struct {
+	Type       string     `json:"type"`
+	Matcher    string     `json:"matcher"`
+	SearchedBy SearchedBy `json:"searchedBy"`
+	Found      Found      `json:"found"`
+}
+
+// SearchedBy is the artifact searched for vulnerabilities.
+type SearchedBy struct {
+	Language  string     `json:"language"`
+	Namespace string     `json:"namespace"`
+	Package   PackageRef `json:"package"`
+}
+
+// PackageRef is a package at a version.
+type PackageRef struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Found is the vulnerability found for the artifact.
+type Found struct {
+	VulnerabilityID string `json:"vulnerabilityID"`
+	// Level is the most precise level at which the vulnerability
+	// was found: "symbol", "package", or "module".
+	Level string `json:"level"`
+	// Symbols are the vulnerable symbols called, if any.
+	Symbols []string `json:"symbols,omitempty"`
+}
+
+// Package is a Syft package, the artifact of a match.
+type Package struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Version   string     `json:"version"`
+	Type      string     `json:"type"`
+	FoundBy   string     `json:"foundBy"`
+	Locations []Location `json:"locations"`
+	Language  string     `json:"language"`
+	Licenses  []string   `json:"licenses"`
+	CPEs      []string   `json:"cpes"`
+	PURL      string     `json:"purl"`
+	Upstreams []string   `json:"upstreams"`
+}
+
+// Location is a file where a package was found,
+// which govulncheck does not report.
+type Location struct {
+	Path string `json:"path"`
+}
+
+// Descriptor describes the tool producing the document.
+type Descriptor struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// Timestamp is the time of the scan in RFC 3339 format.
+	Timestamp string `json:"timestamp"`
+}
@@ -0,0 +1,252 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package grype
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/vuln/internal/cvss"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// severities are the Grype severities of CVSS ratings.
+var severities = map[string]string{
+	cvss.RatingNone:     "Negligible",
+	cvss.RatingLow:      "Low",
+	cvss.RatingMedium:   "Medium",
+	cvss.RatingHigh:     "High",
+	cvss.RatingCritical: "Critical",
+}
+
+type handler struct {
+	w   io.Writer
+	cfg *govulncheck.Config
+	// now returns the time of the scan.
+	now  func() time.Time
+	osvs map[string]*osv.Entry
+	// findings contains same-level findings for an
+	// OSV at the most precise level of granularity
+	// available.
+	findings map[string][]*govulncheck.Finding
+}
+
+// NewHandler returns a handler that writes findings to w as
+// a Grype JSON document once all of them are known.
+func NewHandler(w io.Writer) *handler {
+	return &handler{
+		w:        w,
+		now:      time.Now,
+		osvs:     make(map[string]*osv.Entry),
+		findings: make(map[string][]*govulncheck.Finding),
+	}
+}
+
+func (h *handler) Config(cfg *govulncheck.Config) error {
+	h.cfg = cfg
+	return nil
+}
+
+func (h *handler) Progress(progress *govulncheck.Progress) error {
+	return nil
+}
+
+func (h *handler) SBOM(s *govulncheck.SBOM) error {
+	return nil
+}
+
+func (h *handler) OSV(e *osv.Entry) error {
+	h.osvs[e.ID] = e
+	return nil
+}
+
+// Finding keeps f if it is at least as precise
+// as the findings of its OSV kept so far.
+func (h *handler) Finding(f *govulncheck.Finding) error {
+	h.findings[f.OSV] = govulncheck.MostPrecise(h.findings[f.OSV], f)
+	return nil
+}
+
+// Streaming returns false as the Grype output is a single
+// JSON document that can only be produced once all
+// findings are known.
+func (h *handler) Streaming() bool {
+	return false
+}
+
+// Flush writes the Grype document to w.
+func (h *handler) Flush() error {
+	doc := toGrype(h, h.now())
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = h.w.Write(out)
+	return err
+}
+
+// toGrype returns the Grype document of the findings
+// of h at the scan level, for a scan at time now.
+func toGrype(h *handler, now time.Time) Document {
+	var ids []string
+	for id, fs := range h.findings {
+		// Findings of OSVs not reported by the handler
+		// cannot be described, which should not happen.
+		if h.osvs[id] != nil && govulncheck.Level(fs[0]).Precision() >= h.cfg.ScanLevel.Precision() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	doc := Document{
+		Matches: []Match{},
+		Descriptor: Descriptor{
+			Name:      h.cfg.ScannerName,
+			Version:   h.cfg.ScannerVersion,
+			Timestamp: now.UTC().Format(time.RFC3339),
+		},
+	}
+	if doc.Descriptor.Name == "" {
+		doc.Descriptor.Name = "govulncheck"
+	}
+	for _, id := range ids {
+		e := h.osvs[id]
+		fs := h.findings[id]
+		for _, m := range modules(fs) {
+			doc.Matches = append(doc.Matches, match(h, e, fs, m))
+		}
+	}
+	return doc
+}
+
+// module is a module at a version.
+type module struct {
+	path    string
+	version string
+}
+
+// modules returns the sorted distinct vulnerable modules of findings.
+func modules(findings []*govulncheck.Finding) []module {
+	seen := make(map[module]bool)
+	var mods []module
+	for _, f := range findings {
+		m := module{path: f.Trace[0].Module, version: f.Trace[0].Version}
+		if !seen[m] {
+			seen[m] = true
+			mods = append(mods, m)
+		}
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].path != mods[j].path {
+			return mods[i].path < mods[j].path
+		}
+		return mods[i].version < mods[j].version
+	})
+	return mods
+}
+
+// match returns the match of the vulnerability e
+// in the module m, found by findings.
+func match(h *handler, e *osv.Entry, findings []*govulncheck.Finding, m module) Match {
+	v := Vulnerability{
+		VulnerabilityMetadata: VulnerabilityMetadata{
+			ID:          e.ID,
+			DataSource:  fmt.Sprintf("https://pkg.go.dev/vuln/%s", e.ID),
+			Namespace:   Namespace,
+			Severity:    severity(h, e),
+			URLs:        []string{},
+			Description: e.Summary,
+		},
+		Fix: Fix{Versions: []string{}, State: FixStateNotFixed},
+	}
+	for _, r := range e.References {
+		v.URLs = append(v.URLs, r.URL)
+	}
+	var symbols []string
+	for _, f := range findings {
+		fr := f.Trace[0]
+		if fr.Module != m.path || fr.Version != m.version {
+			continue
+		}
+		if f.FixedVersion != "" && v.Fix.State != FixStateFixed {
+			v.Fix = Fix{Versions: []string{f.FixedVersion}, State: FixStateFixed}
+		}
+		if fr.Function != "" {
+			symbols = append(symbols, symbol(fr))
+		}
+	}
+	sort.Strings(symbols)
+
+	related := []VulnerabilityMetadata{}
+	for _, a := range e.Aliases {
+		related = append(related, VulnerabilityMetadata{ID: a, DataSource: v.DataSource, URLs: []string{}})
+	}
+	return Match{
+		Vulnerability:          v,
+		RelatedVulnerabilities: related,
+		MatchDetails: []MatchDetails{{
+			Type:    MatchType,
+			Matcher: Matcher,
+			SearchedBy: SearchedBy{
+				Language:  Language,
+				Namespace: Namespace,
+				Package:   PackageRef{Name: m.path, Version: m.version},
+			},
+			Found: Found{
+				VulnerabilityID: e.ID,
+				Level:           string(govulncheck.Level(findings[0])),
+				Symbols:         symbols,
+			},
+		}},
+		Artifact: Package{
+			ID:        artifactID(m),
+			Name:      m.path,
+			Version:   m.version,
+			Type:      ArtifactType,
+			FoundBy:   FoundBy,
+			Locations: []Location{},
+			Language:  Language,
+			Licenses:  []string{},
+			CPEs:      []string{},
+			PURL:      govulncheck.PURL(m.path, m.version),
+			Upstreams: []string{},
+		},
+	}
+}
+
+// severity returns the Grype severity of e, which is its rating
+// in the severity overrides of the config, if any, and the rating
+// of its CVSS v3 severity otherwise. It is "Unknown" if e has
+// neither.
+func severity(h *handler, e *osv.Entry) string {
+	if r, ok := cvss.OverriddenRating(e, h.cfg.SeverityOverrides); ok {
+		return severities[r]
+	}
+	if score, ok := cvss.EntryScore(e); ok {
+		return severities[cvss.Rating(score)]
+	}
+	return "Unknown"
+}
+
+// symbol returns the name of the function of fr,
+// qualified by its receiver, if any.
+func symbol(fr *govulncheck.Frame) string {
+	if fr.Receiver != "" {
+		return strings.TrimPrefix(fr.Receiver, "*") + "." + fr.Function
+	}
+	return fr.Function
+}
+
+// artifactID returns the ID of the artifact of m, a hash of
+// its package URL, so that it is stable across runs.
+func artifactID(m module) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(govulncheck.PURL(m.path, m.version))))[:16]
+}
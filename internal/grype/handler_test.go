@@ -0,0 +1,155 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package grype
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+func TestHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	h.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol, ScannerName: "govulncheck", ScannerVersion: "v1.1.0"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []*osv.Entry{
+		{
+			ID:         "GO-2021-0265",
+			Summary:    "Panic in gjson",
+			Aliases:    []string{"CVE-2021-42248"},
+			Severity:   []osv.Severity{{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H"}},
+			References: []osv.Reference{{Type: osv.ReferenceTypeFix, URL: "https://github.com/tidwall/gjson/commit/1"}},
+		},
+		{ID: "GO-2021-0001"},
+	} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []*govulncheck.Finding{
+		{OSV: "GO-2021-0265", FixedVersion: "v1.9.3", Trace: []*govulncheck.Frame{{Module: "github.com/tidwall/gjson", Version: "v1.6.5"}}},
+		{OSV: "GO-2021-0265", FixedVersion: "v1.9.3", Trace: []*govulncheck.Frame{{
+			Module:   "github.com/tidwall/gjson",
+			Version:  "v1.6.5",
+			Package:  "github.com/tidwall/gjson",
+			Function: "Get",
+		}}},
+		// Vulnerabilities imported but not called
+		// are not matches of a symbol level scan.
+		{OSV: "GO-2021-0001", Trace: []*govulncheck.Frame{{Module: "example.com/m", Version: "v1.0.0", Package: "example.com/m/p"}}},
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got Document
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := Document{
+		Matches: []Match{{
+			Vulnerability: Vulnerability{
+				VulnerabilityMetadata: VulnerabilityMetadata{
+					ID:          "GO-2021-0265",
+					DataSource:  "https://pkg.go.dev/vuln/GO-2021-0265",
+					Namespace:   Namespace,
+					Severity:    "High",
+					URLs:        []string{"https://github.com/tidwall/gjson/commit/1"},
+					Description: "Panic in gjson",
+				},
+				Fix: Fix{Versions: []string{"v1.9.3"}, State: FixStateFixed},
+			},
+			RelatedVulnerabilities: []VulnerabilityMetadata{{
+				ID:         "CVE-2021-42248",
+				DataSource: "https://pkg.go.dev/vuln/GO-2021-0265",
+				URLs:       []string{},
+			}},
+			MatchDetails: []MatchDetails{{
+				Type:    MatchType,
+				Matcher: Matcher,
+				SearchedBy: SearchedBy{
+					Language:  "go",
+					Namespace: Namespace,
+					Package:   PackageRef{Name: "github.com/tidwall/gjson", Version: "v1.6.5"},
+				},
+				Found: Found{VulnerabilityID: "GO-2021-0265", Level: "symbol", Symbols: []string{"Get"}},
+			}},
+			Artifact: Package{
+				ID:        artifactID(module{"github.com/tidwall/gjson", "v1.6.5"}),
+				Name:      "github.com/tidwall/gjson",
+				Version:   "v1.6.5",
+				Type:      "go-module",
+				FoundBy:   "govulncheck reachability",
+				Locations: []Location{},
+				Language:  "go",
+				Licenses:  []string{},
+				CPEs:      []string{},
+				PURL:      "pkg:golang/github.com%2Ftidwall%2Fgjson@v1.6.5",
+				Upstreams: []string{},
+			},
+		}},
+		Descriptor: Descriptor{Name: "govulncheck", Version: "v1.1.0", Timestamp: "2024-01-02T03:04:05Z"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("document mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestNoMatches(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// Grype tooling expects a list of matches, even if empty.
+	var doc map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := doc["matches"].([]any); !ok || len(m) != 0 {
+		t.Errorf("got matches %v; want an empty list", doc["matches"])
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	cvss := []osv.Severity{{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H"}}
+	h := NewHandler(nil)
+	h.cfg = &govulncheck.Config{SeverityOverrides: map[string]string{
+		"GO-0000-0003":  "low",
+		"CVE-0000-0004": "critical",
+		"GO-0000-0005":  "bogus",
+	}}
+	for _, test := range []struct {
+		e    *osv.Entry
+		want string
+	}{
+		{&osv.Entry{ID: "GO-0000-0001"}, "Unknown"},
+		{&osv.Entry{ID: "GO-0000-0002", Severity: cvss}, "High"},
+		{&osv.Entry{ID: "GO-0000-0003", Severity: cvss}, "Low"},
+		// Overrides rate entries without CVSS severity.
+		{&osv.Entry{ID: "GO-0000-0004", Aliases: []string{"CVE-0000-0004"}}, "Critical"},
+		{&osv.Entry{ID: "GO-0000-0005", Severity: cvss}, "High"},
+	} {
+		if got := severity(h, test.e); got != test.want {
+			t.Errorf("%s: got %s; want %s", test.e.ID, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package metrics implements a govulncheck handler writing a summary
+// of the scan as gauges in the Prometheus text exposition format, for
+// collection by, say, the textfile collector of node_exporter.
+//
+// The output is written once the scan is over. Vulnerabilities are
+// counted at the most precise level at which they were found: "symbol"
+// if a vulnerable symbol is called, "package" if a vulnerable package
+// is imported, and "module" if a vulnerable module is only required.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// Names of the metrics.
+const (
+	VulnerabilitiesMetric = "govulncheck_vulnerabilities"
+	FindingsMetric        = "govulncheck_findings"
+	FixableMetric         = "govulncheck_fixable_vulnerabilities"
+	CompleteMetric        = "govulncheck_scan_complete"
+)
+
+// levels are the values of the level label, indexed by their precision.
+var levels = []string{govulncheck.ScanLevelModule, govulncheck.ScanLevelPackage, govulncheck.ScanLevelSymbol}
+
+type handler struct {
+	w   io.Writer
+	cfg *govulncheck.Config
+	// findings contains same-level findings for an
+	// OSV at the most precise level of granularity
+	// available.
+	findings map[string][]*govulncheck.Finding
+	// failed is set if the scan stopped because of an error.
+	failed bool
+}
+
+// NewHandler returns a handler that writes metrics of the scan to w.
+func NewHandler(w io.Writer) *handler {
+	return &handler{
+		w:        w,
+		findings: make(map[string][]*govulncheck.Finding),
+	}
+}
+
+func (h *handler) Config(cfg *govulncheck.Config) error {
+	h.cfg = cfg
+	return nil
+}
+
+func (h *handler) Progress(progress *govulncheck.Progress) error {
+	return nil
+}
+
+func (h *handler) SBOM(s *govulncheck.SBOM) error {
+	return nil
+}
+
+func (h *handler) OSV(e *osv.Entry) error {
+	return nil
+}
+
+// Finding keeps f if it is at least as precise
+// as the findings of its OSV kept so far.
+func (h *handler) Finding(f *govulncheck.Finding) error {
+	h.findings[f.OSV] = govulncheck.MostPrecise(h.findings[f.OSV], f)
+	return nil
+}
+
+// Failure records that the scan stopped because of an error,
+// in which case the metrics describe the findings so far.
+func (h *handler) Failure(f *govulncheck.Failure) error {
+	h.failed = true
+	return nil
+}
+
+// Streaming returns false as the metrics summarize
+// all findings, once they are known.
+func (h *handler) Streaming() bool {
+	return false
+}
+
+// Flush writes the metrics to w.
+func (h *handler) Flush() error {
+	vulns := make([]int, len(levels))
+	findings := make([]int, len(levels))
+	fixable := 0
+	for _, fs := range h.findings {
+		l := govulncheck.Level(fs[0]).Precision()
+		vulns[l]++
+		findings[l] += len(fs)
+		if l >= h.cfg.ScanLevel.Precision() && fs[0].FixedVersion != "" {
+			fixable++
+		}
+	}
+
+	var b strings.Builder
+	gauge(&b, VulnerabilitiesMetric, "Number of vulnerabilities by the most precise level at which they were found.")
+	for i, l := range levels {
+		fmt.Fprintf(&b, "%s{level=%q} %d\n", VulnerabilitiesMetric, l, vulns[i])
+	}
+	gauge(&b, FindingsMetric, "Number of findings of vulnerabilities by the most precise level at which they were found.")
+	for i, l := range levels {
+		fmt.Fprintf(&b, "%s{level=%q} %d\n", FindingsMetric, l, findings[i])
+	}
+	gauge(&b, FixableMetric, "Number of vulnerabilities found at the scan level that have a fixed version.")
+	fmt.Fprintf(&b, "%s %d\n", FixableMetric, fixable)
+	gauge(&b, CompleteMetric, "Whether the scan completed, 1, or stopped because of an error, 0.")
+	complete := 1
+	if h.failed {
+		complete = 0
+	}
+	fmt.Fprintf(&b, "%s %d\n", CompleteMetric, complete)
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// gauge writes the HELP and TYPE lines of the gauge name.
+func gauge(b *strings.Builder, name, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", name)
+}
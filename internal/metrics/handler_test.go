@@ -0,0 +1,91 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+)
+
+// parse returns the samples of the metrics in the
+// Prometheus text exposition format out, keyed by
+// metric name and labels, and the types of metrics.
+func parse(t *testing.T, out []byte) (samples map[string]float64, types map[string]string) {
+	samples = make(map[string]float64)
+	types = make(map[string]string)
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := s.Text()
+		if typ, ok := strings.CutPrefix(line, "# TYPE "); ok {
+			name, typ, _ := strings.Cut(typ, " ")
+			types[name] = typ
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		if i < 0 {
+			t.Fatalf("invalid sample %q", line)
+		}
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("invalid sample %q: %v", line, err)
+		}
+		samples[line[:i]] = v
+	}
+	return samples, types
+}
+
+func TestMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []*govulncheck.Finding{
+		{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m1"}}},
+		{OSV: "GO-0000-0001", FixedVersion: "v1.0.1", Trace: []*govulncheck.Frame{{Module: "m1", Package: "m1/p", Function: "F"}}},
+		{OSV: "GO-0000-0001", FixedVersion: "v1.0.1", Trace: []*govulncheck.Frame{{Module: "m1", Package: "m1/p", Function: "G"}}},
+		{OSV: "GO-0000-0002", FixedVersion: "v1.0.1", Trace: []*govulncheck.Frame{{Module: "m2", Package: "m2/p"}}},
+		{OSV: "GO-0000-0003", Trace: []*govulncheck.Frame{{Module: "m3"}}},
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Failure(&govulncheck.Failure{Message: "failed"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, types := parse(t, buf.Bytes())
+	want := map[string]float64{
+		`govulncheck_vulnerabilities{level="symbol"}`:  1,
+		`govulncheck_vulnerabilities{level="package"}`: 1,
+		`govulncheck_vulnerabilities{level="module"}`:  1,
+		`govulncheck_findings{level="symbol"}`:         2,
+		`govulncheck_findings{level="package"}`:        1,
+		`govulncheck_findings{level="module"}`:         1,
+		`govulncheck_fixable_vulnerabilities`:          1,
+		`govulncheck_scan_complete`:                    0,
+	}
+	if diff := cmp.Diff(want, samples); diff != "" {
+		t.Errorf("samples mismatch (-want, +got):\n%s", diff)
+	}
+	for _, name := range []string{VulnerabilitiesMetric, FindingsMetric, FixableMetric, CompleteMetric} {
+		if types[name] != "gauge" {
+			t.Errorf("got type %q for %s; want gauge", types[name], name)
+		}
+	}
+}
@@ -78,52 +78,8 @@ func foundAtLevel(f *govulncheck.Finding) findingLevel {
 	return required
 }
 
-// moreSpecific favors a call finding over a non-call
-// finding and a package finding over a module finding.
-func moreSpecific(f1, f2 *govulncheck.Finding) int {
-	if len(f1.Trace) > 1 && len(f2.Trace) > 1 {
-		// Both are call stack findings.
-		return 0
-	}
-	if len(f1.Trace) > 1 {
-		return -1
-	}
-	if len(f2.Trace) > 1 {
-		return 1
-	}
-
-	fr1, fr2 := f1.Trace[0], f2.Trace[0]
-	if fr1.Function != "" && fr2.Function == "" {
-		return -1
-	}
-	if fr1.Function == "" && fr2.Function != "" {
-		return 1
-	}
-	if fr1.Package != "" && fr2.Package == "" {
-		return -1
-	}
-	if fr1.Package == "" && fr2.Package != "" {
-		return -1
-	}
-	return 0 // findings always have module info
-}
-
 func (h *handler) Finding(f *govulncheck.Finding) error {
-	fs := h.findings[f.OSV]
-	if len(fs) == 0 {
-		fs = []*govulncheck.Finding{f}
-	} else {
-		if ms := moreSpecific(f, fs[0]); ms == -1 {
-			// The new finding is more specific, so we need
-			// to erase existing findings and add the new one.
-			fs = []*govulncheck.Finding{f}
-		} else if ms == 0 {
-			// The new finding is at the same level of precision.
-			fs = append(fs, f)
-		}
-		// Otherwise, the new finding is at a less precise level.
-	}
-	h.findings[f.OSV] = fs
+	h.findings[f.OSV] = govulncheck.MostPrecise(h.findings[f.OSV], f)
 	return nil
 }
 
@@ -216,6 +172,12 @@ func statements(h *handler) []Statement {
 		fLevel := foundAtLevel(h.findings[id][0])
 		if fLevel >= scanLevel {
 			s.Status = StatusAffected
+			// A module scan only tells that the vulnerable module
+			// is required, which is not enough to decide whether
+			// the product is affected.
+			if fLevel == required {
+				s.Status = StatusUnderInvestigation
+			}
 		} else {
 			s.Status = StatusNotAffected
 			s.ImpactStatement = Impact
@@ -5,9 +5,11 @@
 package openvex
 
 import (
+	"io"
 	"testing"
 
 	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
 )
 
 func TestSubcomponentSet(t *testing.T) {
@@ -118,65 +120,53 @@ func TestSubcomponentSet(t *testing.T) {
 	}
 }
 
-// Copied from internal/sarif
-func TestMoreSpecific(t *testing.T) {
-	frame := func(m, p, f string) *govulncheck.Frame {
-		return &govulncheck.Frame{
-			Module:   m,
-			Package:  p,
-			Function: f,
+func TestFindingOrder(t *testing.T) {
+	pkg := &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m1", Package: "p1"}}}
+	mod := &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m1"}}}
+	for _, order := range [][]*govulncheck.Finding{{pkg, mod}, {mod, pkg}} {
+		h := NewHandler(io.Discard)
+		for _, f := range order {
+			if err := h.Finding(f); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if fs := h.findings["GO-0000-0001"]; len(fs) != 1 || fs[0] != pkg {
+			t.Errorf("got findings %v; want the package finding only", fs)
 		}
 	}
+}
 
+func TestStatements(t *testing.T) {
+	called := []*govulncheck.Frame{{Module: "m", Package: "m/p", Function: "F"}, {Module: "main", Package: "main", Function: "main"}}
+	imported := []*govulncheck.Frame{{Module: "m", Package: "m/p"}}
+	required := []*govulncheck.Frame{{Module: "m"}}
 	for _, tc := range []struct {
-		name   string
-		want   int
-		trace1 []*govulncheck.Frame
-		trace2 []*govulncheck.Frame
+		name          string
+		level         govulncheck.ScanLevel
+		trace         []*govulncheck.Frame
+		status        string
+		justification string
 	}{
-		{"sym-vs-sym", 0,
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "v1"), frame("m1", "p1", "f2")},
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "v2"), frame("m1", "p1", "f1"), frame("m2", "p2", "f2")},
-		},
-		{"sym-vs-pkg", -1,
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "v1"), frame("m1", "p1", "f2")},
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "")},
-		},
-		{"pkg-vs-sym", 1,
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "")},
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "v1"), frame("m2", "p2", "v2")},
-		},
-		{"pkg-vs-mod", -1,
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "")},
-			[]*govulncheck.Frame{
-				frame("m1", "", "")},
-		},
-		{"mod-vs-sym", 1,
-			[]*govulncheck.Frame{
-				frame("m1", "", "")},
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "v2"), frame("m1", "p1", "f1")},
-		},
-		{"mod-vs-mod", 0,
-			[]*govulncheck.Frame{
-				frame("m1", "", "")},
-			[]*govulncheck.Frame{
-				frame("m2", "", "")},
-		},
+		{"symbol-called", govulncheck.ScanLevelSymbol, called, StatusAffected, ""},
+		{"symbol-imported", govulncheck.ScanLevelSymbol, imported, StatusNotAffected, JustificationNotExecuted},
+		{"symbol-required", govulncheck.ScanLevelSymbol, required, StatusNotAffected, JustificationNotPresent},
+		{"package-imported", govulncheck.ScanLevelPackage, imported, StatusAffected, ""},
+		{"module-required", govulncheck.ScanLevelModule, required, StatusUnderInvestigation, ""},
 	} {
-		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			f1 := &govulncheck.Finding{Trace: tc.trace1}
-			f2 := &govulncheck.Finding{Trace: tc.trace2}
-			if got := moreSpecific(f1, f2); got != tc.want {
-				t.Errorf("want %d; got %d", tc.want, got)
+			h := NewHandler(nil)
+			h.Config(&govulncheck.Config{ScanLevel: tc.level})
+			h.OSV(&osv.Entry{ID: "GO-0000-0001"})
+			h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: tc.trace})
+			ss := statements(h)
+			if len(ss) != 1 {
+				t.Fatalf("want 1 statement; got %d", len(ss))
+			}
+			if got := ss[0].Status; got != tc.status {
+				t.Errorf("status: want %s; got %s", tc.status, got)
+			}
+			if got := ss[0].Justification; got != tc.justification {
+				t.Errorf("justification: want %q; got %q", tc.justification, got)
 			}
 		})
 	}
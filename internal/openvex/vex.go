@@ -23,8 +23,9 @@ const (
 	DefaultPID    = "Unknown Product"
 
 	// The following are defined by the VEX standard.
-	StatusAffected    = "affected"
-	StatusNotAffected = "not_affected"
+	StatusAffected           = "affected"
+	StatusNotAffected        = "not_affected"
+	StatusUnderInvestigation = "under_investigation"
 
 	// The following are defined by the VEX standard.
 	JustificationNotExecuted = "vulnerable_code_not_in_execute_path"
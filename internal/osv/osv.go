@@ -20,14 +20,24 @@ import "time"
 // defines the interpretation of the RangeEvent object's Introduced
 // and Fixed fields.
 //
-// In this implementation, only the "SEMVER" type is supported.
+// In this implementation, only the "SEMVER" type is fully supported.
+// Versions of other range types cannot be compared to module versions.
 //
 // See https://ossf.github.io/osv-schema/#affectedrangestype-field.
 type RangeType string
 
-// RangeTypeSemver indicates a semantic version as defined by
-// SemVer 2.0.0, with no leading "v" prefix.
-const RangeTypeSemver RangeType = "SEMVER"
+const (
+	// RangeTypeSemver indicates a semantic version as defined by
+	// SemVer 2.0.0, with no leading "v" prefix.
+	RangeTypeSemver RangeType = "SEMVER"
+
+	// RangeTypeEcosystem indicates a version as defined by the
+	// ecosystem of the package.
+	RangeTypeEcosystem RangeType = "ECOSYSTEM"
+
+	// RangeTypeGit indicates a full git commit hash.
+	RangeTypeGit RangeType = "GIT"
+)
 
 // Ecosystem identifies the overall library ecosystem.
 // In this implementation, only the "Go" ecosystem is supported.
@@ -77,6 +87,11 @@ type RangeEvent struct {
 	Introduced string `json:"introduced,omitempty"`
 	// Fixed is a version that fixes the vulnerability.
 	Fixed string `json:"fixed,omitempty"`
+	// LastAffected is the last known version affected by the
+	// vulnerability. It is used instead of Fixed when the
+	// version fixing the vulnerability is not known, meaning
+	// that all versions after LastAffected are not affected.
+	LastAffected string `json:"last_affected,omitempty"`
 }
 
 // Range describes the affected versions of the vulnerable module.
@@ -85,7 +100,7 @@ type RangeEvent struct {
 type Range struct {
 	// Type is the version type that should be used to interpret the
 	// versions in Events. Required.
-	// In this implementation, only the "SEMVER" type is supported.
+	// In this implementation, only the "SEMVER" type is fully supported.
 	Type RangeType `json:"type"`
 	// Events is a list of versions representing the ranges in which
 	// the module is vulnerable. Required.
@@ -119,6 +134,32 @@ const (
 	ReferenceTypeWeb = ReferenceType("WEB")
 )
 
+// SeverityType is the quantitative scoring method used to
+// describe a severity.
+type SeverityType string
+
+const (
+	// SeverityTypeCVSSV3 is a CVSS vector string representing
+	// the unique characteristics and severity of the vulnerability
+	// using a version of the CVSS v3 standard.
+	SeverityTypeCVSSV3 = SeverityType("CVSS_V3")
+)
+
+// Severity describes the severity of a vulnerability using a
+// quantitative scoring method.
+//
+// The Go vulnerability database does not currently publish
+// severities, but other databases following the OSV schema do.
+//
+// See https://ossf.github.io/osv-schema/#severity-field.
+type Severity struct {
+	// The scoring method. Required.
+	Type SeverityType `json:"type"`
+	// The score, encoded according to Type. Required.
+	// For CVSS_V3, this is a CVSS vector string.
+	Score string `json:"score"`
+}
+
 // Reference is a reference URL containing additional information,
 // advisories, issue tracker entries, etc., about the vulnerability.
 //
@@ -197,12 +238,19 @@ type Entry struct {
 	// Aliases is a list of IDs for the same vulnerability in other
 	// databases.
 	Aliases []string `json:"aliases,omitempty"`
+	// Related is a list of IDs of closely related vulnerabilities,
+	// which are not the same vulnerability, such as the upstream
+	// CVE of a vulnerability in a fork.
+	Related []string `json:"related,omitempty"`
 	// Summary gives a one-line, English textual summary of the vulnerability.
 	// It is recommended that this field be kept short, on the order of no more
 	// than 120 characters.
 	Summary string `json:"summary,omitempty"`
 	// Details contains additional English textual details about the vulnerability.
 	Details string `json:"details"`
+	// Severity contains quantitative severity scores of the
+	// vulnerability, if any are known.
+	Severity []Severity `json:"severity,omitempty"`
 	// Affected contains information on the modules and versions
 	// affected by the vulnerability.
 	Affected []Affected `json:"affected"`
@@ -237,4 +285,9 @@ type DatabaseSpecific struct {
 	URL string `json:"url,omitempty"`
 	// The review status of this report (UNREVIEWED or REVIEWED).
 	ReviewStatus ReviewStatus `json:"review_status,omitempty"`
+	// The Common Weakness Enumeration (CWE) IDs of the weaknesses
+	// behind the vulnerability, of the form "CWE-NNN", if known.
+	// The Go vulnerability database does not set them, but other
+	// databases, such as the GitHub Advisory Database, do.
+	CWEIDs []string `json:"cwe_ids,omitempty"`
 }
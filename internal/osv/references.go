@@ -0,0 +1,24 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package osv
+
+// ReferenceURLs returns the URLs of the references of e of type t,
+// in the order of the entry, without duplicates. For instance, the
+// URLs of the references of type ReferenceTypeFix are links to the
+// commits fixing the vulnerability.
+func ReferenceURLs(e *Entry, t ReferenceType) []string {
+	if e == nil {
+		return nil
+	}
+	var urls []string
+	seen := make(map[string]bool)
+	for _, r := range e.References {
+		if r.Type == t && !seen[r.URL] {
+			seen[r.URL] = true
+			urls = append(urls, r.URL)
+		}
+	}
+	return urls
+}
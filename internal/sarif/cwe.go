@@ -0,0 +1,176 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sarif
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"golang.org/x/vuln/internal/osv"
+)
+
+// cweTaxonomy is the name of the taxonomy of weakness categories.
+const cweTaxonomy = "CWE categories"
+
+// categoryNames maps the IDs of weakness categories to their names.
+var categoryNames = map[string]string{
+	"access-control":       "Access Control",
+	"concurrency":          "Concurrency",
+	"cryptography":         "Cryptography",
+	"data-authenticity":    "Data Authenticity",
+	"file-handling":        "File Handling",
+	"information-exposure": "Sensitive Information Exposure",
+	"injection":            "Injection",
+	"input-validation":     "Improper Input Validation",
+	"memory-safety":        "Memory Safety",
+	"numeric-errors":       "Numeric Errors",
+	"resource-management":  "Resource Management",
+}
+
+// cweCategories maps the IDs of common weaknesses in Go vulnerabilities
+// to the IDs of their categories. Other weaknesses are not categorized.
+var cweCategories = map[string]string{
+	"CWE-269":  "access-control",
+	"CWE-284":  "access-control",
+	"CWE-285":  "access-control",
+	"CWE-287":  "access-control",
+	"CWE-306":  "access-control",
+	"CWE-639":  "access-control",
+	"CWE-862":  "access-control",
+	"CWE-863":  "access-control",
+	"CWE-362":  "concurrency",
+	"CWE-366":  "concurrency",
+	"CWE-367":  "concurrency",
+	"CWE-667":  "concurrency",
+	"CWE-295":  "cryptography",
+	"CWE-310":  "cryptography",
+	"CWE-326":  "cryptography",
+	"CWE-327":  "cryptography",
+	"CWE-328":  "cryptography",
+	"CWE-330":  "cryptography",
+	"CWE-338":  "cryptography",
+	"CWE-345":  "data-authenticity",
+	"CWE-346":  "data-authenticity",
+	"CWE-347":  "data-authenticity",
+	"CWE-352":  "data-authenticity",
+	"CWE-22":   "file-handling",
+	"CWE-23":   "file-handling",
+	"CWE-36":   "file-handling",
+	"CWE-59":   "file-handling",
+	"CWE-73":   "file-handling",
+	"CWE-434":  "file-handling",
+	"CWE-200":  "information-exposure",
+	"CWE-203":  "information-exposure",
+	"CWE-208":  "information-exposure",
+	"CWE-209":  "information-exposure",
+	"CWE-532":  "information-exposure",
+	"CWE-74":   "injection",
+	"CWE-77":   "injection",
+	"CWE-78":   "injection",
+	"CWE-79":   "injection",
+	"CWE-89":   "injection",
+	"CWE-91":   "injection",
+	"CWE-93":   "injection",
+	"CWE-94":   "injection",
+	"CWE-113":  "injection",
+	"CWE-116":  "injection",
+	"CWE-643":  "injection",
+	"CWE-917":  "injection",
+	"CWE-1336": "injection",
+	"CWE-20":   "input-validation",
+	"CWE-129":  "input-validation",
+	"CWE-444":  "input-validation",
+	"CWE-601":  "input-validation",
+	"CWE-1284": "input-validation",
+	"CWE-1286": "input-validation",
+	"CWE-119":  "memory-safety",
+	"CWE-120":  "memory-safety",
+	"CWE-125":  "memory-safety",
+	"CWE-416":  "memory-safety",
+	"CWE-476":  "memory-safety",
+	"CWE-787":  "memory-safety",
+	"CWE-190":  "numeric-errors",
+	"CWE-191":  "numeric-errors",
+	"CWE-369":  "numeric-errors",
+	"CWE-681":  "numeric-errors",
+	"CWE-682":  "numeric-errors",
+	"CWE-400":  "resource-management",
+	"CWE-401":  "resource-management",
+	"CWE-404":  "resource-management",
+	"CWE-405":  "resource-management",
+	"CWE-407":  "resource-management",
+	"CWE-674":  "resource-management",
+	"CWE-770":  "resource-management",
+	"CWE-772":  "resource-management",
+	"CWE-789":  "resource-management",
+	"CWE-834":  "resource-management",
+	"CWE-835":  "resource-management",
+	"CWE-1333": "resource-management",
+}
+
+// cweIDs returns the sorted distinct CWE IDs of e, in upper case.
+func cweIDs(e *osv.Entry) []string {
+	if e.DatabaseSpecific == nil {
+		return nil
+	}
+	var ids []string
+	for _, id := range e.DatabaseSpecific.CWEIDs {
+		id = strings.ToUpper(strings.TrimSpace(id))
+		if id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// relationships returns the relationships of the Rule of e
+// to the categories of its weaknesses, sorted by category.
+func relationships(e *osv.Entry) []Relationship {
+	var cats []string
+	for _, id := range cweIDs(e) {
+		if c, ok := cweCategories[id]; ok && !slices.Contains(cats, c) {
+			cats = append(cats, c)
+		}
+	}
+	sort.Strings(cats)
+	var rs []Relationship
+	for _, c := range cats {
+		rs = append(rs, Relationship{
+			Target: ReportingDescriptorReference{ID: c, ToolComponent: ToolComponentReference{Name: cweTaxonomy}},
+			Kinds:  []string{"subset"},
+		})
+	}
+	return rs
+}
+
+// taxonomies returns the taxonomy of the categories of the
+// weaknesses of entries, or nil if none is categorized. Each
+// category lists the CWE IDs of entries it groups.
+func taxonomies(entries []*osv.Entry) []Taxonomy {
+	cwes := make(map[string][]string) // by category
+	for _, e := range entries {
+		for _, id := range cweIDs(e) {
+			if c, ok := cweCategories[id]; ok && !slices.Contains(cwes[c], id) {
+				cwes[c] = append(cwes[c], id)
+			}
+		}
+	}
+	if len(cwes) == 0 {
+		return nil
+	}
+	var taxa []Taxon
+	for c, ids := range cwes {
+		sort.Strings(ids)
+		taxa = append(taxa, Taxon{ID: c, Name: categoryNames[c], Properties: TaxonTags{Tags: ids}})
+	}
+	sort.Slice(taxa, func(i, j int) bool { return taxa[i].ID < taxa[j].ID })
+	return []Taxonomy{{
+		Name:           cweTaxonomy,
+		InformationURI: "https://cwe.mitre.org",
+		Taxa:           taxa,
+	}}
+}
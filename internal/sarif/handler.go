@@ -5,29 +5,68 @@
 package sarif
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"net/url"
+	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
+	"golang.org/x/mod/module"
 	"golang.org/x/vuln/internal"
+	"golang.org/x/vuln/internal/cvss"
 	"golang.org/x/vuln/internal/govulncheck"
 	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/semver"
 	"golang.org/x/vuln/internal/traces"
 )
 
 // handler for sarif output.
 type handler struct {
-	w    io.Writer
-	cfg  *govulncheck.Config
-	osvs map[string]*osv.Entry
+	w io.Writer
+	// path, if not empty, is the file to which
+	// the output is written instead of w.
+	path string
+	// splitTemplate, if not empty, is the path template
+	// of the files to which the output is split by level.
+	splitTemplate string
+	cfg           *govulncheck.Config
+	osvs          map[string]*osv.Entry
 	// findings contains same-level findings for an
 	// OSV at the most precise level of granularity
 	// available. This means, for instance, that if
 	// an osv is indeed called, then all findings for
 	// the osv will have call stack info.
 	findings map[string][]*govulncheck.Finding
+	mu       sync.Mutex // guards findings, which may be delivered concurrently
+	// notifications are the non-fatal
+	// problems recorded during the scan.
+	notifications []Notification
+	// failed is set if the scan stopped because of an error.
+	failed bool
+	// sources caches the lines of the files read for
+	// snippets, which are nil for files not read.
+	sources map[string][]string
+	// rootPrefix is the path of the module directory relative
+	// to the module root, if configured, using "/" delimiters.
+	rootPrefix string
+	// ruleIDs caches the rule IDs of the OSV
+	// entries of h, keyed by OSV ID. It is nil
+	// until computed and reset by new entries.
+	ruleIDs map[string]string
+	// now returns the current time, against
+	// which the age of the data is checked.
+	now func() time.Time
 }
 
 func NewHandler(w io.Writer) *handler {
@@ -35,16 +74,70 @@ func NewHandler(w io.Writer) *handler {
 		w:        w,
 		osvs:     make(map[string]*osv.Entry),
 		findings: make(map[string][]*govulncheck.Finding),
+		sources:  make(map[string][]string),
+		now:      time.Now,
 	}
 }
 
+// NewFileHandler returns a handler writing the sarif output to the
+// file at path. The output is written to a temporary file in the same
+// directory, which is renamed to path once complete, so that path is
+// either left untouched or holds the complete output, even if Flush
+// fails or the process crashes while writing.
+func NewFileHandler(path string) *handler {
+	h := NewHandler(nil)
+	h.path = path
+	return h
+}
+
+// LevelPlaceholder is replaced by the level of results in
+// the path template of NewSplitHandler.
+const LevelPlaceholder = "{level}"
+
+// NewSplitHandler returns a handler splitting the sarif output by the
+// level of results, for teams triaging them in separate queues. The
+// results of each level, "error", "warning", and "note", are written
+// to the file at template with LevelPlaceholder replaced by the level,
+// such as "govulncheck-{level}.sarif". Each file is a complete sarif
+// log, with the tool, rules, and notifications of the scan, and is
+// written even if it has no results, like those of NewFileHandler.
+// MaxSarifBytes does not apply to split output.
+func NewSplitHandler(template string) *handler {
+	h := NewHandler(nil)
+	h.splitTemplate = template
+	return h
+}
+
+// Config records c. It fails if c has a module root that does
+// not contain the module directory, a package weight that is
+// not positive, or if the handler splits its output by a path
+// template without LevelPlaceholder.
 func (h *handler) Config(c *govulncheck.Config) error {
 	h.cfg = c
+	if err := checkWeights(c.PackageWeights); err != nil {
+		return err
+	}
+	if h.splitTemplate != "" && !strings.Contains(h.splitTemplate, LevelPlaceholder) {
+		return fmt.Errorf("sarif path template %q does not contain %s", h.splitTemplate, LevelPlaceholder)
+	}
+	if c.ModuleRoot == "" {
+		return nil
+	}
+	prefix, err := govulncheck.RootPrefix(c.ModuleRoot, c.SarifURIRoots[SrcRootID])
+	if err != nil {
+		return err
+	}
+	h.rootPrefix = prefix
 	return nil
 }
 
+// Progress records warnings as warning notifications. Other
+// progress messages are not needed by sarif.
 func (h *handler) Progress(p *govulncheck.Progress) error {
-	return nil // not needed by sarif
+	if p.Warning {
+		h.warn(strings.TrimSpace(p.Message))
+	}
+	return nil
 }
 
 func (h *handler) SBOM(s *govulncheck.SBOM) error {
@@ -53,85 +146,393 @@ func (h *handler) SBOM(s *govulncheck.SBOM) error {
 
 func (h *handler) OSV(e *osv.Entry) error {
 	h.osvs[e.ID] = e
+	h.ruleIDs = nil
 	return nil
 }
 
-// moreSpecific favors a call finding over a non-call
-// finding and a package finding over a module finding.
-func moreSpecific(f1, f2 *govulncheck.Finding) int {
-	if len(f1.Trace) > 1 && len(f2.Trace) > 1 {
-		// Both are call stack findings.
-		return 0
+// ruleID returns the ID of the rule of the vulnerability id: the first
+// alias of its entry with the preferred alias prefix of the config, if
+// any, unless another entry of h has the same alias, in which case the
+// rules of both would be ambiguous and id is used instead.
+func (h *handler) ruleID(id string) string {
+	if h.cfg.PreferAliasPrefix == "" {
+		return id
 	}
-	if len(f1.Trace) > 1 {
-		return -1
+	if h.ruleIDs == nil {
+		h.ruleIDs = make(map[string]string)
+		count := make(map[string]int)
+		for id, e := range h.osvs {
+			a := preferredAlias(e, h.cfg.PreferAliasPrefix)
+			h.ruleIDs[id] = a
+			count[a]++
+		}
+		for id, a := range h.ruleIDs {
+			if a == "" || count[a] > 1 {
+				h.ruleIDs[id] = id
+			}
+		}
 	}
-	if len(f2.Trace) > 1 {
-		return 1
+	if rid, ok := h.ruleIDs[id]; ok {
+		return rid
 	}
+	return id
+}
 
-	fr1, fr2 := f1.Trace[0], f2.Trace[0]
-	if fr1.Function != "" && fr2.Function == "" {
-		return -1
+func (h *handler) Finding(f *govulncheck.Finding) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// Only the findings at the most precise level are kept,
+	// whatever the order in which they are delivered.
+	h.findings[f.OSV] = govulncheck.MostPrecise(h.findings[f.OSV], f)
+	return nil
+}
+
+// Notify records n as a non-fatal problem of the scan. The
+// notifications are reported, in the order of recording, as
+// tool execution notifications of the sarif output.
+func (h *handler) Notify(n Notification) {
+	h.notifications = append(h.notifications, n)
+}
+
+// Failure records the error that stopped the scan as an error
+// notification of an unsuccessful invocation. The results are
+// those of the findings collected before the failure.
+func (h *handler) Failure(f *govulncheck.Failure) error {
+	h.failed = true
+	h.Notify(Notification{Level: "error", Message: Description{Text: f.Message}})
+	return nil
+}
+
+// Findings returns a copy of the findings collected so far, keyed
+// by OSV ID. Only the findings at the most precise level available
+// for an OSV are kept, as in the sarif output.
+func (h *handler) Findings() map[string][]*govulncheck.Finding {
+	findings := make(map[string][]*govulncheck.Finding, len(h.findings))
+	for id, fs := range h.findings {
+		cs := make([]*govulncheck.Finding, len(fs))
+		for i, f := range fs {
+			c := *f
+			cs[i] = &c
+		}
+		findings[id] = cs
+	}
+	return findings
+}
+
+// Concurrent returns true as findings can be delivered
+// concurrently: they are sorted on Flush.
+func (h *handler) Concurrent() bool {
+	return true
+}
+
+// Streaming returns false as sarif output is a single
+// JSON document that can only be produced once all
+// findings are known.
+func (h *handler) Streaming() bool {
+	return false
+}
+
+// Flush is used to print out to w, or to the file of the
+// handler, the sarif json output. This is needed as sarif
+// is not streamed.
+//
+// The output is indented, unless compact output is
+// requested by the config.
+func (h *handler) Flush() error {
+	for _, fs := range h.findings {
+		sortFindings(fs)
+	}
+	h.staleData()
+	if h.splitTemplate != "" {
+		return h.writeSplit(toSarif(h))
 	}
-	if fr1.Function == "" && fr2.Function != "" {
-		return 1
+	s, err := h.marshal(toSarif(h))
+	if err != nil {
+		return err
 	}
-	if fr1.Package != "" && fr2.Package == "" {
-		return -1
+	if max := h.cfg.MaxSarifBytes; max > 0 && len(s) > max {
+		if s, err = h.truncate(max); err != nil {
+			return err
+		}
 	}
-	if fr1.Package == "" && fr2.Package != "" {
-		return -1
+	if h.path != "" {
+		return writeFileAtomic(h.path, s)
 	}
-	return 0 // findings always have module info
+	_, err = h.w.Write(s)
+	return err
 }
 
-func (h *handler) Finding(f *govulncheck.Finding) error {
-	fs := h.findings[f.OSV]
-	if len(fs) == 0 {
-		fs = []*govulncheck.Finding{f}
-	} else {
-		if ms := moreSpecific(f, fs[0]); ms == -1 {
-			// The new finding is more specific, so we need
-			// to erase existing findings and add the new one.
-			fs = []*govulncheck.Finding{f}
-		} else if ms == 0 {
-			// The new finding is equal to an existing one and
-			// because of the invariant on h.findings, it is
-			// also equal to all existing ones.
-			fs = append(fs, f)
-		}
-		// Otherwise, the new finding is at a less precise level.
-	}
-	h.findings[f.OSV] = fs
+// writeSplit writes l to a file for each result level, named after
+// the split template of h, holding the results of l at that level.
+// All files are written to temporary files before any is renamed, so
+// that a failure to write one leaves the previous files untouched.
+// The set of files is not replaced atomically though: a failure to
+// rename a file leaves the files of the preceding levels updated.
+func (h *handler) writeSplit(l Log) (err error) {
+	var paths, temps []string
+	defer func() {
+		if err != nil {
+			for _, tmp := range temps {
+				os.Remove(tmp)
+			}
+		}
+	}()
+	for _, level := range []string{errorLevel, warningLevel, informationalLevel} {
+		s, err := h.marshal(levelLog(l, level))
+		if err != nil {
+			return err
+		}
+		path := strings.ReplaceAll(h.splitTemplate, LevelPlaceholder, level)
+		tmp, err := writeTemp(path, s)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		temps = append(temps, tmp)
+	}
+	for len(temps) > 0 {
+		if err := os.Rename(temps[0], paths[0]); err != nil {
+			return err
+		}
+		paths, temps = paths[1:], temps[1:]
+	}
 	return nil
 }
 
-// Flush is used to print out to w the sarif json output.
-// This is needed as sarif is not streamed.
-func (h *handler) Flush() error {
-	sLog := toSarif(h)
-	s, err := json.MarshalIndent(sLog, "", "  ")
+// levelLog returns a copy of l whose runs only have the results at level.
+func levelLog(l Log, level string) Log {
+	runs := make([]Run, len(l.Runs))
+	for i, r := range l.Runs {
+		results := []Result{} // present even if empty
+		for _, res := range r.Results {
+			if res.Level == level {
+				results = append(results, res)
+			}
+		}
+		r.Results = results
+		runs[i] = r
+	}
+	l.Runs = runs
+	return l
+}
+
+// writeFileAtomic writes data to the file at path by renaming a
+// complete temporary file to it. The temporary file is removed if
+// writing or renaming it fails.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := writeTemp(path, data)
 	if err != nil {
 		return err
 	}
-	h.w.Write(s)
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
 	return nil
 }
 
+// writeTemp writes data to a new temporary file in the directory
+// of path and returns its name. The temporary file is removed if
+// writing it fails.
+func writeTemp(path string, data []byte) (name string, err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+	// Temporary files are only readable by their owner.
+	if err := f.Chmod(0644); err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// staleData records a warning notification if the
+// vulnerability data of the scan is stale, according
+// to the maximum age of the data in the config.
+func (h *handler) staleData() {
+	if msg := govulncheck.StaleDataWarning(h.cfg, h.now()); msg != "" {
+		h.warn(msg)
+	}
+}
+
+// warn records a warning notification with message msg, once.
+func (h *handler) warn(msg string) {
+	for _, n := range h.notifications {
+		if n.Message.Text == msg {
+			return
+		}
+	}
+	h.Notify(Notification{Level: "warning", Message: Description{Text: msg}})
+}
+
+// marshal returns the JSON encoding of l, which is indented
+// unless compact output is requested by the config.
+func (h *handler) marshal(l Log) ([]byte, error) {
+	if h.cfg.CompactOutput {
+		return json.Marshal(l)
+	}
+	return json.MarshalIndent(l, "", "  ")
+}
+
+// truncate returns the sarif output of h without the results of the
+// fewest vulnerabilities needed for the output to be at most max bytes
+// long, recording the number of omitted vulnerabilities in a warning
+// notification. Vulnerabilities are omitted by increasing priority:
+// module level findings first, then package level ones, and then the
+// lowest ranked first. If the output does not fit even without any
+// results, all of them are omitted.
+func (h *handler) truncate(max int) ([]byte, error) {
+	ids := omissionOrder(h)
+	findings, notifications := h.findings, h.notifications
+	defer func() { h.findings, h.notifications = findings, notifications }()
+
+	var err error
+	output := func(n int) []byte {
+		h.findings = make(map[string][]*govulncheck.Finding, len(findings))
+		for id, fs := range findings {
+			h.findings[id] = fs
+		}
+		for _, id := range ids[:n] {
+			delete(h.findings, id)
+		}
+		h.notifications = append(slices.Clip(notifications), Notification{
+			Level:   "warning",
+			Message: Description{Text: fmt.Sprintf("Omitted the results of %d vulnerabilities to keep the output within %d bytes.", n, max)},
+		})
+		s, merr := h.marshal(toSarif(h))
+		if err == nil {
+			err = merr
+		}
+		return s
+	}
+	// Omitting the results of more vulnerabilities
+	// only makes the output shorter.
+	n := sort.Search(len(ids), func(n int) bool { return len(output(n)) <= max })
+	s := output(n)
+	return s, err
+}
+
+// omissionOrder returns the IDs of the vulnerabilities of h in
+// the order in which their results are omitted to reduce the size
+// of the output: module level findings first, then package level,
+// and then call level ones, each by increasing rank.
+func omissionOrder(h *handler) []string {
+	var ids []string
+	for id := range h.findings {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		fi, fj := h.findings[ids[i]][0], h.findings[ids[j]][0]
+		if pi, pj := govulncheck.Level(fi).Precision(), govulncheck.Level(fj).Precision(); pi != pj {
+			return pi < pj
+		}
+		if ri, rj := rank(fi, h.osvs[ids[i]], h.cfg), rank(fj, h.osvs[ids[j]], h.cfg); ri != rj {
+			return ri < rj
+		}
+		return ids[i] > ids[j]
+	})
+	return ids
+}
+
+// sortFindings sorts findings of the same vulnerability in the
+// order in which they are emitted by govulncheck, by package,
+// symbol, and module, so that the output does not depend on the
+// order in which findings were delivered.
+func sortFindings(findings []*govulncheck.Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		fi, fj := findings[i].Trace[0], findings[j].Trace[0]
+		if fi.Package != fj.Package {
+			return fi.Package < fj.Package
+		}
+		if si, sj := symbolName(fi), symbolName(fj); si != sj {
+			return si < sj
+		}
+		if fi.Module != fj.Module {
+			return fi.Module < fj.Module
+		}
+		return traceString(findings[i].Trace) < traceString(findings[j].Trace)
+	})
+}
+
+// symbolName returns the name of the symbol of fr as
+// govulncheck reports it, such as "T.M" for methods.
+func symbolName(fr *govulncheck.Frame) string {
+	if fr.Receiver == "" {
+		return fr.Function
+	}
+	return strings.TrimPrefix(fr.Receiver, "*") + "." + fr.Function
+}
+
+// traceString returns a string identifying trace.
+func traceString(trace []*govulncheck.Frame) string {
+	var b strings.Builder
+	for _, fr := range trace {
+		fmt.Fprintf(&b, "|%s %s", fr.Module, symbol(fr))
+		if p := fr.Position; p != nil {
+			fmt.Fprintf(&b, " %s:%d:%d", p.Filename, p.Line, p.Column)
+		}
+	}
+	return b.String()
+}
+
 func toSarif(h *handler) Log {
 	cfg := h.cfg
+	const infoURI = "https://pkg.go.dev/golang.org/x/vuln/cmd/govulncheck"
 	r := Run{
 		Tool: Tool{
 			Driver: Driver{
 				Name:           cfg.ScannerName,
 				Version:        cfg.ScannerVersion,
-				InformationURI: "https://pkg.go.dev/golang.org/x/vuln/cmd/govulncheck",
+				InformationURI: infoURI,
 				Properties:     *cfg,
 				Rules:          rules(h),
 			},
 		},
-		Results: results(h),
+		Results:            results(h),
+		Taxonomies:         taxonomies(ruleEntries(h)),
+		OriginalURIBaseIDs: originalURIBaseIDs(h),
+		Properties:         &RunProperties{ScanLevel: scanLevelName(cfg)},
+	}
+	if cfg.SarifAutomationID != "" {
+		r.AutomationDetails = &AutomationDetails{ID: cfg.SarifAutomationID}
+	}
+	if len(h.notifications) > 0 {
+		r.Invocations = []Invocation{{
+			ExecutionSuccessful:        !h.failed,
+			ToolExecutionNotifications: h.notifications,
+		}}
+	}
+	if cfg.ScannerNameOverride != "" || cfg.ScannerVersionOverride != "" {
+		// Keep track of the govulncheck engine
+		// producing the results for provenance.
+		r.Tool.Extensions = []Extension{{
+			Name:           cfg.ScannerName,
+			Version:        cfg.ScannerVersion,
+			InformationURI: infoURI,
+		}}
+		if cfg.ScannerNameOverride != "" {
+			r.Tool.Driver.Name = cfg.ScannerNameOverride
+			// The information URI is about govulncheck.
+			r.Tool.Driver.InformationURI = ""
+		}
+		if cfg.ScannerVersionOverride != "" {
+			r.Tool.Driver.Version = cfg.ScannerVersionOverride
+		}
 	}
 
 	return Log{
@@ -141,54 +542,111 @@ func toSarif(h *handler) Log {
 	}
 }
 
+// scanLevelName returns the level of the scan configured by cfg
+// in human-readable form: "symbol", "package", or "module".
+func scanLevelName(cfg *govulncheck.Config) string {
+	switch {
+	case cfg.ScanLevel.WantSymbols():
+		return "symbol"
+	case cfg.ScanLevel.WantPackages():
+		return "package"
+	default:
+		return "module"
+	}
+}
+
 func rules(h *handler) []Rule {
 	var rs []Rule
 	for id := range h.findings {
 		osv := h.osvs[id]
-		// s is either summary if it exists, or details
-		// otherwise. Govulncheck text does the same.
+		// The short description is the summary if it exists, or
+		// truncated details otherwise. The full description is
+		// always the details, if any.
 		s := osv.Summary
 		if s == "" {
-			s = osv.Details
+			s = truncate(osv.Details, maxShortDescriptionLen)
+		}
+		full := osv.Details
+		if full == "" {
+			full = osv.Summary
+		}
+		g, _ := ruleGUID(osv.ID)
+		rid := h.ruleID(osv.ID)
+		tags := ruleTags(osv)
+		if rid != osv.ID {
+			tags.OSVID = osv.ID
 		}
 		rs = append(rs, Rule{
-			ID:               osv.ID,
-			ShortDescription: Description{Text: fmt.Sprintf("[%s] %s", osv.ID, s)},
-			FullDescription:  Description{Text: s},
-			HelpURI:          fmt.Sprintf("https://pkg.go.dev/vuln/%s", osv.ID),
-			Help:             Description{Text: osv.Details},
-			Properties:       RuleTags{Tags: osv.Aliases},
+			ID:                   rid,
+			GUID:                 g,
+			ShortDescription:     Description{Text: fmt.Sprintf("[%s] %s", rid, s)},
+			FullDescription:      Description{Text: full},
+			HelpURI:              helpURI(osv.ID),
+			Help:                 Description{Text: osv.Details},
+			DefaultConfiguration: defaultConfiguration(osv, h.cfg),
+			Relationships:        relationships(osv),
+			Properties:           tags,
 		})
 	}
 	sort.SliceStable(rs, func(i, j int) bool { return rs[i].ID < rs[j].ID })
 	return rs
 }
 
+// ruleEntries returns the OSV entries of the Rules of h.
+func ruleEntries(h *handler) []*osv.Entry {
+	var entries []*osv.Entry
+	for id := range h.findings {
+		entries = append(entries, h.osvs[id])
+	}
+	return entries
+}
+
+// relatedPrefix distinguishes the IDs of related
+// vulnerabilities from aliases in rule tags.
+const relatedPrefix = "related:"
+
+// ruleTags returns the rule properties of e.
+func ruleTags(e *osv.Entry) RuleTags {
+	tags := RuleTags{
+		Tags:      append(slices.Clip(e.Aliases), cweIDs(e)...),
+		Published: timeString(e.Published),
+		Modified:  timeString(e.Modified),
+	}
+	for _, r := range e.Related {
+		tags.Tags = append(tags.Tags, relatedPrefix+r)
+	}
+	for _, c := range e.Credits {
+		tags.Credits = append(tags.Credits, c.Name)
+	}
+	return tags
+}
+
+// timeString returns t in RFC 3339 format,
+// or the empty string if t is unknown.
+func timeString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
 func results(h *handler) []Result {
 	results := make([]Result, 0, len(h.findings))
+	upgrades := moduleUpgrades(h)
 	for osv, fs := range h.findings {
-		var locs []Location
-		if h.cfg.ScanMode != govulncheck.ScanModeBinary {
-			// Attach result to the go.mod file for source analysis.
-			// But there is no such place for binaries.
-			locs = []Location{{PhysicalLocation: PhysicalLocation{
-				ArtifactLocation: ArtifactLocation{
-					URI:       "go.mod",
-					URIBaseID: SrcRootID,
-				},
-				Region: Region{StartLine: 1}, // for now, point to the first line
-			},
-				Message: Description{Text: fmt.Sprintf("Findings for vulnerability %s", osv)}, // not having a message here results in an invalid sarif
-			}}
+		if h.cfg.SarifCalledOnly && !govulncheck.IsCalled(fs[0]) {
+			// Findings are at their most precise level,
+			// so this vulnerability is not called.
+			continue
 		}
-
-		res := Result{
-			RuleID:    osv,
-			Level:     level(fs[0], h.cfg),
-			Message:   Description{Text: resultMessage(fs, h.cfg)},
-			Stacks:    stacks(h, fs),
-			CodeFlows: codeFlows(h, fs),
-			Locations: locs,
+		if h.cfg.ResultPerStack && govulncheck.IsCalled(fs[0]) {
+			results = append(results, stackResults(h, osv, fs, upgrades)...)
+			continue
+		}
+		res := result(h, osv, fs, upgrades)
+		if pkgs := modulePackages(h.osvs[osv], fs); h.cfg.SarifPackageResults && govulncheck.IsModuleOnly(fs[0]) && len(pkgs) > 1 {
+			results = append(results, packageResults(res, osv, pkgs)...)
+			continue
 		}
 		results = append(results, res)
 	}
@@ -196,12 +654,190 @@ func results(h *handler) []Result {
 	return results
 }
 
-func resultMessage(findings []*govulncheck.Finding, cfg *govulncheck.Config) string {
-	// We can infer the findings' level by just looking at the
-	// top trace frame of any finding.
-	frame := findings[0].Trace[0]
+// result returns the Result for the same-level findings fs of
+// the vulnerability osv. The upgrades map modules to their
+// recommended versions, as computed by moduleUpgrades.
+func result(h *handler, osv string, fs []*govulncheck.Finding, upgrades map[string]string) Result {
+	var locs []Location
+	if h.cfg.ScanMode != govulncheck.ScanModeBinary {
+		// Attach result to the go.mod file for source analysis.
+		// But there is no such place for binaries.
+		region := Region{StartLine: 1} // by default, point to the first line
+		if pos := fs[0].GoModLocation; govulncheck.IsModuleOnly(fs[0]) && pos != nil && pos.Line > 0 {
+			// Module level findings point to the
+			// require directive of their module.
+			region = Region{StartLine: pos.Line, StartColumn: pos.Column}
+		}
+		locs = []Location{{PhysicalLocation: PhysicalLocation{
+			ArtifactLocation: h.artifactLocation("go.mod", SrcRootID),
+			Region:           region,
+		},
+			Message: Description{Text: fmt.Sprintf("Findings for vulnerability %s", osv)}, // not having a message here results in an invalid sarif
+		}}
+	}
+
+	props := properties(fs, upgrades, h.osvs[osv], h.cfg.EmbedOSV)
+	rid := h.ruleID(osv)
+	if rid != osv {
+		if props == nil {
+			props = &ResultProperties{}
+		}
+		props.OSVID = osv
+	}
+	return Result{
+		RuleID:           rid,
+		GUID:             resultGUID(osv, fs),
+		Kind:             resultKind(fs),
+		Level:            resultLevel(fs, h.osvs[osv], h.cfg),
+		Rank:             resultRank(fs, h.osvs[osv], h.cfg),
+		Message:          Description{Text: resultMessage(fs, h.osvs[osv], h.cfg)},
+		Stacks:           stacks(h, fs),
+		CodeFlows:        codeFlows(h, fs),
+		Locations:        locs,
+		RelatedLocations: relatedLocations(h, fs),
+		Properties:       props,
+	}
+}
+
+// stackResults returns a Result for each call path of the called
+// findings fs of the vulnerability osv, as requested by ResultPerStack.
+// A call path is the sequence of functions of a call stack, so stacks
+// only differing by the positions of their calls share a Result. The
+// results share the rule of osv, and differ by their GUIDs and
+// fingerprints, which are derived from their call paths.
+func stackResults(h *handler, osv string, fs []*govulncheck.Finding, upgrades map[string]string) []Result {
+	var paths []string
+	byPath := make(map[string][]*govulncheck.Finding)
+	for _, f := range fs {
+		path := callPath(f)
+		if byPath[path] == nil {
+			paths = append(paths, path)
+		}
+		byPath[path] = append(byPath[path], f)
+	}
+	sort.Strings(paths)
+	var results []Result
+	for _, path := range paths {
+		res := result(h, osv, byPath[path], upgrades)
+		res.GUID = stackResultGUID(osv, path)
+		res.PartialFingerprints = map[string]string{callPathFingerprint: callPathHash(osv, path)}
+		results = append(results, res)
+	}
+	return results
+}
+
+// packageResults returns a copy of the Result res of module level
+// findings of the vulnerability osv for each of their affected packages
+// pkgs. The copies differ by their GUIDs, messages, and the logical
+// locations of their packages.
+func packageResults(res Result, osv string, pkgs []string) []Result {
+	var results []Result
+	for _, pkg := range pkgs {
+		r := res
+		r.GUID = packageResultGUID(osv, pkg)
+		r.Message.Text += fmt.Sprintf(" The vulnerable package of this result is %s.", pkg)
+		r.Locations = nil
+		for _, l := range res.Locations {
+			l.LogicalLocations = []LogicalLocation{{FullyQualifiedName: pkg, Kind: "namespace"}}
+			r.Locations = append(r.Locations, l)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// properties returns the properties of the Result for findings,
+// or nil if there are none to report. The upgrades map modules to
+// their recommended versions, as computed by moduleUpgrades. The
+// entry, if not nil, is embedded in the properties.
+func properties(findings []*govulncheck.Finding, upgrades map[string]string, entry *osv.Entry, embed bool) *ResultProperties {
+	props := ResultProperties{
+		IntroducedVersion:  findings[0].IntroducedVersion,
+		RecommendedVersion: upgrades[findings[0].Trace[0].Module],
+		Direct:             findings[0].Direct,
+		Confidence:         string(findings[0].Confidence),
+		FixCommits:         osv.ReferenceURLs(entry, osv.ReferenceTypeFix),
+		WorkspaceModule:    findings[0].WorkspaceModule,
+	}
+	if embed {
+		props.OSV = entry
+	}
+	for _, f := range findings {
+		if crossesUnsafe(f) {
+			props.CrossesUnsafe = true
+		}
+		if f.ThroughInit {
+			props.ThroughInit = true
+		}
+		if f.ThroughReflection {
+			props.ThroughReflection = true
+		}
+		if by := f.RemediateBy; by != nil && (props.RemediateBy == nil || by.Before(*props.RemediateBy)) {
+			props.RemediateBy = by
+		}
+		if f.WorkspaceModule != props.WorkspaceModule {
+			props.WorkspaceModule = ""
+		}
+		for _, b := range f.Binaries {
+			if !slices.Contains(props.Binaries, b) {
+				props.Binaries = append(props.Binaries, b)
+			}
+		}
+	}
+	if reflect.ValueOf(props).IsZero() {
+		return nil
+	}
+	return &props
+}
+
+// moduleUpgrades returns, for each module with several vulnerabilities
+// reported in h that have different fixed versions, the version of the
+// module fixing all of them, see semver.Upgrades. Modules for which there
+// is no such version are not included.
+func moduleUpgrades(h *handler) map[string]string {
+	var vulns []semver.ModuleVuln
+	for id, fs := range h.findings {
+		fr := fs[0].Trace[0]
+		vulns = append(vulns, semver.ModuleVuln{Module: fr.Module, Version: fr.Version, Fixed: fs[0].FixedVersion, Entry: h.osvs[id]})
+	}
+	upgrades := make(map[string]string)
+	for _, u := range semver.Upgrades(vulns) {
+		if u.Version != "" {
+			upgrades[u.Module] = u.Version
+		}
+	}
+	return upgrades
+}
+
+// crossesUnsafe reports whether any frame in the trace of f
+// is in package unsafe or in a cgo generated file.
+func crossesUnsafe(f *govulncheck.Finding) bool {
+	for _, fr := range f.Trace {
+		if fr.Package == "unsafe" {
+			return true
+		}
+		if fr.Position != nil && isCgoFile(fr.Position.Filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCgoFile reports whether filename is generated by cgo.
+func isCgoFile(filename string) bool {
+	base := path.Base(filepath.ToSlash(filename))
+	return strings.HasPrefix(base, "_cgo_") || strings.HasSuffix(base, ".cgo1.go") || strings.HasSuffix(base, ".cgo2.c")
+}
+
+// resultMessage returns the message summarizing findings of the
+// vulnerability entry. For imported but not called vulnerabilities,
+// the message lists the vulnerable symbols of the imported packages
+// so that developers know which symbols to avoid.
+func resultMessage(findings []*govulncheck.Finding, entry *osv.Entry, cfg *govulncheck.Config) string {
+	// We can infer the findings' level by just looking at any finding.
+	f0 := findings[0]
 	uniqueElems := make(map[string]bool)
-	if frame.Function == "" && frame.Package == "" { // module level findings
+	if govulncheck.IsModuleOnly(f0) {
 		for _, f := range findings {
 			uniqueElems[f.Trace[0].Module] = true
 		}
@@ -217,22 +853,70 @@ func resultMessage(findings []*govulncheck.Finding, cfg *govulncheck.Config) str
 	sort.Strings(elems)
 
 	l := len(elems)
-	elemList := list(elems)
+	elemList := list(bounded(elems, cfg.SarifMaxListed))
 	main, addition := "", ""
 	const runCallAnalysis = "Run the call-level analysis to understand whether your code actually calls the vulnerabilities."
 	switch {
-	case frame.Function != "":
+	case govulncheck.IsCalled(f0):
 		main = fmt.Sprintf("calls vulnerable functions in %d package%s (%s).", l, choose("", "s", l == 1), elemList)
-	case frame.Package != "":
+	case govulncheck.IsImported(f0):
 		main = fmt.Sprintf("imports %d vulnerable package%s (%s)", l, choose("", "s", l == 1), elemList)
 		addition = choose(", but doesn’t appear to call any of the vulnerable symbols.", ". "+runCallAnalysis, cfg.ScanLevel.WantSymbols())
+		if syms := vulnerableSymbols(entry, uniqueElems); cfg.ScanLevel.WantSymbols() && len(syms) > 0 {
+			addition += fmt.Sprintf(" Avoid using the vulnerable symbol%s %s.", choose("", "s", len(syms) == 1), list(syms))
+		}
 	default:
 		main = fmt.Sprintf("depends on %d vulnerable module%s (%s)", l, choose("", "s", l == 1), elemList)
 		informational := ", but doesn't appear to " + choose("call", "import", cfg.ScanLevel.WantSymbols()) + " any of the vulnerable symbols."
 		addition = choose(informational, ". "+runCallAnalysis, cfg.ScanLevel.WantPackages())
 	}
 
-	return fmt.Sprintf("Your code %s%s", main, addition)
+	return fmt.Sprintf("Your code %s%s%s", main, addition, replacements(findings))
+}
+
+// replacements returns sentences describing the replacements of
+// the vulnerable modules of findings, if they record them, as in
+// " Module new@v1.0.0 replaces old@v0.1.0.".
+func replacements(findings []*govulncheck.Finding) string {
+	seen := make(map[string]bool)
+	var sentences []string
+	for _, f := range findings {
+		fr := f.Trace[0]
+		if fr.ReplacedModule == "" {
+			continue
+		}
+		s := fmt.Sprintf(" Module %s replaces %s.", moduleVersion(fr.Module, fr.Version), moduleVersion(fr.ReplacedModule, fr.ReplacedVersion))
+		if !seen[s] {
+			seen[s] = true
+			sentences = append(sentences, s)
+		}
+	}
+	sort.Strings(sentences)
+	return strings.Join(sentences, "")
+}
+
+// moduleVersion returns path@version, or path
+// if the version is unknown, as for directories.
+func moduleVersion(path, version string) string {
+	if version == "" {
+		return path
+	}
+	return path + "@" + version
+}
+
+const (
+	failKind   = "fail"
+	reviewKind = "review"
+)
+
+// resultKind returns the kind of the Result for findings, which
+// are at their most precise level: fail if they are call-level
+// findings, and review otherwise.
+func resultKind(findings []*govulncheck.Finding) string {
+	if govulncheck.IsCalled(findings[0]) {
+		return failKind
+	}
+	return reviewKind
 }
 
 const (
@@ -241,19 +925,41 @@ const (
 	informationalLevel = "note"
 )
 
-func level(f *govulncheck.Finding, cfg *govulncheck.Config) string {
-	fr := f.Trace[0]
+// resultLevel returns the level of the Result for findings of the
+// vulnerability e. Findings reachable only from test code, or for
+// build-time tool dependencies, are demoted to note level.
+func resultLevel(findings []*govulncheck.Finding, e *osv.Entry, cfg *govulncheck.Config) string {
+	for _, f := range findings {
+		if !f.TestOnly && !f.BuildTime {
+			return level(findings[0], e, cfg)
+		}
+	}
+	return informationalLevel
+}
+
+// level returns the level of the finding f of the vulnerability e.
+// The severity overrides of cfg for e, if any, take precedence over
+// the reachability of f.
+func level(f *govulncheck.Finding, e *osv.Entry, cfg *govulncheck.Config) string {
+	if e != nil {
+		if r, ok := cvss.OverriddenRating(e, cfg.SeverityOverrides); ok {
+			return ratingLevel(r)
+		}
+	}
 	switch {
 	case cfg.ScanLevel.WantSymbols():
-		if fr.Function != "" {
+		if govulncheck.IsCalled(f) {
 			return errorLevel
 		}
-		if fr.Package != "" {
+		if govulncheck.IsImported(f) {
+			if cfg.FailOnImport {
+				return errorLevel
+			}
 			return warningLevel
 		}
 		return informationalLevel
 	case cfg.ScanLevel.WantPackages():
-		if fr.Package != "" {
+		if !govulncheck.IsModuleOnly(f) {
 			return errorLevel
 		}
 		return warningLevel
@@ -262,8 +968,113 @@ func level(f *govulncheck.Finding, cfg *govulncheck.Config) string {
 	}
 }
 
+// ratingLevel returns the level of results of vulnerabilities
+// of severity rating r: error for critical and high, warning
+// for medium, and note otherwise.
+func ratingLevel(r string) string {
+	switch r {
+	case cvss.RatingCritical, cvss.RatingHigh:
+		return errorLevel
+	case cvss.RatingMedium:
+		return warningLevel
+	default:
+		return informationalLevel
+	}
+}
+
+// defaultConfiguration returns the default configuration of the
+// Rule of e, whose level follows the severity of e subject to the
+// overrides of cfg, or nil if e has no severity.
+func defaultConfiguration(e *osv.Entry, cfg *govulncheck.Config) *ReportingConfiguration {
+	score, ok := cvss.OverriddenEntryScore(e, cfg.SeverityOverrides)
+	if !ok {
+		return nil
+	}
+	return &ReportingConfiguration{Level: ratingLevel(cvss.Rating(score))}
+}
+
+// rank computes the priority of a result, between 0 and 100, for
+// the finding f of the vulnerability e. The reachability of the
+// finding makes up to 60 points, while the CVSS score of e, if
+// any, makes up to 40 points. The score respects the severity
+// overrides of cfg, and is scaled by the weight of f relative to
+// the highest package weight of cfg.
+func rank(f *govulncheck.Finding, e *osv.Entry, cfg *govulncheck.Config) float64 {
+	var r float64
+	switch govulncheck.Level(f) {
+	case govulncheck.ScanLevelSymbol:
+		r = 60
+	case govulncheck.ScanLevelPackage:
+		r = 35
+	default:
+		r = 10
+	}
+	if e != nil {
+		if score, ok := cvss.OverriddenEntryScore(e, cfg.SeverityOverrides); ok {
+			r += score * 4
+		}
+	}
+	r *= packageWeight(f, cfg.PackageWeights) / maxWeight(cfg.PackageWeights)
+	return math.Round(r*10) / 10
+}
+
+// resultRank returns the highest rank of the findings fs
+// of the vulnerability e.
+func resultRank(fs []*govulncheck.Finding, e *osv.Entry, cfg *govulncheck.Config) float64 {
+	var r float64
+	for _, f := range fs {
+		r = max(r, rank(f, e, cfg))
+	}
+	return r
+}
+
+// defaultWeight is the weight of packages missing from PackageWeights.
+const defaultWeight = 1.0
+
+// packageWeight returns the highest weight of the packages of the trace
+// of f, or defaultWeight if none of them has one.
+func packageWeight(f *govulncheck.Finding, weights map[string]float64) float64 {
+	w, found := 0.0, false
+	for _, fr := range f.Trace {
+		if pw, ok := weights[fr.Package]; ok && fr.Package != "" && (!found || pw > w) {
+			w, found = pw, true
+		}
+	}
+	if !found {
+		return defaultWeight
+	}
+	return w
+}
+
+// checkWeights reports an error for the first package, by path,
+// whose weight is not a positive finite number, which would make
+// ranks negative or undefined.
+func checkWeights(weights map[string]float64) error {
+	pkgs := make([]string, 0, len(weights))
+	for pkg := range weights {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		if w := weights[pkg]; !(w > 0) || math.IsInf(w, 1) {
+			return fmt.Errorf("weight of package %q is %v; want a positive number", pkg, w)
+		}
+	}
+	return nil
+}
+
+// maxWeight returns the highest weight of weights, counting the
+// default weight of unlisted packages, by which ranks are normalized.
+func maxWeight(weights map[string]float64) float64 {
+	m := defaultWeight
+	for _, w := range weights {
+		m = max(m, w)
+	}
+	return m
+}
+
 func stacks(h *handler, fs []*govulncheck.Finding) []Stack {
-	if fs[0].Trace[0].Function == "" { // not call level findings
+	if !govulncheck.IsCalled(fs[0]) {
 		return nil
 	}
 
@@ -272,20 +1083,36 @@ func stacks(h *handler, fs []*govulncheck.Finding) []Stack {
 		stacks = append(stacks, stack(h, f))
 	}
 	// Sort stacks for deterministic output. We sort by message
-	// which is effectively sorting by full symbol name. The
-	// performance should not be an issue here.
-	sort.SliceStable(stacks, func(i, j int) bool { return stacks[i].Message.Text < stacks[j].Message.Text })
+	// which is effectively sorting by full symbol name, possibly
+	// preceded by the stack depth. The performance should not be
+	// an issue here.
+	sort.SliceStable(stacks, func(i, j int) bool {
+		if h.cfg.SortStacksByDepth {
+			if di, dj := len(stacks[i].Frames), len(stacks[j].Frames); di != dj {
+				return di < dj
+			}
+		}
+		return stacks[i].Message.Text < stacks[j].Message.Text
+	})
 	return stacks
 }
 
 // stack transforms call stack in f to a sarif stack.
 func stack(h *handler, f *govulncheck.Finding) Stack {
 	trace := f.Trace
+	if h.cfg.CollapseStdlib {
+		trace = govulncheck.CollapseStdlib(trace)
+	}
+	trace = govulncheck.TruncateTrace(trace, h.cfg.MaxTraceDepth)
 	top := trace[len(trace)-1] // belongs to top level module
 
 	var frames []Frame
 	for i := len(trace) - 1; i >= 0; i-- { // vulnerable symbol is at the top frame
 		frame := trace[i]
+		if frame.Elided() || frame.Collapsed() {
+			frames = append(frames, Frame{Location: Location{Message: Description{Text: frame.Function}}})
+			continue
+		}
 		pos := govulncheck.Position{Line: 1, Column: 1}
 		if frame.Position != nil {
 			pos = *frame.Position
@@ -295,16 +1122,13 @@ func stack(h *handler, f *govulncheck.Finding) Stack {
 			Module:   frame.Module + "@" + frame.Version,
 			Location: Location{Message: Description{Text: symbol(frame)}}, // show the (full) symbol name
 		}
-		file, base := fileURIInfo(pos.Filename, top.Module, frame.Module, frame.Version)
 		if h.cfg.ScanMode != govulncheck.ScanModeBinary {
 			sf.Location.PhysicalLocation = PhysicalLocation{
-				ArtifactLocation: ArtifactLocation{
-					URI:       file,
-					URIBaseID: base,
-				},
+				ArtifactLocation: h.fileLocation(pos.Filename, top.Module, frame.Module, frame.Version),
 				Region: Region{
 					StartLine:   pos.Line,
 					StartColumn: pos.Column,
+					Snippet:     h.snippet(frame, top),
 				},
 			}
 		}
@@ -317,8 +1141,77 @@ func stack(h *handler, f *govulncheck.Finding) Stack {
 	}
 }
 
+// relatedLocations returns the distinct call sites of vulnerable
+// symbols in the traces of fs, that is, the positions of their
+// second-from-top frames, the definitions of the vulnerable
+// symbols, that is, the positions of the top frames, and the
+// go.mod locations of fs, if any. There are none in binary mode.
+func relatedLocations(h *handler, fs []*govulncheck.Finding) []Location {
+	if h.cfg.ScanMode == govulncheck.ScanModeBinary {
+		return nil
+	}
+	// Locations are compared before their snippets are set.
+	type locationKey struct {
+		PhysicalLocation
+		msg string
+	}
+	seen := make(map[locationKey]bool)
+	var locs []Location
+	add := func(pos *govulncheck.Position, al ArtifactLocation, msg string, snippet *ArtifactContent) {
+		loc := Location{
+			PhysicalLocation: PhysicalLocation{
+				ArtifactLocation: al,
+				Region: Region{
+					StartLine:   pos.Line,
+					StartColumn: pos.Column,
+				},
+			},
+			Message: Description{Text: msg},
+		}
+		if key := (locationKey{loc.PhysicalLocation, msg}); !seen[key] {
+			seen[key] = true
+			loc.PhysicalLocation.Region.Snippet = snippet
+			locs = append(locs, loc)
+		}
+	}
+	addFrame := func(fr, top *govulncheck.Frame, msg string) {
+		if fr.Elided() || fr.Collapsed() || fr.Position == nil || fr.Position.Line <= 0 {
+			return
+		}
+		add(fr.Position, h.fileLocation(fr.Position.Filename, top.Module, fr.Module, fr.Version), msg, h.snippet(fr, top))
+	}
+	for _, f := range fs {
+		// The go.mod locations of module level
+		// findings are the locations of results.
+		if pos := f.GoModLocation; pos != nil && pos.Line > 0 && !govulncheck.IsModuleOnly(f) {
+			add(pos, h.artifactLocation(pos.Filename, SrcRootID), fmt.Sprintf("Requirement of vulnerable module %s", f.Trace[0].Module), nil)
+		}
+		if len(f.Trace) < 2 || !govulncheck.IsCalled(f) {
+			continue
+		}
+		vuln, top := f.Trace[0], f.Trace[len(f.Trace)-1]
+		addFrame(f.Trace[1], top, fmt.Sprintf("Call to vulnerable function %s", symbol(vuln)))
+		addFrame(vuln, top, fmt.Sprintf("Vulnerable function definition: %s", symbol(vuln)))
+	}
+	// Sort locations for deterministic output.
+	sort.SliceStable(locs, func(i, j int) bool {
+		li, lj := locs[i].PhysicalLocation, locs[j].PhysicalLocation
+		if li.ArtifactLocation.URI != lj.ArtifactLocation.URI {
+			return li.ArtifactLocation.URI < lj.ArtifactLocation.URI
+		}
+		if li.Region.StartLine != lj.Region.StartLine {
+			return li.Region.StartLine < lj.Region.StartLine
+		}
+		if li.Region.StartColumn != lj.Region.StartColumn {
+			return li.Region.StartColumn < lj.Region.StartColumn
+		}
+		return locs[i].Message.Text < locs[j].Message.Text
+	})
+	return locs
+}
+
 func codeFlows(h *handler, fs []*govulncheck.Finding) []CodeFlow {
-	if fs[0].Trace[0].Function == "" { // not call level findings
+	if !govulncheck.IsCalled(fs[0]) {
 		return nil
 	}
 
@@ -360,6 +1253,11 @@ func threadFlows(h *handler, fs []*govulncheck.Finding) []ThreadFlow {
 			// TODO: should we, similar to govulncheck text output, only
 			// mention three elements of the compact trace?
 			frame := trace[i]
+			if frame.Elided() || frame.Collapsed() {
+				// Markers of removed frames have no location.
+				tf = append(tf, ThreadFlowLocation{Location: Location{Message: Description{Text: frame.Function}}})
+				continue
+			}
 			pos := govulncheck.Position{Line: 1, Column: 1}
 			if frame.Position != nil {
 				pos = *frame.Position
@@ -369,16 +1267,13 @@ func threadFlows(h *handler, fs []*govulncheck.Finding) []ThreadFlow {
 				Module:   frame.Module + "@" + frame.Version,
 				Location: Location{Message: Description{Text: symbol(frame)}}, // show the (full) symbol name
 			}
-			file, base := fileURIInfo(pos.Filename, top.Module, frame.Module, frame.Version)
 			if h.cfg.ScanMode != govulncheck.ScanModeBinary {
 				tfl.Location.PhysicalLocation = PhysicalLocation{
-					ArtifactLocation: ArtifactLocation{
-						URI:       file,
-						URIBaseID: base,
-					},
+					ArtifactLocation: h.fileLocation(pos.Filename, top.Module, frame.Module, frame.Version),
 					Region: Region{
 						StartLine:   pos.Line,
 						StartColumn: pos.Column,
+						Snippet:     h.snippet(frame, top),
 					},
 				}
 			}
@@ -389,6 +1284,124 @@ func threadFlows(h *handler, fs []*govulncheck.Finding) []ThreadFlow {
 	return tfs
 }
 
+// fileLocation returns the artifact location of filename in module
+// mod at version, where top is the module analyzed.
+func (h *handler) fileLocation(filename, top, mod, version string) ArtifactLocation {
+	file, base := fileURIInfo(filename, top, mod, version)
+	if base == GoModCacheID && h.absoluteURIs() {
+		// The module cache stores modules at their escaped paths.
+		if p, err := module.EscapePath(mod); err == nil {
+			mod = p
+		}
+		if v, err := module.EscapeVersion(version); err == nil {
+			version = v
+		}
+		file = filepath.ToSlash(filepath.Join(mod+"@"+version, filename))
+	}
+	return h.artifactLocation(file, base)
+}
+
+// artifactLocation returns the location of file, a path relative
+// to base, as an absolute file URI if requested by the config and
+// the root of base is known.
+func (h *handler) artifactLocation(file, base string) ArtifactLocation {
+	if root := h.cfg.SarifURIRoots[base]; h.absoluteURIs() && root != "" {
+		return ArtifactLocation{URI: fileURI(filepath.Join(root, filepath.FromSlash(file)))}
+	}
+	if base == SrcRootID && h.rootPrefix != "" {
+		file = h.rootRelative(file)
+	}
+	return ArtifactLocation{URI: file, URIBaseID: base}
+}
+
+// rootRelative returns file, a path relative to the module
+// directory, relative to the module root instead. Files outside
+// of the module root, which are reported as notifications, keep
+// their paths relative to the module directory.
+func (h *handler) rootRelative(file string) string {
+	p, ok := govulncheck.RootRelative(h.rootPrefix, file)
+	if !ok {
+		h.outsideRoot(file)
+	}
+	return p
+}
+
+// outsideRoot records a notification that
+// file is outside of the module root, once.
+func (h *handler) outsideRoot(file string) {
+	h.warn(fmt.Sprintf("File %s is not in the module root %s, so its path is relative to the module directory.", file, h.cfg.ModuleRoot))
+}
+
+// maxSnippetFileSize is the size of the largest
+// file whose lines are included as snippets.
+const maxSnippetFileSize = 1 << 20
+
+// snippet returns the source line at the position of frame, if
+// requested by the config and frame is in top, the module analyzed.
+// It returns nil if the file of frame cannot be read, is larger than
+// maxSnippetFileSize, or is not UTF-8 text.
+func (h *handler) snippet(frame, top *govulncheck.Frame) *ArtifactContent {
+	if !h.cfg.SarifSnippets || frame.Module != top.Module || frame.Position == nil || frame.Position.Line <= 0 {
+		return nil
+	}
+	lines := h.sourceLines(frame.Position.Filename)
+	if frame.Position.Line > len(lines) {
+		return nil
+	}
+	return &ArtifactContent{Text: lines[frame.Position.Line-1]}
+}
+
+// sourceLines returns the lines of filename, relative to the
+// root of %SRCROOT%, or nil if they cannot be used for snippets.
+func (h *handler) sourceLines(filename string) []string {
+	if lines, ok := h.sources[filename]; ok {
+		return lines
+	}
+	var lines []string
+	path := filepath.FromSlash(filename)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(h.cfg.SarifURIRoots[SrcRootID], path)
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() && fi.Size() <= maxSnippetFileSize {
+		if data, err := os.ReadFile(path); err == nil && utf8.Valid(data) && bytes.IndexByte(data, 0) < 0 {
+			lines = strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		}
+	}
+	h.sources[filename] = lines
+	return lines
+}
+
+func (h *handler) absoluteURIs() bool {
+	return h.cfg.SarifURIBase == govulncheck.SarifURIAbsolute
+}
+
+// fileURI returns the file URI of the absolute path p.
+func fileURI(p string) string {
+	p = filepath.ToSlash(p)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // a Windows path, such as C:/dir
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// originalURIBaseIDs returns the descriptions of the URIBaseID offsets
+// of relative paths. There are none in binary mode or if paths are
+// absolute.
+func originalURIBaseIDs(h *handler) map[string]ArtifactLocation {
+	if h.cfg.ScanMode == govulncheck.ScanModeBinary || h.absoluteURIs() {
+		return nil
+	}
+	srcRoot := "The root directory of the module analyzed."
+	if h.rootPrefix != "" {
+		srcRoot = "The root directory containing the module analyzed."
+	}
+	return map[string]ArtifactLocation{
+		SrcRootID:    {Description: &Description{Text: srcRoot}},
+		GoRootID:     {Description: &Description{Text: "The root directory of the Go installation, as reported by go env GOROOT."}},
+		GoModCacheID: {Description: &Description{Text: "The module cache directory, as reported by go env GOMODCACHE."}},
+	}
+}
+
 func fileURIInfo(filename, top, module, version string) (string, string) {
 	if top == module {
 		return filename, SrcRootID
@@ -5,11 +5,24 @@
 package sarif
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
 )
 
 func scanLevel(f *govulncheck.Finding) string {
@@ -247,7 +260,7 @@ func TestHandlerModule(t *testing.T) {
 	}
 }
 
-func TestMoreSpecific(t *testing.T) {
+func TestFindingOrder(t *testing.T) {
 	frame := func(m, p, f string) *govulncheck.Frame {
 		return &govulncheck.Frame{
 			Module:   m,
@@ -255,56 +268,37 @@ func TestMoreSpecific(t *testing.T) {
 			Function: f,
 		}
 	}
-
+	sym := []*govulncheck.Frame{frame("m1", "p1", "v1"), frame("m1", "p1", "f2")}
+	bin := []*govulncheck.Frame{frame("m1", "p1", "v1")}
+	pkg := []*govulncheck.Frame{frame("m1", "p1", "")}
+	mod := []*govulncheck.Frame{frame("m1", "", "")}
 	for _, tc := range []struct {
 		name   string
-		want   int
 		trace1 []*govulncheck.Frame
 		trace2 []*govulncheck.Frame
+		want   string
+		count  int // of findings kept
 	}{
-		{"sym-vs-sym", 0,
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "v1"), frame("m1", "p1", "f2")},
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "v2"), frame("m1", "p1", "f1"), frame("m2", "p2", "f2")},
-		},
-		{"sym-vs-pkg", -1,
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "v1"), frame("m1", "p1", "f2")},
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "")},
-		},
-		{"pkg-vs-sym", 1,
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "")},
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "v1"), frame("m2", "p2", "v2")},
-		},
-		{"pkg-vs-mod", -1,
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "")},
-			[]*govulncheck.Frame{
-				frame("m1", "", "")},
-		},
-		{"mod-vs-sym", 1,
-			[]*govulncheck.Frame{
-				frame("m1", "", "")},
-			[]*govulncheck.Frame{
-				frame("m1", "p1", "v2"), frame("m1", "p1", "f1")},
-		},
-		{"mod-vs-mod", 0,
-			[]*govulncheck.Frame{
-				frame("m1", "", "")},
-			[]*govulncheck.Frame{
-				frame("m2", "", "")},
-		},
+		{"sym-vs-sym", sym, []*govulncheck.Frame{frame("m1", "p1", "v2"), frame("m1", "p1", "f1")}, "symbol", 2},
+		{"sym-vs-pkg", sym, pkg, "symbol", 1},
+		{"bin-vs-pkg", bin, pkg, "symbol", 1},
+		{"pkg-vs-mod", pkg, mod, "package", 1},
+		{"mod-vs-sym", mod, sym, "symbol", 1},
+		{"mod-vs-mod", mod, []*govulncheck.Frame{frame("m2", "", "")}, "module", 2},
 	} {
-		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			f1 := &govulncheck.Finding{Trace: tc.trace1}
-			f2 := &govulncheck.Finding{Trace: tc.trace2}
-			if got := moreSpecific(f1, f2); got != tc.want {
-				t.Errorf("want %d; got %d", tc.want, got)
+			// The findings kept do not depend on the order of delivery.
+			for _, order := range [][][]*govulncheck.Frame{{tc.trace1, tc.trace2}, {tc.trace2, tc.trace1}} {
+				h := NewHandler(io.Discard)
+				for _, trace := range order {
+					if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: trace}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				fs := h.findings["GO-0000-0001"]
+				if len(fs) != tc.count || scanLevel(fs[0]) != tc.want {
+					t.Errorf("got %d findings at level %s; want %d at level %s", len(fs), scanLevel(fs[0]), tc.count, tc.want)
+				}
 			}
 		})
 	}
@@ -341,9 +335,1878 @@ func TestResultMessage(t *testing.T) {
 		{[]*govulncheck.Finding{finding("m1", "", ""), finding("m2", "", "")}, govulncheck.ScanLevelSymbol,
 			"Your code depends on 2 vulnerable modules (m1 and m2), but doesn't appear to call any of the vulnerable symbols."},
 	} {
-		got := resultMessage(tc.findings, config(tc.level))
+		got := resultMessage(tc.findings, nil, config(tc.level))
 		if tc.want != got {
 			t.Errorf("want %s; got %s", tc.want, got)
 		}
 	}
 }
+
+func TestResultMessageBounded(t *testing.T) {
+	var findings []*govulncheck.Finding
+	for i := range 10 {
+		findings = append(findings, &govulncheck.Finding{
+			Trace: []*govulncheck.Frame{{Module: "m", Package: fmt.Sprintf("p%d", i)}},
+		})
+	}
+	for _, tc := range []struct {
+		max  int
+		want string
+	}{
+		{0, "Your code imports 10 vulnerable packages (p0, p1, p2, p3, p4, and 5 more). Run the call-level analysis to understand whether your code actually calls the vulnerabilities."},
+		{2, "Your code imports 10 vulnerable packages (p0, p1, and 8 more). Run the call-level analysis to understand whether your code actually calls the vulnerabilities."},
+		{10, "Your code imports 10 vulnerable packages (p0, p1, p2, p3, p4, p5, p6, p7, p8, and p9). Run the call-level analysis to understand whether your code actually calls the vulnerabilities."},
+		{-1, "Your code imports 10 vulnerable packages (p0, p1, p2, p3, p4, p5, p6, p7, p8, and p9). Run the call-level analysis to understand whether your code actually calls the vulnerabilities."},
+	} {
+		got := resultMessage(findings, nil, &govulncheck.Config{ScanLevel: govulncheck.ScanLevelPackage, SarifMaxListed: tc.max})
+		if got != tc.want {
+			t.Errorf("max %d: got message\n\t%q\nwant\n\t%q", tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestResultMessageReplaced(t *testing.T) {
+	findings := []*govulncheck.Finding{{
+		OSV: "GO-0000-0001",
+		Trace: []*govulncheck.Frame{{
+			Module:          "example.com/fork",
+			Version:         "v1.1.0",
+			ReplacedModule:  "example.com/m",
+			ReplacedVersion: "v1.0.0",
+		}},
+	}}
+	got := resultMessage(findings, nil, &govulncheck.Config{ScanLevel: govulncheck.ScanLevelModule})
+	want := "Your code depends on 1 vulnerable module (example.com/fork). Run the call-level analysis to understand whether your code actually calls the vulnerabilities. Module example.com/fork@v1.1.0 replaces example.com/m@v1.0.0."
+	if got != want {
+		t.Errorf("got message\n\t%q\nwant\n\t%q", got, want)
+	}
+}
+
+func TestResultMessageImportedOnly(t *testing.T) {
+	entry := &osv.Entry{
+		ID: "GO-0000-0001",
+		Affected: []osv.Affected{{
+			Module: osv.Module{Path: "m"},
+			EcosystemSpecific: osv.EcosystemSpecific{
+				Packages: []osv.Package{
+					{Path: "m/p1", Symbols: []string{"F", "T.M"}},
+					{Path: "m/p2", Symbols: []string{"G"}},
+					{Path: "m/p3", Symbols: []string{"H"}},
+				},
+			},
+		}},
+	}
+	findings := []*govulncheck.Finding{
+		{OSV: entry.ID, Trace: []*govulncheck.Frame{{Module: "m", Package: "m/p1"}}},
+		{OSV: entry.ID, Trace: []*govulncheck.Frame{{Module: "m", Package: "m/p2"}}},
+	}
+
+	for _, tc := range []struct {
+		level govulncheck.ScanLevel
+		want  string
+	}{
+		{govulncheck.ScanLevelSymbol,
+			"Your code imports 2 vulnerable packages (m/p1 and m/p2), but doesn’t appear to call any of the vulnerable symbols. Avoid using the vulnerable symbols m/p1.F, m/p1.T.M, and m/p2.G."},
+		{govulncheck.ScanLevelPackage,
+			"Your code imports 2 vulnerable packages (m/p1 and m/p2). Run the call-level analysis to understand whether your code actually calls the vulnerabilities."},
+	} {
+		got := resultMessage(findings, entry, &govulncheck.Config{ScanLevel: tc.level})
+		if got != tc.want {
+			t.Errorf("%s: want %s; got %s", tc.level, tc.want, got)
+		}
+	}
+}
+
+func TestCrossesUnsafe(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		trace []*govulncheck.Frame
+		want  bool
+	}{
+		{"safe", []*govulncheck.Frame{
+			{Module: "m", Package: "m/p", Function: "F", Position: &govulncheck.Position{Filename: "p/p.go"}},
+			{Module: "main", Package: "main", Function: "main", Position: &govulncheck.Position{Filename: "main.go"}},
+		}, false},
+		{"unsafe", []*govulncheck.Frame{
+			{Module: "m", Package: "m/p", Function: "F"},
+			{Module: "stdlib", Package: "unsafe", Function: "Pointer"},
+			{Module: "main", Package: "main", Function: "main"},
+		}, true},
+		{"cgo", []*govulncheck.Frame{
+			{Module: "m", Package: "m/p", Function: "F"},
+			{Module: "main", Package: "main", Function: "_Cfunc_f", Position: &govulncheck.Position{Filename: "/tmp/go-build/_cgo_gotypes.go"}},
+		}, true},
+	} {
+		f := &govulncheck.Finding{OSV: "GO-0000-0001", Trace: tc.trace}
+		props := properties([]*govulncheck.Finding{f}, nil, nil, false)
+		if got := props != nil && props.CrossesUnsafe; got != tc.want {
+			t.Errorf("%s: want %t; got %t", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestPreferAliasPrefix(t *testing.T) {
+	h := newTestHandler()
+	h.cfg.PreferAliasPrefix = "CVE-"
+	for _, e := range []*osv.Entry{
+		{ID: "GO-0000-0001", Aliases: []string{"GHSA-xxxx-yyyy-zzzz", "CVE-2024-1234"}},
+		// Entries without a matching alias keep their OSV ID.
+		{ID: "GO-0000-0002", Aliases: []string{"GHSA-aaaa-bbbb-cccc"}},
+		// So do entries sharing an alias, whose rules
+		// would be ambiguous otherwise.
+		{ID: "GO-0000-0003", Aliases: []string{"CVE-2024-5678"}},
+		{ID: "GO-0000-0004", Aliases: []string{"GHSA-dddd-eeee-ffff", "CVE-2024-5678"}},
+	} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(&govulncheck.Finding{OSV: e.ID, Trace: []*govulncheck.Frame{{Module: "m", Version: "v1.0.0"}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var gotRules, gotResults []string
+	for _, r := range rules(h) {
+		gotRules = append(gotRules, r.ID+" "+r.Properties.OSVID)
+	}
+	for _, r := range results(h) {
+		var id string
+		if r.Properties != nil {
+			id = r.Properties.OSVID
+		}
+		gotResults = append(gotResults, r.RuleID+" "+id)
+	}
+	want := []string{"CVE-2024-1234 GO-0000-0001", "GO-0000-0002 ", "GO-0000-0003 ", "GO-0000-0004 "}
+	if diff := cmp.Diff(want, gotRules); diff != "" {
+		t.Errorf("rules mismatch (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, gotResults); diff != "" {
+		t.Errorf("results mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestThroughInit(t *testing.T) {
+	findings := []*govulncheck.Finding{
+		{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m", Package: "m/p", Function: "F"}, {Module: "main", Package: "main", Function: "main"}}},
+		{OSV: "GO-0000-0001", ThroughInit: true, Trace: []*govulncheck.Frame{{Module: "m", Package: "m/p", Function: "F"}, {Module: "main", Package: "main", Function: "init#1"}}},
+	}
+	props := properties(findings, nil, nil, false)
+	if props == nil || !props.ThroughInit || props.ThroughReflection {
+		t.Errorf("got properties %+v; want ThroughInit only", props)
+	}
+}
+
+func TestWorkspaceModule(t *testing.T) {
+	finding := func(main string) *govulncheck.Finding {
+		return &govulncheck.Finding{OSV: "GO-0000-0001", WorkspaceModule: main, Trace: []*govulncheck.Frame{
+			{Module: "m", Package: "m/p", Function: "F"},
+			{Module: main, Package: main, Function: "main"},
+		}}
+	}
+	for _, tc := range []struct {
+		name     string
+		findings []*govulncheck.Finding
+		want     string
+	}{
+		{"one module", []*govulncheck.Finding{finding("example.com/a"), finding("example.com/a")}, "example.com/a"},
+		{"several modules", []*govulncheck.Finding{finding("example.com/a"), finding("example.com/b")}, ""},
+	} {
+		var got string
+		if props := properties(tc.findings, nil, nil, false); props != nil {
+			got = props.WorkspaceModule
+		}
+		if got != tc.want {
+			t.Errorf("%s: got workspace module %q; want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	config := func(l govulncheck.ScanLevel) *govulncheck.Config {
+		return &govulncheck.Config{ScanLevel: l}
+	}
+
+	finding := func(m, p, f string) *govulncheck.Finding {
+		return &govulncheck.Finding{
+			Trace: []*govulncheck.Frame{
+				{Module: m, Package: p, Function: f},
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		finding *govulncheck.Finding
+		level   govulncheck.ScanLevel
+		want    string
+	}{
+		{finding("m", "p", "f"), govulncheck.ScanLevelSymbol, errorLevel},
+		{finding("m", "p", ""), govulncheck.ScanLevelSymbol, warningLevel},
+		{finding("m", "", ""), govulncheck.ScanLevelSymbol, informationalLevel},
+		{finding("m", "p", ""), govulncheck.ScanLevelPackage, errorLevel},
+		{finding("m", "", ""), govulncheck.ScanLevelPackage, warningLevel},
+		{finding("m", "", ""), govulncheck.ScanLevelModule, errorLevel},
+	} {
+		if got := level(tc.finding, nil, config(tc.level)); got != tc.want {
+			t.Errorf("%v at %s scan: want %s; got %s", tc.finding.Trace[0], tc.level, tc.want, got)
+		}
+	}
+}
+
+func TestLevelFailOnImport(t *testing.T) {
+	cfg := &govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol, FailOnImport: true}
+	for _, tc := range []struct {
+		frame *govulncheck.Frame
+		want  string
+	}{
+		{&govulncheck.Frame{Module: "m", Package: "p", Function: "f"}, errorLevel},
+		{&govulncheck.Frame{Module: "m", Package: "p"}, errorLevel},
+		{&govulncheck.Frame{Module: "m"}, informationalLevel},
+	} {
+		f := &govulncheck.Finding{Trace: []*govulncheck.Frame{tc.frame}}
+		if got := level(f, nil, cfg); got != tc.want {
+			t.Errorf("%v: want %s; got %s", tc.frame, tc.want, got)
+		}
+	}
+}
+
+func TestResultLevelTestOnly(t *testing.T) {
+	cfg := &govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}
+	finding := func(testOnly bool) *govulncheck.Finding {
+		return &govulncheck.Finding{
+			OSV:      "GO-0000-0001",
+			TestOnly: testOnly,
+			Trace:    []*govulncheck.Frame{{Module: "m", Package: "m/p", Function: "F"}},
+		}
+	}
+	if got := resultLevel([]*govulncheck.Finding{finding(true), finding(true)}, nil, cfg); got != informationalLevel {
+		t.Errorf("test only: want %s; got %s", informationalLevel, got)
+	}
+	if got := resultLevel([]*govulncheck.Finding{finding(true), finding(false)}, nil, cfg); got != errorLevel {
+		t.Errorf("mixed: want %s; got %s", errorLevel, got)
+	}
+}
+
+func TestLevelSeverityOverrides(t *testing.T) {
+	e := &osv.Entry{
+		ID:      "GO-0000-0001",
+		Aliases: []string{"CVE-0000-0001"},
+		// The CVSS score, 1.8, is low.
+		Severity: []osv.Severity{{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.0/AV:L/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N"}},
+	}
+	// A module-level finding of a symbol scan is a note.
+	f := &govulncheck.Finding{OSV: e.ID, Trace: []*govulncheck.Frame{{Module: "m", Version: "v1.0.0"}}}
+	cfg := &govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}
+	if got := level(f, e, cfg); got != informationalLevel {
+		t.Fatalf("without overrides: want %s; got %s", informationalLevel, got)
+	}
+
+	cfg.SeverityOverrides = map[string]string{"CVE-0000-0001": "critical"}
+	if got := level(f, e, cfg); got != errorLevel {
+		t.Errorf("critical override: want %s; got %s", errorLevel, got)
+	}
+	if got, want := rank(f, e, cfg), rank(f, &osv.Entry{ID: "C", Severity: []osv.Severity{
+		{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H"}, // 10.0
+	}}, cfg); got != want {
+		t.Errorf("critical override: want rank %v; got %v", want, got)
+	}
+}
+
+func TestResultKind(t *testing.T) {
+	for _, tc := range []struct {
+		frame *govulncheck.Frame
+		want  string
+	}{
+		{&govulncheck.Frame{Module: "m", Package: "m/p", Function: "F"}, failKind},
+		{&govulncheck.Frame{Module: "m", Package: "m/p"}, reviewKind},
+		{&govulncheck.Frame{Module: "m"}, reviewKind},
+	} {
+		fs := []*govulncheck.Finding{{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{tc.frame}}}
+		if got := resultKind(fs); got != tc.want {
+			t.Errorf("%v: want %s; got %s", tc.frame, tc.want, got)
+		}
+	}
+}
+
+func TestResultLevelBuildTime(t *testing.T) {
+	cfg := &govulncheck.Config{ScanLevel: govulncheck.ScanLevelModule}
+	f := &govulncheck.Finding{
+		OSV:       "GO-0000-0001",
+		BuildTime: true,
+		Trace:     []*govulncheck.Frame{{Module: "m", Version: "v1.0.0"}},
+	}
+	if got := resultLevel([]*govulncheck.Finding{f}, nil, cfg); got != informationalLevel {
+		t.Errorf("want %s; got %s", informationalLevel, got)
+	}
+}
+
+func TestRank(t *testing.T) {
+	critical := &osv.Entry{ID: "C", Severity: []osv.Severity{
+		{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H"}, // 10.0
+	}}
+	low := &osv.Entry{ID: "L", Severity: []osv.Severity{
+		{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.0/AV:L/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N"}, // 1.8
+	}}
+	unknown := &osv.Entry{ID: "U"}
+
+	called := &govulncheck.Finding{Trace: []*govulncheck.Frame{
+		{Module: "m", Package: "p", Function: "f"}, {Module: "m", Package: "p", Function: "main"}}}
+	imported := &govulncheck.Finding{Trace: []*govulncheck.Frame{{Module: "m", Package: "p"}}}
+	required := &govulncheck.Finding{Trace: []*govulncheck.Frame{{Module: "m"}}}
+
+	cfg := &govulncheck.Config{}
+	for _, tc := range []struct {
+		name    string
+		finding *govulncheck.Finding
+		entry   *osv.Entry
+		want    float64
+	}{
+		{"called-critical", called, critical, 100},
+		{"called-unknown", called, unknown, 60},
+		{"imported-critical", imported, critical, 75},
+		{"required-low", required, low, 17.2},
+		{"required-unknown", required, unknown, 10},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rank(tc.finding, tc.entry, cfg); got != tc.want {
+				t.Errorf("want %v; got %v", tc.want, got)
+			}
+		})
+	}
+
+	if rank(called, critical, cfg) <= rank(required, low, cfg) {
+		t.Error("want called critical finding ranked above a module-only low finding")
+	}
+}
+
+func TestRankPackageWeights(t *testing.T) {
+	critical := &osv.Entry{ID: "C", Severity: []osv.Severity{
+		{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H"}, // 10.0
+	}}
+	low := &osv.Entry{ID: "L", Severity: []osv.Severity{
+		{Type: osv.SeverityTypeCVSSV3, Score: "CVSS:3.0/AV:L/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N"}, // 1.8
+	}}
+
+	payments := &govulncheck.Finding{Trace: []*govulncheck.Frame{
+		{Module: "m", Package: "m/p", Function: "f"}, {Module: "main", Package: "main/payments", Function: "Charge"}}}
+	elsewhere := &govulncheck.Finding{Trace: []*govulncheck.Frame{
+		{Module: "m", Package: "m/p", Function: "f"}, {Module: "main", Package: "main/docs", Function: "Render"}}}
+
+	cfg := &govulncheck.Config{PackageWeights: map[string]float64{"main/payments": 3}}
+	lowRank, criticalRank := rank(payments, low, cfg), rank(elsewhere, critical, cfg)
+	if lowRank <= criticalRank {
+		t.Errorf("want low finding in weighted package ranked above critical finding elsewhere; got %v <= %v", lowRank, criticalRank)
+	}
+	if want := 67.2; lowRank != want {
+		t.Errorf("want rank %v for the highest weight; got %v", want, lowRank)
+	}
+
+	// Without weights, ranks are unchanged.
+	if got, want := rank(elsewhere, critical, &govulncheck.Config{}), 100.0; got != want {
+		t.Errorf("want %v; got %v", want, got)
+	}
+
+	for _, w := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		cfg := &govulncheck.Config{PackageWeights: map[string]float64{"main/payments": 3, "main/docs": w}}
+		if err := NewHandler(io.Discard).Config(cfg); err == nil {
+			t.Errorf("want an error for weight %v", w)
+		}
+	}
+	if err := NewHandler(io.Discard).Config(cfg); err != nil {
+		t.Errorf("want no error for positive weights; got %v", err)
+	}
+}
+
+func TestFlushCompact(t *testing.T) {
+	fs := `
+{
+  "finding": {
+    "osv": "GO-2021-0054",
+    "trace": [
+      {
+        "module": "github.com/tidwall/gjson",
+        "package": "github.com/tidwall/gjson"
+      }
+    ]
+  }
+}`
+
+	flush := func(compact bool) []byte {
+		var buf bytes.Buffer
+		h := NewHandler(&buf)
+		cfg := &govulncheck.Config{ScanLevel: govulncheck.ScanLevelPackage, CompactOutput: compact}
+		if err := h.Config(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.OSV(&osv.Entry{ID: "GO-2021-0054"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := govulncheck.HandleJSON(strings.NewReader(fs), h); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	indented, compact := flush(false), flush(true)
+	if !json.Valid(compact) {
+		t.Fatalf("compact output is not valid JSON:\n%s", compact)
+	}
+	if bytes.ContainsRune(compact, '\n') {
+		t.Errorf("compact output contains new lines:\n%s", compact)
+	}
+	if len(compact) >= len(indented) {
+		t.Errorf("want compact output smaller than %d bytes; got %d", len(indented), len(compact))
+	}
+	var want bytes.Buffer
+	if err := json.Compact(&want, indented); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want.Bytes(), compact) {
+		t.Errorf("compact output differs from indented output")
+	}
+}
+
+func TestRecommendedVersion(t *testing.T) {
+	entry := func(id string, events ...osv.RangeEvent) *osv.Entry {
+		return &osv.Entry{ID: id, Affected: []osv.Affected{{
+			Module: osv.Module{Path: "m"},
+			Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: events}},
+		}}}
+	}
+	finding := func(id, fixed string) *govulncheck.Finding {
+		return &govulncheck.Finding{
+			OSV:          id,
+			FixedVersion: fixed,
+			Trace:        []*govulncheck.Frame{{Module: "m", Version: "v1.0.0", Package: "m/p"}},
+		}
+	}
+
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelPackage, ScanMode: govulncheck.ScanModeBinary}); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []*osv.Entry{
+		entry("GO-0000-0001", osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.2.0"}),
+		entry("GO-0000-0002", osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.4.0"}),
+	} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []*govulncheck.Finding{finding("GO-0000-0001", "v1.2.0"), finding("GO-0000-0002", "v1.4.0")} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var log Log
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range log.Runs[0].Results {
+		if r.Properties == nil || r.Properties.RecommendedVersion != "v1.4.0" {
+			t.Errorf("%s: want recommended version v1.4.0; got %+v", r.RuleID, r.Properties)
+		}
+	}
+}
+
+func TestSarifCalledOnly(t *testing.T) {
+	fs := `
+{
+  "finding": {
+    "osv": "GO-2020-0015",
+    "trace": [
+      {
+        "module": "golang.org/x/text"
+      }
+    ]
+  }
+}
+{
+  "finding": {
+    "osv": "GO-2021-0054",
+    "trace": [
+      {
+        "module": "github.com/tidwall/gjson",
+        "package": "github.com/tidwall/gjson"
+      }
+    ]
+  }
+}
+{
+  "finding": {
+    "osv": "GO-2021-0265",
+    "trace": [
+      {
+        "module": "github.com/tidwall/gjson",
+        "package": "github.com/tidwall/gjson",
+        "function": "Get",
+        "receiver": "Result"
+      },
+      {
+        "module": "golang.org/vuln",
+        "package": "golang.org/vuln",
+        "function": "main"
+      }
+    ]
+  }
+}`
+
+	for _, tc := range []struct {
+		calledOnly bool
+		want       []string
+	}{
+		{false, []string{"GO-2020-0015", "GO-2021-0054", "GO-2021-0265"}},
+		{true, []string{"GO-2021-0265"}},
+	} {
+		h := newTestHandler()
+		h.cfg = &govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol, SarifCalledOnly: tc.calledOnly}
+		if err := govulncheck.HandleJSON(strings.NewReader(fs), h); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range results(h) {
+			got = append(got, r.RuleID)
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("calledOnly=%t: (-want;got+): %s", tc.calledOnly, diff)
+		}
+	}
+}
+
+func TestScannerOverride(t *testing.T) {
+	h := newTestHandler()
+	h.cfg = &govulncheck.Config{
+		ScannerName:            "govulncheck",
+		ScannerVersion:         "v1.1.0",
+		ScannerNameOverride:    "acme-scan",
+		ScannerVersionOverride: "v3.2.1",
+	}
+	tool := toSarif(h).Runs[0].Tool
+	want := Tool{
+		Driver: Driver{
+			Name:       "acme-scan",
+			Version:    "v3.2.1",
+			Properties: *h.cfg,
+		},
+		Extensions: []Extension{{
+			Name:           "govulncheck",
+			Version:        "v1.1.0",
+			InformationURI: "https://pkg.go.dev/golang.org/x/vuln/cmd/govulncheck",
+		}},
+	}
+	if diff := cmp.Diff(want, tool); diff != "" {
+		t.Errorf("(-want;got+): %s", diff)
+	}
+}
+
+func TestAutomationDetails(t *testing.T) {
+	for _, id := range []string{"", "govulncheck/linux-amd64"} {
+		var buf bytes.Buffer
+		h := NewHandler(&buf)
+		if err := h.Config(&govulncheck.Config{SarifAutomationID: id}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		var log Log
+		if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Fatal(err)
+		}
+		got := log.Runs[0].AutomationDetails
+		if id == "" {
+			if got != nil || strings.Contains(buf.String(), "automationDetails") {
+				t.Errorf("want no automation details by default; got %v", got)
+			}
+			continue
+		}
+		if got == nil || got.ID != id {
+			t.Errorf("want automation details ID %q; got %v", id, got)
+		}
+	}
+}
+
+func TestMaxSarifBytes(t *testing.T) {
+	flush := func(max int) (Log, int) {
+		var buf bytes.Buffer
+		h := NewHandler(&buf)
+		if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol, MaxSarifBytes: max}); err != nil {
+			t.Fatal(err)
+		}
+		for i := range 50 {
+			id := fmt.Sprintf("GO-0000-%04d", i)
+			if err := h.OSV(&osv.Entry{ID: id, Details: strings.Repeat("details ", 100)}); err != nil {
+				t.Fatal(err)
+			}
+			fr := &govulncheck.Frame{Module: fmt.Sprintf("example.com/m%d", i), Version: "v1.0.0"}
+			switch {
+			case i == 0:
+				fr.Package, fr.Function = fr.Module, "F"
+			case i < 10:
+				fr.Package = fr.Module
+			}
+			if err := h.Finding(&govulncheck.Finding{OSV: id, Trace: []*govulncheck.Frame{fr}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := h.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		var log Log
+		if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Fatal(err)
+		}
+		return log, buf.Len()
+	}
+
+	full, size := flush(0)
+	if n := len(full.Runs[0].Results); n != 50 {
+		t.Fatalf("got %d results without limit; want 50", n)
+	}
+	max := size / 4
+	log, size := flush(max)
+	if size > max {
+		t.Errorf("got %d bytes; want at most %d", size, max)
+	}
+	results := log.Runs[0].Results
+	if len(results) == 0 || len(results) >= 50 {
+		t.Fatalf("got %d results; want some to be omitted", len(results))
+	}
+	// The called vulnerability is the last to be omitted.
+	called := false
+	for _, r := range results {
+		called = called || r.RuleID == "GO-0000-0000"
+	}
+	if !called {
+		t.Errorf("the result of the called vulnerability was omitted")
+	}
+	invs := log.Runs[0].Invocations
+	want := fmt.Sprintf("Omitted the results of %d vulnerabilities to keep the output within %d bytes.", 50-len(results), max)
+	if len(invs) != 1 || len(invs[0].ToolExecutionNotifications) != 1 || invs[0].ToolExecutionNotifications[0].Message.Text != want {
+		t.Errorf("got invocations %+v; want a notification %q", invs, want)
+	}
+}
+
+func TestRunScanLevel(t *testing.T) {
+	for _, level := range []govulncheck.ScanLevel{govulncheck.ScanLevelSymbol, govulncheck.ScanLevelPackage, govulncheck.ScanLevelModule} {
+		t.Run(string(level), func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewHandler(&buf)
+			if err := h.Config(&govulncheck.Config{ScanLevel: level}); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			var log Log
+			if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+				t.Fatal(err)
+			}
+			if got := log.Runs[0].Properties; got == nil || got.ScanLevel != string(level) {
+				t.Errorf("got run properties %v; want scan level %q", got, level)
+			}
+		})
+	}
+}
+
+func TestModuleLocation(t *testing.T) {
+	h := newTestHandler()
+	h.cfg = &govulncheck.Config{ScanLevel: govulncheck.ScanLevelModule, ScanMode: govulncheck.ScanModeSource}
+	if err := h.Finding(&govulncheck.Finding{
+		OSV:           "GO-0000-0001",
+		GoModLocation: &govulncheck.Position{Filename: "go.mod", Line: 11, Column: 2},
+		Trace:         []*govulncheck.Frame{{Module: "golang.org/x/text", Version: "v0.3.0"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rs := results(h)
+	want := Region{StartLine: 11, StartColumn: 2}
+	if got := rs[0].Locations[0].PhysicalLocation.Region; got != want {
+		t.Errorf("want %+v; got %+v", want, got)
+	}
+	// The require directive is not repeated as a related location.
+	if got := rs[0].RelatedLocations; len(got) != 0 {
+		t.Errorf("want no related locations; got %+v", got)
+	}
+}
+
+func TestStacksOrder(t *testing.T) {
+	frame := func(p, f string) *govulncheck.Frame {
+		return &govulncheck.Frame{Module: "m", Package: p, Function: f}
+	}
+	fs := []*govulncheck.Finding{
+		{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{frame("m/a", "A"), frame("m/p", "F"), frame("main", "main")}},
+		{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{frame("m/b", "B"), frame("main", "main")}},
+	}
+	for _, tc := range []struct {
+		byDepth bool
+		want    []string
+	}{
+		{false, []string{"m/a.A", "m/b.B"}},
+		{true, []string{"m/b.B", "m/a.A"}},
+	} {
+		h := newTestHandler()
+		h.cfg = &govulncheck.Config{SortStacksByDepth: tc.byDepth}
+		var got []string
+		for _, s := range stacks(h, fs) {
+			got = append(got, strings.TrimPrefix(s.Message.Text, "A call stack for vulnerable function "))
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("byDepth=%t: (-want;got+): %s", tc.byDepth, diff)
+		}
+	}
+}
+
+func TestRuleDescriptions(t *testing.T) {
+	details := strings.Repeat("word ", 30)
+	h := newTestHandler()
+	for _, e := range []*osv.Entry{
+		{ID: "GO-0000-0001", Summary: "summary", Details: "details"},
+		{ID: "GO-0000-0002", Details: details},
+	} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(&govulncheck.Finding{OSV: e.ID, Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string][2]string{
+		"GO-0000-0001": {"[GO-0000-0001] summary", "details"},
+		"GO-0000-0002": {"[GO-0000-0002] " + truncate(details, maxShortDescriptionLen), details},
+	}
+	for _, r := range rules(h) {
+		got := [2]string{r.ShortDescription.Text, r.FullDescription.Text}
+		if got != want[r.ID] {
+			t.Errorf("%s: want %q; got %q", r.ID, want[r.ID], got)
+		}
+	}
+}
+
+func TestGUIDs(t *testing.T) {
+	parse := &govulncheck.Frame{Module: "golang.org/x/text", Version: "v0.3.0", Package: "golang.org/x/text/language", Function: "Parse"}
+	match := &govulncheck.Frame{Module: "golang.org/x/text", Version: "v0.3.0", Package: "golang.org/x/text/language", Function: "MatchStrings"}
+	run := func(frames ...*govulncheck.Frame) Log {
+		h := newTestHandler()
+		if err := h.OSV(&osv.Entry{ID: "GO-2021-0113"}); err != nil {
+			t.Fatal(err)
+		}
+		for _, fr := range frames {
+			if err := h.Finding(&govulncheck.Finding{OSV: "GO-2021-0113", Trace: []*govulncheck.Frame{fr}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return toSarif(h)
+	}
+	guids := func(l Log) (string, string) {
+		return l.Runs[0].Tool.Driver.Rules[0].GUID, l.Runs[0].Results[0].GUID
+	}
+
+	rule, result := guids(run(parse, match))
+	if want := "681c911b-dcaa-5ad7-90c1-7d72affdf5df"; rule != want {
+		t.Errorf("rule GUID: got %s; want %s", rule, want)
+	}
+	// GUIDs do not depend on the order of findings.
+	if r, res := guids(run(match, parse)); r != rule || res != result {
+		t.Errorf("GUIDs differ across runs: got %s, %s; want %s, %s", r, res, rule, result)
+	}
+	// Results with other vulnerable symbols have other GUIDs.
+	if r, res := guids(run(parse)); r != rule || res == result {
+		t.Errorf("got GUIDs %s, %s; want rule GUID %s and result GUID other than %s", r, res, rule, result)
+	}
+}
+
+func TestPackageResults(t *testing.T) {
+	e := &osv.Entry{
+		ID: "GO-2023-1840",
+		Affected: []osv.Affected{{
+			Module: osv.Module{Path: "stdlib"},
+			EcosystemSpecific: osv.EcosystemSpecific{Packages: []osv.Package{
+				{Path: "runtime"},
+				{Path: "crypto/tls"},
+			}},
+		}},
+	}
+	run := func(packageResults bool) []Result {
+		h := newTestHandler()
+		h.cfg.SarifPackageResults = packageResults
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+		f := &govulncheck.Finding{OSV: e.ID, Trace: []*govulncheck.Frame{{Module: "stdlib", Version: "v1.20.4"}}}
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+		return results(h)
+	}
+
+	if got := run(false); len(got) != 1 {
+		t.Fatalf("got %d results; want 1", len(got))
+	}
+	got := run(true)
+	var pkgs []string
+	guids := make(map[string]bool)
+	for _, r := range got {
+		if r.RuleID != e.ID {
+			t.Errorf("got rule %s; want %s", r.RuleID, e.ID)
+		}
+		guids[r.GUID] = true
+		for _, l := range r.Locations {
+			for _, ll := range l.LogicalLocations {
+				pkgs = append(pkgs, ll.FullyQualifiedName)
+			}
+		}
+	}
+	if diff := cmp.Diff([]string{"crypto/tls", "runtime"}, pkgs); diff != "" {
+		t.Errorf("packages mismatch (-want, +got):\n%s", diff)
+	}
+	if len(guids) != len(got) {
+		t.Errorf("got %d distinct GUIDs for %d results", len(guids), len(got))
+	}
+	if want := " The vulnerable package of this result is crypto/tls."; !strings.HasSuffix(got[0].Message.Text, want) {
+		t.Errorf("got message %q; want suffix %q", got[0].Message.Text, want)
+	}
+}
+
+func TestResultPerStack(t *testing.T) {
+	vuln := &govulncheck.Frame{Module: "m", Package: "m/p", Function: "F", Position: &govulncheck.Position{Filename: "p/p.go", Line: 3}}
+	callers := []*govulncheck.Frame{
+		{Module: "main", Package: "main", Function: "A", Position: &govulncheck.Position{Filename: "main.go", Line: 10}},
+		{Module: "main", Package: "main", Function: "B", Position: &govulncheck.Position{Filename: "main.go", Line: 20}},
+		{Module: "main", Package: "main", Function: "C", Position: &govulncheck.Position{Filename: "main.go", Line: 30}},
+	}
+	for _, perStack := range []bool{false, true} {
+		var buf bytes.Buffer
+		h := NewHandler(&buf)
+		if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol, ResultPerStack: perStack}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.OSV(&osv.Entry{ID: "GO-0000-0001"}); err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range callers {
+			if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{vuln, c}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := h.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		var log Log
+		if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Fatal(err)
+		}
+		results := log.Runs[0].Results
+		want := 1
+		if perStack {
+			want = len(callers)
+		}
+		if len(results) != want {
+			t.Fatalf("perStack=%t: got %d results; want %d", perStack, len(results), want)
+		}
+		if !perStack {
+			if n := len(results[0].Stacks); n != len(callers) {
+				t.Errorf("got %d stacks; want %d", n, len(callers))
+			}
+			if results[0].PartialFingerprints != nil {
+				t.Errorf("got fingerprints %v; want none", results[0].PartialFingerprints)
+			}
+			continue
+		}
+		guids := make(map[string]bool)
+		fingerprints := make(map[string]bool)
+		for _, r := range results {
+			if r.RuleID != "GO-0000-0001" {
+				t.Errorf("got rule %s; want GO-0000-0001", r.RuleID)
+			}
+			if n := len(r.Stacks); n != 1 {
+				t.Errorf("got %d stacks in result; want 1", n)
+			}
+			guids[r.GUID] = true
+			fingerprints[r.PartialFingerprints[callPathFingerprint]] = true
+		}
+		if len(guids) != len(callers) || len(fingerprints) != len(callers) || fingerprints[""] {
+			t.Errorf("got GUIDs %v and fingerprints %v; want distinct ones per stack", guids, fingerprints)
+		}
+	}
+}
+
+func TestRuleTags(t *testing.T) {
+	h := newTestHandler()
+	e := &osv.Entry{
+		ID:        "GO-0000-0001",
+		Aliases:   []string{"CVE-0000-0001", "GHSA-xxxx-yyyy-zzzz"},
+		Related:   []string{"CVE-0000-0002"},
+		Published: time.Date(2021, 4, 14, 20, 4, 52, 0, time.UTC),
+		Modified:  time.Date(2023, 4, 3, 15, 57, 51, 0, time.UTC),
+		Credits:   []osv.Credit{{Name: "Jane Doe"}},
+	}
+	for _, e := range []*osv.Entry{e, {ID: "GO-0000-0002"}} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(&govulncheck.Finding{OSV: e.ID, Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := rules(h)
+	want := []RuleTags{
+		{
+			Tags:      []string{"CVE-0000-0001", "GHSA-xxxx-yyyy-zzzz", "related:CVE-0000-0002"},
+			Published: "2021-04-14T20:04:52Z",
+			Modified:  "2023-04-03T15:57:51Z",
+			Credits:   []string{"Jane Doe"},
+		},
+		{}, // unknown times are omitted
+	}
+	for i, r := range got {
+		if diff := cmp.Diff(want[i], r.Properties); diff != "" {
+			t.Errorf("%s: mismatch (-want, +got):\n%s", r.ID, diff)
+		}
+	}
+}
+
+func TestCWECategories(t *testing.T) {
+	const highVector = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H" // 7.5
+	withCWEs := func(id string, cwes ...string) *osv.Entry {
+		return &osv.Entry{ID: id, DatabaseSpecific: &osv.DatabaseSpecific{CWEIDs: cwes}}
+	}
+	entries := []*osv.Entry{
+		withCWEs("GO-0000-0001", "CWE-400"),
+		withCWEs("GO-0000-0002", "cwe-835", "CWE-79"),
+		withCWEs("GO-0000-0003", "CWE-770", "CWE-9999"), // unknown CWE
+		withCWEs("GO-0000-0004"),
+	}
+	entries[0].Severity = []osv.Severity{{Type: osv.SeverityTypeCVSSV3, Score: highVector}}
+	h := newTestHandler()
+	for _, e := range entries {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(&govulncheck.Finding{OSV: e.ID, Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := toSarif(h)
+
+	got := make(map[string][]string)
+	for _, r := range log.Runs[0].Tool.Driver.Rules {
+		for _, rel := range r.Relationships {
+			if rel.Target.ToolComponent.Name != cweTaxonomy {
+				t.Errorf("%s: want taxonomy %q; got %q", r.ID, cweTaxonomy, rel.Target.ToolComponent.Name)
+			}
+			// The rule is a subset of the category it relates to.
+			if !slices.Equal(rel.Kinds, []string{"subset"}) {
+				t.Errorf("%s: want kinds [subset]; got %v", r.ID, rel.Kinds)
+			}
+			got[r.ID] = append(got[r.ID], rel.Target.ID)
+		}
+	}
+	want := map[string][]string{
+		"GO-0000-0001": {"resource-management"},
+		"GO-0000-0002": {"injection", "resource-management"},
+		"GO-0000-0003": {"resource-management"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("relationships mismatch (-want, +got):\n%s", diff)
+	}
+
+	wantTaxonomies := []Taxonomy{{
+		Name:           cweTaxonomy,
+		InformationURI: "https://cwe.mitre.org",
+		Taxa: []Taxon{
+			{ID: "injection", Name: "Injection", Properties: TaxonTags{Tags: []string{"CWE-79"}}},
+			{ID: "resource-management", Name: "Resource Management", Properties: TaxonTags{Tags: []string{"CWE-400", "CWE-770", "CWE-835"}}},
+		},
+	}}
+	if diff := cmp.Diff(wantTaxonomies, log.Runs[0].Taxonomies); diff != "" {
+		t.Errorf("taxonomies mismatch (-want, +got):\n%s", diff)
+	}
+
+	rules := log.Runs[0].Tool.Driver.Rules
+	if diff := cmp.Diff([]string{"CWE-79", "CWE-835"}, rules[1].Properties.Tags); diff != "" {
+		t.Errorf("tags mismatch (-want, +got):\n%s", diff)
+	}
+	if c := rules[0].DefaultConfiguration; c == nil || c.Level != errorLevel {
+		t.Errorf("want default configuration at error level; got %v", c)
+	}
+	if c := rules[1].DefaultConfiguration; c != nil {
+		t.Errorf("want no default configuration without severity; got %v", c)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	if govulncheck.Streaming(NewHandler(nil)) {
+		t.Error("want sarif handler to be non-streaming")
+	}
+}
+
+func TestStackMaxTraceDepth(t *testing.T) {
+	var trace []*govulncheck.Frame
+	for i := 0; i < 50; i++ {
+		trace = append(trace, &govulncheck.Frame{Module: "m", Package: "m/p", Function: fmt.Sprintf("F%d", i)})
+	}
+	h := newTestHandler()
+	h.cfg = &govulncheck.Config{MaxTraceDepth: 10}
+	s := stack(h, &govulncheck.Finding{OSV: "GO-0000-0001", Trace: trace})
+	if len(s.Frames) != 12 {
+		t.Fatalf("want 12 frames; got %d", len(s.Frames))
+	}
+	var got []string
+	for _, i := range []int{0, 1, 2, 11} {
+		got = append(got, s.Frames[i].Location.Message.Text)
+	}
+	want := []string{"m/p.F49", "...", "m/p.F9", "m/p.F0"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want;got+): %s", diff)
+	}
+}
+
+func TestMarkerLocations(t *testing.T) {
+	trace := []*govulncheck.Frame{
+		{Module: "stdlib", Package: "net/http", Function: "V", Position: &govulncheck.Position{Filename: "src/net/http/v.go", Line: 3}},
+		{Function: "[2 stdlib frames]"},
+		{Module: "m", Package: "m/p", Function: "U", Position: &govulncheck.Position{Filename: "p/u.go", Line: 5}},
+		{Function: govulncheck.ElidedFunction},
+		{Module: "m", Package: "m/p", Function: "main", Position: &govulncheck.Position{Filename: "p/main.go", Line: 7}},
+	}
+	f := &govulncheck.Finding{OSV: "GO-0000-0001", Trace: trace}
+	h := newTestHandler()
+	h.cfg = &govulncheck.Config{ScanMode: govulncheck.ScanModeSource}
+
+	var markers []string
+	for _, tf := range threadFlows(h, []*govulncheck.Finding{f}) {
+		for _, l := range tf.Locations {
+			if l.Module == "@" || strings.Contains(l.Location.PhysicalLocation.ArtifactLocation.URI, "@") {
+				t.Errorf("got location %+v for a marker frame", l)
+			}
+			if l.Module == "" {
+				markers = append(markers, l.Location.Message.Text)
+			}
+		}
+	}
+	if diff := cmp.Diff([]string{"[2 stdlib frames]"}, markers); diff != "" {
+		t.Errorf("markers mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The call site of the vulnerable function is unknown.
+	for _, l := range relatedLocations(h, []*govulncheck.Finding{f}) {
+		if strings.HasPrefix(l.Message.Text, "Call to") || strings.Contains(l.PhysicalLocation.ArtifactLocation.URI, "@") {
+			t.Errorf("got related location %+v for a marker frame", l)
+		}
+	}
+}
+
+func TestStackCollapseStdlib(t *testing.T) {
+	trace := []*govulncheck.Frame{
+		{Module: "stdlib", Package: "net/http", Function: "V"},
+		{Module: "stdlib", Package: "net/http", Function: "S1"},
+		{Module: "stdlib", Package: "net/http", Function: "S2"},
+		{Module: "m", Package: "m/p", Function: "U"},
+		{Module: "stdlib", Package: "net/http", Function: "S3"},
+		{Module: "m", Package: "m/p", Function: "main"},
+	}
+	h := newTestHandler()
+	h.cfg = &govulncheck.Config{CollapseStdlib: true}
+	s := stack(h, &govulncheck.Finding{OSV: "GO-0000-0001", Trace: trace})
+	var got []string
+	for _, f := range s.Frames {
+		got = append(got, f.Location.Message.Text)
+	}
+	want := []string{"m/p.main", "net/http.S3", "m/p.U", "[2 stdlib frames]", "net/http.V"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want;got+): %s", diff)
+	}
+}
+
+func TestEmbedOSV(t *testing.T) {
+	entry := &osv.Entry{ID: "GO-0000-0001", Summary: "summary", Details: "details"}
+	for _, embed := range []bool{false, true} {
+		var buf bytes.Buffer
+		h := NewHandler(&buf)
+		if err := h.Config(&govulncheck.Config{EmbedOSV: embed}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.OSV(entry); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(&govulncheck.Finding{OSV: entry.ID, Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		var log Log
+		if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Fatal(err)
+		}
+		var got *osv.Entry
+		if props := log.Runs[0].Results[0].Properties; props != nil {
+			got = props.OSV
+		}
+		want := entry
+		if !embed {
+			want = nil
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("embed=%t: (-want;got+): %s", embed, diff)
+		}
+	}
+}
+
+func TestFixCommits(t *testing.T) {
+	entry := &osv.Entry{
+		ID: "GO-0000-0001",
+		References: []osv.Reference{
+			{Type: osv.ReferenceTypeReport, URL: "https://go.dev/issue/1"},
+			{Type: osv.ReferenceTypeFix, URL: "https://go.dev/cl/1"},
+			{Type: osv.ReferenceTypeWeb, URL: "https://example.com/advisory"},
+			{Type: osv.ReferenceTypeFix, URL: "https://go.googlesource.com/m/+/abcdef"},
+			{Type: osv.ReferenceTypeFix, URL: "https://go.dev/cl/1"},
+		},
+	}
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	if err := h.Config(&govulncheck.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.OSV(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Finding(&govulncheck.Finding{OSV: entry.ID, Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	var log Log
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	if props := log.Runs[0].Results[0].Properties; props != nil {
+		got = props.FixCommits
+	}
+	want := []string{"https://go.dev/cl/1", "https://go.googlesource.com/m/+/abcdef"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want;got+): %s", diff)
+	}
+}
+
+func TestFileHandler(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.sarif")
+	const previous = "previous report"
+	if err := os.WriteFile(path, []byte(previous), 0644); err != nil {
+		t.Fatal(err)
+	}
+	flush := func(path string, entry *osv.Entry) error {
+		h := NewFileHandler(path)
+		if err := h.Config(&govulncheck.Config{EmbedOSV: true}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.OSV(entry); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(&govulncheck.Finding{OSV: entry.ID, Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+			t.Fatal(err)
+		}
+		return h.Flush()
+	}
+	files := func() []string {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	// The embedded entry cannot be serialized, as
+	// times after year 9999 have no JSON encoding.
+	bad := &osv.Entry{ID: "GO-0000-0001", Modified: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := flush(path, bad); err == nil {
+		t.Fatal("want a serialization error")
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != previous {
+		t.Errorf("got %q, %v; want the previous report untouched", got, err)
+	}
+	if diff := cmp.Diff([]string{"out.sarif"}, files()); diff != "" {
+		t.Errorf("files mismatch (-want, +got):\n%s", diff)
+	}
+
+	// A directory in place of the report makes the final
+	// rename fail once the temporary file has been written.
+	blocked := filepath.Join(dir, "blocked.sarif")
+	if err := os.MkdirAll(filepath.Join(blocked, "report"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := flush(blocked, &osv.Entry{ID: "GO-0000-0001"}); err == nil {
+		t.Fatal("want a rename error")
+	}
+	if diff := cmp.Diff([]string{"blocked.sarif", "out.sarif"}, files()); diff != "" {
+		t.Errorf("files mismatch (-want, +got):\n%s", diff)
+	}
+	if err := os.RemoveAll(blocked); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := flush(path, &osv.Entry{ID: "GO-0000-0001"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log Log
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(log.Runs[0].Results); n != 1 {
+		t.Errorf("got %d results; want 1", n)
+	}
+	if diff := cmp.Diff([]string{"out.sarif"}, files()); diff != "" {
+		t.Errorf("files mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestResultConfidence(t *testing.T) {
+	frame := &govulncheck.Frame{Module: "m", Package: "m/p", Function: "F"}
+	for _, tc := range []struct {
+		mode       govulncheck.ScanMode
+		confidence govulncheck.Confidence
+		want       string
+	}{
+		{govulncheck.ScanModeSource, "", ""},
+		{govulncheck.ScanModeBinary, govulncheck.ConfidenceMedium, "medium"},
+	} {
+		var buf bytes.Buffer
+		h := NewHandler(&buf)
+		if err := h.Config(&govulncheck.Config{ScanMode: tc.mode}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.OSV(&osv.Entry{ID: "GO-0000-0001"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Confidence: tc.confidence, Trace: []*govulncheck.Frame{frame}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		var log Log
+		if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if props := log.Runs[0].Results[0].Properties; props != nil {
+			got = props.Confidence
+		}
+		if got != tc.want {
+			t.Errorf("%s: want confidence %q; got %q", tc.mode, tc.want, got)
+		}
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	if err := h.Config(&govulncheck.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.OSV(&osv.Entry{ID: "GO-0000-0001"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+		t.Fatal(err)
+	}
+	n := Notification{Level: "warning", Message: Description{Text: "could not fetch vulnerabilities for example.com/m"}}
+	h.Notify(n)
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	var log Log
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 1 {
+		t.Errorf("want 1 result; got %d", len(run.Results))
+	}
+	want := []Invocation{{ExecutionSuccessful: true, ToolExecutionNotifications: []Notification{n}}}
+	if diff := cmp.Diff(want, run.Invocations); diff != "" {
+		t.Errorf("invocations mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestStaleData(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	fresh, old := now.AddDate(0, 0, -3), now.AddDate(0, 0, -30)
+	for _, tc := range []struct {
+		name     string
+		modified *time.Time
+		want     []Notification
+	}{
+		{"fresh", &fresh, nil},
+		{"stale", &old, []Notification{{
+			Level:   "warning",
+			Message: Description{Text: "The vulnerability data was last modified on 2024-05-02, more than 7 days ago, so recent vulnerabilities may be missed. Consider updating the data."},
+		}}},
+		// The modification times of entries do not tell
+		// how recent the data is, so there is no warning.
+		{"unknown", nil, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewHandler(&buf)
+			h.now = func() time.Time { return now }
+			if err := h.Config(&govulncheck.Config{MaxDataAgeDays: 7, DBLastModified: tc.modified}); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.OSV(&osv.Entry{ID: "GO-0000-0001", Modified: old}); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			var log Log
+			if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+				t.Fatal(err)
+			}
+			var got []Notification
+			if invs := log.Runs[0].Invocations; len(invs) > 0 {
+				got = invs[0].ToolExecutionNotifications
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("notifications mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProgressWarnings(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -30)
+	cfg := &govulncheck.Config{MaxDataAgeDays: 7, DBLastModified: &old}
+	stale := govulncheck.StaleDataWarning(cfg, now)
+
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	h.now = func() time.Time { return now }
+	if err := h.Config(cfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []*govulncheck.Progress{
+		{Message: "Scanning your code and 2 packages across 1 dependent module for known vulnerabilities..."},
+		{Message: stale, Warning: true},
+		{Message: "warning: failed to extract build system specification GOOS:  GOARCH: \n", Warning: true},
+	} {
+		if err := h.Progress(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	var log Log
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	// The stale data warning is reported once, even
+	// though the handler also checks for stale data.
+	want := []Notification{
+		{Level: "warning", Message: Description{Text: stale}},
+		{Level: "warning", Message: Description{Text: "warning: failed to extract build system specification GOOS:  GOARCH:"}},
+	}
+	if diff := cmp.Diff(want, log.Runs[0].Invocations[0].ToolExecutionNotifications); diff != "" {
+		t.Errorf("notifications mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestRelatedLocations(t *testing.T) {
+	vuln := &govulncheck.Frame{Module: "golang.org/x/text", Version: "v0.3.0", Package: "golang.org/x/text/language", Function: "Parse"}
+	caller := func(line int) *govulncheck.Frame {
+		return &govulncheck.Frame{Module: "example.com/main", Package: "main", Function: "main",
+			Position: &govulncheck.Position{Filename: "main.go", Line: line, Column: 3}}
+	}
+	fs := []*govulncheck.Finding{
+		{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{vuln, caller(20)}},
+		{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{vuln, caller(10)}},
+		{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{vuln, caller(10)}}, // duplicate call site
+	}
+	h := newTestHandler()
+	var got []Region
+	for _, l := range relatedLocations(h, fs) {
+		if l.PhysicalLocation.ArtifactLocation.URI != "main.go" {
+			t.Errorf("want main.go; got %s", l.PhysicalLocation.ArtifactLocation.URI)
+		}
+		got = append(got, l.PhysicalLocation.Region)
+	}
+	want := []Region{{StartLine: 10, StartColumn: 3}, {StartLine: 20, StartColumn: 3}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want;got+): %s", diff)
+	}
+
+	h.cfg = &govulncheck.Config{ScanMode: govulncheck.ScanModeBinary}
+	if locs := relatedLocations(h, fs); len(locs) != 0 {
+		t.Errorf("want no related locations in binary mode; got %v", locs)
+	}
+}
+
+func TestRelatedLocationsDefinition(t *testing.T) {
+	vuln := &govulncheck.Frame{Module: "golang.org/x/text", Version: "v0.3.0", Package: "golang.org/x/text/language", Function: "Parse",
+		Position: &govulncheck.Position{Filename: "language/parse.go", Line: 228, Column: 6}}
+	caller := &govulncheck.Frame{Module: "example.com/main", Package: "main", Function: "main",
+		Position: &govulncheck.Position{Filename: "main.go", Line: 10, Column: 3}}
+	fs := []*govulncheck.Finding{{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{vuln, caller}}}
+	got := relatedLocations(newTestHandler(), fs)
+	want := []Location{
+		{
+			PhysicalLocation: PhysicalLocation{
+				ArtifactLocation: ArtifactLocation{URI: "golang.org/x/text@v0.3.0/language/parse.go", URIBaseID: GoModCacheID},
+				Region:           Region{StartLine: 228, StartColumn: 6},
+			},
+			Message: Description{Text: "Vulnerable function definition: golang.org/x/text/language.Parse"},
+		},
+		{
+			PhysicalLocation: PhysicalLocation{
+				ArtifactLocation: ArtifactLocation{URI: "main.go", URIBaseID: SrcRootID},
+				Region:           Region{StartLine: 10, StartColumn: 3},
+			},
+			Message: Description{Text: "Call to vulnerable function golang.org/x/text/language.Parse"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want;got+): %s", diff)
+	}
+}
+
+func TestRelatedLocationsGoMod(t *testing.T) {
+	fs := []*govulncheck.Finding{{
+		OSV:           "GO-0000-0001",
+		GoModLocation: &govulncheck.Position{Filename: "go.mod", Line: 7, Column: 2},
+		Trace:         []*govulncheck.Frame{{Module: "golang.org/x/text", Version: "v0.3.0", Package: "golang.org/x/text/language"}},
+	}}
+	got := relatedLocations(newTestHandler(), fs)
+	want := []Location{{
+		PhysicalLocation: PhysicalLocation{
+			ArtifactLocation: ArtifactLocation{URI: "go.mod", URIBaseID: SrcRootID},
+			Region:           Region{StartLine: 7, StartColumn: 2},
+		},
+		Message: Description{Text: "Requirement of vulnerable module golang.org/x/text"},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want;got+): %s", diff)
+	}
+}
+
+func TestSnippets(t *testing.T) {
+	dir := t.TempDir()
+	src := "package main\n\nfunc main() {\n\tlanguage.Parse(\"en\")\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bin.go"), []byte("package main\x00\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vuln := &govulncheck.Frame{Module: "golang.org/x/text", Version: "v0.3.0", Package: "golang.org/x/text/language", Function: "Parse",
+		Position: &govulncheck.Position{Filename: "language/parse.go", Line: 1, Column: 1}}
+	caller := func(file string, line int) *govulncheck.Frame {
+		return &govulncheck.Frame{Module: "example.com/main", Package: "main", Function: "main",
+			Position: &govulncheck.Position{Filename: file, Line: line, Column: 2}}
+	}
+	snippets := func(h *handler, f *govulncheck.Finding) []string {
+		var got []string
+		for _, fr := range stack(h, f).Frames {
+			text := "<nil>"
+			if s := fr.Location.PhysicalLocation.Region.Snippet; s != nil {
+				text = s.Text
+			}
+			got = append(got, text)
+		}
+		return got
+	}
+
+	h := newTestHandler()
+	h.cfg.SarifURIRoots = map[string]string{SrcRootID: dir}
+	f := &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{vuln, caller("main.go", 4)}}
+	if got := snippets(h, f); got[0] != "<nil>" {
+		t.Errorf("want no snippets unless requested; got %q", got)
+	}
+
+	h.cfg.SarifSnippets = true
+	// Dependency frames have no snippets.
+	if diff := cmp.Diff([]string{"\tlanguage.Parse(\"en\")", "<nil>"}, snippets(h, f)); diff != "" {
+		t.Errorf("(-want;got+): %s", diff)
+	}
+	locs := relatedLocations(h, []*govulncheck.Finding{f})
+	if s := locs[1].PhysicalLocation.Region.Snippet; s == nil || s.Text != "\tlanguage.Parse(\"en\")" {
+		t.Errorf("want snippet of call site in related locations; got %v", s)
+	}
+	for _, fr := range []*govulncheck.Frame{
+		caller("missing.go", 4), // missing file
+		caller("main.go", 10),   // past the end of the file
+		caller("bin.go", 1),     // binary file
+	} {
+		f := &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{vuln, fr}}
+		if got := snippets(h, f); got[0] != "<nil>" {
+			t.Errorf("%s:%d: want no snippet; got %q", fr.Position.Filename, fr.Position.Line, got[0])
+		}
+	}
+}
+
+func TestModuleRoot(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "services", "api")
+	vuln := &govulncheck.Frame{Module: "golang.org/x/text", Version: "v0.3.0", Package: "golang.org/x/text/language", Function: "Parse",
+		Position: &govulncheck.Position{Filename: "language/parse.go", Line: 228, Column: 6}}
+	caller := func(file string) *govulncheck.Frame {
+		return &govulncheck.Frame{Module: "example.com/main", Package: "main", Function: "main",
+			Position: &govulncheck.Position{Filename: file, Line: 10, Column: 3}}
+	}
+	fs := []*govulncheck.Finding{
+		{OSV: "GO-0000-0001", GoModLocation: &govulncheck.Position{Filename: "go.mod", Line: 7, Column: 2}, Trace: []*govulncheck.Frame{vuln, caller("cmd/main.go")}},
+		{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{vuln, caller("../../../outside.go")}},
+	}
+
+	h := newTestHandler()
+	if err := h.Config(&govulncheck.Config{ModuleRoot: root, SarifURIRoots: map[string]string{SrcRootID: dir}}); err != nil {
+		t.Fatal(err)
+	}
+	var got []ArtifactLocation
+	for _, l := range relatedLocations(h, fs) {
+		got = append(got, l.PhysicalLocation.ArtifactLocation)
+	}
+	want := []ArtifactLocation{
+		{URI: "golang.org/x/text@v0.3.0/language/parse.go", URIBaseID: GoModCacheID},
+		{URI: "services/api/cmd/main.go", URIBaseID: SrcRootID},
+		{URI: "../../../outside.go", URIBaseID: SrcRootID}, // outside of the module root
+		{URI: "services/api/go.mod", URIBaseID: SrcRootID},
+	}
+	sortLocations := cmpopts.SortSlices(func(a, b ArtifactLocation) bool { return a.URI < b.URI })
+	if diff := cmp.Diff(want, got, sortLocations); diff != "" {
+		t.Errorf("(-want;got+): %s", diff)
+	}
+	if len(h.notifications) != 1 || !strings.Contains(h.notifications[0].Message.Text, "outside.go") {
+		t.Errorf("want a notification for the file outside of the module root; got %v", h.notifications)
+	}
+
+	// The module directory must be in the module root.
+	h = newTestHandler()
+	if err := h.Config(&govulncheck.Config{ModuleRoot: dir, SarifURIRoots: map[string]string{SrcRootID: root}}); err == nil {
+		t.Error("want error for module directory outside of the module root")
+	}
+}
+
+func TestURIBase(t *testing.T) {
+	vuln := &govulncheck.Frame{Module: "github.com/BurntSushi/toml", Version: "v1.0.0", Package: "github.com/BurntSushi/toml", Function: "Decode",
+		Position: &govulncheck.Position{Filename: "decode.go", Line: 12, Column: 6}}
+	caller := &govulncheck.Frame{Module: "example.com/main", Package: "main", Function: "main",
+		Position: &govulncheck.Position{Filename: "main.go", Line: 10, Column: 3}}
+	fs := []*govulncheck.Finding{{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{vuln, caller}}}
+	uris := func(h *handler) []ArtifactLocation {
+		var als []ArtifactLocation
+		for _, l := range relatedLocations(h, fs) {
+			als = append(als, l.PhysicalLocation.ArtifactLocation)
+		}
+		return als
+	}
+
+	// Paths are relative by default.
+	h := newTestHandler()
+	want := []ArtifactLocation{
+		{URI: "github.com/BurntSushi/toml@v1.0.0/decode.go", URIBaseID: GoModCacheID},
+		{URI: "main.go", URIBaseID: SrcRootID},
+	}
+	if diff := cmp.Diff(want, uris(h)); diff != "" {
+		t.Errorf("relative (-want;got+): %s", diff)
+	}
+	ids := originalURIBaseIDs(h)
+	for _, id := range []string{SrcRootID, GoRootID, GoModCacheID} {
+		if l, ok := ids[id]; !ok || l.Description == nil || l.URI != "" {
+			t.Errorf("want description of %s; got %v", id, l)
+		}
+	}
+
+	h.cfg = &govulncheck.Config{
+		SarifURIBase: govulncheck.SarifURIAbsolute,
+		SarifURIRoots: map[string]string{
+			SrcRootID:    "/home/user/src",
+			GoModCacheID: "/home/user/go/pkg/mod",
+		},
+	}
+	want = []ArtifactLocation{
+		// Module cache paths are escaped.
+		{URI: "file:///home/user/go/pkg/mod/github.com/%21burnt%21sushi/toml@v1.0.0/decode.go"},
+		{URI: "file:///home/user/src/main.go"},
+	}
+	if diff := cmp.Diff(want, uris(h)); diff != "" {
+		t.Errorf("absolute (-want;got+): %s", diff)
+	}
+	if ids := originalURIBaseIDs(h); ids != nil {
+		t.Errorf("want no original URI base IDs for absolute paths; got %v", ids)
+	}
+	// Paths whose base has no known root stay relative.
+	if got, want := h.artifactLocation("net/http/server.go", GoRootID), (ArtifactLocation{URI: "net/http/server.go", URIBaseID: GoRootID}); got != want {
+		t.Errorf("want %v; got %v", want, got)
+	}
+}
+
+func TestResultDirect(t *testing.T) {
+	for _, direct := range []bool{false, true} {
+		var buf bytes.Buffer
+		h := NewHandler(&buf)
+		if err := h.Config(&govulncheck.Config{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.OSV(&osv.Entry{ID: "GO-0000-0001"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Direct: direct, Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		var log Log
+		if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Fatal(err)
+		}
+		props := log.Runs[0].Results[0].Properties
+		if got := props != nil && props.Direct; got != direct {
+			t.Errorf("want direct %t; got %t", direct, got)
+		}
+	}
+}
+
+func TestFindings(t *testing.T) {
+	h := newTestHandler()
+	var c govulncheck.Collector = h
+	call := func(id, fn string) *govulncheck.Finding {
+		return &govulncheck.Finding{OSV: id, Trace: []*govulncheck.Frame{{Module: "m", Package: "m/p", Function: fn}}}
+	}
+	for _, f := range []*govulncheck.Finding{call("GO-0000-0001", "A"), call("GO-0000-0001", "B"), call("GO-0000-0002", "C")} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := c.Findings()
+	want := map[string][]*govulncheck.Finding{
+		"GO-0000-0001": {call("GO-0000-0001", "A"), call("GO-0000-0001", "B")},
+		"GO-0000-0002": {call("GO-0000-0002", "C")},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want;got+): %s", diff)
+	}
+
+	// The result is a copy.
+	got["GO-0000-0001"][0].OSV = "changed"
+	delete(got, "GO-0000-0002")
+	if diff := cmp.Diff(want, c.Findings()); diff != "" {
+		t.Errorf("findings changed through copy (-want;got+): %s", diff)
+	}
+}
+
+func TestConcurrentFindingsDeterministic(t *testing.T) {
+	var findings []*govulncheck.Finding
+	for i := range 20 {
+		vuln := &govulncheck.Frame{Module: "example.com/m", Version: "v1.0.0", Package: fmt.Sprintf("example.com/m/p%d", i%3), Function: fmt.Sprintf("F%d", i)}
+		for j := range 2 {
+			caller := &govulncheck.Frame{Module: "example.com/main", Package: "main", Function: "main",
+				Position: &govulncheck.Position{Filename: "main.go", Line: 10*i + j, Column: 3}}
+			findings = append(findings, &govulncheck.Finding{OSV: fmt.Sprintf("GO-0000-000%d", i%4), Trace: []*govulncheck.Frame{vuln, caller}})
+		}
+	}
+	run := func(concurrent bool) string {
+		var buf bytes.Buffer
+		h := NewHandler(&buf)
+		if !h.Concurrent() {
+			t.Fatal("want a concurrent handler")
+		}
+		if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}); err != nil {
+			t.Fatal(err)
+		}
+		for i := range 4 {
+			if err := h.OSV(&osv.Entry{ID: fmt.Sprintf("GO-0000-000%d", i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if concurrent {
+			var wg sync.WaitGroup
+			for i := len(findings) - 1; i >= 0; i-- {
+				wg.Add(1)
+				go func(f *govulncheck.Finding) {
+					defer wg.Done()
+					if err := h.Finding(f); err != nil {
+						t.Error(err)
+					}
+				}(findings[i])
+			}
+			wg.Wait()
+		} else {
+			for _, f := range findings {
+				if err := h.Finding(f); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if err := h.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	want := run(false)
+	for range 5 {
+		if diff := cmp.Diff(want, run(true)); diff != "" {
+			t.Fatalf("concurrent output mismatch (-sequential, +concurrent):\n%s", diff)
+		}
+	}
+}
+
+// failWriter is an io.Writer failing all writes.
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestFlushWriteError(t *testing.T) {
+	h := NewHandler(failWriter{})
+	if err := h.Config(&govulncheck.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Flush(); err == nil {
+		t.Error("want the error of the writer")
+	}
+}
+
+func TestSplitHandler(t *testing.T) {
+	dir := t.TempDir()
+	h := NewSplitHandler(filepath.Join(dir, "govulncheck-{level}.sarif"))
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"GO-0000-0001", "GO-0000-0002", "GO-0000-0003"} {
+		if err := h.OSV(&osv.Entry{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []*govulncheck.Finding{
+		{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{
+			{Module: "m", Package: "m/p", Function: "F"},
+			{Module: "main", Package: "main", Function: "main", Position: &govulncheck.Position{Filename: "main.go", Line: 3}},
+		}},
+		{OSV: "GO-0000-0002", Trace: []*govulncheck.Frame{{Module: "m", Package: "m/q"}}},
+		{OSV: "GO-0000-0003", Trace: []*govulncheck.Frame{{Module: "m"}}},
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for level, want := range map[string]string{
+		"error":   "GO-0000-0001",
+		"warning": "GO-0000-0002",
+		"note":    "GO-0000-0003",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, "govulncheck-"+level+".sarif"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var log Log
+		if err := json.Unmarshal(data, &log); err != nil {
+			t.Fatalf("%s: %v", level, err)
+		}
+		if log.Version != "2.1.0" || log.Schema == "" || len(log.Runs) != 1 {
+			t.Fatalf("%s: got version %q, schema %q, and %d runs; want a complete log", level, log.Version, log.Schema, len(log.Runs))
+		}
+		run := log.Runs[0]
+		rules := make(map[string]bool)
+		for _, r := range run.Tool.Driver.Rules {
+			rules[r.ID] = true
+		}
+		var got []string
+		for _, r := range run.Results {
+			if r.Level != level {
+				t.Errorf("%s: got result of %s at level %s", level, r.RuleID, r.Level)
+			}
+			if !rules[r.RuleID] {
+				t.Errorf("%s: result of %s has no rule", level, r.RuleID)
+			}
+			got = append(got, r.RuleID)
+		}
+		if diff := cmp.Diff([]string{want}, got); diff != "" {
+			t.Errorf("%s: results mismatch (-want, +got):\n%s", level, diff)
+		}
+	}
+
+	if err := NewSplitHandler(filepath.Join(dir, "out.sarif")).Config(&govulncheck.Config{}); err == nil {
+		t.Error("want an error for a template without a level placeholder")
+	}
+}
+
+func TestSplitHandlerEmptyLevels(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "{level}", "govulncheck.sarif")
+	for _, level := range []string{"error", "warning"} {
+		if err := os.Mkdir(filepath.Join(dir, level), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	flush := func() error {
+		h := NewSplitHandler(template)
+		if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.OSV(&osv.Entry{ID: "GO-0000-0001"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m"}}}); err != nil {
+			t.Fatal(err)
+		}
+		return h.Flush()
+	}
+
+	// The file of the last level cannot be created,
+	// which must leave no file of the other levels behind.
+	if err := flush(); err == nil {
+		t.Fatal("want an error for a missing directory")
+	}
+	for _, level := range []string{"error", "warning"} {
+		entries, err := os.ReadDir(filepath.Join(dir, level))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("%s: got %d files; want none", level, len(entries))
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "note"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := flush(); err != nil {
+		t.Fatal(err)
+	}
+	for _, level := range []string{"error", "warning"} {
+		data, err := os.ReadFile(filepath.Join(dir, level, "govulncheck.sarif"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(data, []byte(`"results": []`)) {
+			t.Errorf("%s: got %s; want empty results", level, data)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sarif
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+)
+
+// Merge combines logs, as produced by separate govulncheck
+// invocations, into a single Log.
+//
+// Runs are merged into a single Run only if they agree on the
+// name and version of the driver, the extensions, the automation
+// details, the original URI base IDs, and the run properties, so
+// that the merged Results are interpreted as in their own Runs.
+// Runs that disagree on any of these are kept separate. The
+// driver properties of a merged Run are those of its first Run.
+// Rules are merged by ID: if two Runs define a rule with the
+// same ID, the rule with more information is kept. Taxonomies
+// are merged by name and their taxa by ID. Results are
+// concatenated, dropping duplicates, and so are notifications
+// of Invocations.
+func Merge(logs ...Log) Log {
+	var runs []*Run
+	byKey := make(map[string]*Run)
+	rules := make(map[*Run]map[string]Rule)
+	seen := make(map[*Run]map[string]bool) // keys of results
+	for _, l := range logs {
+		for _, r := range l.Runs {
+			key := runKey(r)
+			m := byKey[key]
+			if m == nil {
+				m = &Run{Tool: r.Tool, Results: []Result{}, AutomationDetails: r.AutomationDetails, OriginalURIBaseIDs: r.OriginalURIBaseIDs, Properties: r.Properties}
+				m.Tool.Driver.Rules = nil
+				byKey[key] = m
+				runs = append(runs, m)
+				rules[m] = make(map[string]Rule)
+				seen[m] = make(map[string]bool)
+			}
+			for _, rule := range r.Tool.Driver.Rules {
+				if old, ok := rules[m][rule.ID]; !ok || richness(rule) > richness(old) {
+					rules[m][rule.ID] = rule
+				}
+			}
+			for _, res := range r.Results {
+				k := resultKey(res)
+				if seen[m][k] {
+					continue
+				}
+				seen[m][k] = true
+				m.Results = append(m.Results, res)
+			}
+			m.Taxonomies = mergeTaxonomies(m.Taxonomies, r.Taxonomies)
+			m.Invocations = mergeInvocations(m.Invocations, r.Invocations)
+		}
+	}
+
+	merged := Log{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+	}
+	for _, r := range runs {
+		for _, rule := range rules[r] {
+			r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, rule)
+		}
+		// Keep the output deterministic, as for a single scan.
+		sort.SliceStable(r.Tool.Driver.Rules, func(i, j int) bool { return r.Tool.Driver.Rules[i].ID < r.Tool.Driver.Rules[j].ID })
+		sort.SliceStable(r.Results, func(i, j int) bool { return r.Results[i].RuleID < r.Results[j].RuleID })
+		merged.Runs = append(merged.Runs, *r)
+	}
+	return merged
+}
+
+// runKey returns a key identifying the runs that can be merged
+// with r, which are those sharing its tool and the context in
+// which its Results are interpreted.
+func runKey(r Run) string {
+	b, _ := json.Marshal(struct { // runs are always encodable
+		Name, Version      string
+		Extensions         []Extension
+		AutomationDetails  *AutomationDetails
+		OriginalURIBaseIDs map[string]ArtifactLocation
+		Properties         *RunProperties
+	}{r.Tool.Driver.Name, r.Tool.Driver.Version, r.Tool.Extensions, r.AutomationDetails, r.OriginalURIBaseIDs, r.Properties})
+	return string(b)
+}
+
+// mergeTaxonomies merges the taxonomies of two runs by name,
+// keeping the first definition of each taxon. Taxa are sorted
+// by ID, as for a single scan.
+func mergeTaxonomies(txs1, txs2 []Taxonomy) []Taxonomy {
+	var merged []Taxonomy
+	for _, tx := range slices.Concat(txs1, txs2) {
+		i := slices.IndexFunc(merged, func(m Taxonomy) bool { return m.Name == tx.Name })
+		if i < 0 {
+			tx.Taxa = slices.Clone(tx.Taxa)
+			merged = append(merged, tx)
+			continue
+		}
+		for _, t := range tx.Taxa {
+			if !slices.ContainsFunc(merged[i].Taxa, func(m Taxon) bool { return m.ID == t.ID }) {
+				merged[i].Taxa = append(merged[i].Taxa, t)
+			}
+		}
+	}
+	for _, tx := range merged {
+		sort.SliceStable(tx.Taxa, func(i, j int) bool { return tx.Taxa[i].ID < tx.Taxa[j].ID })
+	}
+	return merged
+}
+
+// mergeInvocations merges the invocations of two runs of the
+// same tool into at most one invocation, which is successful
+// only if all invocations are.
+func mergeInvocations(invs1, invs2 []Invocation) []Invocation {
+	if len(invs1) == 0 && len(invs2) == 0 {
+		return nil
+	}
+	inv := Invocation{ExecutionSuccessful: true}
+	for _, i := range slices.Concat(invs1, invs2) {
+		inv.ExecutionSuccessful = inv.ExecutionSuccessful && i.ExecutionSuccessful
+		inv.ToolExecutionNotifications = append(inv.ToolExecutionNotifications, i.ToolExecutionNotifications...)
+	}
+	return []Invocation{inv}
+}
+
+// richness estimates the amount of information in r,
+// used to choose between conflicting definitions of
+// a rule.
+func richness(r Rule) int {
+	n := len(r.Properties.Tags)
+	for _, d := range []Description{r.ShortDescription, r.FullDescription, r.Help} {
+		n += len(d.Text) + len(d.Markdown)
+	}
+	if r.HelpURI != "" {
+		n++
+	}
+	return n
+}
+
+// resultKey returns a key identifying res, so that
+// identical results of different runs are merged.
+func resultKey(res Result) string {
+	b, _ := json.Marshal(res) // results are always encodable
+	return string(b)
+}
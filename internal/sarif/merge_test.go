@@ -0,0 +1,138 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sarif
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMerge(t *testing.T) {
+	driver := func(rules ...Rule) Driver {
+		return Driver{Name: "govulncheck", Version: "v1.0.0", Rules: rules}
+	}
+	result := func(id, uri string) Result {
+		return Result{
+			RuleID:    id,
+			Level:     errorLevel,
+			Locations: []Location{{PhysicalLocation: PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: uri}}}},
+		}
+	}
+	// Both logs report GO-0000-0001, but the rule of the
+	// second log has more information.
+	poor := Rule{ID: "GO-0000-0001", ShortDescription: Description{Text: "[GO-0000-0001]"}}
+	rich := Rule{
+		ID:               "GO-0000-0001",
+		ShortDescription: Description{Text: "[GO-0000-0001] summary"},
+		FullDescription:  Description{Text: "details"},
+		HelpURI:          "https://pkg.go.dev/vuln/GO-0000-0001",
+		Properties:       RuleTags{Tags: []string{"CVE-0000-0001"}},
+	}
+	other := Rule{ID: "GO-0000-0002"}
+	notification := Notification{Level: warningLevel, Message: Description{Text: "could not fetch vulnerabilities"}}
+
+	log1 := Log{Runs: []Run{{
+		Tool: Tool{Driver: driver(poor)},
+		Results: []Result{
+			result("GO-0000-0001", "a/go.mod"),
+		},
+	}}}
+	log2 := Log{Runs: []Run{{
+		Tool: Tool{Driver: driver(other, rich)},
+		Results: []Result{
+			result("GO-0000-0002", "b/go.mod"),
+			result("GO-0000-0001", "b/go.mod"),
+			result("GO-0000-0001", "a/go.mod"), // duplicate of log1
+		},
+		Invocations: []Invocation{{ExecutionSuccessful: true, ToolExecutionNotifications: []Notification{notification}}},
+	}}}
+
+	got := Merge(log1, log2)
+	want := Log{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []Run{{
+			Tool: Tool{Driver: driver(rich, other)},
+			Results: []Result{
+				result("GO-0000-0001", "a/go.mod"),
+				result("GO-0000-0001", "b/go.mod"),
+				result("GO-0000-0002", "b/go.mod"),
+			},
+			Invocations: []Invocation{{ExecutionSuccessful: true, ToolExecutionNotifications: []Notification{notification}}},
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestMergeTools(t *testing.T) {
+	log1 := Log{Runs: []Run{{Tool: Tool{Driver: Driver{Name: "govulncheck", Version: "v1.0.0"}}}}}
+	log2 := Log{Runs: []Run{{Tool: Tool{Driver: Driver{Name: "govulncheck", Version: "v1.1.0"}}}}}
+	got := Merge(log1, log2)
+	if len(got.Runs) != 2 {
+		t.Fatalf("want 2 runs for different tool versions; got %d", len(got.Runs))
+	}
+	for i, v := range []string{"v1.0.0", "v1.1.0"} {
+		if got.Runs[i].Tool.Driver.Version != v {
+			t.Errorf("run %d: want version %s; got %s", i, v, got.Runs[i].Tool.Driver.Version)
+		}
+	}
+}
+
+func TestMergeBaseIDs(t *testing.T) {
+	run := func(root, id string) Run {
+		return Run{
+			Tool:               Tool{Driver: Driver{Name: "govulncheck", Version: "v1.0.0"}},
+			AutomationDetails:  &AutomationDetails{ID: id},
+			OriginalURIBaseIDs: map[string]ArtifactLocation{SrcRootID: {URI: root}},
+			Results:            []Result{{RuleID: "GO-0000-0001"}},
+		}
+	}
+	for _, test := range []struct {
+		name string
+		logs []Log
+		want int
+	}{
+		{"same", []Log{{Runs: []Run{run("file:///a/", "ci/1")}}, {Runs: []Run{run("file:///a/", "ci/1")}}}, 1},
+		{"base IDs", []Log{{Runs: []Run{run("file:///a/", "ci/1")}}, {Runs: []Run{run("file:///b/", "ci/1")}}}, 2},
+		{"automation details", []Log{{Runs: []Run{run("file:///a/", "ci/1")}}, {Runs: []Run{run("file:///a/", "ci/2")}}}, 2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := Merge(test.logs...)
+			if len(got.Runs) != test.want {
+				t.Fatalf("want %d runs; got %d", test.want, len(got.Runs))
+			}
+			for i, r := range got.Runs {
+				want := test.logs[i].Runs[0]
+				if diff := cmp.Diff(want, r); diff != "" {
+					t.Errorf("run %d mismatch (-want, +got):\n%s", i, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeTaxonomies(t *testing.T) {
+	taxonomy := func(ids ...string) []Taxonomy {
+		var taxa []Taxon
+		for _, id := range ids {
+			taxa = append(taxa, Taxon{ID: id})
+		}
+		return []Taxonomy{{Name: "CWE categories", Taxa: taxa}}
+	}
+	tool := Tool{Driver: Driver{Name: "govulncheck", Version: "v1.0.0"}}
+	log1 := Log{Runs: []Run{{Tool: tool, Taxonomies: taxonomy("CWE-1211", "CWE-1218")}}}
+	log2 := Log{Runs: []Run{{Tool: tool, Taxonomies: taxonomy("CWE-1019", "CWE-1211")}}}
+	got := Merge(log1, log2)
+	if len(got.Runs) != 1 {
+		t.Fatalf("want 1 run; got %d", len(got.Runs))
+	}
+	want := taxonomy("CWE-1019", "CWE-1211", "CWE-1218")
+	if diff := cmp.Diff(want, got.Runs[0].Taxonomies); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -20,32 +20,59 @@
 // For instance, if the user specified symbol scan level and govulncheck
 // detected a use of a vulnerable symbol, then the Result will have error
 // Level. If the symbol was not used but its package was imported, then the
-// Result Level is warning, and so on.
+// Result Level is warning, and so on. The Result Rank further combines
+// the precision of the finding with the CVSS score of the OSV, if known,
+// and the weights of its packages, if any, to help clients order Results
+// by risk. Independently of the scan level,
+// the Result Kind is fail for called vulnerable symbols and review for
+// vulnerabilities that are only imported or required.
 //
-// Each Result is attached to the first line of the go.mod file. Other
-// ArtifactLocations are paths relative to their enclosing modules.
-// Similar to JSON output format, this makes govulncheck sarif locations
-// portable.
+// Each Result is attached to the go.mod file: module level Results point
+// to the require directive of their module, if known, and other Results
+// to the first line of the file. Other ArtifactLocations are paths relative
+// to their enclosing modules. Similar to JSON output format, this makes
+// govulncheck sarif locations portable.
 //
 // The relative paths in PhysicalLocations also come with a URIBaseID offset.
 // Paths for the source module analyzed, the Go standard library, and third-party
 // dependencies are relative to %SRCROOT%, %GOROOT%, and %GOMODCACHE% offsets,
-// resp. We note that the URIBaseID offsets are not explicitly defined in
-// the sarif output. It is the clients responsibility to set them to resolve
-// paths at their local machines.
+// resp. The offsets are listed in the OriginalURIBaseIDs of the Run, with a
+// description but no URI. It is the clients responsibility to set them to
+// resolve paths at their local machines. Alternatively, paths are absolute
+// file URIs, with no offset, if govulncheck.Config.SarifURIBase is absolute.
 //
 // All paths use "/" delimiter for portability.
 //
 // Properties field of a Tool.Driver is a govulncheck.Config used for the
 // invocation of govulncheck producing the Results. Properties field of
-// a Rule contains information on CVE and GHSA aliases for the corresponding
-// rule OSV. Clients can use this information to, say, suppress and filter
-// vulnerabilities.
+// the Run states the scan level, "symbol", "package", or "module", which
+// tells whether Results can have call stacks. Properties field of
+// a Rule contains information on CVE and GHSA aliases, CWE IDs, and IDs
+// of related vulnerabilities, for the corresponding rule OSV. Clients can use this information to, say,
+// suppress and filter vulnerabilities. Properties field of a Result, if
+// present, contains additional govulncheck information, such as whether
+// the findings pass through unsafe or cgo code.
+//
+// Rules are related to the categories of the weaknesses of their OSVs,
+// according to the CWE IDs of the OSVs, if any. The categories are the
+// taxa of the "CWE categories" taxonomy of the Run, so that clients can
+// group vulnerabilities by weakness category.
+//
+// Non-fatal problems encountered during the scan are recorded as
+// toolExecutionNotifications of the single Invocation of the Run.
+// If the scan stops because of an error, the Results are those of
+// the findings reported before the error, which is recorded as an
+// error notification of an unsuccessful Invocation.
 //
 // Please see the definition of types below for more information.
 package sarif
 
-import "golang.org/x/vuln/internal/govulncheck"
+import (
+	"time"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
 
 // Log is the top-level SARIF object encoded in UTF-8.
 type Log struct {
@@ -64,14 +91,78 @@ type Log struct {
 // in this case govulncheck.
 type Run struct {
 	Tool Tool `json:"tool,omitempty"`
+	// AutomationDetails identifies the run among related runs.
+	// It is only present if configured.
+	AutomationDetails *AutomationDetails `json:"automationDetails,omitempty"`
+	// OriginalURIBaseIDs describe the URIBaseID offsets of relative
+	// paths in the Results. They are absent if paths are absolute.
+	OriginalURIBaseIDs map[string]ArtifactLocation `json:"originalUriBaseIds,omitempty"`
 	// Results contain govulncheck findings. There should be exactly one
-	// Result per a detected use of an OSV.
-	Results []Result `json:"results,omitempty"`
+	// Result per a detected use of an OSV. An empty list means
+	// that nothing was found, so it is never omitted.
+	Results []Result `json:"results"`
+	// Taxonomies hold the categories of weaknesses, derived from the
+	// CWE IDs of the OSVs, to which Rules are related. There is at most
+	// one taxonomy, named "CWE categories", which is only present if any
+	// Rule belongs to a category.
+	Taxonomies []Taxonomy `json:"taxonomies,omitempty"`
+	// Invocations describe the invocation of govulncheck producing
+	// the Results. They are only present if there are problems to
+	// report, in which case there is exactly one.
+	Invocations []Invocation `json:"invocations,omitempty"`
+	// Properties describe the scan producing the Results.
+	Properties *RunProperties `json:"properties,omitempty"`
+}
+
+// RunProperties is a property bag of a Run.
+type RunProperties struct {
+	// ScanLevel is the level of the scan, "symbol", "package", or
+	// "module", which tells whether Results can have call stacks.
+	ScanLevel string `json:"scanLevel,omitempty"`
+}
+
+// AutomationDetails describes the automation, such
+// as a CI job, of which a run is part.
+type AutomationDetails struct {
+	// ID identifies the run, as in "category/run-id". Runs
+	// of the same category share the part before the last "/".
+	ID string `json:"id,omitempty"`
+}
+
+// Invocation describes a single invocation of a static analysis tool.
+type Invocation struct {
+	// ExecutionSuccessful is true if govulncheck completed the scan,
+	// possibly with non-fatal problems.
+	ExecutionSuccessful bool `json:"executionSuccessful"`
+	// ToolExecutionNotifications are problems encountered during the
+	// scan, such as modules whose vulnerabilities could not be fetched,
+	// and the error stopping the scan, if ExecutionSuccessful is false.
+	ToolExecutionNotifications []Notification `json:"toolExecutionNotifications,omitempty"`
+}
+
+// Notification reports a condition encountered by govulncheck
+// during the scan, rather than a finding.
+type Notification struct {
+	// Level is one of "error", "warning", and "note".
+	Level   string      `json:"level,omitempty"`
+	Message Description `json:"message,omitempty"`
 }
 
 // Tool captures information about govulncheck analysis that was run.
 type Tool struct {
 	Driver Driver `json:"driver,omitempty"`
+	// Extensions record the underlying govulncheck engine when
+	// the Driver name or version are overridden by a tool
+	// embedding govulncheck.
+	Extensions []Extension `json:"extensions,omitempty"`
+}
+
+// Extension provides details about a component of the tool
+// used in the analysis.
+type Extension struct {
+	Name           string `json:"name,omitempty"`
+	Version        string `json:"semanticVersion,omitempty"`
+	InformationURI string `json:"informationUri,omitempty"`
 }
 
 // Driver provides details about the govulncheck binary being executed.
@@ -91,21 +182,93 @@ type Driver struct {
 // Rule corresponds to the static analysis rule/analyzer that
 // produces findings. For govulncheck, rules are OSVs.
 type Rule struct {
-	// ID is OSV.ID
-	ID               string      `json:"id,omitempty"`
+	// ID is OSV.ID, or the alias of the OSV with the prefix of
+	// govulncheck.Config.PreferAliasPrefix, if any.
+	ID string `json:"id,omitempty"`
+	// GUID identifies the Rule across runs. It is derived from
+	// the OSV ID, so it is the same for all runs reporting it.
+	GUID string `json:"guid,omitempty"`
+
 	ShortDescription Description `json:"shortDescription,omitempty"`
 	FullDescription  Description `json:"fullDescription,omitempty"`
 	Help             Description `json:"help,omitempty"`
 	HelpURI          string      `json:"helpUri,omitempty"`
-	// Properties contain OSV.Aliases (CVEs and GHSAs) as tags.
-	// Consumers of govulncheck SARIF can use these tags to filter
-	// results.
+	// DefaultConfiguration holds the level of the rule according to
+	// the CVSS score of the OSV, subject to severity overrides: error
+	// for critical and high, warning for medium, and note otherwise.
+	// It is absent if the OSV has no score.
+	DefaultConfiguration *ReportingConfiguration `json:"defaultConfiguration,omitempty"`
+	// Relationships relate the rule to the categories of the weaknesses
+	// of the OSV, which are taxa of the Run taxonomy.
+	Relationships []Relationship `json:"relationships,omitempty"`
+	// Properties contain OSV.Aliases (CVEs and GHSAs), CWE IDs, and
+	// OSV.Related IDs, prefixed with "related:", as tags. Consumers of
+	// govulncheck SARIF can use these tags to filter results. They also
+	// tell how recent the OSV entry is and whom it credits.
 	Properties RuleTags `json:"properties,omitempty"`
 }
 
-// RuleTags defines properties.tags.
+// ReportingConfiguration is the default configuration of a Rule.
+type ReportingConfiguration struct {
+	// Level is one of "error", "warning", and "note".
+	Level string `json:"level,omitempty"`
+}
+
+// Relationship relates a Rule to a taxon, that is, a weakness category.
+type Relationship struct {
+	Target ReportingDescriptorReference `json:"target"`
+	// Kinds is always "subset": the kind describes the rule
+	// with respect to its target, and the vulnerabilities of
+	// the rule are a subset of those of the category.
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+// ReportingDescriptorReference refers to a taxon of a taxonomy.
+type ReportingDescriptorReference struct {
+	ID            string                 `json:"id,omitempty"`
+	ToolComponent ToolComponentReference `json:"toolComponent,omitempty"`
+}
+
+// ToolComponentReference refers to a taxonomy by name.
+type ToolComponentReference struct {
+	Name string `json:"name,omitempty"`
+}
+
+// Taxonomy is a classification of rules, in this
+// case of vulnerabilities by weakness category.
+type Taxonomy struct {
+	Name           string  `json:"name,omitempty"`
+	InformationURI string  `json:"informationUri,omitempty"`
+	Taxa           []Taxon `json:"taxa,omitempty"`
+}
+
+// Taxon is a weakness category, grouping the CWE IDs of its Properties.
+type Taxon struct {
+	ID         string    `json:"id,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	Properties TaxonTags `json:"properties,omitempty"`
+}
+
+// TaxonTags defines properties.tags of a Taxon,
+// which are the CWE IDs of its category.
+type TaxonTags struct {
+	Tags []string `json:"tags,omitempty"`
+}
+
+// RuleTags defines properties.tags, along with
+// other properties of the OSV entry of a rule.
 type RuleTags struct {
 	Tags []string `json:"tags,omitempty"`
+	// OSVID is the ID of the OSV entry of the Rule, when the Rule
+	// is identified by one of its aliases, see
+	// govulncheck.Config.PreferAliasPrefix.
+	OSVID string `json:"osvId,omitempty"`
+	// Published and Modified are the times, in RFC 3339 format, the
+	// OSV entry was published and last modified, if known.
+	Published string `json:"published,omitempty"`
+	Modified  string `json:"modified,omitempty"`
+	// Credits are the names of the entities credited by the OSV entry.
+	Credits []string `json:"credits,omitempty"`
 }
 
 // Description is a text in its raw or markdown form.
@@ -116,25 +279,105 @@ type Description struct {
 
 // Result is a set of govulncheck findings for an OSV. For call stack
 // mode, it will contain call stacks for the OSV. There is exactly
-// one Result per detected OSV. Only findings at the most precise
+// one Result per detected OSV, unless the config requests results
+// per package or per call stack. Only findings at the most precise
 // detected level appear in the Result. For instance, if there are
 // symbol findings for an OSV, those findings will be in the Result,
 // but not the package and module level findings for the same OSV.
 type Result struct {
 	// RuleID is the Rule.ID/OSV producing the finding.
 	RuleID string `json:"ruleId,omitempty"`
+	// GUID identifies the Result across runs. It is derived from
+	// the OSV ID and the vulnerable symbols, packages, or modules
+	// of the findings, so it only changes along with them.
+	GUID string `json:"guid,omitempty"`
+	// PartialFingerprints identify the Result across runs for
+	// consumers tracking alerts. They are only set for results of
+	// a single call path, when Config.ResultPerStack is set, whose
+	// fingerprint "govulncheck/callPath/v1" is derived from the OSV
+	// ID and the functions of the call path.
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	// Kind is "fail" if a vulnerable symbol is called, and "review"
+	// otherwise, as vulnerabilities that are only required or imported
+	// call for a human assessment rather than fail the check.
+	Kind string `json:"kind,omitempty"`
 	// Level is one of "error", "warning", and "note".
 	Level string `json:"level,omitempty"`
+	// Rank is a value between 0 and 100 describing the priority
+	// of the Result, where higher values indicate higher priority.
+	// It combines the reachability of the vulnerability with its
+	// CVSS score, if available, weighted by the criticality of its
+	// packages given by Config.PackageWeights.
+	Rank float64 `json:"rank,omitempty"`
 	// Message explains the overall findings.
 	Message Description `json:"message,omitempty"`
 	// Locations to which the findings are associated. Always
-	// a single location pointing to the first line of the go.mod
-	// file. The path to the file is "go.mod".
+	// a single location pointing to the go.mod file, either to
+	// the require directive of the module for module level
+	// findings or to the first line. The path to the file is
+	// "go.mod".
 	Locations []Location `json:"locations,omitempty"`
+	// RelatedLocations are the distinct call sites and definitions
+	// of the vulnerable symbols across the call stacks of the Result
+	// and, if known, the require directive of the vulnerable module.
+	RelatedLocations []Location `json:"relatedLocations,omitempty"`
 	// CodeFlows summarize call stacks produced by govulncheck.
 	CodeFlows []CodeFlow `json:"codeFlows,omitempty"`
 	// Stacks encode call stacks produced by govulncheck.
 	Stacks []Stack `json:"stacks,omitempty"`
+	// Properties contains additional information about the Result.
+	Properties *ResultProperties `json:"properties,omitempty"`
+}
+
+// ResultProperties is a property bag of a Result holding
+// govulncheck specific information.
+type ResultProperties struct {
+	// IntroducedVersion is the module version that introduced
+	// the vulnerability, if known.
+	IntroducedVersion string `json:"introducedVersion,omitempty"`
+	// CrossesUnsafe is true if a trace of the Result passes
+	// through package unsafe or cgo generated code, which
+	// makes the vulnerable call of higher risk.
+	CrossesUnsafe bool `json:"crossesUnsafe,omitempty"`
+	// ThroughInit and ThroughReflection are true if a trace of the
+	// Result reaches the vulnerability through a package initializer
+	// or package reflect, see govulncheck.Finding, which warrants
+	// extra scrutiny.
+	ThroughInit       bool `json:"throughInit,omitempty"`
+	ThroughReflection bool `json:"throughReflection,omitempty"`
+	// RecommendedVersion is the version of the module of the Result
+	// that fixes all vulnerabilities of the module reported in the Run.
+	// It is only set when the module has several vulnerabilities with
+	// different fixed versions, and is omitted if no single version of
+	// the module fixes all of them.
+	RecommendedVersion string `json:"recommendedVersion,omitempty"`
+	// Direct is true if the module of the Result is a direct
+	// requirement of the main module.
+	Direct bool `json:"direct,omitempty"`
+	// Confidence is the govulncheck.Confidence of the findings of
+	// the Result. It is only set for binary scans, as source findings
+	// are always of high confidence.
+	Confidence string `json:"confidence,omitempty"`
+	// RemediateBy is the earliest remediation deadline of the findings
+	// of the Result, if any, see govulncheck.Finding.RemediateBy.
+	RemediateBy *time.Time `json:"remediateBy,omitempty"`
+	// Binaries are the paths of the binaries in which the findings
+	// of the Result were detected, when several binaries are scanned.
+	Binaries []string `json:"binaries,omitempty"`
+	// WorkspaceModule is the go.work workspace module from which the
+	// findings of the Result originate, see govulncheck.Finding.
+	// It is omitted if they originate from different modules.
+	WorkspaceModule string `json:"workspaceModule,omitempty"`
+	// FixCommits are the URLs of the commits fixing the vulnerability,
+	// as given by the references of type FIX of the OSV entry, so that
+	// developers can review the patch.
+	FixCommits []string `json:"fixCommits,omitempty"`
+	// OSVID is the ID of the OSV entry of the Result, when its
+	// rule is identified by one of its aliases, see RuleTags.
+	OSVID string `json:"osvId,omitempty"`
+	// OSV is the full OSV entry of the Result. It is only set
+	// when govulncheck.Config.EmbedOSV is true.
+	OSV *osv.Entry `json:"osv,omitempty"`
 }
 
 // CodeFlow summarizes a detected offending flow of information in terms of
@@ -178,10 +421,20 @@ type Frame struct {
 	Location Location `json:"location,omitempty"`
 }
 
-// Location is currently a physical location annotated with a message.
+// Location is a physical location annotated with a message.
 type Location struct {
 	PhysicalLocation PhysicalLocation `json:"physicalLocation,omitempty"`
-	Message          Description      `json:"message,omitempty"`
+	// LogicalLocations are the vulnerable packages the
+	// location stands for, if the result is for a package.
+	LogicalLocations []LogicalLocation `json:"logicalLocations,omitempty"`
+	Message          Description       `json:"message,omitempty"`
+}
+
+// LogicalLocation is a named element of a program, such as a package.
+type LogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	// Kind is "namespace" for packages.
+	Kind string `json:"kind,omitempty"`
 }
 
 type PhysicalLocation struct {
@@ -200,8 +453,11 @@ type ArtifactLocation struct {
 	// URI is a path relative to URIBaseID.
 	URI string `json:"uri,omitempty"`
 	// URIBaseID is offset for URI, one of %SRCROOT%, %GOROOT%,
-	// and %GOMODCACHE%.
+	// and %GOMODCACHE%. It is empty if URI is an absolute file URI.
 	URIBaseID string `json:"uriBaseId,omitempty"`
+	// Description, if any, describes the location. It is
+	// only set for the OriginalURIBaseIDs of a Run.
+	Description *Description `json:"description,omitempty"`
 }
 
 // Region is a target region within a file.
@@ -210,4 +466,12 @@ type Region struct {
 	StartColumn int `json:"startColumn,omitempty"`
 	EndLine     int `json:"endLine,omitempty"`
 	EndColumn   int `json:"endColumn,omitempty"`
+	// Snippet is the source line of the region. It is only set
+	// if govulncheck.Config.SarifSnippets is true.
+	Snippet *ArtifactContent `json:"snippet,omitempty"`
+}
+
+// ArtifactContent is a portion of the content of a file.
+type ArtifactContent struct {
+	Text string `json:"text,omitempty"`
 }
@@ -5,9 +5,15 @@
 package sarif
 
 import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"sort"
 	"strings"
+	"unicode"
 
 	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
 )
 
 func choose(s1, s2 string, cond bool) string {
@@ -17,6 +23,25 @@ func choose(s1, s2 string, cond bool) string {
 	return s2
 }
 
+// maxShortDescriptionLen is the maximum length, in runes, of
+// OSV details used as a short description of a Rule.
+const maxShortDescriptionLen = 100
+
+// truncate returns s if it has at most max runes. Otherwise,
+// it cuts s at the last whitespace that keeps it within max
+// runes, including the appended "...".
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	r = r[:max-len("...")]
+	if i := strings.LastIndexFunc(string(r), unicode.IsSpace); i > 0 {
+		return strings.TrimRightFunc(string(r)[:i], unicode.IsSpace) + "..."
+	}
+	return string(r) + "..."
+}
+
 func list(elems []string) string {
 	l := len(elems)
 	if l == 0 {
@@ -30,6 +55,36 @@ func list(elems []string) string {
 	return cList + choose("", ",", l == 2) + " and " + elems[l-1]
 }
 
+// preferredAlias returns the first alias of e with prefix,
+// or the empty string if there is none.
+func preferredAlias(e *osv.Entry, prefix string) string {
+	for _, a := range e.Aliases {
+		if strings.HasPrefix(a, prefix) {
+			return a
+		}
+	}
+	return ""
+}
+
+// defaultMaxListed is the default number of
+// elements listed in the messages of results.
+const defaultMaxListed = 5
+
+// bounded returns the first max elements of elems, followed by
+// "and N more" for the others, for presentation with list. The
+// default max is defaultMaxListed, and negative max keeps all of
+// the elements.
+func bounded(elems []string, max int) []string {
+	if max == 0 {
+		max = defaultMaxListed
+	}
+	if max < 0 || len(elems) <= max {
+		return elems
+	}
+	res := append([]string{}, elems[:max]...)
+	return append(res, fmt.Sprintf("%d more", len(elems)-max))
+}
+
 // symbol is simplified adaptation of internal/scan/symbol.
 func symbol(fr *govulncheck.Frame) string {
 	if fr.Function == "" {
@@ -44,3 +99,156 @@ func symbol(fr *govulncheck.Frame) string {
 	}
 	return sym
 }
+
+// vulnerableSymbols returns the sorted list of vulnerable symbols of
+// entry, qualified by their package, that belong to packages in pkgs.
+func vulnerableSymbols(entry *osv.Entry, pkgs map[string]bool) []string {
+	if entry == nil {
+		return nil
+	}
+	uniqueSyms := make(map[string]bool)
+	for _, a := range entry.Affected {
+		for _, p := range a.EcosystemSpecific.Packages {
+			if !pkgs[p.Path] {
+				continue
+			}
+			for _, s := range p.Symbols {
+				uniqueSyms[p.Path+"."+s] = true
+			}
+		}
+	}
+	var syms []string
+	for s := range uniqueSyms {
+		syms = append(syms, s)
+	}
+	sort.Strings(syms)
+	return syms
+}
+
+// modulePackages returns the sorted list of packages that entry lists
+// as affected for the modules of findings.
+func modulePackages(entry *osv.Entry, findings []*govulncheck.Finding) []string {
+	if entry == nil {
+		return nil
+	}
+	mods := make(map[string]bool)
+	for _, f := range findings {
+		mods[f.Trace[0].Module] = true
+	}
+	uniquePkgs := make(map[string]bool)
+	for _, a := range entry.Affected {
+		if !mods[a.Module.Path] {
+			continue
+		}
+		for _, p := range a.EcosystemSpecific.Packages {
+			uniquePkgs[p.Path] = true
+		}
+	}
+	var pkgs []string
+	for p := range uniquePkgs {
+		pkgs = append(pkgs, p)
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// urlNamespace is the RFC 4122 namespace of name-based GUIDs
+// whose names are URLs, 6ba7b811-9dad-11d1-80b4-00c04fd430c8.
+var urlNamespace = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// guid returns the RFC 4122 version 5 GUID of name in namespace,
+// along with its raw bytes, which can serve as a namespace in turn.
+func guid(namespace [16]byte, name string) (string, [16]byte) {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+	var u [16]byte
+	copy(u[:], h.Sum(nil))
+	u[6] = u[6]&0x0f | 0x50 // version 5
+	u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), u
+}
+
+// ruleGUID returns the GUID of the Rule of the vulnerability id,
+// in the namespace of URLs as derived from its help URI.
+func ruleGUID(id string) (string, [16]byte) {
+	return guid(urlNamespace, helpURI(id))
+}
+
+// resultGUID returns the GUID of the Result for findings of the
+// vulnerability id, in the namespace of the GUID of its Rule. It
+// is derived from the vulnerable symbols, packages, or modules of
+// findings, so that it is stable across runs reporting them.
+func resultGUID(id string, findings []*govulncheck.Finding) string {
+	_, ns := ruleGUID(id)
+	g, _ := guid(ns, strings.Join(vulnerableElements(findings), "\n"))
+	return g
+}
+
+// packageResultGUID returns the GUID of the Result for the package pkg
+// of module level findings of the vulnerability id, as reported when
+// SarifPackageResults is set.
+func packageResultGUID(id, pkg string) string {
+	_, ns := ruleGUID(id)
+	g, _ := guid(ns, pkg)
+	return g
+}
+
+// stackResultGUID returns the GUID of the Result for the call path
+// of findings of the vulnerability id, as computed by callPath, when
+// ResultPerStack is set.
+func stackResultGUID(id, path string) string {
+	_, ns := ruleGUID(id)
+	g, _ := guid(ns, path)
+	return g
+}
+
+// callPathFingerprint is the key of the partial fingerprint
+// of results for the call paths of vulnerabilities.
+const callPathFingerprint = "govulncheck/callPath/v1"
+
+// callPathHash returns the partial fingerprint of the Result for
+// the call path of findings of the vulnerability id.
+func callPathHash(id, path string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(id+"\n"+path)))
+}
+
+// callPath returns the functions of the trace of f, from the vulnerable
+// symbol to the entry point, one per line. Positions are left out, so
+// that the path only changes if the functions calling the symbol do.
+func callPath(f *govulncheck.Finding) string {
+	var fns []string
+	for _, fr := range f.Trace {
+		fns = append(fns, fr.Module+" "+symbol(fr))
+	}
+	return strings.Join(fns, "\n")
+}
+
+// vulnerableElements returns the sorted distinct vulnerable symbols,
+// packages, or modules of findings, depending on their level.
+func vulnerableElements(findings []*govulncheck.Finding) []string {
+	seen := make(map[string]bool)
+	var elems []string
+	for _, f := range findings {
+		fr := f.Trace[0]
+		e := fr.Module
+		switch {
+		case govulncheck.IsCalled(f):
+			e = symbol(fr)
+		case govulncheck.IsImported(f):
+			e = fr.Package
+		}
+		if !seen[e] {
+			seen[e] = true
+			elems = append(elems, e)
+		}
+	}
+	sort.Strings(elems)
+	return elems
+}
+
+// helpURI returns the URI of the documentation
+// of the vulnerability id on pkg.go.dev.
+func helpURI(id string) string {
+	return fmt.Sprintf("https://pkg.go.dev/vuln/%s", id)
+}
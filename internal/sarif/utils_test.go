@@ -23,3 +23,21 @@ func TestList(t *testing.T) {
 		}
 	}
 }
+
+func TestTruncate(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"", 10, ""},
+		{"short", 10, "short"},
+		{"exactly 10", 10, "exactly 10"},
+		{"a few words too many", 12, "a few..."},
+		{"averyveryverylongword", 10, "averyve..."},
+	} {
+		if got := truncate(tc.s, tc.max); got != tc.want {
+			t.Errorf("truncate(%q, %d): want %q; got %q", tc.s, tc.max, tc.want, got)
+		}
+	}
+}
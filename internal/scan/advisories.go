@@ -0,0 +1,107 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// advisoryHandler is a Handler that records the OSV entries
+// considered by a scan and, on Flush, writes a diagnostic line
+// for each of them to w, telling whether it matched the scanned
+// modules, that is, whether any finding was reported for it.
+type advisoryHandler struct {
+	govulncheck.Handler
+	w       io.Writer
+	osvs    map[string]*osv.Entry
+	matched map[string]bool
+	mu      sync.Mutex // guards matched, as findings may be concurrent
+}
+
+func newAdvisoryHandler(h govulncheck.Handler, w io.Writer) *advisoryHandler {
+	return &advisoryHandler{
+		Handler: h,
+		w:       w,
+		osvs:    make(map[string]*osv.Entry),
+		matched: make(map[string]bool),
+	}
+}
+
+// Streaming reports whether the underlying handler streams.
+func (h *advisoryHandler) Streaming() bool {
+	return govulncheck.Streaming(h.Handler)
+}
+
+// Concurrent reports whether the underlying handler is concurrent.
+func (h *advisoryHandler) Concurrent() bool {
+	return govulncheck.Concurrent(h.Handler)
+}
+
+func (h *advisoryHandler) OSV(e *osv.Entry) error {
+	h.osvs[e.ID] = e
+	return h.Handler.OSV(e)
+}
+
+func (h *advisoryHandler) Finding(f *govulncheck.Finding) error {
+	h.mu.Lock()
+	h.matched[f.OSV] = true
+	h.mu.Unlock()
+	return h.Handler.Finding(f)
+}
+
+// Flush flushes the underlying handler and then writes the considered
+// advisories, sorted by ID. They are written even if flushing fails,
+// as the text output does when vulnerabilities are found, whose error
+// is then returned.
+func (h *advisoryHandler) Flush() error {
+	err := Flush(h.Handler)
+	if werr := h.writeAdvisories(); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// writeAdvisories writes a line for each considered advisory to h.w.
+func (h *advisoryHandler) writeAdvisories() error {
+	ids := make([]string, 0, len(h.osvs))
+	for id := range h.osvs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		status := "not matched"
+		if h.matched[id] {
+			status = "matched"
+		}
+		if _, err := fmt.Fprintf(h.w, "considered advisory %s for %s: %s\n", id, strings.Join(affectedModules(h.osvs[id]), ", "), status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *advisoryHandler) Failure(f *govulncheck.Failure) error {
+	return govulncheck.Fail(h.Handler, f)
+}
+
+// affectedModules returns the distinct paths of
+// the modules affected by e, in order.
+func affectedModules(e *osv.Entry) []string {
+	var mods []string
+	for _, a := range e.Affected {
+		if !slices.Contains(mods, a.Module.Path) {
+			mods = append(mods, a.Module.Path)
+		}
+	}
+	return mods
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+func TestAdvisoryHandler(t *testing.T) {
+	var buf strings.Builder
+	inner := test.NewMockHandler()
+	h := newAdvisoryHandler(inner, &buf)
+	for _, e := range []*osv.Entry{
+		{ID: "GO-0000-0002", Affected: []osv.Affected{{Module: osv.Module{Path: "example.com/b"}}}},
+		{ID: "GO-0000-0001", Affected: []osv.Affected{
+			{Module: osv.Module{Path: "example.com/a"}},
+			{Module: osv.Module{Path: "example.com/a/v2"}},
+			{Module: osv.Module{Path: "example.com/a"}},
+		}},
+	} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "example.com/a"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Flush(h); err != nil {
+		t.Fatal(err)
+	}
+
+	// Considered advisories without findings are listed too.
+	want := `considered advisory GO-0000-0001 for example.com/a, example.com/a/v2: matched
+considered advisory GO-0000-0002 for example.com/b: not matched
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	// The underlying handler still gets everything.
+	if len(inner.OSVMessages) != 2 || len(inner.FindingMessages) != 1 {
+		t.Errorf("want 2 OSVs and 1 finding forwarded; got %d and %d", len(inner.OSVMessages), len(inner.FindingMessages))
+	}
+}
+
+func TestAdvisoryHandlerVulnerabilitiesFound(t *testing.T) {
+	var out, buf strings.Builder
+	h := newAdvisoryHandler(NewTextHandler(&out), &buf)
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelModule}); err != nil {
+		t.Fatal(err)
+	}
+	f := modFinding("GO-0000-0001", "example.com/a", "v1.0.1")
+	if err := h.OSV(&osv.Entry{ID: f.OSV, Affected: []osv.Affected{{Module: osv.Module{Path: "example.com/a"}}}, DatabaseSpecific: &osv.DatabaseSpecific{}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Finding(f); err != nil {
+		t.Fatal(err)
+	}
+	// The advisories are listed even though the
+	// text output fails the scan, whose error is kept.
+	if err := Flush(h); err != errVulnerabilitiesFound {
+		t.Errorf("got %v; want %v", err, errVulnerabilitiesFound)
+	}
+	if want := "considered advisory GO-0000-0001 for example.com/a: matched\n"; buf.String() != want {
+		t.Errorf("got %q; want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/vuln/internal/govulncheck"
+)
+
+// baselineKey identifies the findings of
+// a vulnerability for a module across scans.
+func baselineKey(f *govulncheck.Finding) string {
+	return govulncheck.Identity(f, govulncheck.ScanLevelModule)
+}
+
+// loadBaseline reads the JSON output of a prior scan at path and
+// returns the keys of its findings that had no fixed version.
+func loadBaseline(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading baseline: %w", err)
+	}
+	defer f.Close()
+	h := newCollectHandler()
+	if err := govulncheck.HandleJSON(f, h); err != nil {
+		return nil, fmt.Errorf("loading baseline %s: %w", path, err)
+	}
+	unfixable := make(map[string]bool)
+	for _, f := range h.findings {
+		if len(f.Trace) > 0 && f.FixedVersion == "" {
+			unfixable[baselineKey(f)] = true
+		}
+	}
+	return unfixable, nil
+}
+
+// baselineHandler is a Handler that sets NewlyFixable on findings
+// that have a fixed version while they had none in the baseline.
+type baselineHandler struct {
+	govulncheck.Handler
+	unfixable map[string]bool
+}
+
+func newBaselineHandler(h govulncheck.Handler, unfixable map[string]bool) *baselineHandler {
+	return &baselineHandler{Handler: h, unfixable: unfixable}
+}
+
+// Streaming reports whether the underlying handler streams.
+func (h *baselineHandler) Streaming() bool {
+	return govulncheck.Streaming(h.Handler)
+}
+
+// Concurrent reports whether the underlying handler is concurrent.
+func (h *baselineHandler) Concurrent() bool {
+	return govulncheck.Concurrent(h.Handler)
+}
+
+// Finding sets whether f is newly fixable and forwards it.
+func (h *baselineHandler) Finding(f *govulncheck.Finding) error {
+	if f.FixedVersion != "" && len(f.Trace) > 0 && h.unfixable[baselineKey(f)] {
+		f.NewlyFixable = true
+	}
+	return h.Handler.Finding(f)
+}
+
+func (h *baselineHandler) Failure(f *govulncheck.Failure) error {
+	return govulncheck.Fail(h.Handler, f)
+}
+
+func (h *baselineHandler) Flush() error {
+	return Flush(h.Handler)
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+// writeBaseline writes the JSON output of a scan
+// reporting findings to a file and returns its path.
+func writeBaseline(t *testing.T, findings ...*govulncheck.Finding) string {
+	var buf bytes.Buffer
+	h := govulncheck.NewJSONHandler(&buf)
+	if err := h.Config(&govulncheck.Config{ProtocolVersion: govulncheck.ProtocolVersion}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range findings {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func modFinding(id, mod, fixed string) *govulncheck.Finding {
+	return &govulncheck.Finding{OSV: id, FixedVersion: fixed, Trace: []*govulncheck.Frame{{Module: mod, Version: "v1.0.0"}}}
+}
+
+func TestBaselineHandler(t *testing.T) {
+	path := writeBaseline(t,
+		modFinding("GO-0000-0001", "example.com/a", ""),       // becomes fixable
+		modFinding("GO-0000-0002", "example.com/a", ""),       // stays unfixable
+		modFinding("GO-0000-0003", "example.com/a", "v1.0.1"), // already fixable
+		modFinding("GO-0000-0004", "example.com/b", ""),       // other module
+	)
+	unfixable, err := loadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := test.NewMockHandler()
+	h := newBaselineHandler(inner, unfixable)
+	for _, f := range []*govulncheck.Finding{
+		modFinding("GO-0000-0001", "example.com/a", "v1.0.2"),
+		modFinding("GO-0000-0002", "example.com/a", ""),
+		modFinding("GO-0000-0003", "example.com/a", "v1.0.1"),
+		modFinding("GO-0000-0004", "example.com/c", "v1.0.3"),
+		modFinding("GO-0000-0005", "example.com/a", "v1.0.4"), // new vulnerability
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	for _, f := range inner.FindingMessages {
+		if f.NewlyFixable {
+			got = append(got, f.OSV)
+		}
+	}
+	if diff := cmp.Diff([]string{"GO-0000-0001"}, got); diff != "" {
+		t.Errorf("newly fixable mismatch (-want, +got):\n%s", diff)
+	}
+
+	if _, err := loadBaseline(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("want error loading missing baseline")
+	}
+}
+
+func TestFailOnNewlyFixable(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		newlyFixable bool
+		want         error
+	}{
+		{"unfixable", false, nil},
+		{"newly fixable", true, errVulnerabilitiesFound},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewTextHandler(&buf)
+			cfg := &govulncheck.Config{ScanLevel: govulncheck.ScanLevelModule, Baseline: "baseline.json", FailOnNewlyFixable: true}
+			if err := h.Config(cfg); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.OSV(&osv.Entry{ID: "GO-0000-0001", DatabaseSpecific: &osv.DatabaseSpecific{}}); err != nil {
+				t.Fatal(err)
+			}
+			f := modFinding("GO-0000-0001", "example.com/a", "v1.0.2")
+			f.NewlyFixable = test.newlyFixable
+			if err := h.Finding(f); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Flush(); !errors.Is(err, test.want) {
+				t.Errorf("got %v; want %v", err, test.want)
+			}
+		})
+	}
+}
@@ -8,21 +8,30 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"runtime/debug"
+	"slices"
 
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/vuln/internal/buildinfo"
 	"golang.org/x/vuln/internal/client"
 	"golang.org/x/vuln/internal/derrors"
 	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
 	"golang.org/x/vuln/internal/vulncheck"
 )
 
 // runBinary detects presence of vulnerable symbols in an executable or its minimal blob representation.
+// If the pattern is a directory or a glob rather than a file, all matching binaries are scanned, see
+// runBinaries.
 func runBinary(ctx context.Context, handler govulncheck.Handler, cfg *config, client *client.Client) (err error) {
 	defer derrors.Wrap(&err, "govulncheck")
 
+	if !isFile(cfg.patterns[0]) {
+		return runBinaries(ctx, handler, cfg, client, cfg.patterns[0])
+	}
 	bin, err := createBin(cfg.patterns[0])
 	if err != nil {
 		return err
@@ -35,6 +44,120 @@ func runBinary(ctx context.Context, handler govulncheck.Handler, cfg *config, cl
 	return vulncheck.Binary(ctx, handler, bin, &cfg.Config, client)
 }
 
+// runBinaries scans each binary in the directory or matching the
+// glob pattern, skipping files that are not binaries, and reports
+// the findings of all of them to handler.
+//
+// The findings are reported once all binaries have been scanned,
+// with their Binaries set to the paths of the binaries in which
+// they were detected: identical findings of several binaries are
+// reported only once.
+func runBinaries(ctx context.Context, handler govulncheck.Handler, cfg *config, client *client.Client, pattern string) error {
+	paths, err := binaryPaths(pattern)
+	if err != nil {
+		return err
+	}
+	bh := newBinariesHandler(handler)
+	scanned := 0
+	for _, path := range paths {
+		bin, err := createBin(path)
+		if err != nil {
+			continue // not a binary
+		}
+		scanned++
+		bh.binary = path
+		p := &govulncheck.Progress{Message: fmt.Sprintf(binariesProgressMessage, path)}
+		if err := handler.Progress(p); err != nil {
+			return err
+		}
+		if err := vulncheck.Binary(ctx, bh, bin, &cfg.Config, client); err != nil {
+			return err
+		}
+	}
+	if scanned == 0 {
+		return fmt.Errorf("no binaries found for %q", pattern)
+	}
+	return bh.emit()
+}
+
+// binaryPaths returns the paths of the files in the
+// directory dirOrGlob, or matching the glob dirOrGlob.
+func binaryPaths(dirOrGlob string) ([]string, error) {
+	pattern := dirOrGlob
+	if fi, err := os.Stat(dirOrGlob); err == nil && fi.IsDir() {
+		pattern = filepath.Join(dirOrGlob, "*")
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, m := range matches {
+		if isFile(m) {
+			paths = append(paths, m)
+		}
+	}
+	return paths, nil
+}
+
+// binariesHandler is a Handler that collects the findings
+// of several binary scans, merging identical findings.
+type binariesHandler struct {
+	govulncheck.Handler
+	binary   string // path of the binary being scanned
+	osvs     map[string]bool
+	findings []*govulncheck.Finding
+	byKey    map[string]*govulncheck.Finding
+}
+
+func newBinariesHandler(h govulncheck.Handler) *binariesHandler {
+	return &binariesHandler{
+		Handler: h,
+		osvs:    make(map[string]bool),
+		byKey:   make(map[string]*govulncheck.Finding),
+	}
+}
+
+// OSV forwards e, unless it was already forwarded for a previous binary.
+func (h *binariesHandler) OSV(e *osv.Entry) error {
+	if h.osvs[e.ID] {
+		return nil
+	}
+	h.osvs[e.ID] = true
+	return h.Handler.OSV(e)
+}
+
+// Finding records f as a finding of the binary being
+// scanned, merging it with an identical finding of a
+// previous binary, if any.
+func (h *binariesHandler) Finding(f *govulncheck.Finding) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	key := string(b)
+	if prev := h.byKey[key]; prev != nil {
+		if !slices.Contains(prev.Binaries, h.binary) {
+			prev.Binaries = append(prev.Binaries, h.binary)
+		}
+		return nil
+	}
+	f.Binaries = []string{h.binary}
+	h.byKey[key] = f
+	h.findings = append(h.findings, f)
+	return nil
+}
+
+// emit forwards the findings, in the order they were first recorded.
+func (h *binariesHandler) emit() error {
+	for _, f := range h.findings {
+		if err := h.Handler.Finding(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func createBin(path string) (*vulncheck.Bin, error) {
 	// First check if the path points to a Go binary. Otherwise, blob
 	// parsing might json decode a Go binary which takes time.
@@ -0,0 +1,99 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/internal/client"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+	"golang.org/x/vuln/internal/vulncheck"
+)
+
+// writeBlob writes a blob of a binary depending on mods,
+// as produced by extract mode, to path.
+func writeBlob(t *testing.T, path string, mods ...*packages.Module) {
+	t.Helper()
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	if err := enc.Encode(header{Name: extractModeID, Version: extractModeVersion}); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(vulncheck.Bin{Modules: mods}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunBinaries(t *testing.T) {
+	dir := t.TempDir()
+	text := &packages.Module{Path: "golang.org/x/text", Version: "v0.3.0"}
+	other := &packages.Module{Path: "example.com/other", Version: "v1.0.0"}
+	writeBlob(t, filepath.Join(dir, "app1"), text, other)
+	writeBlob(t, filepath.Join(dir, "app2"), text)
+	// Files that are not binaries are skipped.
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a binary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	affects := func(id, module string) *osv.Entry {
+		return &osv.Entry{ID: id, Affected: []osv.Affected{{
+			Module: osv.Module{Path: module},
+			Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "0"}}}},
+		}}}
+	}
+	c, err := client.NewInMemoryClient([]*osv.Entry{
+		affects("GO-0000-0001", "golang.org/x/text"),
+		affects("GO-0000-0002", "example.com/other"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pattern := range []string{dir, filepath.Join(dir, "app*")} {
+		h := test.NewMockHandler()
+		cfg := &config{patterns: []string{pattern}}
+		cfg.ScanMode = govulncheck.ScanModeBinary
+		cfg.ScanLevel = govulncheck.ScanLevelModule
+		if err := runBinary(context.Background(), h, cfg, c); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, f := range h.FindingMessages {
+			got = append(got, fmt.Sprintf("%s %s %v", f.OSV, f.Trace[0].Module, f.Binaries))
+		}
+		sort.Strings(got)
+		// The findings for golang.org/x/text are merged.
+		want := []string{
+			fmt.Sprintf("GO-0000-0001 golang.org/x/text [%s %s]", filepath.Join(dir, "app1"), filepath.Join(dir, "app2")),
+			fmt.Sprintf("GO-0000-0002 example.com/other [%s]", filepath.Join(dir, "app1")),
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s: findings mismatch (-want, +got):\n%s", pattern, diff)
+		}
+		if len(h.OSVMessages) != 2 {
+			t.Errorf("%s: want 2 OSV entries, each reported once; got %d", pattern, len(h.OSVMessages))
+		}
+	}
+
+	cfg := &config{patterns: []string{filepath.Join(dir, "README*")}}
+	if err := runBinary(context.Background(), test.NewMockHandler(), cfg, c); err == nil {
+		t.Error("want error when no binaries match")
+	}
+}
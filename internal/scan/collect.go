@@ -0,0 +1,93 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/vuln/internal/client"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// Collect runs govulncheck in the environment env with the
+// command line arguments args, as RunGovulncheck does, and
+// returns the findings of the scan, in the order they were
+// reported, along with the OSV entries they refer to, keyed
+// by ID. Flags controlling the output have no effect.
+//
+// It is a convenience for clients that do not need streaming
+// results; others should implement a govulncheck.Handler.
+// Extract and convert modes are not supported.
+func Collect(ctx context.Context, env []string, args ...string) ([]*govulncheck.Finding, map[string]*osv.Entry, error) {
+	cfg := &config{env: env}
+	var stderr strings.Builder
+	if err := parseFlags(cfg, &stderr, args); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, nil, err
+	}
+	switch cfg.ScanMode {
+	case govulncheck.ScanModeExtract, govulncheck.ScanModeConvert:
+		return nil, nil, fmt.Errorf("%s mode is not supported when collecting findings", cfg.ScanMode)
+	}
+
+	client, err := client.NewClient(cfg.db, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating client: %w", err)
+	}
+	prepareConfig(ctx, cfg, client)
+	h := newCollectHandler()
+	if err := runScan(ctx, h, cfg, client, nil, nil); err != nil {
+		return nil, nil, err
+	}
+	return h.findings, h.osvs, nil
+}
+
+// collectHandler is a Handler that keeps
+// findings and OSV entries in memory.
+type collectHandler struct {
+	osvs     map[string]*osv.Entry
+	findings []*govulncheck.Finding
+}
+
+func newCollectHandler() *collectHandler {
+	return &collectHandler{osvs: make(map[string]*osv.Entry)}
+}
+
+func (h *collectHandler) Config(config *govulncheck.Config) error {
+	return nil
+}
+
+func (h *collectHandler) SBOM(sbom *govulncheck.SBOM) error {
+	return nil
+}
+
+func (h *collectHandler) Progress(progress *govulncheck.Progress) error {
+	return nil
+}
+
+func (h *collectHandler) OSV(entry *osv.Entry) error {
+	h.osvs[entry.ID] = entry
+	return nil
+}
+
+func (h *collectHandler) Finding(finding *govulncheck.Finding) error {
+	h.findings = append(h.findings, finding)
+	return nil
+}
+
+// Findings returns the findings collected
+// so far, keyed by OSV ID.
+func (h *collectHandler) Findings() map[string][]*govulncheck.Finding {
+	m := make(map[string][]*govulncheck.Finding)
+	for _, f := range h.findings {
+		m[f.OSV] = append(m[f.OSV], f)
+	}
+	return m
+}
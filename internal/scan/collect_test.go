@@ -0,0 +1,57 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/testenv"
+	"golang.org/x/vuln/internal/web"
+)
+
+func TestCollect(t *testing.T) {
+	testenv.NeedsGoBuild(t)
+
+	testdata, err := filepath.Abs(filepath.Join("..", "..", "cmd", "govulncheck", "testdata", "stdlib"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := web.URLFromFilePath(filepath.Join(testdata, "vulndb-v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "GOVERSION=go1.18")
+	findings, osvs, err := Collect(context.Background(), env,
+		"-C", filepath.Join(testdata, "modules", "stdlib"), "-db", db.String(), "-scan", "package", ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.OSV+" "+f.Trace[0].Package)
+		if osvs[f.OSV] == nil {
+			t.Errorf("missing OSV entry for finding of %s", f.OSV)
+		}
+	}
+	sort.Strings(got)
+	// Findings are reported at module and package level.
+	want := []string{"GO-2022-0969 ", "GO-2022-0969 net/http"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestCollectUnsupportedMode(t *testing.T) {
+	if _, _, err := Collect(context.Background(), nil, "-mode", govulncheck.ScanModeExtract, "binary"); err == nil {
+		t.Error("want error collecting findings in extract mode")
+	}
+}
@@ -6,7 +6,12 @@ package scan
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
+
+	"golang.org/x/vuln/internal/cvss"
+	"golang.org/x/vuln/internal/govulncheck"
 )
 
 //lint:file-ignore ST1005 Ignore staticcheck message about error formatting
@@ -65,3 +70,154 @@ func isGoVersionMismatchError(err error) bool {
 	return strings.Contains(msg, "This application uses version go") &&
 		strings.Contains(msg, "It may fail to process source files")
 }
+
+// exitPolicy decides whether the findings of a scan fail it, and with
+// which exit code, for the outputs reporting vulnerabilities through
+// the exit status: the text output and its templates.
+type exitPolicy struct {
+	scanLevel govulncheck.ScanLevel
+	// failOnNewlyFixable is set if only
+	// vulnerabilities that became fixable
+	// since the baseline scan fail the scan.
+	failOnNewlyFixable bool
+	// failOnCalled is set if only called
+	// vulnerabilities fail the scan.
+	failOnCalled bool
+	// severityOverrides override the CVSS
+	// ratings of vulnerabilities.
+	severityOverrides map[string]string
+	// severityExitCodes are the exit codes of
+	// severity ratings, keyed by upper case rating.
+	severityExitCodes map[string]int
+}
+
+func newExitPolicy(cfg *govulncheck.Config) exitPolicy {
+	p := exitPolicy{
+		scanLevel:          cfg.ScanLevel,
+		failOnNewlyFixable: cfg.FailOnNewlyFixable,
+		failOnCalled:       cfg.FailOnCalled,
+		severityOverrides:  cfg.SeverityOverrides,
+		severityExitCodes:  make(map[string]int),
+	}
+	for r, c := range cfg.SeverityExitCodes {
+		p.severityExitCodes[strings.ToUpper(r)] = c
+	}
+	return p
+}
+
+// err returns the error failing the scan with findings, which
+// must be fixed up, or nil if they do not fail it. Vulnerabilities
+// fail the scan when found at the scan level, unless only those that
+// became fixable since the baseline scan, or only called ones, do.
+func (p *exitPolicy) err(findings []*findingSummary) error {
+	vulns := atScanLevel(p.scanLevel, groupByVuln(findings))
+	if p.failOnNewlyFixable {
+		var fixable [][]*findingSummary
+		for _, findings := range vulns {
+			if isNewlyFixable(findings) {
+				fixable = append(fixable, findings)
+			}
+		}
+		if len(fixable) > 0 {
+			return p.vulnerabilitiesFound(fixable)
+		}
+		return nil
+	}
+	if p.failOnCalled {
+		var called [][]*findingSummary
+		for _, findings := range groupByVuln(findings) {
+			if isCalled(findings) {
+				called = append(called, findings)
+			}
+		}
+		if len(called) > 0 {
+			return p.vulnerabilitiesFound(called)
+		}
+		return nil
+	}
+	// We found vulnerabilities when the findings' level matches the scan level.
+	if (isCalled(findings) && p.scanLevel == govulncheck.ScanLevelSymbol) ||
+		(isImported(findings) && p.scanLevel == govulncheck.ScanLevelPackage) ||
+		(isRequired(findings) && p.scanLevel == govulncheck.ScanLevelModule) {
+		return p.vulnerabilitiesFound(vulns)
+	}
+	return nil
+}
+
+// ratings are the severity ratings, from the highest to the lowest.
+var ratings = []string{cvss.RatingCritical, cvss.RatingHigh, cvss.RatingMedium, cvss.RatingLow, cvss.RatingNone}
+
+// vulnerabilitiesFound returns the error reporting that vulns were
+// found. Its exit code is the one of Config.SeverityExitCodes for the
+// highest severity rating of vulns that is listed, and 3 if none is.
+func (p *exitPolicy) vulnerabilitiesFound(vulns [][]*findingSummary) error {
+	if len(p.severityExitCodes) == 0 {
+		return errVulnerabilitiesFound
+	}
+	found := make(map[string]bool)
+	for _, findings := range vulns {
+		if findings[0].OSV == nil {
+			continue
+		}
+		if score, ok := cvss.OverriddenEntryScore(findings[0].OSV, p.severityOverrides); ok {
+			found[cvss.Rating(score)] = true
+		}
+	}
+	for _, r := range ratings {
+		if code, ok := p.severityExitCodes[r]; ok && found[r] {
+			return &exitCodeError{message: errVulnerabilitiesFound.message, code: code}
+		}
+	}
+	return errVulnerabilitiesFound
+}
+
+// maxExitCode is the highest exit code of severity ratings. Shells
+// report commands that cannot run, or are killed by a signal, with
+// higher exit statuses.
+const maxExitCode = 125
+
+// checkSeverityExitCodes returns an error if an exit code of codes is
+// out of range, or is one that govulncheck exits with for another
+// reason: success, an error, or invalid usage.
+func checkSeverityExitCodes(codes map[string]int) error {
+	reserved := map[int]string{
+		0: "success",
+		1: "an error",
+		2: errUsage.message,
+	}
+	var rs []string
+	for r := range codes {
+		rs = append(rs, r)
+	}
+	sort.Strings(rs)
+	for _, r := range rs {
+		code := codes[r]
+		if code < 0 || code > maxExitCode {
+			return fmt.Errorf("exit code %d of %s is not between 0 and %d", code, r, maxExitCode)
+		}
+		if reason, ok := reserved[code]; ok {
+			return fmt.Errorf("exit code %d of %s is reserved for %s", code, r, reason)
+		}
+	}
+	return nil
+}
+
+// atScanLevel returns the vulnerabilities in vulns that are
+// detected at the precision of level.
+func atScanLevel(level govulncheck.ScanLevel, vulns [][]*findingSummary) [][]*findingSummary {
+	var res [][]*findingSummary
+	for _, findings := range vulns {
+		switch level {
+		case govulncheck.ScanLevelSymbol:
+			if !isCalled(findings) {
+				continue
+			}
+		case govulncheck.ScanLevelPackage:
+			if !isImported(findings) {
+				continue
+			}
+		}
+		res = append(res, findings)
+	}
+	return res
+}
@@ -10,7 +10,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/tools/go/buildutil"
 	"golang.org/x/vuln/internal/govulncheck"
@@ -26,6 +29,21 @@ type config struct {
 	show     ShowFlag
 	format   FormatFlag
 	env      []string
+	// redact are the path prefixes removed from
+	// the positions of findings, if any.
+	redact []string
+	// progressInterval, if positive, is the least interval
+	// between the progress messages passed to the handler.
+	progressInterval time.Duration
+	// sarifOutput and sarifSplit are the file, and the path
+	// template of the files per level, of the sarif output.
+	sarifOutput string
+	sarifSplit  string
+	// sarifMerge instructs govulncheck to merge the
+	// sarif files of patterns instead of scanning.
+	sarifMerge bool
+	// flags are the names of the flags set on the command line.
+	flags []string
 }
 
 func parseFlags(cfg *config, stderr io.Writer, args []string) error {
@@ -33,18 +51,56 @@ func parseFlags(cfg *config, stderr io.Writer, args []string) error {
 	var json bool
 	var scanFlag ScanFlag
 	var modeFlag ModeFlag
+	var uriBase string
 	flags := flag.NewFlagSet("", flag.ContinueOnError)
 	flags.SetOutput(stderr)
 	flags.BoolVar(&json, "json", false, "output JSON (Go compatible legacy flag, see format flag)")
 	flags.BoolVar(&cfg.test, "test", false, "analyze test files (only valid for source mode, default false)")
 	flags.StringVar(&cfg.dir, "C", "", "change to `dir` before running govulncheck")
 	flags.StringVar(&cfg.db, "db", "https://vuln.go.dev", "vulnerability database `url`")
+	flags.StringVar(&cfg.OSVDir, "osv-dir", "", "read vulnerabilities from OSV JSON files in `dir` instead of the database")
 	flags.Var(&modeFlag, "mode", "supports 'source', 'binary', and 'extract' (default 'source')")
 	flags.Var(&cfg.tags, "tags", "comma-separated `list` of build tags")
-	flags.Var(&cfg.show, "show", "enable display of additional information specified by the comma separated `list`\nThe supported values are 'traces','color', 'version', and 'verbose'")
-	flags.Var(&cfg.format, "format", "specify format output\nThe supported values are 'text', 'json', 'sarif', and 'openvex' (default 'text')")
+	flags.Var(&cfg.show, "show", "enable display of additional information specified by the comma separated `list`\nThe supported values are 'traces','color', 'version', 'verbose', and 'advisory'")
+	flags.Var(&cfg.format, "format", "specify format output\nThe supported values are 'text', 'json', 'sarif', 'openvex', 'csv', 'spdx', 'metrics', 'grype', 'github', and 'sqlite=path' (default 'text')")
 	flags.BoolVar(&version, "version", false, "print the version information")
 	flags.Var(&scanFlag, "scan", "set the scanning level desired, one of 'module', 'package', or 'symbol' (default 'symbol')")
+	flags.BoolVar(&cfg.CompactOutput, "compact", false, "write non-streaming output formats, such as sarif, without indentation")
+	flags.BoolVar(&cfg.SortBySeverity, "sort-by-severity", false, "present findings by descending severity in text, json, and csv output")
+	flags.Var(&mapFlag[string]{m: &cfg.SeverityOverrides, parse: parseString}, "severity-overrides", "comma-separated `list` of id=rating pairs overriding the severity of vulnerabilities")
+	flags.StringVar(&cfg.Baseline, "baseline", "", "report newly fixable vulnerabilities since the json output of a prior scan in `file`")
+	flags.StringVar(&cfg.ModuleRoot, "module-root", "", "express file paths relative to `dir`, which contains the module, in sarif and github output")
+	flags.BoolVar(&cfg.FailOnNewlyFixable, "fail-on-newly-fixable", false, "only fail the text output for vulnerabilities that became fixable since the baseline")
+	flags.BoolVar(&cfg.FailOnCalled, "fail-on-called", false, "only fail the text output for vulnerabilities that are called")
+	flags.Var(&mapFlag[int]{m: &cfg.SeverityExitCodes, parse: strconv.Atoi}, "severity-exit-codes", "comma-separated `list` of rating=code pairs setting the exit status of the text output")
+	flags.BoolVar(&cfg.UpgradePlan, "upgrade-plan", false, "present the text output as a plan of module upgrades")
+	flags.StringVar(&cfg.TextTemplate, "template", "", "render each finding of the text output with the Go template `text`")
+	flags.BoolVar(&cfg.ListAdvisories, "list-advisories", false, "list the advisories considered by the scan on standard error")
+	flags.Func("redact", "comma-separated `list` of path prefixes to which the positions of findings are made relative", func(s string) error {
+		for _, p := range strings.Split(s, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				cfg.redact = append(cfg.redact, p)
+			}
+		}
+		return nil
+	})
+	flags.DurationVar(&cfg.progressInterval, "progress-interval", 0, "forward at most one progress message per `duration`, coalescing the others")
+	flags.StringVar(&cfg.WebhookURL, "webhook", "", "post the called findings to `url` at the end of the scan")
+	flags.StringVar(&cfg.sarifOutput, "sarif-output", "", "write the sarif output to `file`, replacing it only once complete")
+	flags.StringVar(&cfg.sarifSplit, "sarif-split", "", "write the sarif results of each level to the files at path `template`, such as 'govulncheck-{level}.sarif'")
+	flags.BoolVar(&cfg.sarifMerge, "sarif-merge", false, "merge the sarif files given as arguments, such as those of several scans, instead of scanning")
+	flags.IntVar(&cfg.MaxSarifBytes, "sarif-max-bytes", 0, "omit the least important sarif results to keep the output within `n` bytes")
+	flags.BoolVar(&cfg.SarifCalledOnly, "sarif-called-only", false, "only report called vulnerabilities in sarif output")
+	flags.BoolVar(&cfg.FailOnImport, "sarif-fail-on-import", false, "report imported vulnerabilities at error level in sarif output")
+	flags.BoolVar(&cfg.ResultPerStack, "sarif-result-per-stack", false, "report each call stack as its own sarif result")
+	flags.IntVar(&cfg.SarifMaxListed, "sarif-max-listed", 0, "list `n` packages or modules in sarif messages, all of them if negative (default 5)")
+	flags.StringVar(&cfg.PreferAliasPrefix, "sarif-alias-prefix", "", "identify sarif rules by the alias of vulnerabilities with `prefix`, such as CVE-")
+	flags.Var(&mapFlag[float64]{m: &cfg.PackageWeights, parse: parseFloat}, "sarif-package-weights", "comma-separated `list` of package=weight pairs ranking sarif results")
+	flags.BoolVar(&cfg.SortStacksByDepth, "sarif-sort-stacks-by-depth", false, "list the shortest call stacks of sarif results first")
+	flags.StringVar(&cfg.SarifAutomationID, "sarif-automation-id", "", "set the automation details `id` of the sarif run")
+	flags.StringVar(&uriBase, "sarif-uri-base", "", "refer to files in sarif output with 'relative' paths or 'absolute' URIs (default 'relative')")
+	flags.BoolVar(&cfg.SarifSnippets, "sarif-snippets", false, "include the source lines of call sites in sarif output")
+	flags.BoolVar(&cfg.SarifPackageResults, "sarif-package-results", false, "report module level sarif results per affected package")
 
 	// We don't want to print the whole usage message on each flags
 	// error, so we set to a no-op and do the printing ourselves.
@@ -75,6 +131,9 @@ Usage:
 	}
 	cfg.ScanLevel = govulncheck.ScanLevel(scanFlag)
 	cfg.ScanMode = govulncheck.ScanMode(modeFlag)
+	cfg.SarifURIBase = govulncheck.SarifURIBase(uriBase)
+	cfg.WebhookAuth, _ = getenv(cfg.env, "GOVULNCHECK_WEBHOOK_AUTH")
+	flags.Visit(func(f *flag.Flag) { cfg.flags = append(cfg.flags, f.Name) })
 	if err := validateConfig(cfg, json); err != nil {
 		fmt.Fprintln(flags.Output(), err)
 		return errUsage
@@ -103,7 +162,42 @@ func validateConfig(cfg *config, json bool) error {
 
 	// show flag is only supported with text output
 	if cfg.format != formatText && len(cfg.show) > 0 {
-		return fmt.Errorf("the -show flag is not supported for %s output", cfg.format)
+		return fmt.Errorf("the -show flag is not supported for %s output", cfg.format.name())
+	}
+	for _, name := range cfg.flags {
+		if strings.HasPrefix(name, "sarif-") && cfg.format != formatSarif {
+			return fmt.Errorf("the -%s flag is only supported for sarif output", name)
+		}
+		if textFlags[name] && cfg.format != formatText {
+			return fmt.Errorf("the -%s flag is only supported for text output", name)
+		}
+	}
+	switch cfg.SarifURIBase {
+	case "", govulncheck.SarifURIRelative, govulncheck.SarifURIAbsolute:
+	default:
+		return fmt.Errorf("the -sarif-uri-base flag must be 'relative' or 'absolute'")
+	}
+	if cfg.sarifOutput != "" && cfg.sarifSplit != "" {
+		return fmt.Errorf("the -sarif-output and -sarif-split flags cannot be used together")
+	}
+	if cfg.sarifMerge {
+		if cfg.ScanMode != govulncheck.ScanModeSource {
+			return fmt.Errorf("the -sarif-merge flag is not supported in %s mode", cfg.ScanMode)
+		}
+		if len(cfg.patterns) == 0 {
+			return fmt.Errorf("the -sarif-merge flag requires sarif files to merge")
+		}
+		if cfg.sarifOutput != "" || cfg.sarifSplit != "" {
+			return fmt.Errorf("the -sarif-merge flag cannot be used with the -sarif-output and -sarif-split flags")
+		}
+		// The arguments are files rather than patterns.
+		return nil
+	}
+	if cfg.FailOnNewlyFixable && cfg.Baseline == "" {
+		return fmt.Errorf("the -fail-on-newly-fixable flag requires the -baseline flag")
+	}
+	if err := checkSeverityExitCodes(cfg.SeverityExitCodes); err != nil {
+		return fmt.Errorf("the -severity-exit-codes flag: %v", err)
 	}
 
 	switch cfg.ScanMode {
@@ -122,10 +216,10 @@ func validateConfig(cfg *config, json bool) error {
 			return fmt.Errorf("the -tags flag is not supported in binary mode")
 		}
 		if len(cfg.patterns) != 1 {
-			return fmt.Errorf("only 1 binary can be analyzed at a time")
+			return fmt.Errorf("only 1 binary, directory, or glob of binaries can be analyzed at a time")
 		}
-		if !isFile(cfg.patterns[0]) {
-			return fmt.Errorf("%q is not a file", cfg.patterns[0])
+		if !isFile(cfg.patterns[0]) && !isDirOrGlob(cfg.patterns[0]) {
+			return fmt.Errorf("%q is not a file, directory, or glob", cfg.patterns[0])
 		}
 	case govulncheck.ScanModeExtract:
 		if cfg.test {
@@ -185,17 +279,72 @@ func isFile(path string) bool {
 	return !s.IsDir()
 }
 
+// isDirOrGlob reports whether path is a directory
+// or a glob pattern matching at least one file.
+func isDirOrGlob(path string) bool {
+	if s, err := os.Stat(path); err == nil {
+		return s.IsDir()
+	}
+	matches, err := filepath.Glob(path)
+	return err == nil && len(matches) > 0
+}
+
+// textFlags are the flags only supported with text output.
+var textFlags = map[string]bool{
+	"fail-on-newly-fixable": true,
+	"fail-on-called":        true,
+	"severity-exit-codes":   true,
+	"upgrade-plan":          true,
+	"template":              true,
+}
+
 var errFlagParse = errors.New("see -help for details")
 
+// mapFlag is used for parsing and validation of the flags setting a
+// map of the config from a comma-separated list of key=value pairs,
+// whose values are parsed by parse.
+type mapFlag[V any] struct {
+	m     *map[string]V
+	parse func(string) (V, error)
+}
+
+func (f *mapFlag[V]) Get() interface{} { return *f.m }
+func (f *mapFlag[V]) Set(s string) error {
+	if s == "" {
+		return nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || k == "" {
+			return errFlagParse
+		}
+		val, err := f.parse(v)
+		if err != nil {
+			return errFlagParse
+		}
+		if *f.m == nil {
+			*f.m = make(map[string]V)
+		}
+		(*f.m)[k] = val
+	}
+	return nil
+}
+func (f *mapFlag[V]) String() string { return "" }
+
+func parseString(s string) (string, error) { return s, nil }
+
+func parseFloat(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+
 // ShowFlag is used for parsing and validation of
 // govulncheck -show flag.
 type ShowFlag []string
 
 var supportedShows = map[string]bool{
-	"traces":  true,
-	"color":   true,
-	"verbose": true,
-	"version": true,
+	"traces":   true,
+	"color":    true,
+	"verbose":  true,
+	"version":  true,
+	"advisory": true,
 }
 
 func (v *ShowFlag) Set(s string) error {
@@ -227,6 +376,8 @@ func (v ShowFlag) Update(h *TextHandler) {
 			h.showVersion = true
 		case "verbose":
 			h.showVerbose = true
+		case "advisory":
+			h.showAdvisory = true
 		}
 	}
 }
@@ -241,6 +392,12 @@ const (
 	formatText    = "text"
 	formatSarif   = "sarif"
 	formatOpenVEX = "openvex"
+	formatCSV     = "csv"
+	formatSPDX    = "spdx"
+	formatMetrics = "metrics"
+	formatGrype   = "grype"
+	formatGitHub  = "github"
+	formatSQLite  = "sqlite"
 )
 
 var supportedFormats = map[string]bool{
@@ -248,16 +405,39 @@ var supportedFormats = map[string]bool{
 	formatText:    true,
 	formatSarif:   true,
 	formatOpenVEX: true,
+	formatCSV:     true,
+	formatSPDX:    true,
+	formatMetrics: true,
+	formatGrype:   true,
+	formatGitHub:  true,
 }
 
 func (f *FormatFlag) Get() interface{} { return *f }
 func (f *FormatFlag) Set(s string) error {
+	if path, ok := strings.CutPrefix(s, formatSQLite+"="); ok && path != "" {
+		*f = FormatFlag(s)
+		return nil
+	}
 	if _, ok := supportedFormats[s]; !ok {
 		return errFlagParse
 	}
 	*f = FormatFlag(s)
 	return nil
 }
+
+// name returns the name of the format, without the
+// path of the database of the sqlite format.
+func (f FormatFlag) name() string {
+	name, _, _ := strings.Cut(string(f), "=")
+	return name
+}
+
+// sqlitePath returns the path of the
+// database of the sqlite format, if any.
+func (f FormatFlag) sqlitePath() string {
+	_, path, _ := strings.Cut(string(f), "=")
+	return path
+}
 func (f *FormatFlag) String() string { return "" }
 
 // ModeFlag is used for parsing and validation of
@@ -0,0 +1,62 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+)
+
+func TestParseFlags(t *testing.T) {
+	cfg := &config{env: []string{"GOVULNCHECK_WEBHOOK_AUTH=Bearer token"}}
+	args := []string{
+		"-format", "sarif",
+		"-sarif-package-weights", "example.com/pay=3, example.com/docs=0.5",
+		"-severity-overrides", "GO-2021-0001=high",
+		"-sarif-uri-base", "absolute",
+		"-sarif-max-listed", "-1",
+		"-webhook", "https://example.com/hook",
+		"./...",
+	}
+	if err := parseFlags(cfg, io.Discard, args); err != nil {
+		t.Fatal(err)
+	}
+	want := govulncheck.Config{
+		ScanMode:          govulncheck.ScanModeSource,
+		ScanLevel:         govulncheck.ScanLevelSymbol,
+		PackageWeights:    map[string]float64{"example.com/pay": 3, "example.com/docs": 0.5},
+		SeverityOverrides: map[string]string{"GO-2021-0001": "high"},
+		SarifURIBase:      govulncheck.SarifURIAbsolute,
+		SarifMaxListed:    -1,
+		WebhookURL:        "https://example.com/hook",
+		WebhookAuth:       "Bearer token",
+	}
+	if diff := cmp.Diff(want, cfg.Config); diff != "" {
+		t.Errorf("config mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestParseFlagsErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"-sarif-snippets"},
+		{"-format", "json", "-upgrade-plan"},
+		{"-format", "sarif", "-sarif-uri-base", "home"},
+		{"-format", "sarif", "-sarif-package-weights", "example.com/pay"},
+		{"-format", "sarif", "-sarif-package-weights", "example.com/pay=high"},
+		{"-severity-exit-codes", "CRITICAL=four"},
+		{"-severity-exit-codes", "CRITICAL=126"},
+		{"-severity-exit-codes", "HIGH=-1"},
+		{"-severity-exit-codes", "CRITICAL=4,HIGH=2"},
+		{"-severity-exit-codes", "LOW=0"},
+		{"-fail-on-newly-fixable"},
+	} {
+		if err := parseFlags(&config{}, io.Discard, args); err == nil {
+			t.Errorf("%q: want an error", args)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/mod/module"
+	sv "golang.org/x/mod/semver"
+	"golang.org/x/vuln/internal"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/semver"
+)
+
+// latestFunc returns the latest available version of the module at path.
+type latestFunc func(ctx context.Context, path string) (string, error)
+
+// maxLookups bounds the number of concurrent lookups.
+const maxLookups = 8
+
+// latestHandler is a Handler that sets the LatestVersion of findings
+// from the versions returned by latest, following
+// Config.IncludeLatestVersion. Versions are looked up once per module,
+// in the background as soon as OSV entries name the module, so that
+// they are usually known by the time findings need them.
+type latestHandler struct {
+	govulncheck.Handler
+	ctx    context.Context
+	cancel context.CancelFunc
+	latest latestFunc
+	// lookups holds the lookups of the latest versions
+	// of modules, whose versions are empty for modules
+	// whose lookup failed.
+	lookups map[string]*lookup
+	mu      sync.Mutex    // guards lookups, as findings may be concurrent
+	sem     chan struct{} // bounds concurrent lookups
+}
+
+// lookup is the lookup of the latest version of a module.
+type lookup struct {
+	done    chan struct{} // closed once version is set
+	version string
+}
+
+func newLatestHandler(ctx context.Context, h govulncheck.Handler, latest latestFunc) *latestHandler {
+	ctx, cancel := context.WithCancel(ctx)
+	return &latestHandler{
+		Handler: h,
+		ctx:     ctx,
+		cancel:  cancel,
+		latest:  latest,
+		lookups: make(map[string]*lookup),
+		sem:     make(chan struct{}, maxLookups),
+	}
+}
+
+// Streaming reports whether the underlying handler streams.
+func (h *latestHandler) Streaming() bool {
+	return govulncheck.Streaming(h.Handler)
+}
+
+// Concurrent reports whether the underlying handler is concurrent.
+func (h *latestHandler) Concurrent() bool {
+	return govulncheck.Concurrent(h.Handler)
+}
+
+// OSV starts looking up the latest versions of the
+// modules affected by e, and forwards e.
+func (h *latestHandler) OSV(e *osv.Entry) error {
+	for _, a := range e.Affected {
+		h.lookup(a.Module.Path)
+	}
+	return h.Handler.OSV(e)
+}
+
+// Finding sets the latest version of the vulnerable module
+// of f, if known and newer than the version in use, and
+// forwards f. The standard library has no latest version.
+func (h *latestHandler) Finding(f *govulncheck.Finding) error {
+	if len(f.Trace) > 0 {
+		fr := f.Trace[0]
+		if v := h.version(fr.Module); v != "" && (fr.Version == "" || semver.Less(fr.Version, v)) {
+			f.LatestVersion = v
+		}
+	}
+	return h.Handler.Finding(f)
+}
+
+// lookup returns the lookup of the latest version of the module at
+// path, starting it if needed, or nil if the module has no latest
+// version.
+func (h *latestHandler) lookup(path string) *lookup {
+	if path == "" || path == internal.GoStdModulePath || path == internal.GoCmdModulePath || path == internal.UnknownModulePath {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	l, ok := h.lookups[path]
+	if !ok {
+		l = &lookup{done: make(chan struct{})}
+		h.lookups[path] = l
+		go func() {
+			defer close(l.done)
+			h.sem <- struct{}{}
+			defer func() { <-h.sem }()
+			l.version, _ = h.latest(h.ctx, path)
+		}()
+	}
+	return l
+}
+
+// version returns the latest version of the module at path,
+// or the empty string if it cannot be looked up.
+func (h *latestHandler) version(path string) string {
+	l := h.lookup(path)
+	if l == nil {
+		return ""
+	}
+	<-l.done
+	return l.version
+}
+
+func (h *latestHandler) Failure(f *govulncheck.Failure) error {
+	return govulncheck.Fail(h.Handler, f)
+}
+
+// Flush flushes the underlying handler and
+// cancels the lookups still in progress.
+func (h *latestHandler) Flush() error {
+	defer h.cancel()
+	return Flush(h.Handler)
+}
+
+// proxyTimeout bounds the time spent looking
+// up the latest version of a module.
+const proxyTimeout = 10 * time.Second
+
+// proxyEnv returns env completed with the values of GOPROXY,
+// GONOPROXY, and GOPRIVATE according to the go command, which
+// accounts for their defaults and for the settings of 'go env -w'.
+// It returns env itself if the go command fails.
+func proxyEnv(env []string) []string {
+	cmd := exec.Command("go", "env", "-json", "GOPROXY", "GONOPROXY", "GOPRIVATE")
+	cmd.Env = env
+	out, err := cmd.Output()
+	if err != nil {
+		return env
+	}
+	var vars map[string]string
+	if err := json.Unmarshal(out, &vars); err != nil {
+		return env
+	}
+	res := slices.Clip(env)
+	for _, k := range []string{"GOPROXY", "GONOPROXY", "GOPRIVATE"} {
+		res = append(res, k+"="+vars[k])
+	}
+	return res
+}
+
+// getenv returns the value of the last
+// setting of key in env, if any.
+func getenv(env []string, key string) (string, bool) {
+	var val string
+	found := false
+	for _, kv := range env {
+		if v, ok := strings.CutPrefix(kv, key+"="); ok {
+			val, found = v, true
+		}
+	}
+	return val, found
+}
+
+// proxyLatest returns a latestFunc querying the @latest endpoint of
+// the first module proxy of GOPROXY in env, or nil if there is none,
+// as when the proxy is "off" or "direct". Proxies may be file URLs,
+// whose version lists are read instead. Modules matching GONOPROXY,
+// which defaults to GOPRIVATE, are not looked up, like the go command
+// does, so that their paths are not disclosed to the proxy.
+func proxyLatest(env []string) latestFunc {
+	proxy, ok := getenv(env, "GOPROXY")
+	if !ok {
+		proxy = "https://proxy.golang.org"
+	}
+	proxy, _, _ = strings.Cut(proxy, ",")
+	proxy, _, _ = strings.Cut(proxy, "|")
+	if proxy == "" || proxy == "off" || proxy == "direct" {
+		return nil
+	}
+	noproxy, ok := getenv(env, "GONOPROXY")
+	if !ok {
+		noproxy, _ = getenv(env, "GOPRIVATE")
+	}
+	var lookup latestFunc
+	if dir, ok := strings.CutPrefix(proxy, "file://"); ok {
+		lookup = fileLatest(filepath.FromSlash(dir))
+	} else {
+		lookup = httpLatest(proxy)
+	}
+	return func(ctx context.Context, path string) (string, error) {
+		if module.MatchPrefixPatterns(noproxy, path) {
+			return "", fmt.Errorf("looking up the latest version of %s: not using the proxy for private modules", path)
+		}
+		return lookup(ctx, path)
+	}
+}
+
+// httpLatest returns a latestFunc querying the
+// @latest endpoint of the module proxy at url.
+func httpLatest(proxy string) latestFunc {
+	client := &http.Client{Timeout: proxyTimeout}
+	return func(ctx context.Context, path string) (string, error) {
+		escaped, err := module.EscapePath(path)
+		if err != nil {
+			return "", err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(proxy, "/")+"/"+escaped+"/@latest", nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("looking up the latest version of %s: %s", path, resp.Status)
+		}
+		var info struct{ Version string }
+		if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+			return "", err
+		}
+		return info.Version, nil
+	}
+}
+
+// fileLatest returns a latestFunc reading the version lists of the
+// module proxy in dir, as served for file:// URLs. The latest version
+// is the highest release version, or the highest version if there are
+// only pre-releases.
+func fileLatest(dir string) latestFunc {
+	return func(ctx context.Context, path string) (string, error) {
+		escaped, err := module.EscapePath(path)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(escaped), "@v", "list"))
+		if err != nil {
+			return "", err
+		}
+		var latest, latestRelease string
+		for _, v := range strings.Fields(string(data)) {
+			if !sv.IsValid(v) {
+				continue
+			}
+			if latest == "" || sv.Compare(latest, v) < 0 {
+				latest = v
+			}
+			if sv.Prerelease(v) == "" && (latestRelease == "" || sv.Compare(latestRelease, v) < 0) {
+				latestRelease = v
+			}
+		}
+		if latestRelease != "" {
+			return latestRelease, nil
+		}
+		if latest == "" {
+			return "", fmt.Errorf("looking up the latest version of %s: no versions", path)
+		}
+		return latest, nil
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+func TestLatestHandler(t *testing.T) {
+	latest := map[string]string{
+		"example.com/a": "v1.8.0",
+		"example.com/b": "v1.0.0", // same as the version in use
+	}
+	var mu sync.Mutex
+	lookups := make(map[string]int)
+	stub := func(ctx context.Context, path string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		lookups[path]++
+		if v, ok := latest[path]; ok {
+			return v, nil
+		}
+		return "", errors.New("not found")
+	}
+	inner := test.NewMockHandler()
+	h := newLatestHandler(context.Background(), inner, stub)
+	// Entries start the lookups of their modules.
+	if err := h.OSV(&osv.Entry{ID: "GO-0000-0001", Affected: []osv.Affected{{Module: osv.Module{Path: "example.com/a"}}}}); err != nil {
+		t.Fatal(err)
+	}
+	<-h.lookups["example.com/a"].done
+	for _, f := range []*govulncheck.Finding{
+		{OSV: "GO-0000-0001", FixedVersion: "v1.5.3", Trace: []*govulncheck.Frame{{Module: "example.com/a", Version: "v1.0.0"}}},
+		{OSV: "GO-0000-0002", Trace: []*govulncheck.Frame{{Module: "example.com/a", Version: "v1.0.0", Package: "example.com/a/p"}}},
+		{OSV: "GO-0000-0003", Trace: []*govulncheck.Frame{{Module: "example.com/b", Version: "v1.0.0"}}},
+		{OSV: "GO-0000-0004", Trace: []*govulncheck.Frame{{Module: "example.com/c", Version: "v1.0.0"}}},
+		{OSV: "GO-0000-0005", Trace: []*govulncheck.Frame{{Module: "example.com/c", Version: "v1.0.0"}}},
+		{OSV: "GO-0000-0006", Trace: []*govulncheck.Frame{{Module: "stdlib", Version: "v1.21.0"}}},
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := make(map[string]string)
+	for _, f := range inner.FindingMessages {
+		got[f.OSV] = f.LatestVersion
+	}
+	want := map[string]string{
+		"GO-0000-0001": "v1.8.0",
+		"GO-0000-0002": "v1.8.0",
+		"GO-0000-0003": "",
+		"GO-0000-0004": "",
+		"GO-0000-0005": "",
+		"GO-0000-0006": "",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("latest versions mismatch (-want, +got):\n%s", diff)
+	}
+	// Modules are looked up once, even when the lookup fails,
+	// and the standard library is not looked up.
+	if diff := cmp.Diff(map[string]int{"example.com/a": 1, "example.com/b": 1, "example.com/c": 1}, lookups); diff != "" {
+		t.Errorf("lookups mismatch (-want, +got):\n%s", diff)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProxyLatest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/example.com/!upper/@latest" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"Version":"v1.8.0","Time":"2024-01-01T00:00:00Z"}`))
+	}))
+	defer srv.Close()
+
+	latest := proxyLatest([]string{"GOPROXY=" + srv.URL + ",direct"})
+	if latest == nil {
+		t.Fatal("want a lookup of the proxy")
+	}
+	if v, err := latest(context.Background(), "example.com/Upper"); err != nil || v != "v1.8.0" {
+		t.Errorf("got %q, %v; want v1.8.0", v, err)
+	}
+	if _, err := latest(context.Background(), "example.com/missing"); err == nil {
+		t.Error("want an error for a missing module")
+	}
+	// Private modules are not looked up, so that
+	// their paths are not disclosed to the proxy.
+	for _, env := range [][]string{
+		{"GOPRIVATE=example.com/Upper"},
+		{"GOPRIVATE=other.com", "GONOPROXY=example.com"},
+	} {
+		latest := proxyLatest(append(env, "GOPROXY="+srv.URL))
+		if v, err := latest(context.Background(), "example.com/Upper"); err == nil {
+			t.Errorf("%v: got %q; want no lookup", env, v)
+		}
+	}
+	for _, proxy := range []string{"off", "direct"} {
+		if proxyLatest([]string{"GOPROXY=" + proxy}) != nil {
+			t.Errorf("GOPROXY=%s: want no lookup", proxy)
+		}
+	}
+}
+
+func TestFileProxyLatest(t *testing.T) {
+	dir := t.TempDir()
+	for path, list := range map[string]string{
+		"example.com/!upper": "v1.0.0\nv1.10.0\nv1.9.0\nv2.0.0-pre\n",
+		"example.com/pre":    "v0.1.0-pre\nv0.2.0-pre\n",
+	} {
+		vdir := filepath.Join(dir, filepath.FromSlash(path), "@v")
+		if err := os.MkdirAll(vdir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(vdir, "list"), []byte(list), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	latest := proxyLatest([]string{"GOPROXY=file://" + filepath.ToSlash(dir)})
+	for path, want := range map[string]string{
+		"example.com/Upper": "v1.10.0",
+		"example.com/pre":   "v0.2.0-pre",
+	} {
+		if v, err := latest(context.Background(), path); err != nil || v != want {
+			t.Errorf("%s: got %q, %v; want %s", path, v, err, want)
+		}
+	}
+	if _, err := latest(context.Background(), "example.com/missing"); err == nil {
+		t.Error("want an error for a missing module")
+	}
+}
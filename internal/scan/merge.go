@@ -0,0 +1,44 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/vuln/internal/sarif"
+)
+
+// runSarifMerge writes to out the merge of the sarif logs
+// in the files at cfg.patterns, see sarif.Merge.
+func runSarifMerge(cfg *config, out io.Writer) error {
+	var logs []sarif.Log
+	for _, path := range cfg.patterns {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var l sarif.Log
+		if err := json.Unmarshal(data, &l); err != nil {
+			return fmt.Errorf("reading sarif log %s: %w", path, err)
+		}
+		logs = append(logs, l)
+	}
+	merged := sarif.Merge(logs...)
+	var data []byte
+	var err error
+	if cfg.CompactOutput {
+		data, err = json.Marshal(merged)
+	} else {
+		data, err = json.MarshalIndent(merged, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
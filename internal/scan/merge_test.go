@@ -0,0 +1,54 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/vuln/internal/sarif"
+)
+
+func TestSarifMerge(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, id := range []string{"GO-0000-0001", "GO-0000-0002"} {
+		l := sarif.Log{Runs: []sarif.Run{{
+			Tool:    sarif.Tool{Driver: sarif.Driver{Name: "govulncheck", Rules: []sarif.Rule{{ID: id}}}},
+			Results: []sarif.Result{{RuleID: id, Level: "error"}},
+		}}}
+		data, err := json.Marshal(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file := filepath.Join(dir, id+".sarif")
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	var stdout bytes.Buffer
+	args := append([]string{"-format", "sarif", "-sarif-merge"}, files...)
+	if err := RunGovulncheck(context.Background(), nil, nil, &stdout, io.Discard, args); err != nil {
+		t.Fatal(err)
+	}
+	var got sarif.Log
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Runs) != 1 || len(got.Runs[0].Results) != 2 || len(got.Runs[0].Tool.Driver.Rules) != 2 {
+		t.Errorf("got %+v; want a single run with both results and rules", got.Runs)
+	}
+
+	if err := RunGovulncheck(context.Background(), nil, nil, io.Discard, io.Discard, []string{"-format", "sarif", "-sarif-merge"}); err == nil {
+		t.Error("want an error without files to merge")
+	}
+}
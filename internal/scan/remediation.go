@@ -0,0 +1,97 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/vuln/internal/cvss"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// remediationHandler is a Handler that sets the RemediateBy deadline
+// of findings from the severity of their vulnerabilities, following
+// Config.RemediationDays.
+type remediationHandler struct {
+	govulncheck.Handler
+	// now returns the current time, which is
+	// the time of the scan when configured.
+	now       func() time.Time
+	scanTime  time.Time
+	days      map[string]int // keyed by upper case rating
+	overrides map[string]string
+	osvs      map[string]*osv.Entry
+}
+
+func newRemediationHandler(h govulncheck.Handler, now func() time.Time) *remediationHandler {
+	return &remediationHandler{
+		Handler: h,
+		now:     now,
+		osvs:    make(map[string]*osv.Entry),
+	}
+}
+
+// Streaming reports whether the underlying handler streams.
+func (h *remediationHandler) Streaming() bool {
+	return govulncheck.Streaming(h.Handler)
+}
+
+// Concurrent reports whether the underlying handler is concurrent.
+func (h *remediationHandler) Concurrent() bool {
+	return govulncheck.Concurrent(h.Handler)
+}
+
+// Config records the remediation policy of config
+// and the time of the scan.
+func (h *remediationHandler) Config(config *govulncheck.Config) error {
+	h.scanTime = h.now()
+	h.days = make(map[string]int)
+	for r, d := range config.RemediationDays {
+		h.days[strings.ToUpper(r)] = d
+	}
+	h.overrides = config.SeverityOverrides
+	return h.Handler.Config(config)
+}
+
+func (h *remediationHandler) OSV(e *osv.Entry) error {
+	h.osvs[e.ID] = e
+	return h.Handler.OSV(e)
+}
+
+// Finding sets the deadline of f, if its severity
+// has one, and forwards it.
+func (h *remediationHandler) Finding(f *govulncheck.Finding) error {
+	if by, ok := h.deadline(h.osvs[f.OSV]); ok {
+		f.RemediateBy = &by
+	}
+	return h.Handler.Finding(f)
+}
+
+// deadline returns the deadline for remediating e, if
+// the policy sets one for the severity of e.
+func (h *remediationHandler) deadline(e *osv.Entry) (time.Time, bool) {
+	if e == nil {
+		return time.Time{}, false
+	}
+	score, ok := cvss.OverriddenEntryScore(e, h.overrides)
+	if !ok {
+		return time.Time{}, false
+	}
+	days, ok := h.days[cvss.Rating(score)]
+	if !ok {
+		return time.Time{}, false
+	}
+	return h.scanTime.AddDate(0, 0, days), true
+}
+
+func (h *remediationHandler) Failure(f *govulncheck.Failure) error {
+	return govulncheck.Fail(h.Handler, f)
+}
+
+func (h *remediationHandler) Flush() error {
+	return Flush(h.Handler)
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+func TestRemediationHandler(t *testing.T) {
+	scanTime := time.Date(2024, 1, 30, 12, 0, 0, 0, time.UTC)
+	inner := test.NewMockHandler()
+	h := newRemediationHandler(inner, func() time.Time { return scanTime })
+	cfg := &govulncheck.Config{
+		RemediationDays:   map[string]int{"critical": 7, "MEDIUM": 30, "LOW": 90},
+		SeverityOverrides: map[string]string{"CVE-0000-0005": "LOW"},
+	}
+	if err := h.Config(cfg); err != nil {
+		t.Fatal(err)
+	}
+	// A 7.5 high score, missing from the policy.
+	const highVector = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H"
+	overridden := severityEntry("GO-0000-0005", criticalVector)
+	overridden.Aliases = []string{"CVE-0000-0005"}
+	for _, e := range []*osv.Entry{
+		severityEntry("GO-0000-0001", criticalVector),
+		severityEntry("GO-0000-0002", mediumVector),
+		severityEntry("GO-0000-0003", highVector),
+		severityEntry("GO-0000-0004", ""),
+		overridden,
+	} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []string{"GO-0000-0001", "GO-0000-0002", "GO-0000-0003", "GO-0000-0004", "GO-0000-0005"} {
+		if err := h.Finding(&govulncheck.Finding{OSV: id, Trace: []*govulncheck.Frame{modFrame}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := make(map[string]string)
+	for _, f := range inner.FindingMessages {
+		if f.RemediateBy != nil {
+			got[f.OSV] = f.RemediateBy.Format(time.RFC3339)
+		}
+	}
+	want := map[string]string{
+		"GO-0000-0001": "2024-02-06T12:00:00Z",
+		"GO-0000-0002": "2024-02-29T12:00:00Z",
+		"GO-0000-0005": "2024-04-29T12:00:00Z",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("deadlines mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -17,9 +17,16 @@ import (
 
 	"golang.org/x/telemetry/counter"
 	"golang.org/x/vuln/internal/client"
+	"golang.org/x/vuln/internal/csv"
+	"golang.org/x/vuln/internal/github"
 	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/grype"
+	"golang.org/x/vuln/internal/metrics"
 	"golang.org/x/vuln/internal/openvex"
 	"golang.org/x/vuln/internal/sarif"
+	"golang.org/x/vuln/internal/spdx"
+	"golang.org/x/vuln/internal/sqlite"
+	"golang.org/x/vuln/internal/webhook"
 )
 
 // RunGovulncheck performs main govulncheck functionality and exits the
@@ -30,6 +37,10 @@ func RunGovulncheck(ctx context.Context, env []string, r io.Reader, stdout io.Wr
 	if err := parseFlags(cfg, stderr, args); err != nil {
 		return err
 	}
+	if cfg.sarifMerge {
+		// Merging needs neither the database nor a scan.
+		return runSarifMerge(cfg, stdout)
+	}
 
 	client, err := client.NewClient(cfg.db, nil)
 	if err != nil {
@@ -38,19 +49,89 @@ func RunGovulncheck(ctx context.Context, env []string, r io.Reader, stdout io.Wr
 
 	prepareConfig(ctx, cfg, client)
 	var handler govulncheck.Handler
-	switch cfg.format {
+	switch cfg.format.name() {
 	case formatJSON:
 		handler = govulncheck.NewJSONHandler(stdout)
 	case formatSarif:
-		handler = sarif.NewHandler(stdout)
+		if cfg.ScanMode == govulncheck.ScanModeSource {
+			// Point module results to the require
+			// directives of their modules.
+			cfg.IncludeGoModLocation = true
+		}
+		handler = newSarifHandler(cfg, stdout)
 	case formatOpenVEX:
 		handler = openvex.NewHandler(stdout)
+	case formatCSV:
+		handler = csv.NewHandler(stdout)
+	case formatSPDX:
+		handler = spdx.NewHandler(stdout)
+	case formatMetrics:
+		handler = metrics.NewHandler(stdout)
+	case formatGrype:
+		handler = grype.NewHandler(stdout)
+	case formatGitHub:
+		// Annotate the require directives of vulnerable modules.
+		cfg.IncludeGoModLocation = true
+		handler = github.NewHandler(stdout)
+	case formatSQLite:
+		db, err := sqlite.Open(cfg.format.sqlitePath())
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		handler = sqlite.NewHandler(db)
 	default:
+		if cfg.TextTemplate != "" {
+			handler, err = NewTemplateHandler(stdout, cfg.TextTemplate)
+			if err != nil {
+				return err
+			}
+			break
+		}
 		th := NewTextHandler(stdout)
 		cfg.show.Update(th)
 		handler = th
 	}
 
+	handler = severityOrder(handler, cfg)
+	if cfg.ListAdvisories || debugEnabled(cfg.env, "advisories") {
+		handler = newAdvisoryHandler(handler, stderr)
+	}
+	return runScan(ctx, handler, cfg, client, r, stdout)
+}
+
+// newSarifHandler returns a sarif handler writing to the file or the
+// files per level requested by cfg, if any, and to stdout otherwise.
+func newSarifHandler(cfg *config, stdout io.Writer) govulncheck.Handler {
+	switch {
+	case cfg.sarifSplit != "":
+		return sarif.NewSplitHandler(cfg.sarifSplit)
+	case cfg.sarifOutput != "":
+		return sarif.NewFileHandler(cfg.sarifOutput)
+	default:
+		return sarif.NewHandler(stdout)
+	}
+}
+
+// severityOrder returns handler wrapped in a handler holding findings
+// until the end of the scan to forward them most severe first, if cfg
+// asks for it. Only streaming handlers, such as the JSON handler, are
+// wrapped: the others, like the text handler, hold findings themselves
+// and present them in their own order.
+func severityOrder(handler govulncheck.Handler, cfg *config) govulncheck.Handler {
+	if !cfg.SortBySeverity || !govulncheck.Streaming(handler) {
+		return handler
+	}
+	return newSeverityHandler(handler)
+}
+
+// runScan runs the scan described by cfg, presenting
+// its results with handler.
+func runScan(ctx context.Context, handler govulncheck.Handler, cfg *config, client *client.Client, r io.Reader, stdout io.Writer) error {
+	handler, err := wrapHandler(ctx, handler, cfg)
+	if err != nil {
+		return err
+	}
 	if err := handler.Config(&cfg.Config); err != nil {
 		return err
 	}
@@ -71,11 +152,73 @@ func RunGovulncheck(ctx context.Context, env []string, r io.Reader, stdout io.Wr
 		err = govulncheck.HandleJSON(r, handler)
 	}
 	if err != nil {
+		// Present the findings reported before the error as
+		// partial results. The error takes precedence over
+		// the errors of flushing, like errVulnerabilitiesFound.
+		if ferr := govulncheck.Fail(handler, &govulncheck.Failure{Message: err.Error()}); ferr == nil {
+			Flush(handler)
+		}
 		return err
 	}
 	return Flush(handler)
 }
 
+// wrapHandler returns handler wrapped in the handlers adding
+// the information requested by cfg to findings, or redacting
+// them. The wrappers are concurrent, see Config.FindingWorkers,
+// if handler is.
+func wrapHandler(ctx context.Context, handler govulncheck.Handler, cfg *config) (govulncheck.Handler, error) {
+	if cfg.WebhookURL != "" {
+		handler = webhook.NewHandler(handler, cfg.WebhookURL, cfg.WebhookAuth, nil)
+	}
+	if cfg.IncludeLatestVersion {
+		if latest := proxyLatest(proxyEnv(cfg.env)); latest != nil {
+			handler = newLatestHandler(ctx, handler, latest)
+		}
+	}
+	if len(cfg.RemediationDays) > 0 {
+		handler = newRemediationHandler(handler, time.Now)
+	}
+	if cfg.Baseline != "" {
+		unfixable, err := loadBaseline(cfg.Baseline)
+		if err != nil {
+			return nil, err
+		}
+		handler = newBaselineHandler(handler, unfixable)
+	}
+	if cfg.progressInterval > 0 {
+		handler = govulncheck.NewThrottleHandler(handler, cfg.progressInterval)
+	}
+	if len(cfg.redact) > 0 {
+		// Positions are redacted for all the handlers above.
+		handler = govulncheck.NewRedactHandler(handler, cfg.redact...)
+	}
+	if debugEnabled(cfg.env, "validate") {
+		handler = govulncheck.NewValidateHandler(handler)
+	}
+	return handler, nil
+}
+
+// setSrcRoot sets the root of %SRCROOT% to the directory of the module
+// analyzed in source mode, if not set and used by the config: paths
+// relative to the module root, absolute URIs, and snippets are resolved
+// against it rather than the current directory, which may differ from
+// the scanned directory.
+func setSrcRoot(cfg *config) {
+	if cfg.ScanMode != govulncheck.ScanModeSource || cfg.SarifURIRoots[sarif.SrcRootID] != "" {
+		return
+	}
+	if cfg.ModuleRoot == "" && !cfg.SarifSnippets && cfg.SarifURIBase != govulncheck.SarifURIAbsolute {
+		return
+	}
+	if gm := gomod(cfg.dir); gm != "" {
+		if cfg.SarifURIRoots == nil {
+			cfg.SarifURIRoots = make(map[string]string)
+		}
+		cfg.SarifURIRoots[sarif.SrcRootID] = filepath.Dir(gm)
+	}
+}
+
 func prepareConfig(ctx context.Context, cfg *config, client *client.Client) {
 	cfg.ProtocolVersion = govulncheck.ProtocolVersion
 	cfg.DB = cfg.db
@@ -92,9 +235,15 @@ func prepareConfig(ctx context.Context, cfg *config, client *client.Client) {
 			}
 		}
 	}
+	setSrcRoot(cfg)
 	if bi, ok := debug.ReadBuildInfo(); ok {
 		scannerVersion(cfg, bi)
 	}
+	if cfg.OSVDir != "" {
+		// The database is not used, so there is
+		// no point in asking for its last update.
+		return
+	}
 	if mod, err := client.LastModifiedTime(ctx); err == nil {
 		cfg.DBLastModified = &mod
 	}
@@ -142,7 +291,7 @@ func scannerVersion(cfg *config, bi *debug.BuildInfo) {
 func incTelemetryFlagCounters(cfg *config) {
 	counter.Inc(fmt.Sprintf("govulncheck/mode:%s", cfg.ScanMode))
 	counter.Inc(fmt.Sprintf("govulncheck/scan:%s", cfg.ScanLevel))
-	counter.Inc(fmt.Sprintf("govulncheck/format:%s", cfg.format))
+	counter.Inc(fmt.Sprintf("govulncheck/format:%s", cfg.format.name()))
 
 	if len(cfg.show) == 0 {
 		counter.Inc("govulncheck/show:none")
@@ -5,8 +5,20 @@
 package scan
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
 	"runtime/debug"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/sarif"
 )
 
 func TestGovulncheckVersion(t *testing.T) {
@@ -24,3 +36,305 @@ func TestGovulncheckVersion(t *testing.T) {
 		t.Errorf("got %s; want %s", got.ScannerVersion, want)
 	}
 }
+
+func TestDebugEnabled(t *testing.T) {
+	for _, tc := range []struct {
+		env  []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"GOVULNCHECK_DEBUG=validate"}, true},
+		{[]string{"GOVULNCHECK_DEBUG=other, validate"}, true},
+		{[]string{"GOVULNCHECK_DEBUG=validator"}, false},
+		{[]string{"GOVULNCHECK_DEBUG=validate", "GOVULNCHECK_DEBUG="}, false},
+	} {
+		if got := debugEnabled(tc.env, "validate"); got != tc.want {
+			t.Errorf("%v: want %t; got %t", tc.env, tc.want, got)
+		}
+	}
+}
+
+// partialStream is a stream of govulncheck messages
+// cut short after the first finding.
+const partialStream = `{"config":{"protocol_version":"v1.0.0","scan_level":"module"}}
+{"osv":{"id":"GO-0000-0001","modified":"0001-01-01T00:00:00Z","database_specific":{"url":"https://pkg.go.dev/vuln/GO-0000-0001"}}}
+{"finding":{"osv":"GO-0000-0001","trace":[{"module":"example.com/m","version":"v1.0.0"}]}}
+{"finding":{"osv":`
+
+func runPartialScan(t *testing.T, h govulncheck.Handler) error {
+	t.Helper()
+	cfg := &config{}
+	cfg.ScanMode = govulncheck.ScanModeConvert
+	err := runScan(context.Background(), h, cfg, nil, strings.NewReader(partialStream), io.Discard)
+	if err == nil {
+		t.Fatal("want error for a stream cut short")
+	}
+	return err
+}
+
+func TestRunScanPartialJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := runPartialScan(t, govulncheck.NewJSONHandler(&buf))
+
+	var msgs []govulncheck.Message
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var msg govulncheck.Message
+		if err := dec.Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+	}
+	var findings int
+	for _, msg := range msgs {
+		if msg.Finding != nil {
+			findings++
+		}
+	}
+	if findings != 1 {
+		t.Errorf("want the finding before the error; got %d findings", findings)
+	}
+	last := msgs[len(msgs)-1]
+	if last.Failure == nil || last.Failure.Message != err.Error() {
+		t.Errorf("want failure %q as the last message; got %+v", err, last)
+	}
+}
+
+func TestRunScanPartialSarif(t *testing.T) {
+	var buf bytes.Buffer
+	err := runPartialScan(t, sarif.NewHandler(&buf))
+
+	var log sarif.Log
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 1 {
+		t.Errorf("want the result of the finding before the error; got %d results", len(run.Results))
+	}
+	if len(run.Invocations) != 1 {
+		t.Fatalf("want 1 invocation; got %d", len(run.Invocations))
+	}
+	inv := run.Invocations[0]
+	want := []sarif.Notification{{Level: "error", Message: sarif.Description{Text: err.Error()}}}
+	if inv.ExecutionSuccessful || len(inv.ToolExecutionNotifications) != 1 || inv.ToolExecutionNotifications[0] != want[0] {
+		t.Errorf("want unsuccessful invocation with notifications %v; got %+v", want, inv)
+	}
+}
+
+func TestRunScanPartialText(t *testing.T) {
+	var buf bytes.Buffer
+	runPartialScan(t, NewTextHandler(&buf))
+	got := buf.String()
+	for _, want := range []string{"Vulnerability #1: GO-0000-0001", incompleteMessage} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want output containing %q; got:\n%s", want, got)
+		}
+	}
+}
+
+func TestWrapHandlerConcurrent(t *testing.T) {
+	cfg := &config{env: []string{"GOVULNCHECK_DEBUG=validate"}}
+	cfg.WebhookURL = "http://localhost/hook"
+	cfg.RemediationDays = map[string]int{"critical": 7}
+	cfg.redact = []string{"/home/user"}
+	cfg.progressInterval = time.Second
+	for _, test := range []struct {
+		name    string
+		handler govulncheck.Handler
+		want    bool
+	}{
+		{"sarif", sarif.NewHandler(io.Discard), true},
+		{"advisories", newAdvisoryHandler(sarif.NewHandler(io.Discard), io.Discard), true},
+		{"text", NewTextHandler(io.Discard), false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			h, err := wrapHandler(context.Background(), test.handler, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := govulncheck.Concurrent(h); got != test.want {
+				t.Errorf("want concurrent %t; got %t", test.want, got)
+			}
+			latest := newLatestHandler(context.Background(), newBaselineHandler(test.handler, nil), nil)
+			if got := govulncheck.Concurrent(latest); got != test.want {
+				t.Errorf("latest and baseline: want concurrent %t; got %t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestSetSrcRoot(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		cfg  govulncheck.Config
+		want string
+	}{
+		{name: "snippets", cfg: govulncheck.Config{ScanMode: govulncheck.ScanModeSource, SarifSnippets: true}, want: dir},
+		{name: "module root", cfg: govulncheck.Config{ScanMode: govulncheck.ScanModeSource, ModuleRoot: dir}, want: dir},
+		{name: "absolute", cfg: govulncheck.Config{ScanMode: govulncheck.ScanModeSource, SarifURIBase: govulncheck.SarifURIAbsolute}, want: dir},
+		{name: "unused", cfg: govulncheck.Config{ScanMode: govulncheck.ScanModeSource}},
+		{name: "binary", cfg: govulncheck.Config{ScanMode: govulncheck.ScanModeBinary, SarifSnippets: true}},
+		{name: "set", cfg: govulncheck.Config{ScanMode: govulncheck.ScanModeSource, SarifSnippets: true, SarifURIRoots: map[string]string{sarif.SrcRootID: "/src"}}, want: "/src"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config{Config: tc.cfg, dir: dir}
+			setSrcRoot(cfg)
+			if got := cfg.SarifURIRoots[sarif.SrcRootID]; got != tc.want {
+				t.Errorf("got root %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSortBySeverityStreaming(t *testing.T) {
+	cfg := &config{}
+	cfg.SortBySeverity = true
+	for _, tc := range []struct {
+		name string
+		h    govulncheck.Handler
+		want bool
+	}{
+		{"json", govulncheck.NewJSONHandler(io.Discard), true},
+		{"sarif", sarif.NewHandler(io.Discard), false},
+		{"text", NewTextHandler(io.Discard), false},
+	} {
+		_, sorted := severityOrder(tc.h, cfg).(*severityHandler)
+		if sorted != tc.want {
+			t.Errorf("%s: got sorted %t; want %t", tc.name, sorted, tc.want)
+		}
+	}
+	if _, sorted := severityOrder(govulncheck.NewJSONHandler(io.Discard), &config{}).(*severityHandler); sorted {
+		t.Error("want no sorting unless configured")
+	}
+}
+
+func TestWrapHandlerRedact(t *testing.T) {
+	home := filepath.Join(string(filepath.Separator), "home", "user")
+	cfg := &config{}
+	if err := parseFlags(cfg, io.Discard, []string{"-redact", home + ",", "./..."}); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.redact) != 1 || cfg.redact[0] != home {
+		t.Fatalf("got prefixes %q; want [%q]", cfg.redact, home)
+	}
+	inner := sarif.NewHandler(io.Discard)
+	h, err := wrapHandler(context.Background(), inner, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Config(&govulncheck.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	f := &govulncheck.Finding{
+		OSV:           "GO-0000-0001",
+		GoModLocation: &govulncheck.Position{Filename: filepath.Join(home, "m", "go.mod"), Line: 3},
+		Trace: []*govulncheck.Frame{{
+			Module:   "m",
+			Package:  "m/p",
+			Function: "F",
+			Position: &govulncheck.Position{Filename: filepath.Join(home, "m", "p", "f.go"), Line: 5},
+		}},
+	}
+	if err := h.Finding(f); err != nil {
+		t.Fatal(err)
+	}
+	got := inner.Findings()["GO-0000-0001"][0]
+	if fn := got.Trace[0].Position.Filename; fn != "m/p/f.go" {
+		t.Errorf("got position %q; want m/p/f.go", fn)
+	}
+	if fn := got.GoModLocation.Filename; fn != "m/go.mod" {
+		t.Errorf("got go.mod location %q; want m/go.mod", fn)
+	}
+}
+
+func TestWrapHandlerThrottle(t *testing.T) {
+	cfg := &config{}
+	if err := parseFlags(cfg, io.Discard, []string{"-format", "json", "-progress-interval", "1h", "./..."}); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	h, err := wrapHandler(context.Background(), govulncheck.NewJSONHandler(&buf), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"first", "second", "third"} {
+		if err := h.Progress(&govulncheck.Progress{Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := progressMessages(t, buf.Bytes()); !slices.Equal(got, []string{"first"}) {
+		t.Errorf("before flush: got %q; want the first progress only", got)
+	}
+	if err := Flush(h); err != nil {
+		t.Fatal(err)
+	}
+	if got := progressMessages(t, buf.Bytes()); !slices.Equal(got, []string{"first", "third"}) {
+		t.Errorf("after flush: got %q; want the first and last progress", got)
+	}
+}
+
+// progressMessages returns the messages of the
+// progress messages of the JSON output data.
+func progressMessages(t *testing.T, data []byte) []string {
+	var msgs []string
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for dec.More() {
+		var m govulncheck.Message
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Progress != nil {
+			msgs = append(msgs, m.Progress.Message)
+		}
+	}
+	return msgs
+}
+
+func TestSarifOutputFlags(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		flag  string
+		value string
+		want  []string // files written
+	}{
+		{"-sarif-output", filepath.Join(dir, "out.sarif"), []string{"out.sarif"}},
+		{"-sarif-split", filepath.Join(dir, "{level}.sarif"), []string{"error.sarif", "note.sarif", "out.sarif", "warning.sarif"}},
+	} {
+		cfg := &config{}
+		if err := parseFlags(cfg, io.Discard, []string{"-format", "sarif", tc.flag, tc.value, "./..."}); err != nil {
+			t.Fatal(err)
+		}
+		var stdout bytes.Buffer
+		h := newSarifHandler(cfg, &stdout)
+		if err := h.Config(&cfg.Config); err != nil {
+			t.Fatal(err)
+		}
+		if err := Flush(h); err != nil {
+			t.Fatal(err)
+		}
+		if stdout.Len() != 0 {
+			t.Errorf("%s: got output %q on stdout; want none", tc.flag, stdout.String())
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Name())
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: got files %q; want %q", tc.flag, got, tc.want)
+		}
+	}
+
+	both := []string{"-format", "sarif", "-sarif-output", "out.sarif", "-sarif-split", "{level}.sarif"}
+	if err := parseFlags(&config{}, io.Discard, both); err == nil {
+		t.Error("want an error for both -sarif-output and -sarif-split")
+	}
+}
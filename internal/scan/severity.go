@@ -0,0 +1,131 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"cmp"
+	"sort"
+
+	"golang.org/x/vuln/internal/cvss"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// severityHandler is a Handler that holds findings until
+// Flush, where they are forwarded most severe first.
+type severityHandler struct {
+	govulncheck.Handler
+	osvs     map[string]*osv.Entry
+	findings []*govulncheck.Finding
+	failure  *govulncheck.Failure
+	// overrides are the severity overrides of the config.
+	overrides map[string]string
+}
+
+func newSeverityHandler(h govulncheck.Handler) *severityHandler {
+	return &severityHandler{Handler: h, osvs: make(map[string]*osv.Entry)}
+}
+
+// Streaming returns false as findings are
+// held until the end of the scan.
+func (h *severityHandler) Streaming() bool {
+	return false
+}
+
+func (h *severityHandler) Config(config *govulncheck.Config) error {
+	h.overrides = config.SeverityOverrides
+	return h.Handler.Config(config)
+}
+
+func (h *severityHandler) OSV(e *osv.Entry) error {
+	h.osvs[e.ID] = e
+	return h.Handler.OSV(e)
+}
+
+// Failure holds the failure until Flush, so that
+// it is forwarded after the findings.
+func (h *severityHandler) Failure(f *govulncheck.Failure) error {
+	h.failure = f
+	return nil
+}
+
+func (h *severityHandler) Finding(f *govulncheck.Finding) error {
+	h.findings = append(h.findings, f)
+	return nil
+}
+
+// Flush forwards the findings, sorted by compareSeverity,
+// and the failure, if any, and flushes the underlying handler.
+func (h *severityHandler) Flush() error {
+	sort.SliceStable(h.findings, func(i, j int) bool {
+		fi, fj := h.findings[i], h.findings[j]
+		return compareSeverity(fi, h.osvs[fi.OSV], fj, h.osvs[fj.OSV], h.overrides) < 0
+	})
+	for _, f := range h.findings {
+		if err := h.Handler.Finding(f); err != nil {
+			return err
+		}
+	}
+	h.findings = nil
+	if h.failure != nil {
+		if err := govulncheck.Fail(h.Handler, h.failure); err != nil {
+			return err
+		}
+		h.failure = nil
+	}
+	return Flush(h.Handler)
+}
+
+// sortBySeverity sorts groups of findings per vulnerability,
+// most severe first, as ordered by compareSeverity on the most
+// precise finding of each group.
+func sortBySeverity(byVuln [][]*findingSummary, overrides map[string]string) {
+	sort.SliceStable(byVuln, func(i, j int) bool {
+		fi, fj := mostPrecise(byVuln[i]), mostPrecise(byVuln[j])
+		return compareSeverity(fi.Finding, fi.OSV, fj.Finding, fj.OSV, overrides) < 0
+	})
+}
+
+// mostPrecise returns a finding of findings
+// at the most precise level.
+func mostPrecise(findings []*findingSummary) *findingSummary {
+	best := findings[0]
+	for _, f := range findings[1:] {
+		if govulncheck.Level(f.Finding).Precision() > govulncheck.Level(best.Finding).Precision() {
+			best = f
+		}
+	}
+	return best
+}
+
+// compareSeverity returns a negative number if finding f1 of the
+// vulnerability e1 is more severe than finding f2 of e2, a positive
+// number if it is less severe, and zero otherwise.
+//
+// Findings are ordered by descending CVSS score of their entries,
+// subject to the severity overrides, where entries with no score
+// come last, then by descending reachability, from call-level to
+// module-level findings, and then by OSV ID.
+func compareSeverity(f1 *govulncheck.Finding, e1 *osv.Entry, f2 *govulncheck.Finding, e2 *osv.Entry, overrides map[string]string) int {
+	if c := cmp.Compare(score(e2, overrides), score(e1, overrides)); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(govulncheck.Level(f2).Precision(), govulncheck.Level(f1).Precision()); c != 0 {
+		return c
+	}
+	return cmp.Compare(f1.OSV, f2.OSV)
+}
+
+// score returns the CVSS score of e, subject to
+// the severity overrides, or -1 if unknown.
+func score(e *osv.Entry, overrides map[string]string) float64 {
+	if e == nil {
+		return -1
+	}
+	if s, ok := cvss.OverriddenEntryScore(e, overrides); ok {
+		return s
+	}
+	return -1
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+const (
+	criticalVector = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H" // 9.8
+	mediumVector   = "CVSS:3.1/AV:N/AC:L/PR:L/UI:R/S:C/C:L/I:L/A:N" // 5.4
+)
+
+func severityEntry(id, vector string) *osv.Entry {
+	e := &osv.Entry{ID: id}
+	if vector != "" {
+		e.Severity = []osv.Severity{{Type: osv.SeverityTypeCVSSV3, Score: vector}}
+	}
+	return e
+}
+
+var (
+	modFrame  = &govulncheck.Frame{Module: "m"}
+	funcFrame = &govulncheck.Frame{Module: "m", Package: "m/p", Function: "F"}
+)
+
+func TestSeverityHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := newSeverityHandler(govulncheck.NewJSONHandler(&buf))
+	for _, e := range []*osv.Entry{
+		severityEntry("GO-0000-0001", ""),
+		severityEntry("GO-0000-0002", mediumVector),
+		severityEntry("GO-0000-0003", criticalVector),
+		severityEntry("GO-0000-0004", mediumVector),
+	} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []*govulncheck.Finding{
+		{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{funcFrame}},
+		{OSV: "GO-0000-0004", Trace: []*govulncheck.Frame{modFrame}},
+		{OSV: "GO-0000-0002", Trace: []*govulncheck.Frame{modFrame}},
+		{OSV: "GO-0000-0003", Trace: []*govulncheck.Frame{modFrame}},
+		{OSV: "GO-0000-0004", Trace: []*govulncheck.Frame{funcFrame}},
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if govulncheck.Streaming(h) {
+		t.Error("want no streaming when sorting by severity")
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var msg govulncheck.Message
+		if err := dec.Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if f := msg.Finding; f != nil {
+			got = append(got, f.OSV+" "+f.Trace[0].Function)
+		}
+	}
+	want := []string{
+		"GO-0000-0003 ",
+		"GO-0000-0004 F", // ties on score are broken by reachability
+		"GO-0000-0002 ",
+		"GO-0000-0004 ", // then by ID
+		"GO-0000-0001 F",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings order mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestSortBySeverity(t *testing.T) {
+	summary := func(id, vector string, fr *govulncheck.Frame) *findingSummary {
+		return &findingSummary{
+			Finding: &govulncheck.Finding{OSV: id, Trace: []*govulncheck.Frame{fr}},
+			OSV:     severityEntry(id, vector),
+		}
+	}
+	byVuln := groupByVuln([]*findingSummary{
+		summary("GO-0000-0001", mediumVector, modFrame),
+		summary("GO-0000-0002", "", funcFrame),
+		summary("GO-0000-0003", criticalVector, modFrame),
+		summary("GO-0000-0004", mediumVector, modFrame),
+		summary("GO-0000-0004", mediumVector, funcFrame),
+	})
+	ids := func() []string {
+		var ids []string
+		for _, fs := range byVuln {
+			ids = append(ids, fs[0].OSV.ID)
+		}
+		return ids
+	}
+	sortBySeverity(byVuln, nil)
+	want := []string{"GO-0000-0003", "GO-0000-0004", "GO-0000-0001", "GO-0000-0002"}
+	if diff := cmp.Diff(want, ids()); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	// Overrides take precedence over CVSS scores.
+	sortBySeverity(byVuln, map[string]string{"GO-0000-0002": "CRITICAL", "GO-0000-0003": "LOW"})
+	want = []string{"GO-0000-0002", "GO-0000-0004", "GO-0000-0001", "GO-0000-0003"}
+	if diff := cmp.Diff(want, ids()); diff != "" {
+		t.Errorf("with overrides: mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -6,6 +6,7 @@ package scan
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"golang.org/x/tools/go/packages"
@@ -39,7 +40,20 @@ func runSource(ctx context.Context, handler govulncheck.Handler, cfg *config, cl
 		if isGoVersionMismatchError(err) {
 			return fmt.Errorf("%v\n\n%v", errGoVersionMismatch, err)
 		}
-		return fmt.Errorf("loading packages: %w", err)
+		err = fmt.Errorf("loading packages: %w", err)
+		if inModule(graph.TopPkgs()) {
+			// The modules are known regardless of the packages
+			// failing to load, so report the vulnerable ones.
+			if merr := vulncheck.Modules(ctx, handler, &cfg.Config, client, graph); merr != nil {
+				return errors.Join(err, merr)
+			}
+		}
+		return err
+	}
+	if cfg.IncludeTools {
+		if err := graph.LoadTools(pkgConfig, cfg.tags); err != nil {
+			return fmt.Errorf("loading tools: %w", err)
+		}
 	}
 
 	if cfg.ScanLevel.WantPackages() && len(graph.TopPkgs()) == 0 {
@@ -47,3 +61,13 @@ func runSource(ctx context.Context, handler govulncheck.Handler, cfg *config, cl
 	}
 	return vulncheck.Source(ctx, handler, &cfg.Config, client, graph)
 }
+
+// inModule reports whether any of pkgs belongs to a module.
+func inModule(pkgs []*packages.Package) bool {
+	for _, p := range pkgs {
+		if p.Module != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -16,6 +16,7 @@ import (
 
 	"golang.org/x/vuln/internal/govulncheck"
 	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/semver"
 	"golang.org/x/vuln/internal/traces"
 )
 
@@ -103,6 +104,17 @@ func isCalled(findings []*findingSummary) bool {
 	return false
 }
 
+// isNewlyFixable reports whether any of findings
+// became fixable since the baseline scan.
+func isNewlyFixable(findings []*findingSummary) bool {
+	for _, f := range findings {
+		if f.NewlyFixable {
+			return true
+		}
+	}
+	return false
+}
+
 func getOSV(osvs []*osv.Entry, id string) *osv.Entry {
 	for _, entry := range osvs {
 		if entry.ID == id {
@@ -168,6 +180,77 @@ func platforms(mod string, e *osv.Entry) []string {
 	return keys
 }
 
+// moduleUpgrades computes, for each module with several vulnerabilities
+// in vulns that have different fixed versions, the version of the module
+// that fixes all of them, see semver.Upgrades. Versions are presented as
+// in the rest of the text output.
+func moduleUpgrades(vulns [][]*findingSummary) []semver.Upgrade {
+	var mvs []semver.ModuleVuln
+	for _, findings := range vulns {
+		for _, module := range groupByModule(findings) {
+			fr := module[0].Trace[0]
+			mvs = append(mvs, semver.ModuleVuln{Module: fr.Module, Version: fr.Version, Fixed: module[0].FixedVersion, Entry: module[0].OSV})
+		}
+	}
+	upgrades := semver.Upgrades(mvs)
+	for i, u := range upgrades {
+		if u.Version != "" {
+			upgrades[i].Version = moduleVersionString(u.Module, u.Version)
+		}
+	}
+	return upgrades
+}
+
+// upgradeStep is a step of an upgrade plan: the upgrade
+// of a module to a version fixing vulnerabilities.
+type upgradeStep struct {
+	Module string
+	// Version fixing the vulnerabilities. It is empty
+	// for the vulnerabilities that have no fix.
+	Version string
+	// OSVs are the sorted IDs of the vulnerabilities.
+	OSVs []string
+}
+
+// upgradePlan groups the vulnerabilities in vulns by module and
+// by the fixed version of the module, as given by FixedVersion of
+// their findings. The steps are sorted by module and then version,
+// the vulnerabilities without a fix of a module coming last.
+func upgradePlan(vulns [][]*findingSummary) []upgradeStep {
+	type key struct{ module, version string }
+	osvs := make(map[key]map[string]bool)
+	for _, findings := range vulns {
+		for _, module := range groupByModule(findings) {
+			k := key{module[0].Trace[0].Module, module[0].FixedVersion}
+			if osvs[k] == nil {
+				osvs[k] = make(map[string]bool)
+			}
+			osvs[k][module[0].Finding.OSV] = true
+		}
+	}
+
+	var plan []upgradeStep
+	for k, ids := range osvs {
+		step := upgradeStep{Module: k.module, Version: k.version}
+		for id := range ids {
+			step.OSVs = append(step.OSVs, id)
+		}
+		sort.Strings(step.OSVs)
+		plan = append(plan, step)
+	}
+	sort.Slice(plan, func(i, j int) bool {
+		si, sj := plan[i], plan[j]
+		if si.Module != sj.Module {
+			return si.Module < sj.Module
+		}
+		if si.Version == "" || sj.Version == "" {
+			return sj.Version == ""
+		}
+		return semver.Less(si.Version, sj.Version)
+	})
+	return plan
+}
+
 func posToString(p *govulncheck.Position) string {
 	if p == nil || p.Line <= 0 {
 		return ""
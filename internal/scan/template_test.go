@@ -5,10 +5,13 @@
 package scan
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
 )
 
 func TestCompactTrace(t *testing.T) {
@@ -61,3 +64,39 @@ func TestCompactTrace(t *testing.T) {
 		})
 	}
 }
+
+func TestUpgradePlan(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewTextHandler(&buf)
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelModule, UpgradePlan: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []*govulncheck.Finding{
+		modFinding("GO-0000-0002", "example.com/a", "v1.0.2"),
+		modFinding("GO-0000-0001", "example.com/a", "v1.0.2"),
+		modFinding("GO-0000-0003", "example.com/b", ""),
+	} {
+		if err := h.OSV(&osv.Entry{ID: f.OSV, DatabaseSpecific: &osv.DatabaseSpecific{}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Flush(); err != errVulnerabilitiesFound {
+		t.Fatalf("got %v; want %v", err, errVulnerabilitiesFound)
+	}
+
+	want := `=== Upgrade Plan ===
+
+Upgrade example.com/a to v1.0.2 to fix GO-0000-0001, GO-0000-0002.
+No fixed version of example.com/b is available for GO-0000-0003.
+
+`
+	if got := buf.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("got output\n%s\nwant prefix\n%s", got, want)
+	}
+	if got := buf.String(); strings.Contains(got, "Module Results") {
+		t.Errorf("got output with findings of vulnerabilities:\n%s", got)
+	}
+}
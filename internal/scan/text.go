@@ -9,8 +9,10 @@ import (
 	"io"
 	"sort"
 	"strings"
+	"time"
 
 	"golang.org/x/vuln/internal"
+	"golang.org/x/vuln/internal/cvss"
 	"golang.org/x/vuln/internal/govulncheck"
 	"golang.org/x/vuln/internal/osv"
 	"golang.org/x/vuln/internal/vulncheck"
@@ -26,6 +28,8 @@ const (
 	sectionStyle
 	keyStyle
 	valueStyle
+	criticalStyle
+	highStyle
 )
 
 // NewtextHandler returns a handler that writes govulncheck output as text.
@@ -40,13 +44,39 @@ type TextHandler struct {
 	findings  []*findingSummary
 	scanLevel govulncheck.ScanLevel
 	scanMode  govulncheck.ScanMode
+	// maxFindings is the limit of findings of each
+	// level emitted by govulncheck, if any.
+	maxFindings int
+	// truncated is set if govulncheck reported
+	// dropping findings because of maxFindings.
+	truncated bool
+	// sortBySeverity is set if vulnerabilities
+	// are presented most severe first.
+	sortBySeverity bool
+	// severityOverrides override the CVSS
+	// ratings of vulnerabilities.
+	severityOverrides map[string]string
+	// failed is set if the scan stopped
+	// because of an error.
+	failed bool
+	// baseline is set if findings are
+	// compared to a baseline scan.
+	baseline bool
+	// exit decides whether the findings
+	// fail the scan, and with which code.
+	exit exitPolicy
+	// upgradePlan is set if vulnerabilities
+	// are presented grouped by the module
+	// upgrades fixing them.
+	upgradePlan bool
 
 	err error
 
-	showColor   bool
-	showTraces  bool
-	showVersion bool
-	showVerbose bool
+	showColor    bool
+	showTraces   bool
+	showVersion  bool
+	showVerbose  bool
+	showAdvisory bool
 }
 
 const (
@@ -54,6 +84,8 @@ const (
 
 	binaryProgressMessage = `Scanning your binary for known vulnerabilities...`
 
+	binariesProgressMessage = `Scanning binary %s for known vulnerabilities...`
+
 	noVulnsMessage = `No vulnerabilities found.`
 
 	noOtherVulnsMessage = `No other vulnerabilities found.`
@@ -61,9 +93,15 @@ const (
 	verboseMessage = `'-show verbose' for more details`
 
 	symbolMessage = `'-scan symbol' for more fine grained vulnerability detection`
+
+	incompleteMessage = `The scan did not complete, so there may be other vulnerabilities.`
 )
 
 func (h *TextHandler) Flush() error {
+	if h.failed && len(h.findings) == 0 {
+		// The error stopping the scan is all there is to report.
+		return h.err
+	}
 	if h.showVerbose {
 		h.printSBOM()
 	}
@@ -74,16 +112,20 @@ func (h *TextHandler) Flush() error {
 		counters := h.allVulns(h.findings)
 		h.summary(counters)
 	}
+	if h.failed {
+		h.print("\n", incompleteMessage, "\n")
+	}
 	if h.err != nil {
 		return h.err
 	}
-	// We found vulnerabilities when the findings' level matches the scan level.
-	if (isCalled(h.findings) && h.scanLevel == govulncheck.ScanLevelSymbol) ||
-		(isImported(h.findings) && h.scanLevel == govulncheck.ScanLevelPackage) ||
-		(isRequired(h.findings) && h.scanLevel == govulncheck.ScanLevelModule) {
-		return errVulnerabilitiesFound
-	}
+	return h.exit.err(h.findings)
+}
 
+// Failure records that the scan stopped because of an error, in
+// which case Flush presents the findings reported so far, if any,
+// as incomplete results.
+func (h *TextHandler) Failure(f *govulncheck.Failure) error {
+	h.failed = true
 	return nil
 }
 
@@ -91,6 +133,12 @@ func (h *TextHandler) Flush() error {
 func (h *TextHandler) Config(config *govulncheck.Config) error {
 	h.scanLevel = config.ScanLevel
 	h.scanMode = config.ScanMode
+	h.maxFindings = config.MaxFindings
+	h.sortBySeverity = config.SortBySeverity
+	h.severityOverrides = config.SeverityOverrides
+	h.baseline = config.Baseline != ""
+	h.exit = newExitPolicy(config)
+	h.upgradePlan = config.UpgradePlan
 
 	if !h.showVersion {
 		return nil
@@ -168,6 +216,9 @@ func (h *TextHandler) printSBOM() error {
 
 // Progress writes progress updates during govulncheck execution.
 func (h *TextHandler) Progress(progress *govulncheck.Progress) error {
+	if progress.Truncated {
+		h.truncated = true
+	}
 	if h.showVerbose {
 		h.print(progress.Message, "\n\n")
 	}
@@ -182,7 +233,7 @@ func (h *TextHandler) OSV(entry *osv.Entry) error {
 
 // Finding gathers vulnerability findings to be written.
 func (h *TextHandler) Finding(finding *govulncheck.Finding) error {
-	if err := validateFindings(finding); err != nil {
+	if err := govulncheck.ValidateFinding(finding); err != nil {
 		return err
 	}
 	h.findings = append(h.findings, newFindingSummary(finding))
@@ -191,6 +242,9 @@ func (h *TextHandler) Finding(finding *govulncheck.Finding) error {
 
 func (h *TextHandler) allVulns(findings []*findingSummary) summaryCounters {
 	byVuln := groupByVuln(findings)
+	if h.sortBySeverity {
+		sortBySeverity(byVuln, h.severityOverrides)
+	}
 	var called, imported, required [][]*findingSummary
 	mods := map[string]struct{}{}
 	stdlibCalled := false
@@ -210,7 +264,11 @@ func (h *TextHandler) allVulns(findings []*findingSummary) summaryCounters {
 		}
 	}
 
-	if h.scanLevel.WantSymbols() {
+	if h.upgradePlan {
+		h.upgrades(h.atScanLevel(byVuln))
+	}
+
+	if h.scanLevel.WantSymbols() && !h.upgradePlan {
 		h.style(sectionStyle, "=== Symbol Results ===\n\n")
 		if len(called) == 0 {
 			h.print(noVulnsMessage, "\n\n")
@@ -220,7 +278,7 @@ func (h *TextHandler) allVulns(findings []*findingSummary) summaryCounters {
 		}
 	}
 
-	if h.scanLevel == govulncheck.ScanLevelPackage || (h.scanLevel.WantPackages() && h.showVerbose) {
+	if (h.scanLevel == govulncheck.ScanLevelPackage || (h.scanLevel.WantPackages() && h.showVerbose)) && !h.upgradePlan {
 		h.style(sectionStyle, "=== Package Results ===\n\n")
 		if len(imported) == 0 {
 			h.print(choose(!h.scanLevel.WantSymbols(), noVulnsMessage, noOtherVulnsMessage), "\n\n")
@@ -230,7 +288,7 @@ func (h *TextHandler) allVulns(findings []*findingSummary) summaryCounters {
 		}
 	}
 
-	if h.showVerbose || h.scanLevel == govulncheck.ScanLevelModule {
+	if (h.showVerbose || h.scanLevel == govulncheck.ScanLevelModule) && !h.upgradePlan {
 		h.style(sectionStyle, "=== Module Results ===\n\n")
 		if len(required) == 0 {
 			h.print(choose(!h.scanLevel.WantPackages(), noVulnsMessage, noOtherVulnsMessage), "\n\n")
@@ -249,6 +307,31 @@ func (h *TextHandler) allVulns(findings []*findingSummary) summaryCounters {
 	}
 }
 
+// upgrades prints the upgrade plan fixing the vulnerabilities
+// in vulns: for each module, the versions to upgrade to, each
+// with the vulnerabilities it fixes.
+func (h *TextHandler) upgrades(vulns [][]*findingSummary) {
+	h.style(sectionStyle, "=== Upgrade Plan ===\n\n")
+	plan := upgradePlan(vulns)
+	if len(plan) == 0 {
+		h.print(noVulnsMessage, "\n\n")
+		return
+	}
+	for _, step := range plan {
+		name := step.Module
+		if name == internal.GoStdModulePath {
+			name = "the Go standard library"
+		}
+		if step.Version == "" {
+			h.wrap("", fmt.Sprintf("No fixed version of %s is available for %s.", name, strings.Join(step.OSVs, ", ")), 80)
+		} else {
+			h.wrap("", fmt.Sprintf("Upgrade %s to %s to fix %s.", name, moduleVersionString(step.Module, step.Version), strings.Join(step.OSVs, ", ")), 80)
+		}
+		h.print("\n")
+	}
+	h.print("\n")
+}
+
 func (h *TextHandler) vulnerability(index int, findings []*findingSummary) {
 	h.style(keyStyle, "Vulnerability")
 	h.print(" #", index+1, ": ")
@@ -257,6 +340,7 @@ func (h *TextHandler) vulnerability(index int, findings []*findingSummary) {
 	} else {
 		h.style(osvImportedStyle, findings[0].OSV.ID)
 	}
+	h.severity(findings[0].OSV)
 	h.print("\n")
 	h.style(detailsStyle)
 	description := findings[0].OSV.Summary
@@ -268,6 +352,13 @@ func (h *TextHandler) vulnerability(index int, findings []*findingSummary) {
 	h.print("\n")
 	h.style(keyStyle, "  More info:")
 	h.print(" ", findings[0].OSV.DatabaseSpecific.URL, "\n")
+	if by := findings[0].RemediateBy; by != nil {
+		h.style(keyStyle, "  Remediate by:")
+		h.print(" ", by.Format(time.DateOnly), "\n")
+	}
+	if h.showAdvisory {
+		h.advisory(findings[0].OSV)
+	}
 
 	byModule := groupByModule(findings)
 	first := true
@@ -288,6 +379,8 @@ func (h *TextHandler) vulnerability(index int, findings []*findingSummary) {
 		// All findings on a module are found and fixed at the same version
 		foundVersion := moduleVersionString(lastFrame.Module, lastFrame.Version)
 		fixedVersion := moduleVersionString(lastFrame.Module, module[0].FixedVersion)
+		introducedVersion := moduleVersionString(lastFrame.Module, module[0].IntroducedVersion)
+		lastAffectedVersion := moduleVersionString(lastFrame.Module, module[0].LastAffectedVersion)
 		if !first {
 			h.print("\n")
 		}
@@ -298,17 +391,49 @@ func (h *TextHandler) vulnerability(index int, findings []*findingSummary) {
 		} else {
 			h.style(keyStyle, "Module: ")
 			h.print(mod)
+			if module[0].Direct {
+				h.print(" (direct dependency)")
+			}
 		}
 		h.print("\n    ")
 		h.style(keyStyle, "Found in: ")
 		h.print(path, "@", foundVersion, "\n    ")
+		if replaced := lastFrame.ReplacedModule; replaced != "" {
+			h.style(keyStyle, "Replaces: ")
+			h.print(replaced, "@", moduleVersionString(replaced, lastFrame.ReplacedVersion), "\n    ")
+		}
+		if pos := module[0].GoModLocation; pos != nil {
+			h.style(keyStyle, "Required at: ")
+			h.print(fmt.Sprintf("%s:%d", pos.Filename, pos.Line), "\n    ")
+		}
+		if introducedVersion != "" {
+			h.style(keyStyle, "Introduced in: ")
+			h.print(path, "@", introducedVersion, "\n    ")
+		}
 		h.style(keyStyle, "Fixed in: ")
-		if fixedVersion != "" {
+		if mod == internal.GoStdModulePath && strings.HasPrefix(module[0].FixedVersion, "go") {
+			// The fix is reported as a Go release.
+			h.print("Go ", strings.TrimPrefix(fixedVersion, "go"))
+		} else if fixedVersion != "" {
 			h.print(path, "@", fixedVersion)
+			if rev := module[0].FixedRevision; rev != "" {
+				h.print(" (", rev, ")")
+			}
+			if latest := module[0].LatestVersion; latest != "" && latest != module[0].FixedVersion {
+				h.print("; latest is ", latest)
+			}
+		} else if lastAffectedVersion != "" {
+			h.print("versions after ", path, "@", lastAffectedVersion)
+		} else if module[0].FixedInAdvisory {
+			h.print("fix available, see advisory")
 		} else {
 			h.print("N/A")
 		}
 		h.print("\n")
+		if ranges := module[0].AffectedRanges; len(ranges) > 0 {
+			h.style(keyStyle, "    Affected: ")
+			h.print(affectedString(mod, ranges), "\n")
+		}
 		platforms := platforms(mod, module[0].OSV)
 		if len(platforms) > 0 {
 			h.style(keyStyle, "    Platforms: ")
@@ -325,6 +450,54 @@ func (h *TextHandler) vulnerability(index int, findings []*findingSummary) {
 	h.print("\n")
 }
 
+// advisory prints when entry was published and
+// last modified, if known, whom it credits, and
+// the commits fixing the vulnerability.
+func (h *TextHandler) advisory(entry *osv.Entry) {
+	const layout = "2006-01-02"
+	if !entry.Published.IsZero() {
+		h.style(keyStyle, "  Published:")
+		h.print(" ", entry.Published.UTC().Format(layout), "\n")
+	}
+	if !entry.Modified.IsZero() {
+		h.style(keyStyle, "  Modified:")
+		h.print(" ", entry.Modified.UTC().Format(layout), "\n")
+	}
+	if len(entry.Credits) > 0 {
+		var names []string
+		for _, c := range entry.Credits {
+			names = append(names, c.Name)
+		}
+		h.style(keyStyle, "  Credits:")
+		h.print(" ", strings.Join(names, "; "), "\n")
+	}
+	for _, url := range osv.ReferenceURLs(entry, osv.ReferenceTypeFix) {
+		h.style(keyStyle, "  Fix:")
+		h.print(" ", url, "\n")
+	}
+}
+
+// severity prints a marker with the CVSS rating of entry,
+// if it is high or critical, to visually prioritize the
+// vulnerability. Markers are only shown in color mode.
+func (h *TextHandler) severity(entry *osv.Entry) {
+	if !h.showColor {
+		return
+	}
+	score, ok := cvss.OverriddenEntryScore(entry, h.severityOverrides)
+	if !ok {
+		return
+	}
+	switch rating := cvss.Rating(score); rating {
+	case cvss.RatingCritical:
+		h.print(" ")
+		h.style(criticalStyle, "[", rating, "]")
+	case cvss.RatingHigh:
+		h.print(" ")
+		h.style(highStyle, "[", rating, "]")
+	}
+}
+
 // pkg gives the package information for findings summaries
 // if one exists. This is only used to print package path
 // instead of a module for stdlib vulnerabilities at symbol
@@ -380,6 +553,9 @@ func (h *TextHandler) traces(traces []*findingSummary) {
 		}
 
 		h.print("      #", i+1, ": ")
+		if m := entry.WorkspaceModule; m != "" {
+			h.print("[", m, "] ")
+		}
 
 		if !h.showTraces { // show summarized traces
 			h.print(entry.Compact, "\n")
@@ -441,6 +617,38 @@ func (h *TextHandler) summary(c summaryCounters) {
 	}
 	h.print(".\n")
 
+	// print vulnerabilities that became fixable since the baseline scan
+	if h.baseline {
+		if ids := h.newlyFixable(); len(ids) == 0 {
+			h.print("No vulnerabilities became fixable since the baseline scan.\n")
+		} else {
+			h.wrap("", fmt.Sprintf("%d %s became fixable since the baseline scan: %s.",
+				len(ids), choose(len(ids) == 1, "vulnerability", "vulnerabilities"), strings.Join(ids, ", ")), 80)
+			h.print("\n")
+		}
+	}
+
+	// print module upgrades fixing all of their vulnerabilities at once
+	for _, u := range moduleUpgrades(h.atScanLevel(groupByVuln(h.findings))) {
+		name := u.Module
+		if name == internal.GoStdModulePath {
+			name = "the Go standard library"
+		}
+		if u.Version == "" {
+			h.wrap("", fmt.Sprintf("No single version of %s fixes all %d of its vulnerabilities.", name, u.Vulns), 80)
+		} else {
+			h.wrap("", fmt.Sprintf("Upgrade %s to %s to fix all %d of its vulnerabilities.", name, u.Version, u.Vulns), 80)
+		}
+		h.print("\n")
+	}
+
+	// warn that findings were dropped
+	if h.truncated {
+		first := choose(h.maxFindings == 1, "finding", fmt.Sprintf("%d findings", h.maxFindings))
+		h.wrap("", fmt.Sprintf("Reporting stopped after the first %s of each level, so there may be other vulnerabilities.", first), 80)
+		h.print("\n")
+	}
+
 	// print summary for vulnerabilities found at other levels of scan precision
 	if other := h.summaryOtherVulns(c); other != "" {
 		h.wrap("", other, 80)
@@ -454,6 +662,25 @@ func (h *TextHandler) summary(c summaryCounters) {
 	}
 }
 
+// newlyFixable returns the IDs of the vulnerabilities detected at the
+// precision of the scan level that became fixable since the baseline.
+func (h *TextHandler) newlyFixable() []string {
+	var ids []string
+	for _, findings := range h.atScanLevel(groupByVuln(h.findings)) {
+		if isNewlyFixable(findings) {
+			ids = append(ids, findings[0].Finding.OSV)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// atScanLevel returns the vulnerabilities in vulns that are
+// detected at the precision of the scan level of h.
+func (h *TextHandler) atScanLevel(vulns [][]*findingSummary) [][]*findingSummary {
+	return atScanLevel(h.scanLevel, vulns)
+}
+
 func (h *TextHandler) summaryOtherVulns(c summaryCounters) string {
 	var summary strings.Builder
 	if c.VulnerabilitiesRequired+c.VulnerabilitiesImported == 0 {
@@ -515,6 +742,10 @@ func (h *TextHandler) style(style style, values ...any) {
 			h.print(colorFaint, fgYellow)
 		case valueStyle:
 			h.print(colorBold, fgCyan)
+		case criticalStyle:
+			h.print(colorBold, fgWhite, bgRed)
+		case highStyle:
+			h.print(colorBold, fgRedHi)
 		}
 	}
 	h.print(values...)
@@ -0,0 +1,209 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/vulncheck"
+	"golang.org/x/vuln/internal/webhook"
+)
+
+func TestFailOnCalled(t *testing.T) {
+	imported := modFinding("GO-0000-0001", "example.com/a", "v1.0.1")
+	imported.Trace[0].Package = "example.com/a/p"
+	called := modFinding("GO-0000-0002", "example.com/b", "v1.0.1")
+	called.Trace[0].Package = "example.com/b/p"
+	called.Trace[0].Function = "F"
+
+	for _, test := range []struct {
+		name     string
+		level    govulncheck.ScanLevel
+		findings []*govulncheck.Finding
+		want     error
+	}{
+		// Module and package findings would fail the
+		// scan at their levels without FailOnCalled.
+		{"module", govulncheck.ScanLevelModule, []*govulncheck.Finding{modFinding("GO-0000-0001", "example.com/a", "v1.0.1")}, nil},
+		{"imported", govulncheck.ScanLevelPackage, []*govulncheck.Finding{imported}, nil},
+		{"called", govulncheck.ScanLevelSymbol, []*govulncheck.Finding{imported, called}, errVulnerabilitiesFound},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewTextHandler(&buf)
+			cfg := &govulncheck.Config{ScanLevel: test.level, FailOnCalled: true}
+			if err := h.Config(cfg); err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range test.findings {
+				if err := h.OSV(&osv.Entry{ID: f.OSV, DatabaseSpecific: &osv.DatabaseSpecific{}}); err != nil {
+					t.Fatal(err)
+				}
+				if err := h.Finding(f); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := h.Flush(); !errors.Is(err, test.want) {
+				t.Errorf("got %v; want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestSeverityExitCodes(t *testing.T) {
+	const (
+		highVector = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H" // 7.5
+		lowVector  = "CVSS:3.1/AV:N/AC:H/PR:N/UI:R/S:U/C:L/I:N/A:N" // 3.1
+	)
+	entries := []*osv.Entry{
+		severityEntry("GO-0000-0001", criticalVector),
+		severityEntry("GO-0000-0002", highVector),
+		severityEntry("GO-0000-0003", mediumVector),
+		severityEntry("GO-0000-0004", lowVector),
+	}
+	impFrame := &govulncheck.Frame{Module: "m", Package: "m/p"}
+	finding := func(id string, fr *govulncheck.Frame) *govulncheck.Finding {
+		return &govulncheck.Finding{OSV: id, Trace: []*govulncheck.Frame{fr}}
+	}
+	for _, test := range []struct {
+		name     string
+		findings []*govulncheck.Finding
+		want     int
+	}{
+		// The critical vulnerability is imported but
+		// not called, so it does not fail the scan.
+		{"high", []*govulncheck.Finding{
+			finding("GO-0000-0001", impFrame),
+			finding("GO-0000-0002", funcFrame),
+			finding("GO-0000-0003", funcFrame),
+		}, 6},
+		{"critical", []*govulncheck.Finding{
+			finding("GO-0000-0001", funcFrame),
+			finding("GO-0000-0002", funcFrame),
+		}, 4},
+		// Ratings that are not listed exit with status 3.
+		{"medium", []*govulncheck.Finding{
+			finding("GO-0000-0003", funcFrame),
+		}, 3},
+		// The highest rating is not listed, so
+		// the highest listed one sets the status.
+		{"medium and low", []*govulncheck.Finding{
+			finding("GO-0000-0003", funcFrame),
+			finding("GO-0000-0004", funcFrame),
+		}, 7},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewTextHandler(&buf)
+			cfg := &govulncheck.Config{
+				ScanLevel:         govulncheck.ScanLevelSymbol,
+				SeverityExitCodes: map[string]int{"critical": 4, "HIGH": 6, "Low": 7},
+			}
+			if err := h.Config(cfg); err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				e.DatabaseSpecific = &osv.DatabaseSpecific{}
+				if err := h.OSV(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, f := range test.findings {
+				if err := h.Finding(f); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var code int
+			if err, ok := h.Flush().(interface{ ExitCode() int }); ok {
+				code = err.ExitCode()
+			}
+			if code != test.want {
+				t.Errorf("got exit code %d; want %d", code, test.want)
+			}
+		})
+	}
+}
+
+func TestWebhookExitCode(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		status int
+	}{
+		{"posted", http.StatusOK},
+		{"failed", http.StatusInternalServerError},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+			}))
+			defer srv.Close()
+
+			var buf bytes.Buffer
+			h := webhook.NewHandler(NewTextHandler(&buf), srv.URL, "", srv.Client())
+			if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.OSV(&osv.Entry{ID: "GO-0000-0001", DatabaseSpecific: &osv.DatabaseSpecific{}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{funcFrame}}); err != nil {
+				t.Fatal(err)
+			}
+			err := Flush(h)
+			var exit interface{ ExitCode() int }
+			if !errors.As(err, &exit) || exit.ExitCode() != 3 {
+				t.Fatalf("got %v; want an error with exit code 3", err)
+			}
+			if posted := err == errVulnerabilitiesFound; posted != (test.status == http.StatusOK) {
+				t.Errorf("got %v; want the webhook error only if posting failed", err)
+			}
+		})
+	}
+}
+
+func TestMaxFindingsTruncation(t *testing.T) {
+	msg := fmt.Sprintf(vulncheck.TruncatedFindingsMessage, 1)
+	for _, test := range []struct {
+		name      string
+		progress  *govulncheck.Progress
+		truncated bool
+	}{
+		// Exactly MaxFindings findings do not imply truncation.
+		{"none", nil, false},
+		// Truncation is told by the progress, not its message.
+		{"message", &govulncheck.Progress{Message: msg}, false},
+		{"truncated", &govulncheck.Progress{Message: msg, Truncated: true}, true},
+	} {
+		var buf bytes.Buffer
+		h := NewTextHandler(&buf)
+		if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelModule, MaxFindings: 1}); err != nil {
+			t.Fatal(err)
+		}
+		f := modFinding("GO-0000-0001", "example.com/a", "v1.0.1")
+		if err := h.OSV(&osv.Entry{ID: f.OSV, DatabaseSpecific: &osv.DatabaseSpecific{}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+		if test.progress != nil {
+			if err := h.Progress(test.progress); err != nil {
+				t.Fatal(err)
+			}
+		}
+		h.Flush()
+		if got := strings.Contains(buf.String(), "Reporting stopped"); got != test.truncated {
+			t.Errorf("%s: got truncation warning %t; want %t", test.name, got, test.truncated)
+		}
+	}
+}
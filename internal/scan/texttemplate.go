@@ -0,0 +1,190 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+
+	"golang.org/x/vuln/internal/cvss"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// DefaultTextTemplate is a template rendering findings
+// in the style of the text output.
+const DefaultTextTemplate = `Vulnerability: {{.OSV}}{{with .Severity}} [{{.}}]{{end}}
+    {{.Summary}}
+  More info: {{.URL}}
+  Module: {{.Module}}
+    Found in: {{.Module}}@{{.Version}}
+    Fixed in: {{if .FixedVersion}}{{.Module}}@{{.FixedVersion}}{{else}}N/A{{end}}
+{{- if .Symbol}}
+    Symbol: {{.Symbol}}
+{{- range .Trace}}
+      {{.}}
+{{- end}}
+{{- end}}
+
+`
+
+// TemplateFinding is the data rendered by
+// the template of a template handler.
+type TemplateFinding struct {
+	// OSV is the ID of the vulnerability.
+	OSV string
+	// Aliases are the aliases of the vulnerability, such as CVE IDs.
+	Aliases []string
+	// Summary is the summary of the vulnerability,
+	// or its details if it has no summary.
+	Summary string
+	// URL is the URL of the vulnerability report.
+	URL string
+	// Severity is the CVSS rating of the vulnerability,
+	// such as "HIGH", or empty if it has no CVSS severity.
+	Severity string
+	// Level is the level of the finding: "called",
+	// "imported", or "required".
+	Level string
+
+	// Module and Version identify the vulnerable module.
+	Module  string
+	Version string
+	// Package is the vulnerable package, if known.
+	Package string
+	// Symbol is the vulnerable symbol, if called.
+	Symbol string
+	// FixedVersion is the version of Module fixing the
+	// vulnerability, if any.
+	FixedVersion string
+	// Trace is the call stack reaching Symbol, from
+	// the entry point to Symbol, with a line per frame.
+	Trace []string
+
+	// Finding is the underlying finding.
+	Finding *govulncheck.Finding
+}
+
+// templateHandler is a Handler that renders
+// each finding with a text/template.
+type templateHandler struct {
+	w        io.Writer
+	tmpl     *template.Template
+	osvs     []*osv.Entry
+	findings []*findingSummary
+	// overrides are the severity overrides of the config.
+	overrides map[string]string
+	// exit decides whether the findings
+	// fail the scan, as in the text output.
+	exit exitPolicy
+}
+
+// NewTemplateHandler returns a handler that writes, for each
+// vulnerability, its findings at the most precise level found,
+// rendered with the text/template text executed on a
+// TemplateFinding. It returns an error if text is not a valid
+// template.
+func NewTemplateHandler(w io.Writer, text string) (govulncheck.Handler, error) {
+	tmpl, err := template.New("finding").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid text template: %w", err)
+	}
+	return &templateHandler{w: w, tmpl: tmpl}, nil
+}
+
+func (h *templateHandler) Config(config *govulncheck.Config) error {
+	h.overrides = config.SeverityOverrides
+	h.exit = newExitPolicy(config)
+	return nil
+}
+
+func (h *templateHandler) SBOM(sbom *govulncheck.SBOM) error {
+	return nil
+}
+
+func (h *templateHandler) Progress(progress *govulncheck.Progress) error {
+	return nil
+}
+
+func (h *templateHandler) OSV(entry *osv.Entry) error {
+	h.osvs = append(h.osvs, entry)
+	return nil
+}
+
+// Finding gathers vulnerability findings to be rendered.
+func (h *templateHandler) Finding(finding *govulncheck.Finding) error {
+	if err := govulncheck.ValidateFinding(finding); err != nil {
+		return err
+	}
+	h.findings = append(h.findings, newFindingSummary(finding))
+	return nil
+}
+
+// Flush renders the findings gathered by h. Like the text output,
+// it then reports whether vulnerabilities were found, which is the
+// exit status of the scan.
+func (h *templateHandler) Flush() error {
+	fixupFindings(h.osvs, h.findings)
+	for _, findings := range groupByVuln(h.findings) {
+		level := govulncheck.Level(mostPrecise(findings).Finding)
+		for _, f := range findings {
+			if govulncheck.Level(f.Finding) != level {
+				continue
+			}
+			if err := h.tmpl.Execute(h.w, templateFinding(f, h.overrides)); err != nil {
+				return err
+			}
+		}
+	}
+	err := h.exit.err(h.findings)
+	h.findings = nil
+	return err
+}
+
+// templateFinding returns the template data for f, whose
+// severity is subject to the severity overrides.
+func templateFinding(f *findingSummary, overrides map[string]string) *TemplateFinding {
+	frame := f.Trace[0]
+	tf := &TemplateFinding{
+		OSV:          f.OSV.ID,
+		Aliases:      f.OSV.Aliases,
+		Summary:      f.OSV.Summary,
+		Module:       frame.Module,
+		Version:      moduleVersionString(frame.Module, frame.Version),
+		Package:      frame.Package,
+		Symbol:       symbol(frame, false),
+		FixedVersion: moduleVersionString(frame.Module, f.FixedVersion),
+		Finding:      f.Finding,
+	}
+	if tf.Summary == "" {
+		tf.Summary = f.OSV.Details
+	}
+	if f.OSV.DatabaseSpecific != nil {
+		tf.URL = f.OSV.DatabaseSpecific.URL
+	}
+	if score, ok := cvss.OverriddenEntryScore(f.OSV, overrides); ok {
+		tf.Severity = cvss.Rating(score)
+	}
+	switch govulncheck.Level(f.Finding) {
+	case govulncheck.ScanLevelSymbol:
+		tf.Level = "called"
+	case govulncheck.ScanLevelPackage:
+		tf.Level = "imported"
+	default:
+		tf.Level = "required"
+	}
+	if tf.Symbol != "" {
+		for i := len(f.Trace) - 1; i >= 0; i-- {
+			fr := f.Trace[i]
+			line := symbol(fr, false)
+			if fr.Position != nil {
+				line += " @ " + symbolPath(fr)
+			}
+			tf.Trace = append(tf.Trace, line)
+		}
+	}
+	return tf
+}
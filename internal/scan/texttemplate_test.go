@@ -0,0 +1,117 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scan
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+func runTemplateHandler(t *testing.T, text string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	h, err := NewTemplateHandler(&buf, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []*osv.Entry{
+		{ID: "GO-0000-0001", Summary: "first", Aliases: []string{"CVE-0000-0001"}, Severity: []osv.Severity{{Type: osv.SeverityTypeCVSSV3, Score: criticalVector}}},
+		{ID: "GO-0000-0002", Summary: "second"},
+	} {
+		if err := h.OSV(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []*govulncheck.Finding{
+		{OSV: "GO-0000-0001", FixedVersion: "v1.2.0", Trace: []*govulncheck.Frame{{Module: "m", Version: "v1.0.0"}}},
+		{OSV: "GO-0000-0001", FixedVersion: "v1.2.0", Trace: []*govulncheck.Frame{
+			{Module: "m", Version: "v1.0.0", Package: "m/p", Function: "F"},
+			{Module: "main", Package: "main", Function: "main", Position: &govulncheck.Position{Filename: "main.go", Line: 7, Column: 3}},
+		}},
+		{OSV: "GO-0000-0002", Trace: []*govulncheck.Frame{{Module: "n", Version: "v0.1.0", Package: "n/q"}}},
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Flush(h); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestTemplateHandlerCSV(t *testing.T) {
+	const csv = `{{.OSV}},{{.Severity}},{{.Level}},{{.Module}}@{{.Version}},{{.FixedVersion}},{{.Symbol}},{{len .Trace}}` + "\n"
+	got := runTemplateHandler(t, csv)
+	// Only the most precise findings of each vulnerability are
+	// rendered, in the order of the text output.
+	want := `GO-0000-0002,,imported,n@v0.1.0,,,0
+GO-0000-0001,CRITICAL,called,m@v1.0.0,v1.2.0,m/p.F,2
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestTemplateHandlerDefault(t *testing.T) {
+	got := runTemplateHandler(t, DefaultTextTemplate)
+	for _, want := range []string{
+		"Vulnerability: GO-0000-0001 [CRITICAL]\n    first\n",
+		"    Fixed in: m@v1.2.0\n    Symbol: m/p.F\n      main.main @ main/main.go:7:3\n      m/p.F\n",
+		"Vulnerability: GO-0000-0002\n",
+		"    Fixed in: N/A\n\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want output containing %q; got:\n%s", want, got)
+		}
+	}
+}
+
+func TestTemplateHandlerInvalid(t *testing.T) {
+	if _, err := NewTemplateHandler(&bytes.Buffer{}, "{{.OSV"); err == nil {
+		t.Error("want error for invalid template")
+	}
+}
+
+func TestTemplateHandlerExitCode(t *testing.T) {
+	imported := &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m", Version: "v1.0.0", Package: "m/p"}}}
+	called := &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m", Version: "v1.0.0", Package: "m/p", Function: "F"}}}
+	for _, test := range []struct {
+		name    string
+		level   govulncheck.ScanLevel
+		finding *govulncheck.Finding
+		want    error
+	}{
+		{"called", govulncheck.ScanLevelSymbol, called, errVulnerabilitiesFound},
+		{"imported", govulncheck.ScanLevelSymbol, imported, nil},
+		{"imported-package", govulncheck.ScanLevelPackage, imported, errVulnerabilitiesFound},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h, err := NewTemplateHandler(&buf, DefaultTextTemplate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Config(&govulncheck.Config{ScanLevel: test.level}); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.OSV(&osv.Entry{ID: "GO-0000-0001"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Finding(test.finding); err != nil {
+				t.Fatal(err)
+			}
+			// The exit status is that of the text output.
+			if err := Flush(h); err != test.want {
+				t.Errorf("got %v; want %v", err, test.want)
+			}
+		})
+	}
+}
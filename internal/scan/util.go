@@ -5,7 +5,6 @@
 package scan
 
 import (
-	"fmt"
 	"os"
 	"os/exec"
 	"strings"
@@ -14,47 +13,75 @@ import (
 	"golang.org/x/vuln/internal/govulncheck"
 )
 
-// validateFindings checks that the supplied findings all obey the protocol
-// rules.
-func validateFindings(findings ...*govulncheck.Finding) error {
-	for _, f := range findings {
-		if f.OSV == "" {
-			return fmt.Errorf("invalid finding: all findings must have an associated OSV")
-		}
-		if len(f.Trace) < 1 {
-			return fmt.Errorf("invalid finding: all callstacks must have at least one frame")
-		}
-		for _, frame := range f.Trace {
-			if frame.Version != "" && frame.Module == "" {
-				return fmt.Errorf("invalid finding: if Frame.Version (%s) is set, Frame.Module must also be", frame.Version)
-			}
-			if frame.Package != "" && frame.Module == "" {
-				return fmt.Errorf("invalid finding: if Frame.Package (%s) is set, Frame.Module must also be", frame.Package)
-			}
-			if frame.Function != "" && frame.Package == "" {
-				return fmt.Errorf("invalid finding: if Frame.Function (%s) is set, Frame.Package must also be", frame.Function)
-			}
-		}
-	}
-	return nil
-}
-
 func moduleVersionString(modulePath, version string) string {
 	if version == "" {
 		return ""
 	}
-	if modulePath == internal.GoStdModulePath || modulePath == internal.GoCmdModulePath {
+	// Versions of the standard library may already be Go releases
+	// when Config.GoFixedVersions is set.
+	if (modulePath == internal.GoStdModulePath || modulePath == internal.GoCmdModulePath) && !strings.HasPrefix(version, "go") {
 		version = semverToGoTag(version)
 	}
 	return version
 }
 
+// affectedString returns a human-readable description of the
+// affected ranges of versions of modulePath, such as
+// ">=v1.2.0, <v1.5.3; >=v2.0.0, <v2.0.1".
+func affectedString(modulePath string, ranges []*govulncheck.AffectedRange) string {
+	var descs []string
+	for _, r := range ranges {
+		var bounds []string
+		if r.Introduced != "" {
+			bounds = append(bounds, ">="+moduleVersionString(modulePath, r.Introduced))
+		}
+		if r.Fixed != "" {
+			bounds = append(bounds, "<"+moduleVersionString(modulePath, r.Fixed))
+		}
+		if r.LastAffected != "" {
+			bounds = append(bounds, "<="+moduleVersionString(modulePath, r.LastAffected))
+		}
+		if len(bounds) == 0 {
+			return "all versions"
+		}
+		descs = append(descs, strings.Join(bounds, ", "))
+	}
+	return strings.Join(descs, "; ")
+}
+
 func gomodExists(dir string) bool {
+	return gomod(dir) != ""
+}
+
+// gomod returns the path of the go.mod file of the
+// main module in dir, or the empty string if none.
+func gomod(dir string) string {
 	cmd := exec.Command("go", "env", "GOMOD")
 	cmd.Dir = dir
 	out, err := cmd.Output()
 	output := strings.TrimSpace(string(out))
 	// If module-aware mode is enabled, but there is no go.mod, GOMOD will be os.DevNull
 	// If module-aware mode is disabled, GOMOD will be the empty string.
-	return err == nil && !(output == os.DevNull || output == "")
+	if err != nil || output == os.DevNull {
+		return ""
+	}
+	return output
+}
+
+// debugEnabled reports whether the debugging option name is listed
+// in the comma separated GOVULNCHECK_DEBUG variable of env. The last
+// setting of the variable in env takes precedence.
+//
+// The options are "validate", which makes govulncheck fail on OSV
+// entries and findings that do not conform to the JSON protocol, and
+// "advisories", which lists the OSV entries considered by the scan on
+// standard error, as does Config.ListAdvisories.
+func debugEnabled(env []string, name string) bool {
+	value, _ := getenv(env, "GOVULNCHECK_DEBUG")
+	for _, opt := range strings.Split(value, ",") {
+		if strings.TrimSpace(opt) == name {
+			return true
+		}
+	}
+	return false
 }
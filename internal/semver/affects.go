@@ -35,10 +35,11 @@ func Affects(a []osv.Range, v string) bool {
 // ContainsSemver checks if semver version v is in the
 // range encoded by ar. If ar is not a semver range,
 // returns false. A range is interpreted as a left-closed
-// and right-open interval.
+// and right-open interval, unless it is closed by a
+// LastAffected event, in which case it is right-closed.
 //
 // Assumes that
-//   - exactly one of Introduced or Fixed fields is set
+//   - exactly one of Introduced, Fixed, or LastAffected fields is set
 //   - ranges in ar are not overlapping
 //   - beginning of time is encoded with .Introduced="0"
 //   - no-fix is not an event, as opposed to being an
@@ -55,10 +56,28 @@ func ContainsSemver(ar osv.Range, v string) bool {
 	// versions prefixed with 'v', and versions prefixed with 'go'.
 	v = canonicalizeSemverPrefix(v)
 
-	// Sort events by semver versions. Event for beginning
-	// of time, if present, always comes first.
-	sort.SliceStable(ar.Events, func(i, j int) bool {
-		e1 := ar.Events[i]
+	SortEvents(ar.Events)
+
+	var affected bool
+	for _, e := range ar.Events {
+		if !affected && e.Introduced != "" {
+			affected = e.Introduced == "0" || !Less(v, e.Introduced)
+		} else if affected && e.Fixed != "" {
+			affected = Less(v, e.Fixed)
+		} else if affected && e.LastAffected != "" {
+			affected = !Less(e.LastAffected, v)
+		}
+	}
+
+	return affected
+}
+
+// SortEvents sorts events by their semver versions.
+// The event for the beginning of time, if present,
+// always comes first.
+func SortEvents(events []osv.RangeEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		e1 := events[i]
 		v1 := e1.Introduced
 		if v1 == "0" {
 			// -inf case.
@@ -66,9 +85,11 @@ func ContainsSemver(ar osv.Range, v string) bool {
 		}
 		if e1.Fixed != "" {
 			v1 = e1.Fixed
+		} else if e1.LastAffected != "" {
+			v1 = e1.LastAffected
 		}
 
-		e2 := ar.Events[j]
+		e2 := events[j]
 		v2 := e2.Introduced
 		if v2 == "0" {
 			// -inf case.
@@ -76,19 +97,10 @@ func ContainsSemver(ar osv.Range, v string) bool {
 		}
 		if e2.Fixed != "" {
 			v2 = e2.Fixed
+		} else if e2.LastAffected != "" {
+			v2 = e2.LastAffected
 		}
 
 		return Less(v1, v2)
 	})
-
-	var affected bool
-	for _, e := range ar.Events {
-		if !affected && e.Introduced != "" {
-			affected = e.Introduced == "0" || !Less(v, e.Introduced)
-		} else if affected && e.Fixed != "" {
-			affected = Less(v, e.Fixed)
-		}
-	}
-
-	return affected
 }
@@ -126,6 +126,24 @@ func TestAffectsSemver(t *testing.T) {
 			version: "go3.0.1",
 			want:    true,
 		},
+		{
+			// v1.2.0 <= last affected v1.2.0
+			affects: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "0"}, {LastAffected: "1.2.0"}}}},
+			version: "v1.2.0",
+			want:    true,
+		},
+		{
+			// v1.2.1 > last affected v1.2.0
+			affects: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{LastAffected: "1.2.0"}, {Introduced: "1.0.0"}}}},
+			version: "v1.2.1",
+			want:    false,
+		},
+		{
+			// v0.9.0 < introduced v1.0.0
+			affects: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "1.0.0"}, {LastAffected: "1.2.0"}}}},
+			version: "v0.9.0",
+			want:    false,
+		},
 	}
 
 	for _, c := range cases {
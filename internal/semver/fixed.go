@@ -4,7 +4,11 @@
 
 package semver
 
-import "golang.org/x/vuln/internal/osv"
+import (
+	"sort"
+
+	"golang.org/x/vuln/internal/osv"
+)
 
 // NonSupersededFix returns a fixed version from ranges
 // that is not superseded by any other fix or any other
@@ -34,3 +38,41 @@ func NonSupersededFix(ranges []osv.Range) string {
 	}
 	return latestFixed
 }
+
+// CommonFix returns the earliest fixed version in rangeSets that is
+// greater than v and that is not affected by any of the rangeSets.
+// Each element of rangeSets describes the affected ranges of a module
+// for a single vulnerability, so upgrading to the returned version
+// fixes all of them at once.
+//
+// Returns "" if there is no such version, for instance when the
+// vulnerabilities are fixed on different, incompatible branches.
+func CommonFix(v string, rangeSets [][]osv.Range) string {
+	var fixes []string
+	for _, ranges := range rangeSets {
+		for _, r := range ranges {
+			if r.Type != osv.RangeTypeSemver {
+				continue
+			}
+			for _, e := range r.Events {
+				if e.Fixed != "" && Less(v, e.Fixed) {
+					fixes = append(fixes, e.Fixed)
+				}
+			}
+		}
+	}
+	sort.SliceStable(fixes, func(i, j int) bool { return Less(fixes[i], fixes[j]) })
+	for _, fix := range fixes {
+		affected := false
+		for _, ranges := range rangeSets {
+			if Affects(ranges, fix) {
+				affected = true
+				break
+			}
+		}
+		if !affected {
+			return fix
+		}
+	}
+	return ""
+}
@@ -138,3 +138,62 @@ func TestNonSupersededFix(t *testing.T) {
 		})
 	}
 }
+
+func TestCommonFix(t *testing.T) {
+	semverRanges := func(events ...osv.RangeEvent) []osv.Range {
+		return []osv.Range{{Type: osv.RangeTypeSemver, Events: events}}
+	}
+	tests := []struct {
+		name      string
+		version   string
+		rangeSets [][]osv.Range
+		want      string
+	}{
+		{
+			name:    "different fixes",
+			version: "v1.1.0",
+			rangeSets: [][]osv.Range{
+				semverRanges(osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.2.0"}),
+				semverRanges(osv.RangeEvent{Introduced: "1.0.0"}, osv.RangeEvent{Fixed: "1.4.0"}),
+			},
+			want: "1.4.0",
+		},
+		{
+			name:    "reintroduced",
+			version: "v1.1.0",
+			rangeSets: [][]osv.Range{
+				semverRanges(osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.2.0"},
+					osv.RangeEvent{Introduced: "1.3.0"}, osv.RangeEvent{Fixed: "1.5.0"}),
+				semverRanges(osv.RangeEvent{Introduced: "1.0.0"}, osv.RangeEvent{Fixed: "1.4.0"}),
+			},
+			want: "1.5.0",
+		},
+		{
+			name:    "incompatible branches",
+			version: "v1.2.0",
+			rangeSets: [][]osv.Range{
+				semverRanges(osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.2.5"},
+					osv.RangeEvent{Introduced: "1.3.0"}),
+				semverRanges(osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.3.1"}),
+			},
+			want: "",
+		},
+		{
+			name:    "no fix",
+			version: "v1.2.0",
+			rangeSets: [][]osv.Range{
+				semverRanges(osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.2.5"}),
+				semverRanges(osv.RangeEvent{Introduced: "0"}),
+			},
+			want: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := CommonFix(test.version, test.rangeSets); got != test.want {
+				t.Errorf("want %q; got %q", test.want, got)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strings"
 
+	"golang.org/x/mod/module"
 	"golang.org/x/mod/semver"
 )
 
@@ -52,6 +53,27 @@ func Valid(v string) bool {
 	return semver.IsValid(canonicalizeSemverPrefix(v))
 }
 
+// DescribePseudoVersion returns a readable description of the
+// pseudo-version v, such as "commit abcdef123456 from 2023-01-01"
+// for v0.0.0-20230101000000-abcdef123456, naming the revision and
+// the UTC date of the commit it refers to. It returns an empty
+// string if v, with or without a "v" prefix, is not a pseudo-version.
+func DescribePseudoVersion(v string) string {
+	v = addSemverPrefix(v)
+	if !module.IsPseudoVersion(v) {
+		return ""
+	}
+	rev, err := module.PseudoVersionRev(v)
+	if err != nil {
+		return ""
+	}
+	t, err := module.PseudoVersionTime(v)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("commit %s from %s", rev, t.Format("2006-01-02"))
+}
+
 var (
 	// Regexp for matching go tags. The groups are:
 	// 1  the major.minor version
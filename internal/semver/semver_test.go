@@ -60,6 +60,25 @@ func TestSemverToGoTag(t *testing.T) {
 	}
 }
 
+func TestDescribePseudoVersion(t *testing.T) {
+	for _, test := range []struct {
+		v    string
+		want string
+	}{
+		{"v0.0.0-20230101000000-abcdef123456", "commit abcdef123456 from 2023-01-01"},
+		{"0.0.0-20230101000000-abcdef123456", "commit abcdef123456 from 2023-01-01"},
+		{"v1.2.4-0.20231231235959-0123456789ab", "commit 0123456789ab from 2023-12-31"},
+		{"v1.2.3-pre.0.20220615120000-fedcba987654", "commit fedcba987654 from 2022-06-15"},
+		{"v1.2.3", ""},
+		{"v1.2.3-rc.1", ""},
+		{"", ""},
+	} {
+		if got := DescribePseudoVersion(test.v); got != test.want {
+			t.Errorf("DescribePseudoVersion(%q): want %q; got %q", test.v, test.want, got)
+		}
+	}
+}
+
 func TestLess(t *testing.T) {
 	for _, test := range []struct {
 		v1   string
@@ -72,6 +91,11 @@ func TestLess(t *testing.T) {
 		{"go1.12.2", "go1.18", true},
 		{"v1.20.0-pre4", "v1.20.0-pre.4", false},
 		{"v1.20.0-pre4", "v1.20.0-pre4.4", true},
+		{"v1.9.0", "v1.10.0", true},
+		{"1.10.0", "v1.9.0", false},
+		{"v0.0.0-20200101000000-aaaaaaaaaaaa", "v0.0.0-20210101000000-bbbbbbbbbbbb", true},
+		{"v0.0.0-20210101000000-bbbbbbbbbbbb", "v0.1.0", true},
+		{"v2.9.0+incompatible", "v2.10.0+incompatible", true},
 	} {
 		if got := Less(test.v1, test.v2); got != test.want {
 			t.Errorf("want Less(%s, %s)=%t; got %t", test.v1, test.v2, test.want, got)
@@ -0,0 +1,81 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package semver
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/vuln/internal/osv"
+)
+
+// ModuleVuln is a vulnerability of a module in use.
+type ModuleVuln struct {
+	// Module is the path of the module.
+	Module string
+	// Version of Module in use.
+	Version string
+	// Fixed is the version of Module fixing the
+	// vulnerability, or empty if there is none.
+	Fixed string
+	// Entry is the OSV entry of the vulnerability, if known.
+	Entry *osv.Entry
+}
+
+// Upgrade is the upgrade of a module to a
+// version fixing all of its vulnerabilities.
+type Upgrade struct {
+	Module string
+	// Version fixing all vulnerabilities of Module. It is
+	// empty if no single version fixes all of them.
+	Version string
+	// Vulns is the number of vulnerabilities of Module.
+	Vulns int
+}
+
+// Upgrades computes, for each module with several vulnerabilities in
+// vulns that have different fixed versions, the version of the module
+// fixing all of them, if any, see CommonFix. Modules with a vulnerability
+// without a fix are skipped, since there is nothing to recommend for
+// them. Upgrades are sorted by module.
+func Upgrades(vulns []ModuleVuln) []Upgrade {
+	type modVulns struct {
+		version   string
+		fixes     map[string]bool
+		rangeSets [][]osv.Range
+	}
+	mods := make(map[string]*modVulns)
+	for _, v := range vulns {
+		mv := mods[v.Module]
+		if mv == nil {
+			mv = &modVulns{version: v.Version, fixes: make(map[string]bool)}
+			mods[v.Module] = mv
+		}
+		mv.fixes[v.Fixed] = true
+		var ranges []osv.Range
+		if v.Entry != nil {
+			for _, a := range v.Entry.Affected {
+				if a.Module.Path == v.Module {
+					ranges = append(ranges, a.Ranges...)
+				}
+			}
+		}
+		mv.rangeSets = append(mv.rangeSets, ranges)
+	}
+
+	var upgrades []Upgrade
+	for mod, mv := range mods {
+		if mv.fixes[""] || len(mv.fixes) < 2 {
+			continue
+		}
+		u := Upgrade{Module: mod, Vulns: len(mv.rangeSets)}
+		if fix := CommonFix(mv.version, mv.rangeSets); fix != "" {
+			u.Version = "v" + strings.TrimPrefix(fix, "v")
+		}
+		upgrades = append(upgrades, u)
+	}
+	sort.Slice(upgrades, func(i, j int) bool { return upgrades[i].Module < upgrades[j].Module })
+	return upgrades
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package semver
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/osv"
+)
+
+func TestUpgrades(t *testing.T) {
+	vuln := func(mod, fixed string, events ...osv.RangeEvent) ModuleVuln {
+		e := &osv.Entry{Affected: []osv.Affected{{
+			Module: osv.Module{Path: mod},
+			Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: events}},
+		}}}
+		return ModuleVuln{Module: mod, Version: "v1.0.0", Fixed: fixed, Entry: e}
+	}
+	vulns := []ModuleVuln{
+		vuln("example.com/a", "v1.2.0", osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.2.0"}),
+		vuln("example.com/a", "v1.1.0", osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.1.0"}),
+		// Fixed on incompatible branches.
+		vuln("example.com/b", "v1.0.1", osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.0.1"}, osv.RangeEvent{Introduced: "1.1.0"}),
+		vuln("example.com/b", "v1.1.0", osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.1.0"}),
+		// Not fixed.
+		vuln("example.com/c", "v1.1.0", osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.1.0"}),
+		vuln("example.com/c", "", osv.RangeEvent{Introduced: "0"}),
+		// A single fixed version.
+		vuln("example.com/d", "v1.1.0", osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.1.0"}),
+		vuln("example.com/d", "v1.1.0", osv.RangeEvent{Introduced: "0"}, osv.RangeEvent{Fixed: "1.1.0"}),
+	}
+	want := []Upgrade{
+		{Module: "example.com/a", Version: "v1.2.0", Vulns: 2},
+		{Module: "example.com/b", Vulns: 2},
+	}
+	if diff := cmp.Diff(want, Upgrades(vulns)); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spdx
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+type findingLevel int
+
+const (
+	invalid findingLevel = iota
+	required
+	imported
+	called
+)
+
+type handler struct {
+	w   io.Writer
+	cfg *govulncheck.Config
+	// now returns the time of creation of the document.
+	now  func() time.Time
+	osvs map[string]*osv.Entry
+	// findings contains same-level findings for an
+	// OSV at the most precise level of granularity
+	// available.
+	findings map[string][]*govulncheck.Finding
+}
+
+// NewHandler returns a handler that writes findings to w as
+// an SPDX 3.0 document once all of them are known.
+func NewHandler(w io.Writer) *handler {
+	return &handler{
+		w:        w,
+		now:      time.Now,
+		osvs:     make(map[string]*osv.Entry),
+		findings: make(map[string][]*govulncheck.Finding),
+	}
+}
+
+func (h *handler) Config(cfg *govulncheck.Config) error {
+	h.cfg = cfg
+	return nil
+}
+
+func (h *handler) Progress(progress *govulncheck.Progress) error {
+	return nil
+}
+
+func (h *handler) SBOM(s *govulncheck.SBOM) error {
+	return nil
+}
+
+func (h *handler) OSV(e *osv.Entry) error {
+	h.osvs[e.ID] = e
+	return nil
+}
+
+// foundAtLevel returns the level at which f is
+// present in the scanned product.
+func foundAtLevel(f *govulncheck.Finding) findingLevel {
+	switch {
+	case govulncheck.IsCalled(f):
+		return called
+	case govulncheck.IsImported(f):
+		return imported
+	default:
+		return required
+	}
+}
+
+// Finding keeps f if it is at least as precise
+// as the findings of its OSV kept so far.
+func (h *handler) Finding(f *govulncheck.Finding) error {
+	fs := h.findings[f.OSV]
+	switch {
+	case len(fs) == 0 || foundAtLevel(f) > foundAtLevel(fs[0]):
+		fs = []*govulncheck.Finding{f}
+	case foundAtLevel(f) == foundAtLevel(fs[0]):
+		fs = append(fs, f)
+	}
+	h.findings[f.OSV] = fs
+	return nil
+}
+
+// Streaming returns false as the SPDX output is a single
+// JSON document that can only be produced once all
+// findings are known.
+func (h *handler) Streaming() bool {
+	return false
+}
+
+// Flush writes the SPDX document to w.
+func (h *handler) Flush() error {
+	doc := toSPDX(h, h.now())
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = h.w.Write(out)
+	return err
+}
+
+// module is a module at a version.
+type module struct {
+	path    string
+	version string
+}
+
+// builder accumulates the elements of a document
+// whose IDs are relative to a namespace.
+type builder struct {
+	ns       string
+	packages map[module]*Package
+	vulns    []any
+	rels     []any
+}
+
+func (b *builder) element(typ, id, name string) Element {
+	return Element{Type: typ, ID: b.ns + id, CreationInfo: creationInfoID, Name: name}
+}
+
+// pkg returns the ID of the package of m, adding it if needed.
+func (b *builder) pkg(m module) string {
+	if p, ok := b.packages[m]; ok {
+		return p.ID
+	}
+	p := &Package{
+		Element:    b.element(typePackage, "package-"+url.PathEscape(m.path+"@"+m.version), m.path),
+		Version:    m.version,
+		PackageURL: govulncheck.PURL(m.path, m.version),
+	}
+	b.packages[m] = p
+	return p.ID
+}
+
+// relationship returns a Relationship of type
+// typ from the element from to the elements to.
+func (b *builder) relationship(typ, relType, from string, to ...string) Relationship {
+	return Relationship{
+		Element:          b.element(typ, fmt.Sprintf("relationship-%d", len(b.rels)+1), ""),
+		RelationshipType: relType,
+		From:             from,
+		To:               to,
+	}
+}
+
+// creationInfoID is the blank node ID of the
+// CreationInfo shared by all elements.
+const creationInfoID = "_:creationinfo"
+
+// toSPDX returns the SPDX document of the findings of h, created at
+// the time created. The IDs of its elements are in a namespace derived
+// from a hash of the findings, so that they are stable across runs.
+func toSPDX(h *handler, created time.Time) Document {
+	var scanLevel findingLevel
+	switch h.cfg.ScanLevel {
+	case govulncheck.ScanLevelModule:
+		scanLevel = required
+	case govulncheck.ScanLevelPackage:
+		scanLevel = imported
+	case govulncheck.ScanLevelSymbol:
+		scanLevel = called
+	}
+
+	var ids []string
+	for id, fs := range h.findings {
+		// Findings of OSVs not reported by the handler
+		// cannot be described, which should not happen.
+		if len(fs) > 0 && h.osvs[id] != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	b := &builder{ns: Namespace + hashFindings(h, ids) + "#", packages: make(map[module]*Package)}
+	for _, id := range ids {
+		e := h.osvs[id]
+		vuln := vulnerability(b, e)
+		b.vulns = append(b.vulns, vuln)
+
+		fs := h.findings[id]
+		level := foundAtLevel(fs[0])
+		for _, m := range modules(fs) {
+			pkg := b.pkg(m)
+			b.rels = append(b.rels, b.relationship(typeRelationship, relAssociated, pkg, vuln.ID))
+
+			fixed := fixedVersion(fs, m)
+			var a Assessment
+			switch {
+			case level >= scanLevel && level == required:
+				// A module scan only tells that the vulnerable module
+				// is required, which is not enough to decide whether
+				// the package is affected.
+				a.Relationship = b.relationship(typeUnderInvestigation, relUnderInvestigation, vuln.ID, pkg)
+			case level >= scanLevel:
+				a.Relationship = b.relationship(typeAffected, relAffects, vuln.ID, pkg)
+				a.ActionStatement = "No fixed version is available."
+				if fixed != "" {
+					a.ActionStatement = fmt.Sprintf("Upgrade %s to %s.", m.path, fixed)
+				}
+			default:
+				a.Relationship = b.relationship(typeNotAffected, relDoesNotAffect, vuln.ID, pkg)
+				a.ImpactStatement = Impact
+				// We only reach this case for imported findings
+				// in symbol scans or required findings otherwise.
+				a.Justification = JustificationNotPresent
+				if level == imported {
+					a.Justification = JustificationNotInExecutePath
+				}
+			}
+			b.rels = append(b.rels, a)
+			if fixed != "" {
+				fixedPkg := b.pkg(module{path: m.path, version: fixed})
+				b.rels = append(b.rels, Assessment{Relationship: b.relationship(typeFixed, relFixedIn, vuln.ID, fixedPkg)})
+			}
+		}
+	}
+
+	var pkgs []*Package
+	for _, p := range b.packages {
+		pkgs = append(pkgs, p)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].ID < pkgs[j].ID })
+
+	doc := SpdxDocument{
+		Element:            b.element(typeDocument, "document", "govulncheck"),
+		ProfileConformance: []string{"core", "software", "security"},
+	}
+	graph := []any{
+		CreationInfo{
+			Type:         typeCreationInfo,
+			ID:           creationInfoID,
+			SpecVersion:  SpecVersion,
+			Created:      created.UTC().Format(time.RFC3339),
+			CreatedBy:    []string{b.ns + "author"},
+			CreatedUsing: []string{b.ns + "tool"},
+		},
+		&doc,
+		Agent{Element: b.element(typeAgent, "author", DefaultAuthor)},
+		Tool{Element: b.element(typeTool, "tool", toolName(h.cfg))},
+	}
+	for _, p := range pkgs {
+		graph = append(graph, p)
+	}
+	for _, r := range b.rels {
+		// The vulnerable packages are the roots of the document.
+		if r, ok := r.(Relationship); ok && r.RelationshipType == relAssociated {
+			doc.RootElement = append(doc.RootElement, r.From)
+		}
+	}
+	graph = append(graph, b.vulns...)
+	graph = append(graph, b.rels...)
+	return Document{Context: ContextURI, Graph: graph}
+}
+
+// vulnerability returns the Vulnerability element of e.
+func vulnerability(b *builder, e *osv.Entry) Vulnerability {
+	description := e.Summary
+	if description == "" {
+		description = e.Details
+	}
+	v := Vulnerability{
+		Element:     b.element(typeVuln, "vulnerability-"+e.ID, e.ID),
+		Description: description,
+		ExternalIdentifier: []ExternalIdentifier{
+			{Type: typeExternalIdentifier, IdentifierType: identifierSecurityOther, Identifier: e.ID},
+		},
+		ExternalRef: []ExternalRef{{
+			Type:    typeExternalRef,
+			RefType: refSecurityAdvisory,
+			Locator: []string{fmt.Sprintf("https://pkg.go.dev/vuln/%s", e.ID)},
+		}},
+		PublishedTime: timeString(e.Published),
+		ModifiedTime:  timeString(e.Modified),
+	}
+	for _, a := range e.Aliases {
+		typ := identifierSecurityOther
+		if strings.HasPrefix(a, "CVE-") {
+			typ = identifierCVE
+		}
+		v.ExternalIdentifier = append(v.ExternalIdentifier, ExternalIdentifier{Type: typeExternalIdentifier, IdentifierType: typ, Identifier: a})
+	}
+	return v
+}
+
+// modules returns the sorted distinct vulnerable modules of findings.
+func modules(findings []*govulncheck.Finding) []module {
+	seen := make(map[module]bool)
+	var mods []module
+	for _, f := range findings {
+		m := module{path: f.Trace[0].Module, version: f.Trace[0].Version}
+		if !seen[m] {
+			seen[m] = true
+			mods = append(mods, m)
+		}
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].path != mods[j].path {
+			return mods[i].path < mods[j].path
+		}
+		return mods[i].version < mods[j].version
+	})
+	return mods
+}
+
+// fixedVersion returns the fixed version of m in findings, if any.
+func fixedVersion(findings []*govulncheck.Finding, m module) string {
+	for _, f := range findings {
+		if fr := f.Trace[0]; fr.Module == m.path && fr.Version == m.version && f.FixedVersion != "" {
+			return f.FixedVersion
+		}
+	}
+	return ""
+}
+
+// toolName returns the name of the scanner, with its version if known.
+func toolName(cfg *govulncheck.Config) string {
+	name := cfg.ScannerName
+	if name == "" {
+		name = "govulncheck"
+	}
+	if cfg.ScannerVersion != "" {
+		name += "@" + cfg.ScannerVersion
+	}
+	return name
+}
+
+// timeString returns t in RFC 3339 format,
+// or the empty string if t is unknown.
+func timeString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// hashFindings returns a hash of the findings of h for the OSVs ids,
+// identifying the document of the findings regardless of its time.
+func hashFindings(h *handler, ids []string) string {
+	s := sha256.New()
+	for _, id := range ids {
+		for _, f := range h.findings[id] {
+			fr := f.Trace[0]
+			fmt.Fprintf(s, "%s %s %s %s %s %s %s\n", id, h.cfg.ScanLevel, fr.Module, fr.Version, fr.Package, fr.Function, f.FixedVersion)
+		}
+	}
+	return fmt.Sprintf("%x", s.Sum(nil))
+}
@@ -0,0 +1,182 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spdx
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+func TestCalledFinding(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewHandler(&buf)
+	h.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol, ScannerName: "govulncheck"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.OSV(&osv.Entry{
+		ID:      "GO-2021-0265",
+		Summary: "Panic in gjson",
+		Aliases: []string{"CVE-2021-42248", "GHSA-c9gm-7rfj-8w5h"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Finding(&govulncheck.Finding{
+		OSV:          "GO-2021-0265",
+		FixedVersion: "v1.9.3",
+		Trace: []*govulncheck.Frame{{
+			Module:   "github.com/tidwall/gjson",
+			Version:  "v1.6.5",
+			Package:  "github.com/tidwall/gjson",
+			Function: "Get",
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Context string           `json:"@context"`
+		Graph   []map[string]any `json:"@graph"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Context != ContextURI {
+		t.Errorf("got context %q; want %q", doc.Context, ContextURI)
+	}
+
+	// Index the elements by type and ID, dropping the document
+	// namespace so that the IDs are readable.
+	byID := make(map[string]map[string]any)
+	types := make(map[string]int)
+	var ns string
+	for _, e := range doc.Graph {
+		types[e["type"].(string)]++
+		if e["type"] == typeDocument {
+			id := e["spdxId"].(string)
+			ns = id[:len(id)-len("document")]
+		}
+	}
+	for _, e := range doc.Graph {
+		if id, ok := e["spdxId"].(string); ok {
+			byID[id[len(ns):]] = e
+		}
+	}
+
+	wantTypes := map[string]int{
+		typeCreationInfo: 1,
+		typeDocument:     1,
+		typeAgent:        1,
+		typeTool:         1,
+		typePackage:      2, // the vulnerable and the fixed versions
+		typeVuln:         1,
+		typeRelationship: 1,
+		typeAffected:     1,
+		typeFixed:        1,
+	}
+	if diff := cmp.Diff(wantTypes, types); diff != "" {
+		t.Errorf("element types mismatch (-want, +got):\n%s", diff)
+	}
+
+	pkg := byID["package-github.com%2Ftidwall%2Fgjson@v1.6.5"]
+	if pkg == nil {
+		t.Fatalf("no vulnerable package in %s", buf.String())
+	}
+	if got, want := pkg["software_packageUrl"], "pkg:golang/github.com%2Ftidwall%2Fgjson@v1.6.5"; got != want {
+		t.Errorf("got package URL %v; want %v", got, want)
+	}
+	vuln := byID["vulnerability-GO-2021-0265"]
+	if vuln == nil {
+		t.Fatalf("no vulnerability in %s", buf.String())
+	}
+	wantIdentifiers := []any{
+		map[string]any{"type": typeExternalIdentifier, "externalIdentifierType": "securityOther", "identifier": "GO-2021-0265"},
+		map[string]any{"type": typeExternalIdentifier, "externalIdentifierType": "cve", "identifier": "CVE-2021-42248"},
+		map[string]any{"type": typeExternalIdentifier, "externalIdentifierType": "securityOther", "identifier": "GHSA-c9gm-7rfj-8w5h"},
+	}
+	if diff := cmp.Diff(wantIdentifiers, vuln["externalIdentifier"]); diff != "" {
+		t.Errorf("external identifiers mismatch (-want, +got):\n%s", diff)
+	}
+
+	pkgID, vulnID := pkg["spdxId"].(string), vuln["spdxId"].(string)
+	fixedID := ns + "package-github.com%2Ftidwall%2Fgjson@v1.9.3"
+	if byID["package-github.com%2Ftidwall%2Fgjson@v1.9.3"] == nil {
+		t.Fatalf("no fixed package in %s", buf.String())
+	}
+	for _, want := range []map[string]any{
+		{"type": typeRelationship, "relationshipType": relAssociated, "from": pkgID, "to": []any{vulnID}},
+		{"type": typeAffected, "relationshipType": relAffects, "from": vulnID, "to": []any{pkgID},
+			"security_actionStatement": "Upgrade github.com/tidwall/gjson to v1.9.3."},
+		{"type": typeFixed, "relationshipType": relFixedIn, "from": vulnID, "to": []any{fixedID}},
+	} {
+		var got map[string]any
+		for _, e := range doc.Graph {
+			if e["type"] == want["type"] {
+				got = e
+			}
+		}
+		// The IDs of relationships only matter for their uniqueness.
+		got = cleanRelationship(got)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("%s mismatch (-want, +got):\n%s", want["type"], diff)
+		}
+	}
+
+	if got := byID["document"]["rootElement"]; !cmp.Equal(got, []any{pkgID}) {
+		t.Errorf("got root elements %v; want [%s]", got, pkgID)
+	}
+	if got, want := doc.Graph[0]["created"], "2024-01-02T03:04:05Z"; got != want {
+		t.Errorf("got creation time %v; want %v", got, want)
+	}
+}
+
+// cleanRelationship returns r without its ID and creation info.
+func cleanRelationship(r map[string]any) map[string]any {
+	c := make(map[string]any)
+	for k, v := range r {
+		if k != "spdxId" && k != "creationInfo" {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+func TestStableIDs(t *testing.T) {
+	doc := func(created time.Time) string {
+		var buf bytes.Buffer
+		h := NewHandler(&buf)
+		h.now = func() time.Time { return created }
+		h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol})
+		h.OSV(&osv.Entry{ID: "GO-2021-0265"})
+		h.Finding(&govulncheck.Finding{
+			OSV:   "GO-2021-0265",
+			Trace: []*govulncheck.Frame{{Module: "github.com/tidwall/gjson", Version: "v1.6.5", Package: "github.com/tidwall/gjson"}},
+		})
+		if err := h.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		var d Document
+		if err := json.Unmarshal(buf.Bytes(), &d); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(d.Graph[1:])
+		return string(b)
+	}
+	first := doc(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	second := doc(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	if first != second {
+		t.Errorf("elements differ across creation times:\n%s\n%s", first, second)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package spdx defines the SPDX 3.0 types, of the core, software, and
+// security profiles, supported by govulncheck.
+//
+// The output is a JSON-LD document, following the specification at
+// https://spdx.github.io/spdx-spec/v3.0.1/. Each vulnerable module is
+// a software_Package, linked to each of its vulnerabilities, which are
+// security_Vulnerability elements, by a hasAssociatedVulnerability
+// Relationship. A VEX assessment relationship from the vulnerability to
+// the package then reflects the reachability of the vulnerability, as
+// in the OpenVEX output: vulnerabilities that are called, or imported
+// in a package level scan, affect the package, vulnerabilities that are
+// imported but not called do not affect it, and vulnerable modules
+// detected by a module level scan are under investigation. If the
+// vulnerability is fixed, the fixed version of the module is another
+// software_Package, related to the vulnerability by a fixedIn assessment,
+// and the affects assessment states the upgrade as its action.
+//
+// This is intended to be the minimal amount of information required to
+// output a valid SPDX 3.0 document.
+package spdx
+
+const (
+	ContextURI  = "https://spdx.org/rdf/3.0.1/spdx-context.jsonld"
+	SpecVersion = "3.0.1"
+	// Namespace prefixes the IDs of the elements of a
+	// document, followed by a hash of the document.
+	Namespace = "https://pkg.go.dev/golang.org/x/vuln/cmd/govulncheck/spdx/"
+	Impact    = "Govulncheck determined that the vulnerable code isn't called"
+
+	DefaultAuthor = "Unknown Author"
+
+	// The following are defined by the SPDX security profile.
+	JustificationNotInExecutePath = "vulnerableCodeNotInExecutePath"
+	JustificationNotPresent       = "vulnerableCodeNotPresent"
+)
+
+// Document is the top-level SPDX 3.0 JSON-LD object.
+type Document struct {
+	// Context is always ContextURI.
+	Context string `json:"@context"`
+	// Graph holds the elements of the document, led by the
+	// CreationInfo they share and the SpdxDocument itself.
+	Graph []any `json:"@graph"`
+}
+
+// CreationInfo describes how and when the elements were created.
+type CreationInfo struct {
+	Type string `json:"type"`
+	// ID is a blank node ID, as CreationInfo is not an element.
+	ID          string `json:"@id"`
+	SpecVersion string `json:"specVersion"`
+	// Created is the time of the scan in RFC 3339 format.
+	Created string `json:"created"`
+	// CreatedBy are the IDs of the agents authoring the
+	// document, which govulncheck leaves to the user.
+	CreatedBy []string `json:"createdBy"`
+	// CreatedUsing are the IDs of the tools producing
+	// the document, that is, govulncheck.
+	CreatedUsing []string `json:"createdUsing,omitempty"`
+}
+
+// Element holds the properties common to all SPDX elements.
+type Element struct {
+	Type         string `json:"type"`
+	ID           string `json:"spdxId"`
+	CreationInfo string `json:"creationInfo"`
+	Name         string `json:"name,omitempty"`
+}
+
+// SpdxDocument describes the document and its root elements.
+type SpdxDocument struct {
+	Element
+	// ProfileConformance are the profiles the document conforms to.
+	ProfileConformance []string `json:"profileConformance,omitempty"`
+	// RootElement are the IDs of the vulnerable packages.
+	RootElement []string `json:"rootElement,omitempty"`
+}
+
+// Agent is the author of the document.
+type Agent struct {
+	Element
+}
+
+// Tool is the tool producing the document, govulncheck.
+type Tool struct {
+	Element
+}
+
+// Package is a module at a version.
+type Package struct {
+	Element
+	Version string `json:"software_packageVersion,omitempty"`
+	// PackageURL is of the form pkg:golang/MODULE_PATH@VERSION.
+	PackageURL string `json:"software_packageUrl,omitempty"`
+}
+
+// Vulnerability is a vulnerability reported by an OSV entry.
+type Vulnerability struct {
+	Element
+	// Description is the summary of the OSV, or its
+	// details if there is no summary.
+	Description string `json:"description,omitempty"`
+	// ExternalIdentifier holds the OSV ID and its aliases.
+	ExternalIdentifier []ExternalIdentifier `json:"externalIdentifier,omitempty"`
+	// ExternalRef points to the advisory on pkg.go.dev.
+	ExternalRef []ExternalRef `json:"externalRef,omitempty"`
+	// PublishedTime and ModifiedTime are the times, in RFC 3339
+	// format, the OSV was published and last modified, if known.
+	PublishedTime string `json:"security_publishedTime,omitempty"`
+	ModifiedTime  string `json:"security_modifiedTime,omitempty"`
+}
+
+// ExternalIdentifier identifies a vulnerability in a database.
+type ExternalIdentifier struct {
+	Type string `json:"type"`
+	// IdentifierType is "cve" for CVE IDs and "securityOther" otherwise.
+	IdentifierType string `json:"externalIdentifierType"`
+	Identifier     string `json:"identifier"`
+}
+
+// ExternalRef points to a resource about a vulnerability.
+type ExternalRef struct {
+	Type    string   `json:"type"`
+	RefType string   `json:"externalRefType"`
+	Locator []string `json:"locator"`
+}
+
+// Relationship relates the element From to the elements To.
+type Relationship struct {
+	Element
+	RelationshipType string   `json:"relationshipType"`
+	From             string   `json:"from"`
+	To               []string `json:"to"`
+}
+
+// Assessment is a VEX assessment of a vulnerability, From, for
+// packages, To. Its Type tells whether the packages are affected,
+// not affected, under investigation, or fixed.
+type Assessment struct {
+	Relationship
+	// ActionStatement is set for affected packages.
+	ActionStatement string `json:"security_actionStatement,omitempty"`
+	// Justification and ImpactStatement are
+	// set for packages that are not affected.
+	Justification   string `json:"security_justificationType,omitempty"`
+	ImpactStatement string `json:"security_impactStatement,omitempty"`
+}
+
+// Element types.
+const (
+	typeCreationInfo       = "CreationInfo"
+	typeDocument           = "SpdxDocument"
+	typeAgent              = "Agent"
+	typeTool               = "Tool"
+	typePackage            = "software_Package"
+	typeVuln               = "security_Vulnerability"
+	typeRelationship       = "Relationship"
+	typeAffected           = "security_VexAffectedVulnAssessmentRelationship"
+	typeNotAffected        = "security_VexNotAffectedVulnAssessmentRelationship"
+	typeUnderInvestigation = "security_VexUnderInvestigationVulnAssessmentRelationship"
+	typeFixed              = "security_VexFixedVulnAssessmentRelationship"
+	typeExternalIdentifier = "ExternalIdentifier"
+	typeExternalRef        = "ExternalRef"
+)
+
+// Relationship types.
+const (
+	relAssociated         = "hasAssociatedVulnerability"
+	relAffects            = "affects"
+	relDoesNotAffect      = "doesNotAffect"
+	relUnderInvestigation = "underInvestigationFor"
+	relFixedIn            = "fixedIn"
+)
+
+// Other vocabulary of the SPDX specification.
+const (
+	identifierCVE           = "cve"
+	identifierSecurityOther = "securityOther"
+	refSecurityAdvisory     = "securityAdvisory"
+)
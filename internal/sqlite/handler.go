@@ -0,0 +1,226 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sqlite implements a govulncheck.Handler that stores
+// govulncheck findings in an SQL database, such as SQLite, for
+// teams building their own dashboards.
+//
+// NewHandler works on a *sql.DB opened by the client, with any
+// driver supporting "?" placeholders, as the statements only use
+// standard SQL. Open opens an SQLite database with a pure Go driver,
+// which is how the output of '-format sqlite=path' is written.
+//
+// The schema consists of four tables:
+//
+//	scans(id, scanner_name, scanner_version, scan_level)
+//	osvs(id, summary, severity)
+//	findings(scan, id, osv, module, version, fixed_version, level)
+//	frames(scan, finding, idx, module, version, package, function,
+//	       receiver, filename, line, col)
+//
+// Each scan written to a database is a new row of scans, to which
+// its findings and frames refer, so that several scans can be kept
+// in the same database. Entries of osvs are shared by all scans and
+// hold the latest data of each vulnerability. The severity of an
+// OSV is its CVSS v3 rating, if any, and level is one of "module",
+// "package", and "symbol". For instance, modules affected by called
+// critical vulnerabilities in the latest scan are
+//
+//	SELECT DISTINCT f.module FROM findings f
+//	JOIN osvs o ON o.id = f.osv
+//	WHERE f.scan = (SELECT MAX(id) FROM scans)
+//	AND f.level = 'symbol' AND o.severity = 'CRITICAL'
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+
+	"golang.org/x/vuln/internal/cvss"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// schema is executed when the Config message is received.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS scans (
+		id INTEGER PRIMARY KEY,
+		scanner_name TEXT,
+		scanner_version TEXT,
+		scan_level TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS osvs (
+		id TEXT PRIMARY KEY,
+		summary TEXT,
+		severity TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS findings (
+		scan INTEGER,
+		id INTEGER,
+		osv TEXT,
+		module TEXT,
+		version TEXT,
+		fixed_version TEXT,
+		level TEXT,
+		PRIMARY KEY (scan, id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS frames (
+		scan INTEGER,
+		finding INTEGER,
+		idx INTEGER,
+		module TEXT,
+		version TEXT,
+		package TEXT,
+		function TEXT,
+		receiver TEXT,
+		filename TEXT,
+		line INTEGER,
+		col INTEGER
+	)`,
+}
+
+type handler struct {
+	db *sql.DB
+	tx *sql.Tx
+	mu sync.Mutex // serializes findings, which may be concurrent
+	// scan is the id of the scan being written.
+	scan int64
+	// findings is the number of findings
+	// inserted so far, used as their id.
+	findings int
+	// overrides are the severity overrides of the config.
+	overrides map[string]string
+}
+
+// Open opens the SQLite database at path,
+// creating it if it does not exist.
+func Open(path string) (*sql.DB, error) {
+	return sql.Open("sqlite", path)
+}
+
+// NewHandler returns a handler that writes findings to db.
+// Rows are inserted in a single transaction as messages are
+// received, which is committed on Flush. The transaction is
+// rolled back if inserting a row fails.
+func NewHandler(db *sql.DB) *handler {
+	return &handler{db: db}
+}
+
+// Config begins the transaction, creates the tables,
+// if needed, and records the scan.
+func (h *handler) Config(cfg *govulncheck.Config) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	h.tx = tx
+	h.overrides = cfg.SeverityOverrides
+	for _, s := range schema {
+		if err := h.exec(s); err != nil {
+			return err
+		}
+	}
+	if err := h.tx.QueryRow(`SELECT COALESCE(MAX(id), 0) + 1 FROM scans`).Scan(&h.scan); err != nil {
+		h.rollback()
+		return err
+	}
+	return h.exec(`INSERT INTO scans (id, scanner_name, scanner_version, scan_level) VALUES (?, ?, ?, ?)`,
+		h.scan, cfg.ScannerName, cfg.ScannerVersion, string(cfg.ScanLevel))
+}
+
+func (h *handler) Progress(progress *govulncheck.Progress) error {
+	return nil
+}
+
+func (h *handler) SBOM(s *govulncheck.SBOM) error {
+	return nil
+}
+
+// OSV replaces the entry of e in osvs, if any.
+func (h *handler) OSV(e *osv.Entry) error {
+	if h.tx == nil {
+		return errNoConfig
+	}
+	severity := ""
+	if score, ok := cvss.OverriddenEntryScore(e, h.overrides); ok {
+		severity = cvss.Rating(score)
+	}
+	if err := h.exec(`DELETE FROM osvs WHERE id = ?`, e.ID); err != nil {
+		return err
+	}
+	return h.exec(`INSERT INTO osvs (id, summary, severity) VALUES (?, ?, ?)`,
+		e.ID, e.Summary, severity)
+}
+
+func (h *handler) Finding(f *govulncheck.Finding) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.tx == nil {
+		return errNoConfig
+	}
+	h.findings++
+	id := h.findings
+	fr := f.Trace[0]
+	if err := h.exec(`INSERT INTO findings (scan, id, osv, module, version, fixed_version, level) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		h.scan, id, f.OSV, fr.Module, fr.Version, f.FixedVersion, string(govulncheck.Level(f))); err != nil {
+		return err
+	}
+	for i, fr := range f.Trace {
+		var filename string
+		var line, col int
+		if p := fr.Position; p != nil {
+			filename, line, col = p.Filename, p.Line, p.Column
+		}
+		if err := h.exec(`INSERT INTO frames (scan, finding, idx, module, version, package, function, receiver, filename, line, col) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			h.scan, id, i, fr.Module, fr.Version, fr.Package, fr.Function, fr.Receiver, filename, line, col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Concurrent returns true as findings are inserted one at a time.
+// Their ids then follow the order in which they were delivered.
+func (h *handler) Concurrent() bool {
+	return true
+}
+
+// Streaming returns true as rows are inserted as soon
+// as messages are received.
+func (h *handler) Streaming() bool {
+	return true
+}
+
+// Flush commits the rows inserted so far.
+func (h *handler) Flush() error {
+	if h.tx == nil {
+		return nil
+	}
+	err := h.tx.Commit()
+	h.tx = nil
+	return err
+}
+
+// exec executes query with args in the transaction,
+// which is rolled back if the query fails.
+func (h *handler) exec(query string, args ...any) error {
+	if _, err := h.tx.Exec(query, args...); err != nil {
+		h.rollback()
+		return err
+	}
+	return nil
+}
+
+// rollback rolls back the transaction, after
+// which messages are no longer accepted.
+func (h *handler) rollback() {
+	h.tx.Rollback()
+	h.tx = nil
+}
+
+// errNoConfig is the error of messages received before Config or
+// after the transaction was rolled back.
+var errNoConfig = errors.New("sqlite: message received before config or after a failure")
@@ -0,0 +1,173 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// scan is the output of a symbol level scan finding a called critical
+// vulnerability in example.com/a, an imported critical vulnerability in
+// example.com/b, and a called medium vulnerability in example.com/c.
+const scan = `{"config":{"protocol_version":"v1.0.0","scanner_name":"govulncheck","scanner_version":"v1.1.0","scan_level":"symbol"}}
+{"osv":{"id":"GO-0000-0001","modified":"0001-01-01T00:00:00Z","summary":"critical","severity":[{"type":"CVSS_V3","score":"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}]}}
+{"osv":{"id":"GO-0000-0002","modified":"0001-01-01T00:00:00Z","summary":"critical too","severity":[{"type":"CVSS_V3","score":"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}]}}
+{"osv":{"id":"GO-0000-0003","modified":"0001-01-01T00:00:00Z","summary":"medium","severity":[{"type":"CVSS_V3","score":"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:L/I:L/A:N"}]}}
+{"finding":{"osv":"GO-0000-0001","fixed_version":"v1.0.1","trace":[{"module":"example.com/a","version":"v1.0.0"}]}}
+{"finding":{"osv":"GO-0000-0002","trace":[{"module":"example.com/b","version":"v1.0.0"}]}}
+{"finding":{"osv":"GO-0000-0003","trace":[{"module":"example.com/c","version":"v1.0.0"}]}}
+{"finding":{"osv":"GO-0000-0001","fixed_version":"v1.0.1","trace":[{"module":"example.com/a","version":"v1.0.0","package":"example.com/a/p","function":"F"},{"module":"example.com/main","package":"example.com/main","function":"main","position":{"filename":"main.go","line":5,"column":2}}]}}
+{"finding":{"osv":"GO-0000-0002","trace":[{"module":"example.com/b","version":"v1.0.0","package":"example.com/b"}]}}
+{"finding":{"osv":"GO-0000-0003","trace":[{"module":"example.com/c","version":"v1.0.0","package":"example.com/c","function":"G"}]}}
+`
+
+// calledCritical is the query of the modules affected
+// by called critical vulnerabilities in the latest scan.
+const calledCritical = `SELECT DISTINCT f.module FROM findings f
+	JOIN osvs o ON o.id = f.osv
+	WHERE f.scan = (SELECT MAX(id) FROM scans)
+	AND f.level = 'symbol' AND o.severity = 'CRITICAL'`
+
+func openDB(t *testing.T) *sql.DB {
+	db, err := Open(filepath.Join(t.TempDir(), "govulncheck.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func queryStrings(t *testing.T, db *sql.DB, query string) []string {
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, s)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestHandler(t *testing.T) {
+	db := openDB(t)
+
+	h := NewHandler(db)
+	if err := h.OSV(&osv.Entry{ID: "GO-0000-0001"}); err == nil {
+		t.Error("want error for OSV before config; got none")
+	}
+	// Scan twice into the same database.
+	for i := range 2 {
+		h := NewHandler(db)
+		if err := govulncheck.HandleJSON(strings.NewReader(scan), h); err != nil {
+			t.Fatalf("scan %d: %v", i+1, err)
+		}
+		if i == 0 {
+			if got := queryStrings(t, db, `SELECT name FROM sqlite_master`); len(got) != 0 {
+				t.Errorf("want nothing committed before Flush; got %v", got)
+			}
+		}
+		if err := h.Flush(); err != nil {
+			t.Fatalf("scan %d: %v", i+1, err)
+		}
+	}
+
+	if diff := cmp.Diff([]string{"example.com/a"}, queryStrings(t, db, calledCritical)); diff != "" {
+		t.Errorf("called critical modules mismatch (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"1 v1.1.0 symbol", "2 v1.1.0 symbol"}, queryStrings(t, db,
+		`SELECT id || ' ' || scanner_version || ' ' || scan_level FROM scans ORDER BY id`)); diff != "" {
+		t.Errorf("scans mismatch (-want, +got):\n%s", diff)
+	}
+	// The findings of each scan are kept, and OSVs are shared.
+	if got := queryStrings(t, db, `SELECT COUNT(*) FROM findings`); got[0] != "12" {
+		t.Errorf("got %s findings; want 12", got[0])
+	}
+	if got := queryStrings(t, db, `SELECT COUNT(*) FROM osvs`); got[0] != "3" {
+		t.Errorf("got %s osvs; want 3", got[0])
+	}
+	want := []string{"0 example.com/a/p F  0 0", "1 example.com/main main main.go 5 2"}
+	if diff := cmp.Diff(want, queryStrings(t, db,
+		`SELECT fr.idx || ' ' || fr.package || ' ' || fr.function || ' ' || fr.filename || ' ' || fr.line || ' ' || fr.col
+		FROM frames fr JOIN findings f ON f.scan = fr.scan AND f.id = fr.finding
+		WHERE f.scan = 2 AND f.osv = 'GO-0000-0001' AND f.level = 'symbol' ORDER BY fr.idx`)); diff != "" {
+		t.Errorf("frames mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestHandlerRollback(t *testing.T) {
+	db := openDB(t)
+	// A frames table of another schema fails the insertion of frames.
+	if _, err := db.Exec(`CREATE TABLE frames (scan INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHandler(db)
+	if err := h.Config(&govulncheck.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m"}}}); err == nil {
+		t.Fatal("want an error inserting frames")
+	}
+	if err := h.OSV(&osv.Entry{ID: "GO-0000-0001"}); err == nil {
+		t.Error("want an error for messages after a failure")
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing of the scan is kept, not even its tables.
+	if got := queryStrings(t, db, `SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name`); !cmp.Equal(got, []string{"frames"}) {
+		t.Errorf("got tables %v; want only frames", got)
+	}
+}
+
+func TestHandlerConcurrent(t *testing.T) {
+	db := openDB(t)
+	h := NewHandler(db)
+	if !govulncheck.Concurrent(h) {
+		t.Fatal("want a concurrent handler")
+	}
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelModule}); err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	var wg sync.WaitGroup
+	for i := range 20 {
+		mod := fmt.Sprintf("example.com/m%02d", i)
+		want = append(want, mod)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := h.Finding(&govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: mod}}}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	got := queryStrings(t, db, `SELECT module FROM findings ORDER BY module`)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -21,6 +21,7 @@ type MockHandler struct {
 	ProgressMessages []*govulncheck.Progress
 	OSVMessages      []*osv.Entry
 	FindingMessages  []*govulncheck.Finding
+	FailureMessages  []*govulncheck.Failure
 }
 
 func NewMockHandler() *MockHandler {
@@ -52,6 +53,11 @@ func (h *MockHandler) Finding(finding *govulncheck.Finding) error {
 	return nil
 }
 
+func (h *MockHandler) Failure(failure *govulncheck.Failure) error {
+	h.FailureMessages = append(h.FailureMessages, failure)
+	return nil
+}
+
 func (h *MockHandler) Sort() {
 	sort.Slice(h.FindingMessages, func(i, j int) bool {
 		if h.FindingMessages[i].OSV > h.FindingMessages[j].OSV {
@@ -126,5 +132,10 @@ func (h *MockHandler) Write(to govulncheck.Handler) error {
 			}
 		}
 	}
+	for _, failure := range h.FailureMessages {
+		if err := govulncheck.Fail(to, failure); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -7,6 +7,7 @@ package vulncheck
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/vuln/internal"
@@ -31,18 +32,47 @@ type Bin struct {
 }
 
 // Binary detects presence of vulnerable symbols in bin and
-// emits findings to handler.
-func Binary(ctx context.Context, handler govulncheck.Handler, bin *Bin, cfg *govulncheck.Config, client *client.Client) error {
+// emits findings to handler. Findings are delivered concurrently
+// to concurrent handlers, as requested by cfg.FindingWorkers.
+func Binary(ctx context.Context, handler govulncheck.Handler, bin *Bin, cfg *govulncheck.Config, client *client.Client) (err error) {
+	handler, wait := deliverFindings(handler, cfg)
+	defer func() {
+		if werr := wait(); err == nil {
+			err = werr
+		}
+	}()
+	// Findings are passed to the hook of cfg with their confidence.
+	handler = &binaryConfidenceHandler{hookFindings(limitFindings(handler, cfg), cfg)}
 	vr, err := binary(ctx, handler, bin, cfg, client)
 	if err != nil {
 		return err
 	}
 	if cfg.ScanLevel.WantSymbols() {
-		return emitCallFindings(handler, binaryCallstacks(vr))
+		return emitCallFindings(ctx, handler, cfg, binaryCallstacks(vr), nil)
 	}
 	return nil
 }
 
+// binaryConfidenceHandler is a Handler that sets the confidence
+// of findings of a binary scan before forwarding them.
+type binaryConfidenceHandler struct {
+	govulncheck.Handler
+}
+
+// Finding sets the confidence of f, unless already set, and
+// forwards it. Symbol level findings are of medium confidence
+// since the symbols are recovered from the binary. Package and
+// module level findings are of low confidence.
+func (h *binaryConfidenceHandler) Finding(f *govulncheck.Finding) error {
+	if f.Confidence == "" {
+		f.Confidence = govulncheck.ConfidenceLow
+		if govulncheck.IsCalled(f) {
+			f.Confidence = govulncheck.ConfidenceMedium
+		}
+	}
+	return h.Handler.Finding(f)
+}
+
 // binary detects presence of vulnerable symbols in bin.
 // It does not compute call graphs so the corresponding
 // info in Result will be empty.
@@ -64,13 +94,16 @@ func binary(ctx context.Context, handler govulncheck.Handler, bin *Bin, cfg *gov
 		return nil, err
 	}
 
-	mv, err := FetchVulnerabilities(ctx, client, mods)
+	mv, err := fetchVulnerabilities(ctx, cfg, client, mods)
 	if err != nil {
 		return nil, err
 	}
 
 	// Emit OSV entries immediately in their raw unfiltered form.
-	if err := emitOSVs(handler, mv); err != nil {
+	if err := emitOSVs(ctx, handler, mv); err != nil {
+		return nil, err
+	}
+	if err := emitStaleData(handler, cfg, time.Now()); err != nil {
 		return nil, err
 	}
 
@@ -81,20 +114,23 @@ func binary(ctx context.Context, handler govulncheck.Handler, bin *Bin, cfg *gov
 	// Emit warning message for ancient Go binaries, defined as binaries
 	// built with Go version without support for debug.BuildInfo (< go1.18).
 	if semver.Valid(bin.GoVersion) && semver.Less(bin.GoVersion, "go1.18") {
-		p := &govulncheck.Progress{Message: fmt.Sprintf("warning: binary built with Go version %s, only standard library vulnerabilities will be checked", bin.GoVersion)}
+		p := &govulncheck.Progress{Message: fmt.Sprintf("warning: binary built with Go version %s, only standard library vulnerabilities will be checked", bin.GoVersion), Warning: true}
 		if err := handler.Progress(p); err != nil {
 			return nil, err
 		}
 	}
 
 	if bin.GOOS == "" || bin.GOARCH == "" {
-		p := &govulncheck.Progress{Message: fmt.Sprintf("warning: failed to extract build system specification GOOS: %s GOARCH: %s\n", bin.GOOS, bin.GOARCH)}
+		p := &govulncheck.Progress{Message: fmt.Sprintf("warning: failed to extract build system specification GOOS: %s GOARCH: %s\n", bin.GOOS, bin.GOARCH), Warning: true}
 		if err := handler.Progress(p); err != nil {
 			return nil, err
 		}
 	}
-	affVulns := affectingVulnerabilities(mv, bin.GOOS, bin.GOARCH)
-	if err := emitModuleFindings(handler, affVulns); err != nil {
+	affVulns, dups := mergeDuplicates(affectingVulnerabilities(mv, bin.GOOS, bin.GOARCH))
+	if err := emitDuplicates(ctx, handler, dups); err != nil {
+		return nil, err
+	}
+	if err := emitModuleFindings(ctx, handler, cfg, affVulns, nil); err != nil {
 		return nil, err
 	}
 
@@ -116,7 +152,7 @@ func binary(ctx context.Context, handler govulncheck.Handler, bin *Bin, cfg *gov
 	impVulns := binImportedVulnPackages(graph, pkgSymbols, affVulns)
 	// Emit information on imported vulnerable packages now to
 	// mimic behavior of source.
-	if err := emitPackageFindings(handler, impVulns); err != nil {
+	if err := emitPackageFindings(ctx, handler, cfg, impVulns, nil); err != nil {
 		return nil, err
 	}
 
@@ -13,6 +13,7 @@ import (
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/vuln/internal/buildinfo"
 	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
 	"golang.org/x/vuln/internal/test"
 )
 
@@ -87,3 +88,59 @@ func TestBinary(t *testing.T) {
 		t.Errorf("(-want, +got): %s", diff)
 	}
 }
+
+func TestBinaryConfidence(t *testing.T) {
+	bin := &Bin{
+		Modules: []*packages.Module{
+			{Path: "golang.org/entry"},
+			{Path: "golang.org/amod", Version: "v1.1.3"},
+			{Path: "golang.org/bmod", Version: "v0.5.0"},
+		},
+		GoVersion: "go1.20",
+		PkgSymbols: []buildinfo.Symbol{
+			{Pkg: "golang.org/entry", Name: "main"},
+			{Pkg: "golang.org/amod/avuln", Name: "VulnData.Vuln1"},
+			{Pkg: "golang.org/bmod/bvuln", Name: "NoVuln"},
+		},
+	}
+	c, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := test.NewMockHandler()
+	if err := Binary(context.Background(), mock, bin, &govulncheck.Config{ScanLevel: "symbol"}, c); err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.FindingMessages) == 0 {
+		t.Fatal("want findings; got none")
+	}
+	var symbols int
+	for _, f := range mock.FindingMessages {
+		want := govulncheck.Confidence(govulncheck.ConfidenceLow)
+		if f.Trace[0].Function != "" {
+			want = govulncheck.ConfidenceMedium
+			symbols++
+		}
+		if f.Confidence != want {
+			t.Errorf("%s %v: want confidence %q; got %q", f.OSV, f.Trace[0], want, f.Confidence)
+		}
+	}
+	if symbols == 0 {
+		t.Error("want symbol level findings; got none")
+	}
+
+	// The same findings in source mode are of implied high confidence.
+	source := test.NewMockHandler()
+	vulns := []*Vuln{{
+		OSV:     &osv.Entry{ID: "GO-0000-0001"},
+		Package: &packages.Package{PkgPath: "golang.org/amod/avuln", Module: &packages.Module{Path: "golang.org/amod", Version: "v1.1.3"}},
+	}}
+	if err := emitPackageFindings(context.Background(), source, &govulncheck.Config{}, vulns, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range source.FindingMessages {
+		if f.Confidence != "" {
+			t.Errorf("want no confidence for source finding; got %q", f.Confidence)
+		}
+	}
+}
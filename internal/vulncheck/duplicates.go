@@ -0,0 +1,116 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncheck
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// mergeDuplicates returns affVulns without the OSV entries that
+// duplicate other entries affecting the same module, such as a GHSA
+// and a Go advisory that alias each other, so that the vulnerability
+// is reported once, under the ID of its canonical entry. It also
+// returns the IDs of the dropped entries mapped to their canonical IDs.
+func mergeDuplicates(affVulns affectingVulns) (affectingVulns, map[string]string) {
+	dups := make(map[string]string)
+	var merged affectingVulns
+	for _, mv := range affVulns {
+		canonical := duplicates(mv.Vulns)
+		if len(canonical) == 0 {
+			merged = append(merged, mv)
+			continue
+		}
+		var vulns []*osv.Entry
+		for _, v := range mv.Vulns {
+			if c, ok := canonical[v.ID]; ok {
+				dups[v.ID] = c
+				continue
+			}
+			vulns = append(vulns, v)
+		}
+		merged = append(merged, &ModVulns{Module: mv.Module, Vulns: vulns})
+	}
+	return merged, dups
+}
+
+// duplicates maps the IDs of the entries that duplicate other entries
+// to the IDs of the canonical entries they duplicate. Entries duplicate
+// each other when one is an alias of the other or when they share an
+// alias. The canonical entry of duplicates is the Go advisory, if any,
+// and the entry with the least ID otherwise.
+func duplicates(entries []*osv.Entry) map[string]string {
+	// Group entries by the IDs identifying them,
+	// using a union-find structure over entries.
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	byID := make(map[string]int)
+	for i, e := range entries {
+		for _, id := range append([]string{e.ID}, e.Aliases...) {
+			if j, ok := byID[id]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				byID[id] = i
+			}
+		}
+	}
+
+	groups := make(map[int][]*osv.Entry)
+	for i, e := range entries {
+		groups[find(i)] = append(groups[find(i)], e)
+	}
+	dups := make(map[string]string)
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			gi, gj := strings.HasPrefix(group[i].ID, "GO-"), strings.HasPrefix(group[j].ID, "GO-")
+			if gi != gj {
+				return gi
+			}
+			return group[i].ID < group[j].ID
+		})
+		for _, e := range group[1:] {
+			if e.ID != group[0].ID {
+				dups[e.ID] = group[0].ID
+			}
+		}
+	}
+	return dups
+}
+
+// emitDuplicates emits a progress message to handler for each
+// duplicate OSV entry in dups reported as its canonical entry.
+func emitDuplicates(ctx context.Context, handler govulncheck.Handler, dups map[string]string) error {
+	var ids []string
+	for id := range dups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := handler.Progress(&govulncheck.Progress{Message: fmt.Sprintf(duplicateVulnMessage, id, dups[id])}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
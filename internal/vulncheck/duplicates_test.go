@@ -0,0 +1,101 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncheck
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+func TestDuplicates(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		entries []*osv.Entry
+		want    map[string]string
+	}{
+		{
+			name: "GHSA and CVE aliasing each other",
+			entries: []*osv.Entry{
+				{ID: "GHSA-xxxx-yyyy-zzzz", Aliases: []string{"CVE-2024-0001"}},
+				{ID: "CVE-2024-0001", Aliases: []string{"GHSA-xxxx-yyyy-zzzz"}},
+			},
+			want: map[string]string{"GHSA-xxxx-yyyy-zzzz": "CVE-2024-0001"},
+		},
+		{
+			name: "Go advisory is canonical",
+			entries: []*osv.Entry{
+				{ID: "GHSA-xxxx-yyyy-zzzz", Aliases: []string{"CVE-2024-0001"}},
+				{ID: "GO-2024-0001", Aliases: []string{"CVE-2024-0001", "GHSA-xxxx-yyyy-zzzz"}},
+			},
+			want: map[string]string{"GHSA-xxxx-yyyy-zzzz": "GO-2024-0001"},
+		},
+		{
+			name: "shared alias",
+			entries: []*osv.Entry{
+				{ID: "GO-2024-0002", Aliases: []string{"CVE-2024-0002"}},
+				{ID: "GHSA-aaaa-bbbb-cccc", Aliases: []string{"CVE-2024-0002"}},
+				{ID: "GO-2024-0003", Aliases: []string{"CVE-2024-0003"}},
+			},
+			want: map[string]string{"GHSA-aaaa-bbbb-cccc": "GO-2024-0002"},
+		},
+		{
+			name: "related entries are not duplicates",
+			entries: []*osv.Entry{
+				{ID: "GO-2024-0002", Related: []string{"CVE-2024-0003"}},
+				{ID: "GO-2024-0003", Aliases: []string{"CVE-2024-0003"}},
+			},
+			want: map[string]string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, duplicates(tc.entries)); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	affVulns := affectingVulns{
+		{
+			Module: &packages.Module{Path: "example.com/a", Version: "v1.0.0"},
+			Vulns: []*osv.Entry{
+				{ID: "GHSA-xxxx-yyyy-zzzz", Aliases: []string{"CVE-2024-0001"}},
+				{ID: "CVE-2024-0001", Aliases: []string{"GHSA-xxxx-yyyy-zzzz"}},
+			},
+		},
+	}
+	merged, dups := mergeDuplicates(affVulns)
+	h := test.NewMockHandler()
+	ctx := context.Background()
+	if err := emitDuplicates(ctx, h, dups); err != nil {
+		t.Fatal(err)
+	}
+	if err := emitModuleFindings(ctx, h, &govulncheck.Config{}, merged, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range h.FindingMessages {
+		got = append(got, f.OSV)
+	}
+	if diff := cmp.Diff([]string{"CVE-2024-0001"}, got); diff != "" {
+		t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+	}
+	want := []*govulncheck.Progress{{Message: "Reporting GHSA-xxxx-yyyy-zzzz as CVE-2024-0001, which it duplicates."}}
+	if diff := cmp.Diff(want, h.ProgressMessages); diff != "" {
+		t.Errorf("progress mismatch (-want, +got):\n%s", diff)
+	}
+	// The entries of affVulns are left untouched.
+	if n := len(affVulns[0].Vulns); n != 2 {
+		t.Errorf("got %d entries in affVulns; want 2", n)
+	}
+}
@@ -5,97 +5,662 @@
 package vulncheck
 
 import (
+	"context"
+	"fmt"
 	"go/token"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/internal"
 	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/semver"
 )
 
+// limitHandler is a Handler that forwards at most max
+// findings of each level to the underlying Handler.
+type limitHandler struct {
+	govulncheck.Handler
+	max int
+	n   map[EmitStage]int // number of findings forwarded, by level
+	// truncated is set once a finding has been dropped.
+	truncated bool
+}
+
+// limitFindings returns handler limited to cfg.MaxFindings
+// findings of each level, or handler itself if there is no limit.
+func limitFindings(handler govulncheck.Handler, cfg *govulncheck.Config) govulncheck.Handler {
+	if cfg.MaxFindings <= 0 {
+		return handler
+	}
+	return &limitHandler{Handler: handler, max: cfg.MaxFindings, n: make(map[EmitStage]int)}
+}
+
+// Finding forwards f unless the limit of findings of its level has
+// been reached. Levels are limited separately so that the findings at
+// the most precise level, which decide the outcome of the scan, are
+// not crowded out by the less precise ones emitted before them. When
+// the first finding is dropped, a progress message is emitted instead
+// to record the truncation.
+func (h *limitHandler) Finding(f *govulncheck.Finding) error {
+	stage := findingStage(f)
+	if h.n[stage] < h.max {
+		h.n[stage]++
+		return h.Handler.Finding(f)
+	}
+	if h.truncated {
+		return nil
+	}
+	h.truncated = true
+	return h.Handler.Progress(&govulncheck.Progress{
+		Message:   fmt.Sprintf(TruncatedFindingsMessage, h.max),
+		Warning:   true,
+		Truncated: true,
+	})
+}
+
+// hookHandler is a Handler that passes findings
+// through a hook before forwarding them.
+type hookHandler struct {
+	govulncheck.Handler
+	hook func(*govulncheck.Finding) *govulncheck.Finding
+}
+
+// hookFindings returns handler passing findings through
+// cfg.FindingHook, or handler itself if there is no hook.
+func hookFindings(handler govulncheck.Handler, cfg *govulncheck.Config) govulncheck.Handler {
+	if cfg.FindingHook == nil {
+		return handler
+	}
+	return &hookHandler{Handler: handler, hook: cfg.FindingHook}
+}
+
+// Finding forwards the finding returned by the hook
+// for f, unless the hook drops f by returning nil.
+func (h *hookHandler) Finding(f *govulncheck.Finding) error {
+	if f = h.hook(f); f == nil {
+		return nil
+	}
+	return h.Handler.Finding(f)
+}
+
+// findingPool is a Handler that delivers findings to a
+// concurrent Handler from a pool of goroutines.
+type findingPool struct {
+	govulncheck.Handler
+	findings chan *govulncheck.Finding
+	wg       sync.WaitGroup
+	// barrier is held for reading while findings are delivered
+	// and for writing while other messages are, so that only
+	// findings are delivered concurrently.
+	barrier sync.RWMutex
+
+	mu  sync.Mutex
+	err error // first error of the underlying Handler, as a HandlerError
+}
+
+// deliverFindings returns handler delivering findings from a pool
+// of cfg.FindingWorkers goroutines, if handler is concurrent, and
+// handler itself otherwise. The returned function waits for the
+// delivery of all findings and returns the first error, if any.
+// It must be called once all findings have been emitted.
+func deliverFindings(handler govulncheck.Handler, cfg *govulncheck.Config) (govulncheck.Handler, func() error) {
+	if cfg.FindingWorkers <= 1 || !govulncheck.Concurrent(handler) {
+		return handler, func() error { return nil }
+	}
+	p := &findingPool{Handler: handler, findings: make(chan *govulncheck.Finding)}
+	p.wg.Add(cfg.FindingWorkers)
+	for range cfg.FindingWorkers {
+		go p.work()
+	}
+	return p, p.wait
+}
+
+func (p *findingPool) work() {
+	defer p.wg.Done()
+	for f := range p.findings {
+		p.barrier.RLock()
+		err := p.Handler.Finding(f)
+		p.barrier.RUnlock()
+		if err != nil {
+			p.mu.Lock()
+			if p.err == nil {
+				p.err = handlerError(findingStage(f), f.OSV, err)
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// Finding hands f over to a worker, unless the delivery
+// of a previous finding failed, whose error is returned.
+func (p *findingPool) Finding(f *govulncheck.Finding) error {
+	p.mu.Lock()
+	err := p.err
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	p.findings <- f
+	return nil
+}
+
+// SBOM waits for the findings being delivered, if
+// any, and forwards sbom.
+func (p *findingPool) SBOM(sbom *govulncheck.SBOM) error {
+	p.barrier.Lock()
+	defer p.barrier.Unlock()
+	return p.Handler.SBOM(sbom)
+}
+
+// Progress waits for the findings being delivered,
+// if any, and forwards progress.
+func (p *findingPool) Progress(progress *govulncheck.Progress) error {
+	p.barrier.Lock()
+	defer p.barrier.Unlock()
+	return p.Handler.Progress(progress)
+}
+
+// OSV waits for the findings being delivered,
+// if any, and forwards e.
+func (p *findingPool) OSV(e *osv.Entry) error {
+	p.barrier.Lock()
+	defer p.barrier.Unlock()
+	return p.Handler.OSV(e)
+}
+
+func (p *findingPool) wait() error {
+	close(p.findings)
+	p.wg.Wait()
+	return p.err
+}
+
+// buildTimeHandler is a Handler that marks findings
+// for modules only needed by tools as build-time.
+type buildTimeHandler struct {
+	govulncheck.Handler
+	graph *PackageGraph
+}
+
+// markBuildTime returns handler marking the findings for tool
+// modules of graph as build-time, or handler itself if graph
+// has no tool modules.
+func markBuildTime(handler govulncheck.Handler, graph *PackageGraph) govulncheck.Handler {
+	if len(graph.toolModules) == 0 {
+		return handler
+	}
+	return &buildTimeHandler{Handler: handler, graph: graph}
+}
+
+// Finding sets BuildTime of f, if its vulnerable module is
+// only needed by tools, and forwards it.
+func (h *buildTimeHandler) Finding(f *govulncheck.Finding) error {
+	if len(f.Trace) > 0 && h.graph.IsToolModule(f.Trace[0].Module) {
+		f.BuildTime = true
+	}
+	return h.Handler.Finding(f)
+}
+
 // emitOSVs emits all OSV vuln entries in modVulns to handler.
-func emitOSVs(handler govulncheck.Handler, modVulns []*ModVulns) error {
+//
+// The emit functions stop and return ctx.Err() as soon as
+// ctx is cancelled. Errors of handler are returned as
+// HandlerErrors.
+func emitOSVs(ctx context.Context, handler govulncheck.Handler, modVulns []*ModVulns) error {
 	for _, mv := range modVulns {
 		for _, v := range mv.Vulns {
-			if err := handler.OSV(v); err != nil {
+			if err := ctx.Err(); err != nil {
 				return err
 			}
+			if err := handler.OSV(v); err != nil {
+				return handlerError(StageOSV, v.ID, err)
+			}
 		}
 	}
 	return nil
 }
 
+// emitStaleData emits a progress message to handler warning that
+// the vulnerability data is stale at now, if it is according to cfg.
+func emitStaleData(handler govulncheck.Handler, cfg *govulncheck.Config, now time.Time) error {
+	msg := govulncheck.StaleDataWarning(cfg, now)
+	if msg == "" {
+		return nil
+	}
+	return handler.Progress(&govulncheck.Progress{Message: msg, Warning: true})
+}
+
 // emitModuleFindings emits module-level findings for vulnerabilities in modVulns.
-func emitModuleFindings(handler govulncheck.Handler, affVulns affectingVulns) error {
+// Findings for modules that cfg does not report are skipped.
+//
+// If known and requested by cfg, the go.mod location of a finding is the
+// require directive of its module in requires, as computed by requirements.
+// Module frames have no position, as modules have no source.
+func emitModuleFindings(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.Config, affVulns affectingVulns, requires map[string]requirement) error {
+	// Emit findings in a deterministic order.
+	affVulns = slices.Clone(affVulns)
+	sort.SliceStable(affVulns, func(i, j int) bool { return affVulns[i].Module.Path < affVulns[j].Module.Path })
 	for _, vuln := range affVulns {
+		if !reportModule(cfg, modPath(vuln.Module)) {
+			continue
+		}
 		for _, osv := range vuln.Vulns {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			path, version := affectedPath(vuln.Module, osv.Affected), modVersion(vuln.Module)
+			frame := withReplaced(cfg, frameFromModule(vuln.Module), vuln.Module)
+			fixed := fixedVersion(cfg, path, version, osv.Affected)
 			if err := handler.Finding(&govulncheck.Finding{
-				OSV:          osv.ID,
-				FixedVersion: FixedVersion(modPath(vuln.Module), modVersion(vuln.Module), osv.Affected),
-				Trace:        []*govulncheck.Frame{frameFromModule(vuln.Module)},
+				OSV:                 osv.ID,
+				FixedVersion:        fixed,
+				FixedRevision:       semver.DescribePseudoVersion(fixed),
+				FixedInAdvisory:     FixedInAdvisory(path, version, osv.Affected),
+				IntroducedVersion:   IntroducedVersion(path, version, osv.Affected),
+				LastAffectedVersion: LastAffectedVersion(path, version, osv.Affected),
+				AffectedRanges:      AffectedRanges(path, osv.Affected),
+				Direct:              isDirect(requires, vuln.Module),
+				GoModLocation:       goModLocation(cfg, requires, vuln.Module),
+				Trace:               []*govulncheck.Frame{frame},
 			}); err != nil {
-				return err
+				return handlerError(StageModuleFindings, osv.ID, err)
 			}
 		}
 	}
 	return nil
 }
 
+// affectedPath returns the module path under which affected describes
+// the vulnerable versions of mod: the path of the module replacing mod,
+// if affected lists it, or the path of mod otherwise. An OSV entry can
+// list several modules, such as a module and its renamed successor,
+// whose ranges must not be conflated.
+func affectedPath(mod *packages.Module, affected []osv.Affected) string {
+	if mod == nil {
+		return ""
+	}
+	if mod.Replace != nil {
+		for _, a := range affected {
+			if a.Module.Path == mod.Replace.Path {
+				return mod.Replace.Path
+			}
+		}
+	}
+	return mod.Path
+}
+
+// fixedVersion is FixedVersion, except that fixes of the standard
+// library are reported as Go releases when requested by cfg.
+func fixedVersion(cfg *govulncheck.Config, modulePath, version string, affected []osv.Affected) string {
+	fixed := FixedVersion(modulePath, version, affected)
+	if fixed != "" && cfg.GoFixedVersions && modulePath == internal.GoStdModulePath {
+		return semver.SemverToGoTag(fixed)
+	}
+	return fixed
+}
+
+// goModLocation returns the position of the require directive
+// of mod in requires, if known and requested by cfg.
+func goModLocation(cfg *govulncheck.Config, requires map[string]requirement, mod *packages.Module) *govulncheck.Position {
+	if !cfg.IncludeGoModLocation || mod == nil {
+		return nil
+	}
+	if r, ok := requires[mod.Path]; ok {
+		return &r.pos
+	}
+	return nil
+}
+
+// isDirect reports whether mod is a direct requirement,
+// that is, one not marked as indirect, in requires.
+func isDirect(requires map[string]requirement, mod *packages.Module) bool {
+	if mod == nil {
+		return false
+	}
+	r, ok := requires[mod.Path]
+	return ok && !r.indirect
+}
+
+// requirement is a require directive of a main module.
+type requirement struct {
+	// pos is the position of the directive.
+	pos govulncheck.Position
+	// indirect is set if the directive
+	// is marked with an "// indirect" comment.
+	indirect bool
+}
+
+// requirements returns the require directives in the go.mod files
+// of the main modules of pkgs, keyed by required module path. The
+// file name of their positions is "go.mod", relative to the main
+// module root like other positions of findings.
+//
+// go.mod files that cannot be read or parsed are ignored.
+func requirements(pkgs []*packages.Package) map[string]requirement {
+	requires := make(map[string]requirement)
+	seen := make(map[string]bool)
+	for _, p := range pkgs {
+		if p.Module == nil || !p.Module.Main || p.Module.GoMod == "" || seen[p.Module.GoMod] {
+			continue
+		}
+		seen[p.Module.GoMod] = true
+		data, err := os.ReadFile(p.Module.GoMod)
+		if err != nil {
+			continue
+		}
+		f, err := modfile.ParseLax(p.Module.GoMod, data, nil)
+		if err != nil {
+			continue
+		}
+		for _, r := range f.Require {
+			if _, ok := requires[r.Mod.Path]; ok || r.Syntax == nil {
+				continue
+			}
+			start := r.Syntax.Start
+			requires[r.Mod.Path] = requirement{
+				pos: govulncheck.Position{
+					Filename: "go.mod",
+					Offset:   start.Byte,
+					Line:     start.Line,
+					Column:   start.LineRune,
+				},
+				indirect: r.Indirect,
+			}
+		}
+	}
+	return requires
+}
+
 // emitPackageFinding emits package-level findings fod vulnerabilities in vulns.
-func emitPackageFindings(handler govulncheck.Handler, vulns []*Vuln) error {
+// Findings for modules that cfg does not report, or for packages that cfg
+// reports at module level, are skipped. The go.mod locations of findings
+// are looked up in requires, if requested by cfg.
+func emitPackageFindings(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.Config, vulns []*Vuln, requires map[string]requirement) error {
+	// Emit findings in a deterministic order.
+	vulns = slices.Clone(vulns)
+	sort.SliceStable(vulns, func(i, j int) bool {
+		if vulns[i].OSV.ID != vulns[j].OSV.ID {
+			return vulns[i].OSV.ID < vulns[j].OSV.ID
+		}
+		return vulns[i].Package.PkgPath < vulns[j].Package.PkgPath
+	})
 	for _, v := range vulns {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !reportModule(cfg, modPath(v.Package.Module)) || !packageScanLevel(cfg, v.Package.PkgPath).WantPackages() {
+			continue
+		}
+		path, version := affectedPath(v.Package.Module, v.OSV.Affected), modVersion(v.Package.Module)
+		fixed := fixedVersion(cfg, path, version, v.OSV.Affected)
 		if err := handler.Finding(&govulncheck.Finding{
-			OSV:          v.OSV.ID,
-			FixedVersion: FixedVersion(modPath(v.Package.Module), modVersion(v.Package.Module), v.OSV.Affected),
-			Trace:        []*govulncheck.Frame{frameFromPackage(v.Package)},
+			OSV:                 v.OSV.ID,
+			FixedVersion:        fixed,
+			FixedRevision:       semver.DescribePseudoVersion(fixed),
+			FixedInAdvisory:     FixedInAdvisory(path, version, v.OSV.Affected),
+			IntroducedVersion:   IntroducedVersion(path, version, v.OSV.Affected),
+			LastAffectedVersion: LastAffectedVersion(path, version, v.OSV.Affected),
+			AffectedRanges:      AffectedRanges(path, v.OSV.Affected),
+			Direct:              isDirect(requires, v.Package.Module),
+			GoModLocation:       goModLocation(cfg, requires, v.Package.Module),
+			Trace:               []*govulncheck.Frame{withReplaced(cfg, frameFromPackage(v.Package), v.Package.Module)},
 		}); err != nil {
-			return err
+			return handlerError(StagePackageFindings, v.OSV.ID, err)
 		}
 	}
 	return nil
 }
 
 // emitCallFindings emits call-level findings for vulnerabilities
-// that have a call stack in callstacks.
-func emitCallFindings(handler govulncheck.Handler, callstacks map[*Vuln]CallStack) error {
+// that have a call stack in callstacks, one for each reachable
+// vulnerable symbol. Findings with identical traces for the same
+// OSV are emitted once. Findings reachable only from test code
+// are skipped if cfg.ExcludeTestOnly is set, as are findings for
+// packages that cfg reports at a less precise level than symbol.
+// The go.mod locations of findings are looked up in requires, if
+// requested by cfg.
+func emitCallFindings(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.Config, callstacks map[*Vuln]CallStack, requires map[string]requirement) error {
 	var vulns []*Vuln
 	for v := range callstacks {
 		vulns = append(vulns, v)
 	}
+	// Emit findings in a deterministic order.
+	sort.SliceStable(vulns, func(i, j int) bool {
+		vi, vj := vulns[i], vulns[j]
+		if vi.OSV.ID != vj.OSV.ID {
+			return vi.OSV.ID < vj.OSV.ID
+		}
+		if vi.Package.PkgPath != vj.Package.PkgPath {
+			return vi.Package.PkgPath < vj.Package.PkgPath
+		}
+		return vi.Symbol < vj.Symbol
+	})
 
+	seen := make(map[string]bool)
 	for _, vuln := range vulns {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		stack := callstacks[vuln]
-		if stack == nil {
+		if stack == nil || !reportModule(cfg, modPath(vuln.Package.Module)) || !packageScanLevel(cfg, vuln.Package.PkgPath).WantSymbols() {
 			continue
 		}
-		fixed := FixedVersion(modPath(vuln.Package.Module), modVersion(vuln.Package.Module), vuln.OSV.Affected)
+		testOnly := isTestEntry(stack[0])
+		if testOnly && cfg.ExcludeTestOnly {
+			continue
+		}
+		trace := traceFromEntries(stack, cfg)
+		// Each reachable vulnerable symbol of an OSV is reported
+		// in its own finding, but only once per trace.
+		key := traceKey(vuln.OSV.ID, trace)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		path, version := affectedPath(vuln.Package.Module, vuln.OSV.Affected), modVersion(vuln.Package.Module)
+		fixed := fixedVersion(cfg, path, version, vuln.OSV.Affected)
 		if err := handler.Finding(&govulncheck.Finding{
-			OSV:          vuln.OSV.ID,
-			FixedVersion: fixed,
-			Trace:        traceFromEntries(stack),
+			OSV:                 vuln.OSV.ID,
+			FixedVersion:        fixed,
+			FixedRevision:       semver.DescribePseudoVersion(fixed),
+			FixedInAdvisory:     FixedInAdvisory(path, version, vuln.OSV.Affected),
+			IntroducedVersion:   IntroducedVersion(path, version, vuln.OSV.Affected),
+			LastAffectedVersion: LastAffectedVersion(path, version, vuln.OSV.Affected),
+			AffectedRanges:      AffectedRanges(path, vuln.OSV.Affected),
+			Direct:              isDirect(requires, vuln.Package.Module),
+			GoModLocation:       goModLocation(cfg, requires, vuln.Package.Module),
+			PathCount:           pathCount(cfg, vuln),
+			Trace:               trace,
+			TestOnly:            testOnly,
+			ThroughInit:         throughInit(stack),
+			ThroughReflection:   throughReflection(stack),
 		}); err != nil {
-			return err
+			return handlerError(StageCallFindings, vuln.OSV.ID, err)
 		}
 	}
 	return nil
 }
 
+// throughInit reports whether stack reaches its vulnerable symbol
+// through a package initializer or a function literal within one,
+// which are named, say, "init#1$1".
+func throughInit(stack CallStack) bool {
+	for _, e := range callers(stack) {
+		if isInit(e.Function) || strings.HasPrefix(e.Function.Name, "init$") {
+			return true
+		}
+	}
+	return false
+}
+
+// throughReflection reports whether stack reaches
+// its vulnerable symbol through package reflect.
+func throughReflection(stack CallStack) bool {
+	for _, e := range callers(stack) {
+		if p := e.Function.Package; p != nil && p.PkgPath == "reflect" {
+			return true
+		}
+	}
+	return false
+}
+
+// callers returns the entries of stack calling its vulnerable symbol,
+// which is the last entry.
+func callers(stack CallStack) CallStack {
+	if len(stack) == 0 {
+		return nil
+	}
+	return stack[:len(stack)-1]
+}
+
+// pathCount returns the number of call stacks found
+// reaching vuln, if requested by cfg, and 0 otherwise.
+func pathCount(cfg *govulncheck.Config, vuln *Vuln) int {
+	if !cfg.IncludePathCount {
+		return 0
+	}
+	return vuln.PathCount
+}
+
+// traceKey returns a key identifying the finding
+// for the vulnerability osv with trace.
+func traceKey(osv string, trace []*govulncheck.Frame) string {
+	var b strings.Builder
+	b.WriteString(osv)
+	for _, fr := range trace {
+		fmt.Fprintf(&b, "|%s@%s %s.%s.%s", fr.Module, fr.Version, fr.Package, fr.Receiver, fr.Function)
+		if p := fr.Position; p != nil {
+			fmt.Fprintf(&b, " %s:%d:%d", p.Filename, p.Line, p.Column)
+		}
+	}
+	return b.String()
+}
+
+// reportModule reports whether findings for the module at path
+// are in the scope of cfg.IncludeModulePrefixes and
+// cfg.ExcludeModulePrefixes.
+func reportModule(cfg *govulncheck.Config, path string) bool {
+	for _, p := range cfg.ExcludeModulePrefixes {
+		if hasPathPrefix(path, p) {
+			return false
+		}
+	}
+	if len(cfg.IncludeModulePrefixes) == 0 {
+		return true
+	}
+	for _, p := range cfg.IncludeModulePrefixes {
+		if hasPathPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPathPrefix reports whether the slash-separated path
+// consists of prefix followed by zero or more path elements.
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// packageScanLevel returns the most precise level at which findings
+// for the vulnerable package at path are reported: the level of the
+// longest pattern of cfg.PackageScanLevels matching path, if any. It
+// is symbol otherwise, as cfg.ScanLevel already bounds the analysis.
+func packageScanLevel(cfg *govulncheck.Config, path string) govulncheck.ScanLevel {
+	level, best := govulncheck.ScanLevel(govulncheck.ScanLevelSymbol), ""
+	for p, l := range cfg.PackageScanLevels {
+		if !matchPackagePattern(p, path) {
+			continue
+		}
+		// Break ties between patterns of the same
+		// length deterministically.
+		if best == "" || len(p) > len(best) || (len(p) == len(best) && p < best) {
+			level, best = l, p
+		}
+	}
+	return level
+}
+
+// matchPackagePattern reports whether the package path matches
+// pattern, in which "..." matches any string, including slashes.
+// As for go list, a trailing "/..." also matches the empty string,
+// so that "net/..." matches both "net" and "net/http".
+func matchPackagePattern(pattern, path string) bool {
+	re := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\.\.\.`, `.*`)
+	if r, ok := strings.CutSuffix(re, `/.*`); ok {
+		re = r + `(/.*)?`
+	}
+	matched, err := regexp.MatchString("^"+re+"$", path)
+	return err == nil && matched
+}
+
+// wantSymbols reports whether findings for any of
+// vulns are reported at symbol level according to cfg.
+func wantSymbols(cfg *govulncheck.Config, vulns []*Vuln) bool {
+	for _, v := range vulns {
+		if packageScanLevel(cfg, v.Package.PkgPath).WantSymbols() {
+			return true
+		}
+	}
+	return false
+}
+
+// isTestEntry reports whether the entry point e of a call
+// stack is test code: a function of a test package or one
+// defined in a _test.go file.
+func isTestEntry(e StackEntry) bool {
+	f := e.Function
+	if f == nil {
+		return false
+	}
+	if f.Package != nil {
+		p := f.Package.PkgPath
+		if strings.HasSuffix(p, ".test") || strings.HasSuffix(p, "_test") {
+			return true
+		}
+	}
+	if f.Pos != nil && strings.HasSuffix(f.Pos.Filename, "_test.go") {
+		return true
+	}
+	return e.Call != nil && e.Call.Pos != nil && strings.HasSuffix(e.Call.Pos.Filename, "_test.go")
+}
+
 // traceFromEntries creates a sequence of
 // frames from vcs. Position of a Frame is the
 // call position of the corresponding stack entry.
-func traceFromEntries(vcs CallStack) []*govulncheck.Frame {
+// Standard library frames are collapsed if requested
+// by cfg, and the frames are truncated to the maximum
+// trace depth of cfg, if positive.
+func traceFromEntries(vcs CallStack, cfg *govulncheck.Config) []*govulncheck.Frame {
 	var frames []*govulncheck.Frame
 	for i := len(vcs) - 1; i >= 0; i-- {
 		e := vcs[i]
 		fr := frameFromPackage(e.Function.Package)
+		if e.Function.Package != nil {
+			withReplaced(cfg, fr, e.Function.Package.Module)
+		}
 		fr.Function = e.Function.Name
 		fr.Receiver = e.Function.Receiver()
 		isSink := i == (len(vcs) - 1)
 		fr.Position = posFromStackEntry(e, isSink)
 		frames = append(frames, fr)
 	}
-	return frames
+	if cfg.CollapseStdlib {
+		frames = govulncheck.CollapseStdlib(frames)
+	}
+	return govulncheck.TruncateTrace(frames, cfg.MaxTraceDepth)
 }
 
 func posFromStackEntry(e StackEntry, sink bool) *govulncheck.Position {
@@ -132,7 +697,7 @@ func posFromStackEntry(e StackEntry, sink bool) *govulncheck.Position {
 // The returned paths always use slash as separator
 // so they can work across different platforms.
 func pathRelativeToMod(path string, f *FuncNode) string {
-	if path == "" || f == nil || f.Package == nil { // sanity
+	if path == "" || f == nil || f.Package == nil || f.Package.Module == nil { // sanity
 		return ""
 	}
 
@@ -169,13 +734,21 @@ func modDirWithVendor(modDir, path, module string) string {
 	return filepath.Join(path[:vendorIndex], "vendor", filepath.FromSlash(module))
 }
 
+// frameFromPackage creates a frame for pkg. If module
+// information of pkg is not available, as with packages
+// loaded outside of module mode, the frame will only
+// have the package path.
 func frameFromPackage(pkg *packages.Package) *govulncheck.Frame {
 	fr := &govulncheck.Frame{}
-	if pkg != nil {
-		fr.Module = pkg.Module.Path
-		fr.Version = pkg.Module.Version
-		fr.Package = pkg.PkgPath
+	if pkg == nil {
+		return fr
 	}
+	fr.Package = pkg.PkgPath
+	if pkg.Module == nil {
+		return fr
+	}
+	fr.Module = pkg.Module.Path
+	fr.Version = pkg.Module.Version
 	if pkg.Module.Replace != nil {
 		fr.Module = pkg.Module.Replace.Path
 		fr.Version = pkg.Module.Replace.Version
@@ -196,3 +769,14 @@ func frameFromModule(mod *packages.Module) *govulncheck.Frame {
 
 	return fr
 }
+
+// withReplaced records in fr, the frame of a package or module of mod,
+// the path and version of mod if it is replaced, as requested by cfg.
+// It returns fr.
+func withReplaced(cfg *govulncheck.Config, fr *govulncheck.Frame, mod *packages.Module) *govulncheck.Frame {
+	if cfg.IncludeReplaced && mod != nil && mod.Replace != nil {
+		fr.ReplacedModule = mod.Path
+		fr.ReplacedVersion = mod.Version
+	}
+	return fr
+}
@@ -0,0 +1,834 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/internal"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+func TestFrameFromPackage(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		pkg  *packages.Package
+		want *govulncheck.Frame
+	}{
+		{
+			name: "nil",
+			pkg:  nil,
+			want: &govulncheck.Frame{},
+		},
+		{
+			name: "no-module",
+			pkg:  &packages.Package{PkgPath: "example.com/p"},
+			want: &govulncheck.Frame{Package: "example.com/p"},
+		},
+		{
+			name: "module",
+			pkg: &packages.Package{
+				PkgPath: "example.com/m/p",
+				Module:  &packages.Module{Path: "example.com/m", Version: "v1.0.0"},
+			},
+			want: &govulncheck.Frame{Module: "example.com/m", Version: "v1.0.0", Package: "example.com/m/p"},
+		},
+		{
+			name: "replace",
+			pkg: &packages.Package{
+				PkgPath: "example.com/m/p",
+				Module: &packages.Module{Path: "example.com/m", Version: "v1.0.0",
+					Replace: &packages.Module{Path: "example.com/r", Version: "v1.1.0"}},
+			},
+			want: &govulncheck.Frame{Module: "example.com/r", Version: "v1.1.0", Package: "example.com/m/p"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := frameFromPackage(tc.pkg)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEmitCallFindingsTestOnly(t *testing.T) {
+	mod := &packages.Module{Path: "example.com/m", Version: "v1.0.0"}
+	vpkg := &packages.Package{PkgPath: "example.com/m/vuln", Module: mod}
+	mpkg := &packages.Package{PkgPath: "example.com/m/p", Module: mod}
+	sink := &FuncNode{Name: "Vuln", Package: vpkg, Pos: &token.Position{Filename: "/m/vuln/vuln.go", Line: 3}}
+	tfn := &FuncNode{Name: "TestP", Package: mpkg, Pos: &token.Position{Filename: "/m/p/p_test.go", Line: 5}}
+	main := &FuncNode{Name: "P", Package: mpkg, Pos: &token.Position{Filename: "/m/p/p.go", Line: 5}}
+	stack := func(entry *FuncNode) CallStack {
+		return CallStack{
+			{Function: entry, Call: &CallSite{Parent: entry, Name: "Vuln", Pos: &token.Position{Filename: entry.Pos.Filename, Line: 6}}},
+			{Function: sink},
+		}
+	}
+	callstacks := map[*Vuln]CallStack{
+		{OSV: &osv.Entry{ID: "GO-0000-0001"}, Symbol: "Vuln", Package: vpkg}: stack(tfn),
+		{OSV: &osv.Entry{ID: "GO-0000-0002"}, Symbol: "Vuln", Package: vpkg}: stack(main),
+	}
+
+	for _, exclude := range []bool{false, true} {
+		h := test.NewMockHandler()
+		if err := emitCallFindings(context.Background(), h, &govulncheck.Config{ExcludeTestOnly: exclude}, callstacks, nil); err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]bool)
+		for _, f := range h.FindingMessages {
+			got[f.OSV] = f.TestOnly
+		}
+		want := map[string]bool{"GO-0000-0001": true, "GO-0000-0002": false}
+		if exclude {
+			want = map[string]bool{"GO-0000-0002": false}
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("exclude=%t: test only mismatch (-want, +got):\n%s", exclude, diff)
+		}
+	}
+}
+
+// cancelHandler is a test handler that
+// cancels a context upon the first finding.
+type cancelHandler struct {
+	*test.MockHandler
+	cancel context.CancelFunc
+}
+
+func (h *cancelHandler) Finding(f *govulncheck.Finding) error {
+	h.cancel()
+	return h.MockHandler.Finding(f)
+}
+
+func TestEmitCancel(t *testing.T) {
+	mod := &packages.Module{Path: "example.com/m", Version: "v1.0.0"}
+	pkg := &packages.Package{PkgPath: "example.com/m/p", Module: mod}
+	var vulns []*Vuln
+	for _, id := range []string{"GO-0000-0001", "GO-0000-0002", "GO-0000-0003"} {
+		vulns = append(vulns, &Vuln{OSV: &osv.Entry{ID: id}, Package: pkg})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := &cancelHandler{MockHandler: test.NewMockHandler(), cancel: cancel}
+	err := emitPackageFindings(ctx, h, &govulncheck.Config{}, vulns, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("want %v; got %v", context.Canceled, err)
+	}
+	if got := len(h.FindingMessages); got != 1 {
+		t.Errorf("want 1 finding emitted before cancellation; got %d", got)
+	}
+}
+
+func TestModuleFindingPosition(t *testing.T) {
+	gomod := filepath.Join(t.TempDir(), "go.mod")
+	content := `module example.com/main
+
+go 1.22
+
+require example.com/a v1.0.0
+
+require (
+	// b is vulnerable.
+	example.com/b v1.1.0
+)
+`
+	if err := os.WriteFile(gomod, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	main := &packages.Module{Path: "example.com/main", Main: true, GoMod: gomod}
+	requires := requirements([]*packages.Package{{PkgPath: "example.com/main", Module: main}})
+
+	want := map[string]requirement{
+		"example.com/a": {pos: govulncheck.Position{Filename: "go.mod", Offset: 34, Line: 5, Column: 1}},
+		"example.com/b": {pos: govulncheck.Position{Filename: "go.mod", Offset: 96, Line: 9, Column: 2}},
+	}
+	if diff := cmp.Diff(want, requires, cmp.AllowUnexported(requirement{})); diff != "" {
+		t.Fatalf("positions mismatch (-want, +got):\n%s", diff)
+	}
+
+	affVulns := affectingVulns{
+		{Module: &packages.Module{Path: "example.com/b", Version: "v1.1.0"}, Vulns: []*osv.Entry{{ID: "GO-0000-0001"}}},
+		{Module: &packages.Module{Path: "example.com/c", Version: "v0.1.0"}, Vulns: []*osv.Entry{{ID: "GO-0000-0002"}}},
+	}
+	h := test.NewMockHandler()
+	if err := emitModuleFindings(context.Background(), h, &govulncheck.Config{IncludeGoModLocation: true}, affVulns, requires); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]*govulncheck.Position)
+	for _, f := range h.FindingMessages {
+		// Modules have no source, so module frames have no position.
+		if pos := f.Trace[0].Position; pos != nil {
+			t.Errorf("%s: want no frame position; got %+v", f.OSV, pos)
+		}
+		got[f.OSV] = f.GoModLocation
+	}
+	wantPos := map[string]*govulncheck.Position{
+		"GO-0000-0001": {Filename: "go.mod", Offset: 96, Line: 9, Column: 2},
+		"GO-0000-0002": nil, // not required by the main module
+	}
+	if diff := cmp.Diff(wantPos, got); diff != "" {
+		t.Errorf("finding positions mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestGoModLocation(t *testing.T) {
+	gomod := filepath.Join(t.TempDir(), "go.mod")
+	content := `module example.com/main
+
+go 1.22
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.1.0
+)
+`
+	if err := os.WriteFile(gomod, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	main := &packages.Module{Path: "example.com/main", Main: true, GoMod: gomod}
+	requires := requirements([]*packages.Package{{PkgPath: "example.com/main", Module: main}})
+
+	mod := &packages.Module{Path: "example.com/b", Version: "v1.1.0"}
+	vulns := []*Vuln{{
+		OSV:     &osv.Entry{ID: "GO-0000-0001"},
+		Package: &packages.Package{PkgPath: "example.com/b/p", Module: mod},
+	}}
+	for _, include := range []bool{false, true} {
+		h := test.NewMockHandler()
+		cfg := &govulncheck.Config{IncludeGoModLocation: include}
+		if err := emitPackageFindings(context.Background(), h, cfg, vulns, requires); err != nil {
+			t.Fatal(err)
+		}
+		var want *govulncheck.Position
+		if include {
+			want = &govulncheck.Position{Filename: "go.mod", Offset: 67, Line: 7, Column: 2}
+		}
+		if diff := cmp.Diff(want, h.FindingMessages[0].GoModLocation); diff != "" {
+			t.Errorf("include=%t: go.mod location mismatch (-want, +got):\n%s", include, diff)
+		}
+	}
+}
+
+func TestDirect(t *testing.T) {
+	gomod := filepath.Join(t.TempDir(), "go.mod")
+	content := `module example.com/main
+
+go 1.22
+
+require example.com/direct v1.0.0
+
+require example.com/indirect v1.0.0 // indirect
+`
+	if err := os.WriteFile(gomod, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	main := &packages.Module{Path: "example.com/main", Main: true, GoMod: gomod}
+	requires := requirements([]*packages.Package{{PkgPath: "example.com/main", Module: main}})
+
+	var vulns []*Vuln
+	for i, path := range []string{"example.com/direct", "example.com/indirect"} {
+		mod := &packages.Module{Path: path, Version: "v1.0.0"}
+		vulns = append(vulns, &Vuln{
+			OSV:     &osv.Entry{ID: fmt.Sprintf("GO-0000-000%d", i+1)},
+			Package: &packages.Package{PkgPath: path + "/p", Module: mod},
+		})
+	}
+	h := test.NewMockHandler()
+	if err := emitPackageFindings(context.Background(), h, &govulncheck.Config{}, vulns, requires); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, f := range h.FindingMessages {
+		got[f.Trace[0].Module] = f.Direct
+	}
+	want := map[string]bool{"example.com/direct": true, "example.com/indirect": false}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("direct mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestEmitModulePrefixes(t *testing.T) {
+	affVulns := affectingVulns{
+		{Module: &packages.Module{Path: "example.com/a", Version: "v1.0.0"}, Vulns: []*osv.Entry{{ID: "GO-0000-0001"}}},
+		{Module: &packages.Module{Path: "example.com/a/b", Version: "v1.0.0"}, Vulns: []*osv.Entry{{ID: "GO-0000-0002"}}},
+		{Module: &packages.Module{Path: "example.com/ab", Version: "v1.0.0"}, Vulns: []*osv.Entry{{ID: "GO-0000-0003"}}},
+		{Module: &packages.Module{Path: "other.org/c", Version: "v1.0.0"}, Vulns: []*osv.Entry{{ID: "GO-0000-0004"}}},
+	}
+	for _, tc := range []struct {
+		name             string
+		include, exclude []string
+		want             []string
+	}{
+		{"none", nil, nil, []string{"GO-0000-0001", "GO-0000-0002", "GO-0000-0003", "GO-0000-0004"}},
+		{"include", []string{"example.com/a"}, nil, []string{"GO-0000-0001", "GO-0000-0002"}},
+		{"include-slash", []string{"example.com/"}, nil, []string{"GO-0000-0001", "GO-0000-0002", "GO-0000-0003"}},
+		{"exclude", nil, []string{"example.com/a/b", "other.org"}, []string{"GO-0000-0001", "GO-0000-0003"}},
+		{"exclude-wins", []string{"example.com/a"}, []string{"example.com/a/b"}, []string{"GO-0000-0001"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &govulncheck.Config{IncludeModulePrefixes: tc.include, ExcludeModulePrefixes: tc.exclude}
+			h := test.NewMockHandler()
+			if err := emitModuleFindings(context.Background(), h, cfg, affVulns, nil); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range h.FindingMessages {
+				got = append(got, f.OSV)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPackageScanLevels(t *testing.T) {
+	mod := &packages.Module{Path: "example.com/m", Version: "v1.0.0"}
+	crypto := &packages.Package{PkgPath: "example.com/m/crypto", Module: mod}
+	util := &packages.Package{PkgPath: "example.com/m/util", Module: mod}
+	mpkg := &packages.Package{PkgPath: "example.com/main", Module: &packages.Module{Path: "example.com/main"}}
+	main := &FuncNode{Name: "main", Package: mpkg}
+	entry := &osv.Entry{ID: "GO-0000-0001"}
+	vulns := []*Vuln{
+		{OSV: entry, Symbol: "Encrypt", Package: crypto},
+		{OSV: entry, Symbol: "Split", Package: util},
+	}
+	callstacks := make(map[*Vuln]CallStack)
+	for _, v := range vulns {
+		sink := &FuncNode{Name: v.Symbol, Package: v.Package}
+		callstacks[v] = CallStack{{Function: main, Call: &CallSite{Parent: main, Name: v.Symbol}}, {Function: sink}}
+	}
+	cfg := &govulncheck.Config{
+		ScanLevel: govulncheck.ScanLevelSymbol,
+		PackageScanLevels: map[string]govulncheck.ScanLevel{
+			"example.com/...":          govulncheck.ScanLevelModule,
+			"example.com/m/crypto/...": govulncheck.ScanLevelSymbol,
+		},
+	}
+
+	h := test.NewMockHandler()
+	ctx := context.Background()
+	if err := emitPackageFindings(ctx, h, cfg, vulns, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := emitCallFindings(ctx, h, cfg, callstacks, nil); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range h.FindingMessages {
+		got = append(got, f.Trace[0].Package+"."+f.Trace[0].Function)
+	}
+	// The util package is reported at module level only,
+	// and hence has neither package nor symbol findings.
+	want := []string{"example.com/m/crypto.", "example.com/m/crypto.Encrypt"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestMatchPackagePattern(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"net/http", "net/http", true},
+		{"net/http", "net/http/httputil", false},
+		{"net/...", "net", true},
+		{"net/...", "net/http", true},
+		{"net/...", "netip", false},
+		{"example.com/.../internal", "example.com/a/b/internal", true},
+		{"example.com/.../internal", "example.com/a/internal/x", false},
+		{"a.b", "axb", false},
+	} {
+		if got := matchPackagePattern(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("matchPackagePattern(%q, %q) = %t; want %t", tc.pattern, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestEmitReplaced(t *testing.T) {
+	// The go.mod of the main module has the directive
+	//
+	//	replace example.com/m => example.com/fork v1.1.0
+	mod := &packages.Module{
+		Path:    "example.com/m",
+		Version: "v1.0.0",
+		Replace: &packages.Module{Path: "example.com/fork", Version: "v1.1.0"},
+	}
+	pkg := &packages.Package{PkgPath: "example.com/m/p", Module: mod}
+	entry := &osv.Entry{ID: "GO-0000-0001"}
+	for _, include := range []bool{false, true} {
+		cfg := &govulncheck.Config{IncludeReplaced: include}
+		h := test.NewMockHandler()
+		ctx := context.Background()
+		if err := emitModuleFindings(ctx, h, cfg, affectingVulns{{Module: mod, Vulns: []*osv.Entry{entry}}}, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := emitPackageFindings(ctx, h, cfg, []*Vuln{{OSV: entry, Package: pkg}}, nil); err != nil {
+			t.Fatal(err)
+		}
+		want := &govulncheck.Frame{Module: "example.com/fork", Version: "v1.1.0"}
+		if include {
+			want.ReplacedModule, want.ReplacedVersion = "example.com/m", "v1.0.0"
+		}
+		for _, f := range h.FindingMessages {
+			got := *f.Trace[0]
+			got.Package = ""
+			if diff := cmp.Diff(want, &got); diff != "" {
+				t.Errorf("include %t: frame mismatch (-want, +got):\n%s", include, diff)
+			}
+		}
+	}
+}
+
+func TestLimitFindings(t *testing.T) {
+	var affVulns affectingVulns
+	for _, p := range []string{"example.com/c", "example.com/a", "example.com/b"} {
+		affVulns = append(affVulns, &ModVulns{
+			Module: &packages.Module{Path: p, Version: "v1.0.0"},
+			Vulns:  []*osv.Entry{{ID: "GO-0000-" + p[len(p)-1:]}},
+		})
+	}
+	mock := test.NewMockHandler()
+	h := limitFindings(mock, &govulncheck.Config{MaxFindings: 2})
+	if err := emitModuleFindings(context.Background(), h, &govulncheck.Config{}, affVulns, nil); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range mock.FindingMessages {
+		got = append(got, f.OSV)
+	}
+	if diff := cmp.Diff([]string{"GO-0000-a", "GO-0000-b"}, got); diff != "" {
+		t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+	}
+	if !h.(*limitHandler).truncated {
+		t.Error("want truncation recorded")
+	}
+	if len(mock.ProgressMessages) != 1 || !mock.ProgressMessages[0].Truncated {
+		t.Errorf("want 1 truncation progress message; got %+v", mock.ProgressMessages)
+	}
+
+	// Called findings, emitted last, are not crowded
+	// out by the module findings emitted before them.
+	mock = test.NewMockHandler()
+	h = limitFindings(mock, &govulncheck.Config{MaxFindings: 1})
+	for _, f := range []*govulncheck.Finding{
+		{OSV: "GO-0000-a", Trace: []*govulncheck.Frame{{Module: "example.com/a"}}},
+		{OSV: "GO-0000-b", Trace: []*govulncheck.Frame{{Module: "example.com/b"}}},
+		{OSV: "GO-0000-b", Trace: []*govulncheck.Frame{{Module: "example.com/b", Package: "example.com/b", Function: "F"}}},
+	} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(mock.FindingMessages); n != 2 || !govulncheck.IsCalled(mock.FindingMessages[1]) {
+		t.Errorf("want a module and a called finding; got %d findings", n)
+	}
+
+	if h := limitFindings(mock, &govulncheck.Config{}); h != govulncheck.Handler(mock) {
+		t.Error("want no limit without MaxFindings")
+	}
+}
+
+func TestFindingHook(t *testing.T) {
+	var affVulns affectingVulns
+	for _, p := range []string{"example.com/a", "example.com/b", "example.com/c"} {
+		affVulns = append(affVulns, &ModVulns{
+			Module: &packages.Module{Path: p, Version: "v1.0.0"},
+			Vulns:  []*osv.Entry{{ID: "GO-0000-" + p[len(p)-1:]}},
+		})
+	}
+	cfg := &govulncheck.Config{
+		// Findings dropped by the hook do not count towards the limit.
+		MaxFindings: 2,
+		FindingHook: func(f *govulncheck.Finding) *govulncheck.Finding {
+			if f.OSV == "GO-0000-a" {
+				return nil
+			}
+			f.Annotations = map[string]string{"ticket": "SEC-" + f.OSV[len(f.OSV)-1:]}
+			return f
+		},
+	}
+	mock := test.NewMockHandler()
+	h := hookFindings(limitFindings(mock, cfg), cfg)
+	if err := emitModuleFindings(context.Background(), h, cfg, affVulns, nil); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, f := range mock.FindingMessages {
+		got[f.OSV] = f.Annotations["ticket"]
+	}
+	want := map[string]string{"GO-0000-b": "SEC-b", "GO-0000-c": "SEC-c"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+	}
+
+	if h := hookFindings(mock, &govulncheck.Config{}); h != govulncheck.Handler(mock) {
+		t.Error("want no hook without FindingHook")
+	}
+}
+
+func TestEmitCallFindingsPerSymbol(t *testing.T) {
+	mod := &packages.Module{Path: "example.com/m", Version: "v1.0.0"}
+	vpkg := &packages.Package{PkgPath: "example.com/m/vuln", Module: mod}
+	mpkg := &packages.Package{PkgPath: "example.com/main", Module: &packages.Module{Path: "example.com/main"}}
+	main := &FuncNode{Name: "main", Package: mpkg, Pos: &token.Position{Filename: "/main/main.go", Line: 5}}
+	stack := func(sym string, line int) CallStack {
+		sink := &FuncNode{Name: sym, Package: vpkg, Pos: &token.Position{Filename: "/m/vuln/vuln.go", Line: line}}
+		return CallStack{
+			{Function: main, Call: &CallSite{Parent: main, Name: sym, Pos: &token.Position{Filename: "/main/main.go", Line: line}}},
+			{Function: sink},
+		}
+	}
+	entry := &osv.Entry{ID: "GO-0000-0001"}
+	callstacks := map[*Vuln]CallStack{
+		{OSV: entry, Symbol: "A", Package: vpkg}: stack("A", 10),
+		{OSV: entry, Symbol: "B", Package: vpkg}: stack("B", 20),
+		// Duplicate of the first vulnerability.
+		{OSV: entry, Symbol: "A", Package: vpkg}: stack("A", 10),
+	}
+
+	h := test.NewMockHandler()
+	if err := emitCallFindings(context.Background(), h, &govulncheck.Config{}, callstacks, nil); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range h.FindingMessages {
+		got = append(got, f.OSV+" "+f.Trace[0].Function)
+	}
+	want := []string{"GO-0000-0001 A", "GO-0000-0001 B"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestEmitThroughInit(t *testing.T) {
+	mod := &packages.Module{Path: "example.com/m", Version: "v1.0.0"}
+	vpkg := &packages.Package{PkgPath: "example.com/m/vuln", Module: mod}
+	mpkg := &packages.Package{PkgPath: "example.com/main", Module: &packages.Module{Path: "example.com/main"}}
+	rpkg := &packages.Package{PkgPath: "reflect", Module: &packages.Module{Path: "stdlib"}}
+	stack := func(sym string, callers ...*FuncNode) CallStack {
+		var s CallStack
+		for _, f := range callers {
+			s = append(s, StackEntry{Function: f, Call: &CallSite{Parent: f, Name: sym}})
+		}
+		return append(s, StackEntry{Function: &FuncNode{Name: sym, Package: vpkg}})
+	}
+	main := &FuncNode{Name: "main", Package: mpkg}
+	entry := &osv.Entry{ID: "GO-0000-0001"}
+	callstacks := map[*Vuln]CallStack{
+		{OSV: entry, Symbol: "A", Package: vpkg}: stack("A", main),
+		// The explicit init of the main package calls B.
+		{OSV: entry, Symbol: "B", Package: vpkg}: stack("B", &FuncNode{Name: "init#1", Package: mpkg}),
+		// A function literal of the implicit init calls C.
+		{OSV: entry, Symbol: "C", Package: vpkg}: stack("C", &FuncNode{Name: "init", Package: mpkg}, &FuncNode{Name: "init$1", Package: mpkg}),
+		{OSV: entry, Symbol: "D", Package: vpkg}: stack("D", main, &FuncNode{Name: "Call", RecvType: "reflect.Value", Package: rpkg}),
+	}
+
+	h := test.NewMockHandler()
+	if err := emitCallFindings(context.Background(), h, &govulncheck.Config{}, callstacks, nil); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][2]bool)
+	for _, f := range h.FindingMessages {
+		got[f.Trace[0].Function] = [2]bool{f.ThroughInit, f.ThroughReflection}
+	}
+	want := map[string][2]bool{
+		"A": {false, false},
+		"B": {true, false},
+		"C": {true, false},
+		"D": {false, true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestEmitFixedRevision(t *testing.T) {
+	mod := &packages.Module{Path: "example.com/m", Version: "v0.0.0-20220101000000-111111111111"}
+	affected := func(fixed string) []osv.Affected {
+		return []osv.Affected{{
+			Module: osv.Module{Path: mod.Path},
+			Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: fixed}}}},
+		}}
+	}
+	for _, tc := range []struct {
+		fixed string
+		want  string
+	}{
+		{"0.0.0-20230101000000-abcdef123456", "commit abcdef123456 from 2023-01-01"},
+		{"1.0.0", ""},
+	} {
+		h := test.NewMockHandler()
+		vulns := []*Vuln{{
+			OSV:     &osv.Entry{ID: "GO-0000-0001", Affected: affected(tc.fixed)},
+			Package: &packages.Package{PkgPath: "example.com/m/p", Module: mod},
+		}}
+		if err := emitPackageFindings(context.Background(), h, &govulncheck.Config{}, vulns, nil); err != nil {
+			t.Fatal(err)
+		}
+		f := h.FindingMessages[0]
+		if f.FixedVersion != "v"+tc.fixed {
+			t.Errorf("want fixed version v%s; got %s", tc.fixed, f.FixedVersion)
+		}
+		if f.FixedRevision != tc.want {
+			t.Errorf("%s: want fixed revision %q; got %q", tc.fixed, tc.want, f.FixedRevision)
+		}
+	}
+}
+
+func TestEmitPathCount(t *testing.T) {
+	mod := &packages.Module{Path: "example.com/m", Version: "v1.0.0"}
+	vpkg := &packages.Package{PkgPath: "example.com/m/vuln", Module: mod}
+	mpkg := &packages.Package{PkgPath: "example.com/main", Module: &packages.Module{Path: "example.com/main"}}
+	main := &FuncNode{Name: "main", Package: mpkg}
+	sink := &FuncNode{Name: "A", Package: vpkg}
+	vuln := &Vuln{OSV: &osv.Entry{ID: "GO-0000-0001"}, Symbol: "A", Package: vpkg, PathCount: 3}
+	callstacks := map[*Vuln]CallStack{
+		vuln: {{Function: main, Call: &CallSite{Parent: main, Name: "A"}}, {Function: sink}},
+	}
+
+	for _, include := range []bool{false, true} {
+		h := test.NewMockHandler()
+		if err := emitCallFindings(context.Background(), h, &govulncheck.Config{IncludePathCount: include}, callstacks, nil); err != nil {
+			t.Fatal(err)
+		}
+		want := 0
+		if include {
+			want = 3
+		}
+		if got := h.FindingMessages[0].PathCount; got != want {
+			t.Errorf("include %t: want path count %d; got %d", include, want, got)
+		}
+	}
+}
+
+func TestEmitAffectedModule(t *testing.T) {
+	// The OSV lists a module and its renamed successor,
+	// fixed at different versions.
+	entry := &osv.Entry{
+		ID: "GO-0000-0001",
+		Affected: []osv.Affected{
+			{
+				Module: osv.Module{Path: "example.com/old"},
+				Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "1.5.0"}}}},
+			},
+			{
+				Module: osv.Module{Path: "example.com/new"},
+				Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "1.2.0"}}}},
+			},
+		},
+	}
+	for _, tc := range []struct {
+		name string
+		mod  *packages.Module
+		want string
+	}{
+		{"old", &packages.Module{Path: "example.com/old", Version: "v1.1.0"}, "v1.5.0"},
+		{"new", &packages.Module{Path: "example.com/new", Version: "v1.1.0"}, "v1.2.0"},
+		{"replaced", &packages.Module{Path: "example.com/old", Version: "v1.0.0",
+			Replace: &packages.Module{Path: "example.com/new", Version: "v1.1.0"}}, "v1.2.0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			affVulns := affectingVulnerabilities([]*ModVulns{{Module: tc.mod, Vulns: []*osv.Entry{entry}}}, "", "")
+			h := test.NewMockHandler()
+			if err := emitModuleFindings(context.Background(), h, &govulncheck.Config{}, affVulns, nil); err != nil {
+				t.Fatal(err)
+			}
+			if len(h.FindingMessages) != 1 {
+				t.Fatalf("want 1 finding; got %d", len(h.FindingMessages))
+			}
+			f := h.FindingMessages[0]
+			if f.FixedVersion != tc.want {
+				t.Errorf("want fixed version %s; got %s", tc.want, f.FixedVersion)
+			}
+			if len(f.AffectedRanges) != 1 || f.AffectedRanges[0].Fixed != tc.want {
+				t.Errorf("want a single affected range fixed at %s; got %v", tc.want, f.AffectedRanges)
+			}
+		})
+	}
+}
+
+func TestEmitGoFixedVersions(t *testing.T) {
+	entry := &osv.Entry{
+		ID: "GO-0000-0001",
+		Affected: []osv.Affected{{
+			Module: osv.Module{Path: internal.GoStdModulePath},
+			Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "1.21.5"}}}},
+		}},
+	}
+	std := &packages.Module{Path: internal.GoStdModulePath, Version: "v1.21.1"}
+	affVulns := affectingVulnerabilities([]*ModVulns{{Module: std, Vulns: []*osv.Entry{entry}}}, "", "")
+	for _, tc := range []struct {
+		goFixed bool
+		want    string
+	}{
+		{false, "v1.21.5"},
+		{true, "go1.21.5"},
+	} {
+		h := test.NewMockHandler()
+		cfg := &govulncheck.Config{GoFixedVersions: tc.goFixed}
+		if err := emitModuleFindings(context.Background(), h, cfg, affVulns, nil); err != nil {
+			t.Fatal(err)
+		}
+		if len(h.FindingMessages) != 1 {
+			t.Fatalf("want 1 finding; got %d", len(h.FindingMessages))
+		}
+		if got := h.FindingMessages[0].FixedVersion; got != tc.want {
+			t.Errorf("GoFixedVersions=%t: want fixed version %s; got %s", tc.goFixed, tc.want, got)
+		}
+	}
+}
+
+// concurrentHandler is a concurrent Handler recording the
+// OSVs of findings, each of which takes delay to be handled.
+type concurrentHandler struct {
+	*test.MockHandler
+	delay time.Duration
+	err   error
+
+	mu       sync.Mutex
+	findings []string
+}
+
+func (h *concurrentHandler) Concurrent() bool { return true }
+
+func (h *concurrentHandler) Finding(f *govulncheck.Finding) error {
+	time.Sleep(h.delay)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.findings = append(h.findings, f.OSV)
+	return h.err
+}
+
+func TestDeliverFindings(t *testing.T) {
+	var want []string
+	for i := range 100 {
+		want = append(want, fmt.Sprintf("GO-0000-%04d", i))
+	}
+	for _, workers := range []int{0, 1, 8} {
+		h := &concurrentHandler{MockHandler: test.NewMockHandler()}
+		dh, wait := deliverFindings(h, &govulncheck.Config{FindingWorkers: workers})
+		if _, pooled := dh.(*findingPool); pooled != (workers > 1) {
+			t.Errorf("workers %d: want pooled delivery %v", workers, workers > 1)
+		}
+		for _, id := range want {
+			if err := dh.Finding(&govulncheck.Finding{OSV: id}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := wait(); err != nil {
+			t.Fatal(err)
+		}
+		// All findings are delivered, once.
+		got := slices.Clone(h.findings)
+		sort.Strings(got)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("workers %d: findings mismatch (-want, +got):\n%s", workers, diff)
+		}
+	}
+
+	// Handlers that are not concurrent get findings one at a time.
+	dh, _ := deliverFindings(test.NewMockHandler(), &govulncheck.Config{FindingWorkers: 8})
+	if _, pooled := dh.(*findingPool); pooled {
+		t.Error("want no pooled delivery for a handler that is not concurrent")
+	}
+}
+
+func TestDeliverFindingsError(t *testing.T) {
+	errFinding := errors.New("finding error")
+	h := &concurrentHandler{MockHandler: test.NewMockHandler(), err: errFinding}
+	dh, wait := deliverFindings(h, &govulncheck.Config{FindingWorkers: 4})
+	for range 10 {
+		if err := dh.Finding(&govulncheck.Finding{OSV: "GO-0000-0001"}); err != nil {
+			if !errors.Is(err, errFinding) {
+				t.Errorf("want %v; got %v", errFinding, err)
+			}
+			break
+		}
+	}
+	if err := wait(); !errors.Is(err, errFinding) {
+		t.Errorf("want %v; got %v", errFinding, err)
+	}
+}
+
+func BenchmarkDeliverFindings(b *testing.B) {
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			// The handler simulates I/O for each finding.
+			h := &concurrentHandler{MockHandler: test.NewMockHandler(), delay: 100 * time.Microsecond}
+			dh, wait := deliverFindings(h, &govulncheck.Config{FindingWorkers: workers})
+			f := &govulncheck.Finding{OSV: "GO-0000-0001"}
+			b.ResetTimer()
+			for range b.N {
+				if err := dh.Finding(f); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if err := wait(); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
+
+// barrierHandler is a concurrent Handler failing if
+// other messages are received while findings are.
+type barrierHandler struct {
+	*test.MockHandler
+	t        *testing.T
+	inFlight atomic.Int32
+}
+
+func (h *barrierHandler) Concurrent() bool { return true }
+
+func (h *barrierHandler) Finding(f *govulncheck.Finding) error {
+	h.inFlight.Add(1)
+	defer h.inFlight.Add(-1)
+	time.Sleep(time.Millisecond)
+	return nil
+}
+
+func (h *barrierHandler) Progress(p *govulncheck.Progress) error {
+	if n := h.inFlight.Load(); n != 0 {
+		h.t.Errorf("progress %q received with %d findings in flight", p.Message, n)
+	}
+	return nil
+}
+
+func TestDeliverFindingsBarrier(t *testing.T) {
+	h := &barrierHandler{MockHandler: test.NewMockHandler(), t: t}
+	dh, wait := deliverFindings(h, &govulncheck.Config{FindingWorkers: 4})
+	for i := range 40 {
+		if err := dh.Finding(&govulncheck.Finding{OSV: "GO-0000-0001"}); err != nil {
+			t.Fatal(err)
+		}
+		if i%10 == 0 {
+			if err := dh.Progress(&govulncheck.Progress{Message: fmt.Sprint(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+}
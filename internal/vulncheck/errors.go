@@ -0,0 +1,70 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncheck
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/vuln/internal/govulncheck"
+)
+
+// EmitStage is a stage of a scan emitting messages to a handler.
+type EmitStage string
+
+const (
+	StageOSV             EmitStage = "OSV entries"
+	StageModuleFindings  EmitStage = "module findings"
+	StagePackageFindings EmitStage = "package findings"
+	StageCallFindings    EmitStage = "call findings"
+)
+
+// HandlerError is the error of a handler that failed to receive a
+// message emitted by a scan, such as a handler failing to write its
+// output. It tells failures of the handler apart from failures of
+// the scan itself, like those to load packages or vulnerabilities.
+type HandlerError struct {
+	// Stage is the stage of the scan emitting the message.
+	Stage EmitStage
+	// OSV is the ID of the vulnerability of the message.
+	OSV string
+	// Err is the error of the handler.
+	Err error
+}
+
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("emitting %s of %s: %v", e.Stage, e.OSV, e.Err)
+}
+
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// handlerError returns err, the error of a handler receiving a message
+// about the vulnerability osv at stage, as a HandlerError. Errors that
+// are already HandlerErrors, as those of findings delivered by a pool,
+// are returned as is, as they describe the message that failed.
+func handlerError(stage EmitStage, osv string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var herr *HandlerError
+	if errors.As(err, &herr) {
+		return err
+	}
+	return &HandlerError{Stage: stage, OSV: osv, Err: err}
+}
+
+// findingStage returns the stage emitting f, according to its level.
+func findingStage(f *govulncheck.Finding) EmitStage {
+	switch {
+	case govulncheck.IsCalled(f):
+		return StageCallFindings
+	case govulncheck.IsImported(f):
+		return StagePackageFindings
+	default:
+		return StageModuleFindings
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+// failingHandler is a handler whose OSV
+// and Finding methods fail with err.
+type failingHandler struct {
+	*test.MockHandler
+	err error
+}
+
+func (h *failingHandler) OSV(e *osv.Entry) error               { return h.err }
+func (h *failingHandler) Finding(f *govulncheck.Finding) error { return h.err }
+
+func TestHandlerError(t *testing.T) {
+	errWrite := errors.New("write failed")
+	mod := &packages.Module{Path: "example.com/m", Version: "v1.0.0"}
+	vpkg := &packages.Package{PkgPath: "example.com/m/vuln", Module: mod}
+	mpkg := &packages.Package{PkgPath: "example.com/main", Module: &packages.Module{Path: "example.com/main"}}
+	main := &FuncNode{Name: "main", Package: mpkg}
+	entry := &osv.Entry{ID: "GO-0000-0001"}
+	vuln := &Vuln{OSV: entry, Symbol: "F", Package: vpkg}
+	callstacks := map[*Vuln]CallStack{
+		vuln: {{Function: main, Call: &CallSite{Parent: main, Name: "F"}}, {Function: &FuncNode{Name: "F", Package: vpkg}}},
+	}
+
+	ctx := context.Background()
+	cfg := &govulncheck.Config{}
+	for _, tc := range []struct {
+		stage EmitStage
+		emit  func(govulncheck.Handler) error
+	}{
+		{StageOSV, func(h govulncheck.Handler) error {
+			return emitOSVs(ctx, h, []*ModVulns{{Module: mod, Vulns: []*osv.Entry{entry}}})
+		}},
+		{StageModuleFindings, func(h govulncheck.Handler) error {
+			return emitModuleFindings(ctx, h, cfg, affectingVulns{{Module: mod, Vulns: []*osv.Entry{entry}}}, nil)
+		}},
+		{StagePackageFindings, func(h govulncheck.Handler) error {
+			return emitPackageFindings(ctx, h, cfg, []*Vuln{vuln}, nil)
+		}},
+		{StageCallFindings, func(h govulncheck.Handler) error {
+			return emitCallFindings(ctx, h, cfg, callstacks, nil)
+		}},
+	} {
+		t.Run(string(tc.stage), func(t *testing.T) {
+			err := tc.emit(&failingHandler{MockHandler: test.NewMockHandler(), err: errWrite})
+			var herr *HandlerError
+			if !errors.As(err, &herr) {
+				t.Fatalf("got error %v of type %T; want a *HandlerError", err, err)
+			}
+			if herr.Stage != tc.stage || herr.OSV != entry.ID {
+				t.Errorf("got stage %q and OSV %s; want %q and %s", herr.Stage, herr.OSV, tc.stage, entry.ID)
+			}
+			if !errors.Is(err, errWrite) {
+				t.Errorf("want %v wrapped; got %v", errWrite, err)
+			}
+		})
+	}
+}
+
+func TestHandlerErrorDeliverFindings(t *testing.T) {
+	errWrite := errors.New("write failed")
+	h := &concurrentHandler{MockHandler: test.NewMockHandler(), err: errWrite}
+	dh, wait := deliverFindings(h, &govulncheck.Config{FindingWorkers: 4})
+	f := &govulncheck.Finding{OSV: "GO-0000-0001", Trace: []*govulncheck.Frame{{Module: "m", Package: "m/p"}}}
+	if err := dh.Finding(f); err != nil {
+		t.Fatal(err)
+	}
+	var herr *HandlerError
+	if err := wait(); !errors.As(err, &herr) {
+		t.Fatalf("got error %v of type %T; want a *HandlerError", err, err)
+	}
+	if herr.Stage != StagePackageFindings || herr.OSV != f.OSV {
+		t.Errorf("got stage %q and OSV %s; want %q and %s", herr.Stage, herr.OSV, StagePackageFindings, f.OSV)
+	}
+}
@@ -6,12 +6,58 @@ package vulncheck
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/vuln/internal/client"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
 )
 
+// fetchVulnerabilities fetches vulnerabilities that affect modules
+// using c, unless cfg.OSVDir is set. In that case, the vulnerabilities
+// are loaded from the OSV files in cfg.OSVDir.
+func fetchVulnerabilities(ctx context.Context, cfg *govulncheck.Config, c *client.Client, modules []*packages.Module) ([]*ModVulns, error) {
+	if cfg.OSVDir != "" {
+		entries, err := LoadOSVDir(cfg.OSVDir)
+		if err != nil {
+			return nil, err
+		}
+		if c, err = client.NewInMemoryClient(entries); err != nil {
+			return nil, err
+		}
+	}
+	return FetchVulnerabilities(ctx, c, modules)
+}
+
+// LoadOSVDir parses each file with .json extension in dir
+// into an OSV entry. Subdirectories of dir are ignored.
+func LoadOSVDir(dir string) ([]*osv.Entry, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading OSV directory: %w", err)
+	}
+	var entries []*osv.Entry
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var entry osv.Entry
+		if err := json.Unmarshal(b, &entry); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", f.Name(), err)
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
 // FetchVulnerabilities fetches vulnerabilities that affect the supplied modules.
 func FetchVulnerabilities(ctx context.Context, c *client.Client, modules []*packages.Module) ([]*ModVulns, error) {
 	mreqs := make([]*client.ModuleRequest, len(modules))
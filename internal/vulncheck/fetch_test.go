@@ -6,12 +6,15 @@ package vulncheck_test
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/vuln/internal/client"
+	"golang.org/x/vuln/internal/govulncheck"
 	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
 	"golang.org/x/vuln/internal/vulncheck"
 )
 
@@ -54,3 +57,34 @@ func TestFetchVulnerabilities(t *testing.T) {
 		t.Fatalf("mismatch (-want, +got):\n%s", diff)
 	}
 }
+
+func TestOSVDir(t *testing.T) {
+	cfg := &govulncheck.Config{
+		ScanLevel: govulncheck.ScanLevelModule,
+		OSVDir:    filepath.Join("testdata", "osvdir"),
+	}
+	bin := &vulncheck.Bin{
+		Modules: []*packages.Module{
+			{Path: "example.mod/a", Version: "v1.0.0"},
+			{Path: "example.mod/c", Version: "v1.0.0"},
+		},
+		GoVersion: "go1.22.0",
+	}
+
+	h := test.NewMockHandler()
+	// No client is needed as vulnerabilities come from cfg.OSVDir.
+	if err := vulncheck.Binary(context.Background(), h, bin, cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range h.OSVMessages {
+		got = append(got, e.ID)
+	}
+	if want := []string{"GO-2024-0001"}; !cmp.Equal(got, want) {
+		t.Errorf("want OSVs %v; got %v", want, got)
+	}
+	if len(h.FindingMessages) != 1 || h.FindingMessages[0].FixedVersion != "v1.2.0" {
+		t.Errorf("want a single finding fixed at v1.2.0; got %v", h.FindingMessages)
+	}
+}
@@ -6,10 +6,12 @@ package vulncheck
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"slices"
 	"strings"
 
+	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/vuln/internal"
 	"golang.org/x/vuln/internal/govulncheck"
@@ -25,12 +27,16 @@ type PackageGraph struct {
 	topPkgs  []*packages.Package
 	modules  map[string]*packages.Module  // all modules (even replacing ones)
 	packages map[string]*packages.Package // all packages (even dependencies)
+	// toolModules are the paths of modules that are only
+	// needed by the tools of the main modules.
+	toolModules map[string]bool
 }
 
 func NewPackageGraph(goVersion string) *PackageGraph {
 	graph := &PackageGraph{
-		modules:  map[string]*packages.Module{},
-		packages: map[string]*packages.Package{},
+		modules:     map[string]*packages.Module{},
+		packages:    map[string]*packages.Package{},
+		toolModules: map[string]bool{},
 	}
 
 	goRoot := ""
@@ -196,6 +202,8 @@ func (g *PackageGraph) GetPackage(path string) *packages.Package {
 
 // LoadPackages loads the packages specified by the patterns into the graph.
 // See golang.org/x/tools/go/packages.Load for details of how it works.
+// Modules without a version, as in vendored builds, are given the version
+// pinned by the main modules, see resolveVersions.
 func (g *PackageGraph) LoadPackagesAndMods(cfg *packages.Config, tags []string, patterns []string, wantSymbols bool) error {
 	if len(tags) > 0 {
 		cfg.BuildFlags = []string{fmt.Sprintf("-tags=%s", strings.Join(tags, ","))}
@@ -223,9 +231,81 @@ func (g *PackageGraph) LoadPackagesAndMods(cfg *packages.Config, tags []string,
 	for _, p := range pkgs {
 		g.topPkgs = append(g.topPkgs, g.GetPackage(p.PkgPath))
 	}
+	g.resolveVersions()
 	return err
 }
 
+// LoadTools loads the packages of the tool directives in the go.mod
+// files of the main modules of the top-level packages into the graph,
+// so that the modules providing them are analyzed as well. Tools are
+// not added to the top-level packages: they are only used at build
+// time, for instance by go:generate, and do not end up in binaries.
+//
+// Modules that are only needed by tools are recorded as such, see
+// IsToolModule. LoadTools does nothing if there are no tools.
+func (g *PackageGraph) LoadTools(cfg *packages.Config, tags []string) error {
+	tools := toolPackages(g.topPkgs)
+	if len(tools) == 0 {
+		return nil
+	}
+	toolCfg := *cfg
+	toolCfg.Mode = 0
+	toolCfg.BuildFlags = nil
+	if len(tags) > 0 {
+		toolCfg.BuildFlags = []string{fmt.Sprintf("-tags=%s", strings.Join(tags, ","))}
+	}
+	addLoadMode(&toolCfg, false)
+
+	pkgs, err := packages.Load(&toolCfg, tools...)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for path := range g.modules {
+		known[path] = true
+	}
+	g.AddPackages(pkgs...)
+	for path, mod := range g.modules {
+		if !known[path] {
+			g.toolModules[modPath(mod)] = true
+		}
+	}
+	g.resolveVersions()
+	return nil
+}
+
+// IsToolModule reports whether the module at path, the replacing
+// module path if the module is replaced, is only needed by tools.
+func (g *PackageGraph) IsToolModule(path string) bool {
+	return g.toolModules[path]
+}
+
+// toolPackages returns the package paths of the tool
+// directives of the main modules of pkgs.
+func toolPackages(pkgs []*packages.Package) []string {
+	var tools []string
+	seen := make(map[string]bool)
+	for _, p := range pkgs {
+		if p.Module == nil || !p.Module.Main || p.Module.GoMod == "" || seen[p.Module.GoMod] {
+			continue
+		}
+		seen[p.Module.GoMod] = true
+		data, err := os.ReadFile(p.Module.GoMod)
+		if err != nil {
+			continue
+		}
+		// Tool directives are only parsed for main modules.
+		f, err := modfile.Parse(p.Module.GoMod, data, nil)
+		if err != nil {
+			continue
+		}
+		for _, t := range f.Tool {
+			tools = append(tools, t.Path)
+		}
+	}
+	return tools
+}
+
 func addLoadMode(cfg *packages.Config, wantSymbols bool) {
 	cfg.Mode |=
 		packages.NeedModule |
@@ -7,6 +7,7 @@ package vulncheck
 import (
 	"context"
 	"sync"
+	"time"
 
 	"golang.org/x/tools/go/callgraph"
 	"golang.org/x/tools/go/packages"
@@ -17,23 +18,101 @@ import (
 )
 
 // Source detects vulnerabilities in pkgs and emits the findings to handler.
-func Source(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.Config, client *client.Client, graph *PackageGraph) error {
-	vr, err := source(ctx, handler, cfg, client, graph)
+//
+// Module and package level findings are emitted regardless of the scan
+// level, before any call analysis is done. This way, vulnerabilities that
+// are required or imported, but not called, are still reported at their
+// most precise level when cfg.ScanLevel is symbol.
+//
+// Findings for modules that are only needed by the tools loaded
+// into graph, see PackageGraph.LoadTools, are marked as build-time.
+// When graph spans a workspace of several modules, findings record the
+// workspace module they originate from. Findings are delivered
+// concurrently to concurrent handlers, as requested by
+// cfg.FindingWorkers.
+func Source(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.Config, client *client.Client, graph *PackageGraph) (err error) {
+	handler, wait := deliverFindings(handler, cfg)
+	defer func() {
+		if werr := wait(); err == nil {
+			err = werr
+		}
+	}()
+	handler = markWorkspaceModule(markBuildTime(hookFindings(limitFindings(handler, cfg), cfg), graph), graph)
+	requires := requirements(graph.TopPkgs())
+	vr, err := source(ctx, handler, cfg, client, graph, requires)
 	if err != nil {
 		return err
 	}
 
 	if cfg.ScanLevel.WantSymbols() {
-		return emitCallFindings(handler, sourceCallstacks(vr))
+		return emitCallFindings(ctx, handler, cfg, sourceCallstacks(vr), requires)
 	}
 	return nil
 }
 
+// Modules detects vulnerabilities in the modules of graph and emits
+// module-level findings to handler, as Source does before analyzing
+// packages. Unlike Source, it does not need the packages of graph to
+// have loaded successfully: modules are known from the packages that
+// were listed, even with errors, so that vulnerable modules are still
+// reported when an unrelated package fails to build.
+func Modules(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.Config, client *client.Client, graph *PackageGraph) (err error) {
+	handler, wait := deliverFindings(handler, cfg)
+	defer func() {
+		if werr := wait(); err == nil {
+			err = werr
+		}
+	}()
+	handler = markWorkspaceModule(markBuildTime(hookFindings(limitFindings(handler, cfg), cfg), graph), graph)
+	_, err = modules(ctx, handler, cfg, client, graph, requirements(graph.TopPkgs()))
+	return err
+}
+
+// modules emits the SBOM of graph, the OSV entries of its modules, and
+// the module-level findings to handler. It returns the vulnerabilities
+// affecting the modules.
+func modules(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.Config, client *client.Client, graph *PackageGraph, requires map[string]requirement) (affectingVulns, error) {
+	if err := handler.SBOM(graph.SBOM()); err != nil {
+		return nil, err
+	}
+
+	if err := handler.Progress(&govulncheck.Progress{Message: fetchingVulnsMessage}); err != nil {
+		return nil, err
+	}
+
+	mv, err := fetchVulnerabilities(ctx, cfg, client, graph.Modules())
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit OSV entries immediately in their raw unfiltered form.
+	if err := emitOSVs(ctx, handler, mv); err != nil {
+		return nil, err
+	}
+	if err := emitStaleData(handler, cfg, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := handler.Progress(&govulncheck.Progress{Message: checkingSrcVulnsMessage}); err != nil {
+		return nil, err
+	}
+
+	affVulns, dups := mergeDuplicates(affectingVulnerabilities(mv, "", ""))
+	if err := emitDuplicates(ctx, handler, dups); err != nil {
+		return nil, err
+	}
+	if err := emitModuleFindings(ctx, handler, cfg, affVulns, requires); err != nil {
+		return nil, err
+	}
+	return affVulns, nil
+}
+
 // source detects vulnerabilities in packages. It emits findings to handler
 // and produces a Result that contains info on detected vulnerabilities.
+// The require directives of vulnerable modules are looked up in requires.
 //
 // Assumes that pkgs are non-empty and belong to the same program.
-func source(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.Config, client *client.Client, graph *PackageGraph) (*Result, error) {
+func source(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.Config, client *client.Client, graph *PackageGraph, requires map[string]requirement) (*Result, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -57,33 +136,11 @@ func source(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.C
 		}()
 	}
 
-	if err := handler.SBOM(graph.SBOM()); err != nil {
-		return nil, err
-	}
-
-	if err := handler.Progress(&govulncheck.Progress{Message: fetchingVulnsMessage}); err != nil {
-		return nil, err
-	}
-
-	mv, err := FetchVulnerabilities(ctx, client, graph.Modules())
+	affVulns, err := modules(ctx, handler, cfg, client, graph, requires)
 	if err != nil {
 		return nil, err
 	}
 
-	// Emit OSV entries immediately in their raw unfiltered form.
-	if err := emitOSVs(handler, mv); err != nil {
-		return nil, err
-	}
-
-	if err := handler.Progress(&govulncheck.Progress{Message: checkingSrcVulnsMessage}); err != nil {
-		return nil, err
-	}
-
-	affVulns := affectingVulnerabilities(mv, "", "")
-	if err := emitModuleFindings(handler, affVulns); err != nil {
-		return nil, err
-	}
-
 	if !cfg.ScanLevel.WantPackages() || len(affVulns) == 0 {
 		return &Result{}, nil
 	}
@@ -91,13 +148,13 @@ func source(ctx context.Context, handler govulncheck.Handler, cfg *govulncheck.C
 	impVulns := importedVulnPackages(affVulns, graph)
 	// Emit information on imported vulnerable packages now as
 	// call graph computation might take a while.
-	if err := emitPackageFindings(handler, impVulns); err != nil {
+	if err := emitPackageFindings(ctx, handler, cfg, impVulns, requires); err != nil {
 		return nil, err
 	}
 
-	// Return result immediately if not in symbol mode or
-	// if there are no vulnerabilities imported.
-	if !cfg.ScanLevel.WantSymbols() || len(impVulns) == 0 {
+	// Return result immediately if not in symbol mode or if there
+	// are no vulnerabilities imported to be reported at symbol level.
+	if !cfg.ScanLevel.WantSymbols() || !wantSymbols(cfg, impVulns) {
 		return &Result{Vulns: impVulns}, nil
 	}
 
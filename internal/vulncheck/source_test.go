@@ -6,10 +6,13 @@ package vulncheck
 
 import (
 	"context"
+	"os"
 	"path"
 	"reflect"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/go/packages/packagestest"
 	"golang.org/x/vuln/internal/client"
 	"golang.org/x/vuln/internal/govulncheck"
@@ -205,7 +208,7 @@ func TestCalls(t *testing.T) {
 	}
 
 	cfg := &govulncheck.Config{ScanLevel: "symbol"}
-	result, err := source(context.Background(), test.NewMockHandler(), cfg, c, graph)
+	result, err := source(context.Background(), test.NewMockHandler(), cfg, c, graph, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -307,7 +310,7 @@ func TestAllSymbolsVulnerable(t *testing.T) {
 	}
 
 	cfg := &govulncheck.Config{ScanLevel: "symbol"}
-	result, err := source(context.Background(), test.NewMockHandler(), cfg, client, graph)
+	result, err := source(context.Background(), test.NewMockHandler(), cfg, client, graph, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -375,7 +378,7 @@ func TestNoSyntheticNodes(t *testing.T) {
 	}
 
 	cfg := &govulncheck.Config{ScanLevel: "symbol"}
-	result, err := source(context.Background(), test.NewMockHandler(), cfg, c, graph)
+	result, err := source(context.Background(), test.NewMockHandler(), cfg, c, graph, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -449,7 +452,7 @@ func TestRecursion(t *testing.T) {
 	}
 
 	cfg := &govulncheck.Config{ScanLevel: "symbol"}
-	result, err := source(context.Background(), test.NewMockHandler(), cfg, c, graph)
+	result, err := source(context.Background(), test.NewMockHandler(), cfg, c, graph, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -514,8 +517,253 @@ func TestIssue57174(t *testing.T) {
 	}
 
 	cfg := &govulncheck.Config{ScanLevel: "symbol"}
-	_, err = source(context.Background(), test.NewMockHandler(), cfg, c, graph)
+	_, err = source(context.Background(), test.NewMockHandler(), cfg, c, graph, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 }
+
+// TestImportedNotCalled checks that symbol analysis still emits
+// module and package level findings for a vulnerability whose
+// package is imported, but none of its vulnerable symbols are
+// called.
+func TestImportedNotCalled(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "golang.org/entry",
+			Files: map[string]interface{}{
+				"x/x.go": `
+			package x
+
+			import "golang.org/amod/avuln"
+
+			func X() {
+				avuln.Benign()
+			}`,
+			},
+		},
+		{
+			Name: "golang.org/amod@v1.1.3",
+			Files: map[string]interface{}{"avuln/avuln.go": `
+			package avuln
+
+			type VulnData struct {}
+			func (v VulnData) Vuln1() {}
+			func (v VulnData) Vuln2() {}
+			func Benign() {}
+			`},
+		},
+	})
+	defer e.Cleanup()
+
+	// Load x as entry package.
+	graph := NewPackageGraph("go1.18")
+	err := graph.LoadPackagesAndMods(e.Config, nil, []string{path.Join(e.Temp(), "entry/x")}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.TopPkgs()) != 1 {
+		t.Fatal("failed to load x test package")
+	}
+
+	c, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := test.NewMockHandler()
+	cfg := &govulncheck.Config{ScanLevel: "symbol"}
+	if err := Source(context.Background(), h, cfg, c, graph); err != nil {
+		t.Fatal(err)
+	}
+
+	var module, pkg, call int
+	for _, f := range h.FindingMessages {
+		if f.OSV != "VA" {
+			continue // stdlib findings depend on the Go version used
+		}
+		fr := f.Trace[0]
+		switch {
+		case fr.Function != "":
+			call++
+		case fr.Package != "":
+			pkg++
+		default:
+			module++
+		}
+	}
+	if module != 1 || pkg != 1 || call != 0 {
+		t.Errorf("want 1 module, 1 package, and 0 call findings; got %d, %d, and %d", module, pkg, call)
+	}
+}
+
+// TestTools checks that modules only needed by the tools
+// of the main module are analyzed and reported as build-time.
+func TestTools(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "golang.org/entry",
+			Files: map[string]interface{}{
+				"x/x.go": `
+			package x
+
+			import "golang.org/amod/avuln"
+
+			func X() {
+				avuln.VulnData{}.Vuln1()
+			}`,
+			},
+		},
+		{
+			Name: "golang.org/amod@v1.1.3",
+			Files: map[string]interface{}{"avuln/avuln.go": `
+			package avuln
+
+			type VulnData struct {}
+			func (v VulnData) Vuln1() {}
+			func (v VulnData) Vuln2() {}
+			`},
+		},
+		{
+			Name: "golang.org/bmod@v0.5.0",
+			Files: map[string]interface{}{
+				"bvuln/bvuln.go": `
+			package bvuln
+
+			func Vuln() {}
+			`,
+				"cmd/gen/main.go": `
+			package main
+
+			import "golang.org/bmod/bvuln"
+
+			func main() {
+				bvuln.Vuln()
+			}
+			`},
+		},
+	})
+	defer e.Cleanup()
+
+	// Make cmd/gen a tool of the main module.
+	gomod := path.Join(e.Temp(), "entry", "go.mod")
+	data, err := os.ReadFile(gomod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := modfile.Parse(gomod, data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddGoStmt("1.24"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddTool("golang.org/bmod/cmd/gen"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = f.Format(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(gomod, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	graph := NewPackageGraph("go1.18")
+	if err := graph.LoadPackagesAndMods(e.Config, nil, []string{path.Join(e.Temp(), "entry/x")}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := graph.LoadTools(e.Config, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !graph.IsToolModule("golang.org/bmod") || graph.IsToolModule("golang.org/amod") {
+		t.Errorf("want only golang.org/bmod to be a tool module")
+	}
+
+	c, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := test.NewMockHandler()
+	cfg := &govulncheck.Config{ScanLevel: "package", IncludeTools: true}
+	if err := Source(context.Background(), h, cfg, c, graph); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]bool)
+	for _, f := range h.FindingMessages {
+		if f.OSV == "STD" {
+			continue // stdlib findings depend on the Go version used
+		}
+		fr := f.Trace[0]
+		got[f.OSV+" "+fr.Module+" "+fr.Package] = f.BuildTime
+	}
+	want := map[string]bool{
+		"VA golang.org/amod ":                      false,
+		"VA golang.org/amod golang.org/amod/avuln": false,
+		"VB golang.org/bmod ":                      true,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+// TestModulesLoadError checks that vulnerable modules are
+// reported when a package of theirs fails to load.
+func TestModulesLoadError(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "golang.org/entry",
+			Files: map[string]interface{}{
+				"x/x.go": `
+			package x
+
+			import "golang.org/amod/avuln"
+
+			func X() {
+				avuln.VulnData{}.Vuln1()
+			}`,
+			},
+		},
+		{
+			Name: "golang.org/amod@v1.1.3",
+			Files: map[string]interface{}{"avuln/avuln.go": `
+			package avuln
+
+			import "golang.org/amod/missing"
+
+			type VulnData struct {}
+			func (v VulnData) Vuln1() { missing.F() }
+			`},
+		},
+	})
+	defer e.Cleanup()
+
+	graph := NewPackageGraph("go1.18")
+	if err := graph.LoadPackagesAndMods(e.Config, nil, []string{path.Join(e.Temp(), "entry/x")}, true); err == nil {
+		t.Fatal("want error loading packages")
+	}
+
+	c, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := test.NewMockHandler()
+	cfg := &govulncheck.Config{ScanLevel: "symbol"}
+	if err := Modules(context.Background(), h, cfg, c, graph); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range h.FindingMessages {
+		if f.OSV == "STD" {
+			continue // stdlib findings depend on the Go version used
+		}
+		fr := f.Trace[0]
+		got = append(got, f.OSV+" "+fr.Module+"@"+fr.Version+" "+fr.Package)
+	}
+	// Only module-level findings are reported.
+	want := []string{"VA golang.org/amod@v1.1.3 "}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/types/typeutil"
 	"golang.org/x/vuln/internal"
+	"golang.org/x/vuln/internal/govulncheck"
 	"golang.org/x/vuln/internal/osv"
 	"golang.org/x/vuln/internal/semver"
 
@@ -223,6 +224,13 @@ func funcRecvType(f *ssa.Function) string {
 	return buf.String()
 }
 
+// FixedVersion returns the earliest version of modulePath after version
+// that fixes the vulnerability described by affected and that is not
+// affected again by a later introduction. Versions are ordered by semver
+// precedence, so that, for instance, v1.10.0 comes after v1.9.0 and
+// pseudo-versions come before the release they are based on.
+//
+// It returns an empty string if there is no such version.
 func FixedVersion(modulePath, version string, affected []osv.Affected) string {
 	fixed := earliestValidFix(modulePath, version, affected)
 	// Add "v" prefix if one does not exist. moduleVersionString
@@ -233,6 +241,164 @@ func FixedVersion(modulePath, version string, affected []osv.Affected) string {
 	return fixed
 }
 
+// FixedInAdvisory reports whether affected records a fix for
+// modulePath only in ranges that are not SEMVER ranges, such as
+// ECOSYSTEM or GIT ranges, whose versions cannot be compared to
+// module versions. In that case, FixedVersion returns an empty
+// string rather than misreporting a fixed version.
+func FixedInAdvisory(modulePath, version string, affected []osv.Affected) bool {
+	if FixedVersion(modulePath, version, affected) != "" {
+		return false
+	}
+	for _, a := range affected {
+		if a.Module.Path != modulePath {
+			continue
+		}
+		for _, r := range a.Ranges {
+			if r.Type == osv.RangeTypeSemver {
+				continue
+			}
+			for _, e := range r.Events {
+				if e.Fixed != "" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// IntroducedVersion returns the version of modulePath that introduced
+// the vulnerability affecting version, according to affected. If there
+// are several introduced and fixed pairs, the introduced version of
+// the pair whose range contains version is returned.
+//
+// It returns an empty string if version is not affected or if the
+// vulnerability exists from the beginning of time, that is, if it was
+// introduced at version "0".
+func IntroducedVersion(modulePath, version string, affected []osv.Affected) string {
+	var introduced string
+	for _, a := range affected {
+		if a.Module.Path != modulePath {
+			continue
+		}
+		for _, r := range a.Ranges {
+			if !semver.ContainsSemver(r, version) {
+				continue
+			}
+			for _, e := range r.Events {
+				in := e.Introduced
+				if in == "" || in == "0" || semver.Less(version, in) {
+					continue
+				}
+				if introduced == "" || semver.Less(introduced, in) {
+					introduced = in
+				}
+			}
+		}
+	}
+	// Add "v" prefix if one does not exist, similar to FixedVersion.
+	if introduced != "" && !strings.HasPrefix(introduced, "v") {
+		introduced = "v" + introduced
+	}
+	return introduced
+}
+
+// LastAffectedVersion returns the last version of modulePath affected
+// by the vulnerability affecting version, for advisories that specify
+// a last_affected event instead of a fixed one. Upgrading past the
+// returned version remediates the vulnerability.
+//
+// It returns an empty string if FixedVersion reports a fix for version,
+// or if no last affected version is known.
+func LastAffectedVersion(modulePath, version string, affected []osv.Affected) string {
+	if FixedVersion(modulePath, version, affected) != "" {
+		return ""
+	}
+	var last string
+	for _, a := range affected {
+		if a.Module.Path != modulePath {
+			continue
+		}
+		for _, r := range a.Ranges {
+			if !semver.ContainsSemver(r, version) {
+				continue
+			}
+			for _, e := range r.Events {
+				la := e.LastAffected
+				if la == "" || semver.Less(la, version) {
+					continue
+				}
+				if last == "" || semver.Less(la, last) {
+					last = la
+				}
+			}
+		}
+	}
+	// Add "v" prefix if one does not exist, similar to FixedVersion.
+	if last != "" && !strings.HasPrefix(last, "v") {
+		last = "v" + last
+	}
+	return last
+}
+
+// AffectedRanges returns the ranges of versions of modulePath affected
+// according to the SEMVER ranges of affected, ordered by introduced
+// version. Versions get a "v" prefix, similar to FixedVersion.
+func AffectedRanges(modulePath string, affected []osv.Affected) []*govulncheck.AffectedRange {
+	var ranges []*govulncheck.AffectedRange
+	for _, a := range affected {
+		if a.Module.Path != modulePath {
+			continue
+		}
+		for _, r := range a.Ranges {
+			if r.Type != osv.RangeTypeSemver {
+				continue
+			}
+			events := append([]osv.RangeEvent(nil), r.Events...)
+			semver.SortEvents(events)
+			var cur *govulncheck.AffectedRange
+			for _, e := range events {
+				switch {
+				case e.Introduced != "":
+					if cur != nil {
+						// Overlapping introduced events; keep the first.
+						continue
+					}
+					cur = &govulncheck.AffectedRange{}
+					if e.Introduced != "0" {
+						cur.Introduced = withV(e.Introduced)
+					}
+				case cur != nil && e.Fixed != "":
+					cur.Fixed = withV(e.Fixed)
+					ranges = append(ranges, cur)
+					cur = nil
+				case cur != nil && e.LastAffected != "":
+					cur.LastAffected = withV(e.LastAffected)
+					ranges = append(ranges, cur)
+					cur = nil
+				}
+			}
+			if cur != nil {
+				ranges = append(ranges, cur) // no fix
+			}
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		// The beginning of time comes first.
+		return ranges[j].Introduced != "" && (ranges[i].Introduced == "" || semver.Less(ranges[i].Introduced, ranges[j].Introduced))
+	})
+	return ranges
+}
+
+// withV returns v with a "v" prefix.
+func withV(v string) string {
+	if strings.HasPrefix(v, "v") {
+		return v
+	}
+	return "v" + v
+}
+
 // earliestValidFix returns the earliest fix for version of modulePath that
 // itself is not vulnerable in affected.
 //
@@ -297,6 +463,9 @@ func fixNegated(fix string, affected []osv.Affected) bool {
 }
 
 func modPath(mod *packages.Module) string {
+	if mod == nil {
+		return ""
+	}
 	if mod.Replace != nil {
 		return mod.Replace.Path
 	}
@@ -304,6 +473,9 @@ func modPath(mod *packages.Module) string {
 }
 
 func modVersion(mod *packages.Module) string {
+	if mod == nil {
+		return ""
+	}
 	if mod.Replace != nil {
 		return mod.Replace.Version
 	}
@@ -11,6 +11,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/tools/go/packages/packagestest"
 	"golang.org/x/tools/go/ssa/ssautil"
+	"golang.org/x/vuln/internal/govulncheck"
 	"golang.org/x/vuln/internal/osv"
 )
 
@@ -179,6 +180,71 @@ func TestFixedVersion(t *testing.T) {
 			},
 			want: "v1.4.1",
 		},
+		{
+			name:    "semver ordering",
+			module:  "example.com/module",
+			version: "v1.8.0",
+			in: []osv.Affected{
+				{
+					Module: osv.Module{
+						Path: "example.com/module",
+					},
+					Ranges: []osv.Range{
+						{
+							Type: osv.RangeTypeSemver,
+							Events: []osv.RangeEvent{
+								// v1.10.0 is after v1.9.0, although
+								// it is smaller as a string.
+								{Introduced: "0"}, {Fixed: "v1.9.0"},
+								{Introduced: "v1.9.5"}, {Fixed: "v1.10.0"},
+							},
+						}},
+				},
+			},
+			want: "v1.9.0",
+		},
+		{
+			name:    "pseudo-versions",
+			module:  "example.com/module",
+			version: "v0.0.0-20200101000000-aaaaaaaaaaaa",
+			in: []osv.Affected{
+				{
+					Module: osv.Module{
+						Path: "example.com/module",
+					},
+					Ranges: []osv.Range{
+						{
+							Type: osv.RangeTypeSemver,
+							Events: []osv.RangeEvent{
+								{Introduced: "0"}, {Fixed: "v0.0.0-20210101000000-bbbbbbbbbbbb"},
+								{Introduced: "v0.1.0"}, {Fixed: "v0.1.1"},
+							},
+						}},
+				},
+			},
+			want: "v0.0.0-20210101000000-bbbbbbbbbbbb",
+		},
+		{
+			name:    "incompatible",
+			module:  "example.com/module",
+			version: "v2.9.0+incompatible",
+			in: []osv.Affected{
+				{
+					Module: osv.Module{
+						Path: "example.com/module",
+					},
+					Ranges: []osv.Range{
+						{
+							Type: osv.RangeTypeSemver,
+							Events: []osv.RangeEvent{
+								{Introduced: "0"}, {Fixed: "v2.10.0+incompatible"},
+								{Introduced: "v2.11.0+incompatible"}, {Fixed: "v2.11.2+incompatible"},
+							},
+						}},
+				},
+			},
+			want: "v2.10.0+incompatible",
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			got := FixedVersion(test.module, test.version, test.in)
@@ -189,6 +255,161 @@ func TestFixedVersion(t *testing.T) {
 	}
 }
 
+func TestFixedInAdvisory(t *testing.T) {
+	const module = "example.com/module"
+	ecosystem := osv.Range{
+		Type:   osv.RangeTypeEcosystem,
+		Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "2024-01-15"}},
+	}
+	semverFix := osv.Range{
+		Type:   osv.RangeTypeSemver,
+		Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "1.3.0"}},
+	}
+	for _, test := range []struct {
+		name      string
+		ranges    []osv.Range
+		wantFixed string // as reported by FixedVersion
+		want      bool
+	}{
+		{"ecosystem", []osv.Range{ecosystem}, "", true},
+		{"git", []osv.Range{{Type: osv.RangeTypeGit, Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "abcdef"}}}}, "", true},
+		{"ecosystem and semver", []osv.Range{ecosystem, semverFix}, "v1.3.0", false},
+		{"ecosystem without fix", []osv.Range{{Type: osv.RangeTypeEcosystem, Events: []osv.RangeEvent{{Introduced: "0"}}}}, "", false},
+		{"semver", []osv.Range{semverFix}, "v1.3.0", false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			affected := []osv.Affected{{Module: osv.Module{Path: module}, Ranges: test.ranges}}
+			if got := FixedVersion(module, "v1.2.0", affected); got != test.wantFixed {
+				t.Errorf("want fixed version %q; got %q", test.wantFixed, got)
+			}
+			if got := FixedInAdvisory(module, "v1.2.0", affected); got != test.want {
+				t.Errorf("want fixed in advisory %t; got %t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestIntroducedVersion(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		version string
+		events  []osv.RangeEvent
+		want    string
+	}{
+		{
+			name:    "introduced and fixed",
+			version: "v1.1.0",
+			events:  []osv.RangeEvent{{Introduced: "v1.0.0"}, {Fixed: "v1.2.3"}},
+			want:    "v1.0.0",
+		},
+		{
+			name:    "only introduced",
+			version: "v1.6.0",
+			events:  []osv.RangeEvent{{Introduced: "1.5.0"}},
+			want:    "v1.5.0",
+		},
+		{
+			name:    "from the beginning",
+			version: "v1.1.0",
+			events:  []osv.RangeEvent{{Introduced: "0"}, {Fixed: "v1.2.3"}},
+			want:    "",
+		},
+		{
+			name:    "several",
+			version: "v1.5.2",
+			events: []osv.RangeEvent{
+				{Introduced: "v1.0.0"}, {Fixed: "v1.2.3"},
+				{Introduced: "v1.5.0"}, {Fixed: "v1.5.6"},
+			},
+			want: "v1.5.0",
+		},
+		{
+			name:    "not affected",
+			version: "v1.3.0",
+			events: []osv.RangeEvent{
+				{Introduced: "v1.0.0"}, {Fixed: "v1.2.3"},
+				{Introduced: "v1.5.0"}, {Fixed: "v1.5.6"},
+			},
+			want: "",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			in := []osv.Affected{
+				{
+					Module: osv.Module{Path: "example.com/module"},
+					Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: test.events}},
+				},
+				{
+					// This should be ignored.
+					Module: osv.Module{Path: "example.com/anothermodule"},
+					Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "v1.5.1"}}}},
+				},
+			}
+			got := IntroducedVersion("example.com/module", test.version, in)
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLastAffectedVersion(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		version string
+		events  []osv.RangeEvent
+		want    string
+	}{
+		{
+			name:    "last affected",
+			version: "v1.1.0",
+			events:  []osv.RangeEvent{{Introduced: "0"}, {LastAffected: "1.2.0"}},
+			want:    "v1.2.0",
+		},
+		{
+			name:    "at last affected",
+			version: "v1.2.0",
+			events:  []osv.RangeEvent{{Introduced: "1.0.0"}, {LastAffected: "v1.2.0"}},
+			want:    "v1.2.0",
+		},
+		{
+			name:    "after last affected",
+			version: "v1.2.1",
+			events:  []osv.RangeEvent{{Introduced: "1.0.0"}, {LastAffected: "v1.2.0"}},
+			want:    "",
+		},
+		{
+			name:    "fixed",
+			version: "v1.1.0",
+			events:  []osv.RangeEvent{{Introduced: "0"}, {Fixed: "v1.1.5"}, {Introduced: "v1.3.0"}, {LastAffected: "v1.4.0"}},
+			want:    "",
+		},
+		{
+			name:    "several",
+			version: "v1.3.5",
+			events:  []osv.RangeEvent{{Introduced: "0"}, {Fixed: "v1.1.5"}, {Introduced: "v1.3.0"}, {LastAffected: "v1.4.0"}},
+			want:    "v1.4.0",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			in := []osv.Affected{{
+				Module: osv.Module{Path: "example.com/module"},
+				Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: test.events}},
+			}}
+			if got := LastAffectedVersion("example.com/module", test.version, in); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			// There is never a fixed version for ranges
+			// closed by a last affected version.
+			if test.want != "" {
+				if got := FixedVersion("example.com/module", test.version, in); got != "" {
+					t.Errorf("got fixed version %q, want none", got)
+				}
+			}
+		})
+	}
+}
+
 func TestDbSymbolName(t *testing.T) {
 	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
 		{
@@ -254,3 +475,47 @@ func TestDbSymbolName(t *testing.T) {
 		t.Errorf("(-want;got+): %s", diff)
 	}
 }
+
+func TestAffectedRanges(t *testing.T) {
+	const module = "example.com/module"
+	type ar = govulncheck.AffectedRange
+	for _, test := range []struct {
+		name   string
+		ranges []osv.Range
+		want   []*ar
+	}{
+		{
+			name:   "single",
+			ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "1.2.0"}, {Fixed: "1.5.3"}}}},
+			want:   []*ar{{Introduced: "v1.2.0", Fixed: "v1.5.3"}},
+		},
+		{
+			name: "multiple",
+			ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{
+				// Events are not necessarily sorted.
+				{Introduced: "2.0.0"}, {Fixed: "2.0.1"}, {Introduced: "0"}, {Fixed: "1.5.3"}, {Introduced: "3.0.0"},
+			}}},
+			want: []*ar{{Fixed: "v1.5.3"}, {Introduced: "v2.0.0", Fixed: "v2.0.1"}, {Introduced: "v3.0.0"}},
+		},
+		{
+			name: "multiple ranges",
+			ranges: []osv.Range{
+				{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "1.2.0"}, {LastAffected: "1.4.0"}}},
+				{Type: osv.RangeTypeEcosystem, Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "2024-01-15"}}},
+				{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "1.0.1"}}},
+			},
+			want: []*ar{{Fixed: "v1.0.1"}, {Introduced: "v1.2.0", LastAffected: "v1.4.0"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			affected := []osv.Affected{
+				{Module: osv.Module{Path: "example.com/other"}, Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "0"}}}}},
+				{Module: osv.Module{Path: module}, Ranges: test.ranges},
+			}
+			got := AffectedRanges(module, affected)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
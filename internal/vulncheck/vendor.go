@@ -0,0 +1,94 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncheck
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/internal"
+)
+
+// resolveVersions sets the version of the modules of g that have
+// none, as happens in vendored builds, to the version pinned by the
+// main modules of the top-level packages, so that frames and fixed
+// versions of findings are accurate. Versions are looked up in the
+// vendor/modules.txt file of the main modules and then in the require
+// and replace directives of their go.mod files.
+func (g *PackageGraph) resolveVersions() {
+	var pinned map[string]string
+	for _, mod := range g.modules {
+		if mod.Version != "" || mod.Main || mod.Path == internal.GoStdModulePath || mod.Path == internal.UnknownModulePath {
+			continue
+		}
+		if pinned == nil {
+			pinned = pinnedVersions(g.topPkgs)
+		}
+		mod.Version = pinned[mod.Path]
+	}
+}
+
+// pinnedVersions returns the versions of modules, keyed by module
+// path, pinned by the main modules of pkgs. The versions listed in
+// vendor/modules.txt take precedence over those of go.mod.
+func pinnedVersions(pkgs []*packages.Package) map[string]string {
+	pinned := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, p := range pkgs {
+		if p.Module == nil || !p.Module.Main || p.Module.GoMod == "" || seen[p.Module.GoMod] {
+			continue
+		}
+		seen[p.Module.GoMod] = true
+		if data, err := os.ReadFile(p.Module.GoMod); err == nil {
+			if f, err := modfile.ParseLax(p.Module.GoMod, data, nil); err == nil {
+				for _, r := range f.Require {
+					pinned[r.Mod.Path] = r.Mod.Version
+				}
+				for _, r := range f.Replace {
+					if r.New.Version != "" {
+						pinned[r.New.Path] = r.New.Version
+					}
+				}
+			}
+		}
+		vendored := filepath.Join(filepath.Dir(p.Module.GoMod), "vendor", "modules.txt")
+		if data, err := os.ReadFile(vendored); err == nil {
+			vendorVersions(data, pinned)
+		}
+	}
+	return pinned
+}
+
+// vendorVersions adds to pinned the versions of the modules listed in
+// data, the contents of a vendor/modules.txt file. Modules are listed
+// on lines of the form
+//
+//	# path version
+//	# path version => replacement version
+//
+// where the version of the replaced module is omitted when all of its
+// versions are replaced, and the replacement has no version when it is
+// a directory.
+func vendorVersions(data []byte, pinned map[string]string) {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line, ok := strings.CutPrefix(s.Text(), "# ")
+		if !ok {
+			continue
+		}
+		mod, repl, _ := strings.Cut(line, "=>")
+		if f := strings.Fields(mod); len(f) == 2 {
+			pinned[f[0]] = f[1]
+		}
+		if f := strings.Fields(repl); len(f) == 2 {
+			pinned[f[0]] = f[1]
+		}
+	}
+}
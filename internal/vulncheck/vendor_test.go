@@ -0,0 +1,97 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncheck
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+func TestResolveVersions(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod": `module example.com/main
+
+go 1.22
+
+require (
+	example.com/a v1.0.0
+	example.com/b v1.1.0
+	example.com/c v1.2.0
+)
+
+replace example.com/c => example.com/d v1.3.0
+`,
+		// The vendored version of example.com/a
+		// takes precedence over the go.mod one.
+		"vendor/modules.txt": `# example.com/a v1.0.1
+## explicit; go 1.22
+example.com/a/p
+# example.com/b v1.1.0
+## explicit
+example.com/b
+# example.com/c v1.2.0 => example.com/d v1.3.0
+## explicit
+example.com/c
+`,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Packages loaded from vendor have modules without versions.
+	a := &packages.Package{PkgPath: "example.com/a/p", Module: &packages.Module{Path: "example.com/a"}}
+	b := &packages.Package{PkgPath: "example.com/b", Module: &packages.Module{Path: "example.com/b"}}
+	c := &packages.Package{PkgPath: "example.com/c", Module: &packages.Module{Path: "example.com/c", Replace: &packages.Module{Path: "example.com/d"}}}
+	main := &packages.Package{
+		PkgPath: "example.com/main",
+		Module:  &packages.Module{Path: "example.com/main", Main: true, GoMod: filepath.Join(dir, "go.mod")},
+		Imports: map[string]*packages.Package{a.PkgPath: a, b.PkgPath: b, c.PkgPath: c},
+	}
+	g := &PackageGraph{modules: map[string]*packages.Module{}, packages: map[string]*packages.Package{}}
+	g.AddPackages(main)
+	g.topPkgs = []*packages.Package{main}
+	g.resolveVersions()
+
+	var got []string
+	for _, p := range []*packages.Package{a, b, c} {
+		fr := frameFromPackage(p)
+		got = append(got, fr.Module+"@"+fr.Version)
+	}
+	want := []string{"example.com/a@v1.0.1", "example.com/b@v1.1.0", "example.com/d@v1.3.0"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("frames mismatch (-want, +got):\n%s", diff)
+	}
+	if v := main.Module.Version; v != "" {
+		t.Errorf("got version %q for the main module; want none", v)
+	}
+
+	// The fixed version of findings is computed from the resolved version.
+	affected := []osv.Affected{{
+		Module: osv.Module{Path: "example.com/a"},
+		Ranges: []osv.Range{{Type: osv.RangeTypeSemver, Events: []osv.RangeEvent{{Introduced: "0"}, {Fixed: "1.0.2"}}}},
+	}}
+	h := test.NewMockHandler()
+	vulns := []*Vuln{{OSV: &osv.Entry{ID: "GO-0000-0001", Affected: affected}, Package: a}}
+	if err := emitPackageFindings(context.Background(), h, &govulncheck.Config{}, vulns, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.FindingMessages[0].FixedVersion; got != "v1.0.2" {
+		t.Errorf("got fixed version %q; want v1.0.2", got)
+	}
+}
@@ -20,8 +20,14 @@ const (
 	fetchingVulnsMessage    = "Fetching vulnerabilities from the database..."
 	checkingSrcVulnsMessage = "Checking the code against the vulnerabilities..."
 	checkingBinVulnsMessage = "Checking the binary against the vulnerabilities..."
+
+	duplicateVulnMessage = "Reporting %s as %s, which it duplicates."
 )
 
+// TruncatedFindingsMessage is the format of the progress message
+// recording that findings were dropped because of MaxFindings.
+const TruncatedFindingsMessage = "Stopped reporting findings after the first %d of each level."
+
 // Result contains information on detected vulnerabilities.
 // For call graph analysis, it provides information on reachability
 // of vulnerable symbols through entry points of the program.
@@ -60,6 +66,14 @@ type Vuln struct {
 	// When the package of symbol is not imported, Package will be
 	// unavailable and set to nil.
 	Package *packages.Package
+
+	// PathCount is the number of call stacks reaching CallSink found
+	// when looking for its representative call stack, that is, the
+	// number of shortest call stacks from an entry function to
+	// CallSink, visiting each function once.
+	//
+	// It is zero when no call stack was computed for CallSink.
+	PathCount int
 }
 
 // A FuncNode describes a function in the call graph.
@@ -146,8 +160,9 @@ func affectingVulnerabilities(vulns []*ModVulns, os, arch string) affectingVulns
 				// were, say, reported in the same CVE. We filter such
 				// information out as it might lead to incorrect results:
 				// Computing a latest fix could consider versions of these
-				// different packages.
-				if a.Module.Path != module.Path {
+				// different packages. Information on the module replacing
+				// module, if any, is kept as it describes the code used.
+				if a.Module.Path != module.Path && (module.Replace == nil || a.Module.Path != module.Replace.Path) {
 					continue
 				}
 				if !affected(modVersion, a) {
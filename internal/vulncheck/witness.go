@@ -44,36 +44,47 @@ type StackEntry struct {
 // function or method in res.CallGraph.Entries. During this search,
 // each function is visited at most once to avoid potential
 // exponential explosion. Hence, not all call stacks are analyzed.
+// The number of call stacks found for each vulnerability is recorded
+// as its PathCount.
 func sourceCallstacks(res *Result) map[*Vuln]CallStack {
 	var (
 		wg sync.WaitGroup
 		mu sync.Mutex
 	)
 	stackPerVuln := make(map[*Vuln]CallStack)
+	countPerVuln := make(map[*Vuln]int)
 	for _, vuln := range res.Vulns {
 		vuln := vuln
 		wg.Add(1)
 		go func() {
-			cs := sourceCallstack(vuln, res)
+			cs, n := sourceCallstack(vuln, res)
 			mu.Lock()
 			stackPerVuln[vuln] = cs
+			countPerVuln[vuln] = n
 			mu.Unlock()
 			wg.Done()
 		}()
 	}
 	wg.Wait()
 
+	// Vulns are read by sourceCallstack, so
+	// only update them once all are done.
+	for vuln, n := range countPerVuln {
+		vuln.PathCount = n
+	}
 	updateInitPositions(stackPerVuln)
 	return stackPerVuln
 }
 
 // sourceCallstack finds a representative call stack for vuln.
 // This is a shortest unique call stack with the least
-// number of dynamic call sites.
-func sourceCallstack(vuln *Vuln, res *Result) CallStack {
+// number of dynamic call sites. It also returns the number
+// of candidate call stacks the representative one was
+// chosen from.
+func sourceCallstack(vuln *Vuln, res *Result) (CallStack, int) {
 	vulnSink := vuln.CallSink
 	if vulnSink == nil {
-		return nil
+		return nil, 0
 	}
 
 	entries := make(map[*FuncNode]bool)
@@ -160,9 +171,9 @@ func sourceCallstack(vuln *Vuln, res *Result) CallStack {
 		return true
 	})
 	if len(candidates) == 0 {
-		return nil
+		return nil, 0
 	}
-	return candidates[0]
+	return candidates[0], len(candidates)
 }
 
 // callsites picks a call site from sites for each non-visited function.
@@ -72,6 +72,43 @@ func TestSourceCallstacks(t *testing.T) {
 	}
 }
 
+func TestSourceCallstacksPathCount(t *testing.T) {
+	// Call graph structure for the test program
+	//    entry1  entry2  entry3
+	//       \      |      |
+	//        \     |   interm
+	//         \    |   /    \
+	//           vuln1      vuln2
+	o := &osv.Entry{ID: "o"}
+	e1 := &FuncNode{Name: "entry1"}
+	e2 := &FuncNode{Name: "entry2"}
+	e3 := &FuncNode{Name: "entry3"}
+	i := &FuncNode{Name: "interm", CallSites: []*CallSite{{Parent: e3}}}
+	v1 := &FuncNode{Name: "vuln1", CallSites: []*CallSite{{Parent: e1}, {Parent: e2}, {Parent: i}}}
+	v2 := &FuncNode{Name: "vuln2", CallSites: []*CallSite{{Parent: i}}}
+
+	vp := &packages.Package{PkgPath: "v1", Module: &packages.Module{Path: "m1"}}
+	vuln1 := &Vuln{CallSink: v1, Package: vp, OSV: o, Symbol: "vuln1"}
+	vuln2 := &Vuln{CallSink: v2, Package: vp, OSV: o, Symbol: "vuln2"}
+	vuln3 := &Vuln{Package: vp, OSV: o, Symbol: "vuln3"} // not called
+	res := &Result{
+		EntryFunctions: []*FuncNode{e1, e2, e3},
+		Vulns:          []*Vuln{vuln1, vuln2, vuln3},
+	}
+
+	sourceCallstacks(res)
+	// Only the shortest call stacks are counted, so the
+	// stack of vuln1 through interm is not.
+	for _, c := range []struct {
+		vuln *Vuln
+		want int
+	}{{vuln1, 2}, {vuln2, 1}, {vuln3, 0}} {
+		if c.vuln.PathCount != c.want {
+			t.Errorf("%s: want %d paths; got %d", c.vuln.Symbol, c.want, c.vuln.PathCount)
+		}
+	}
+}
+
 func TestSourceUniqueCallStack(t *testing.T) {
 	// Call graph structure for the test program
 	//    entry1      entry2
@@ -190,7 +227,7 @@ func TestInits(t *testing.T) {
 		t.Fatal("failed to load x test package")
 	}
 	cfg := &govulncheck.Config{ScanLevel: "symbol"}
-	result, err := source(context.Background(), test.NewMockHandler(), cfg, testClient, graph)
+	result, err := source(context.Background(), test.NewMockHandler(), cfg, testClient, graph, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,109 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncheck
+
+import (
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/vuln/internal/govulncheck"
+)
+
+// workspaceHandler is a Handler that records the workspace
+// modules from which findings originate.
+type workspaceHandler struct {
+	govulncheck.Handler
+	// mains are the paths of the workspace modules.
+	mains map[string]bool
+	// pkgImporters and modImporters are the workspace modules
+	// importing each package and module, keyed by path.
+	pkgImporters map[string]map[string]bool
+	modImporters map[string]map[string]bool
+}
+
+// markWorkspaceModule returns handler recording the workspace
+// module of findings, see govulncheck.Finding.WorkspaceModule,
+// or handler itself if the top-level packages of graph are not
+// in a workspace of several modules.
+func markWorkspaceModule(handler govulncheck.Handler, graph *PackageGraph) govulncheck.Handler {
+	mains := make(map[string]bool)
+	for _, p := range graph.TopPkgs() {
+		if p.Module != nil && p.Module.Main {
+			mains[p.Module.Path] = true
+		}
+	}
+	if len(mains) < 2 {
+		return handler
+	}
+	h := &workspaceHandler{
+		Handler:      handler,
+		mains:        mains,
+		pkgImporters: make(map[string]map[string]bool),
+		modImporters: make(map[string]map[string]bool),
+	}
+	// The packages imported by a workspace module are those
+	// reachable from its top-level packages, visited once
+	// per module.
+	seen := make(map[string]map[*packages.Package]bool)
+	var visit func(main string, p *packages.Package)
+	visit = func(main string, p *packages.Package) {
+		if seen[main][p] {
+			return
+		}
+		seen[main][p] = true
+		addImporter(h.pkgImporters, p.PkgPath, main)
+		addImporter(h.modImporters, modPath(p.Module), main)
+		for _, imp := range p.Imports {
+			visit(main, imp)
+		}
+	}
+	for _, p := range graph.TopPkgs() {
+		if p.Module == nil || !p.Module.Main {
+			continue
+		}
+		if seen[p.Module.Path] == nil {
+			seen[p.Module.Path] = make(map[*packages.Package]bool)
+		}
+		visit(p.Module.Path, p)
+	}
+	return h
+}
+
+func addImporter(importers map[string]map[string]bool, path, main string) {
+	if importers[path] == nil {
+		importers[path] = make(map[string]bool)
+	}
+	importers[path][main] = true
+}
+
+// Finding sets the WorkspaceModule of f, if known, and forwards it.
+func (h *workspaceHandler) Finding(f *govulncheck.Finding) error {
+	f.WorkspaceModule = h.workspaceModule(f)
+	return h.Handler.Finding(f)
+}
+
+// workspaceModule returns the workspace module from which f originates:
+// the module of its entry point if it is called, and the only workspace
+// module importing its vulnerable package or module otherwise.
+func (h *workspaceHandler) workspaceModule(f *govulncheck.Finding) string {
+	if len(f.Trace) == 0 {
+		return ""
+	}
+	if govulncheck.IsCalled(f) {
+		if entry := f.Trace[len(f.Trace)-1]; h.mains[entry.Module] {
+			return entry.Module
+		}
+		return ""
+	}
+	importers := h.modImporters[f.Trace[0].Module]
+	if pkg := f.Trace[0].Package; pkg != "" {
+		importers = h.pkgImporters[pkg]
+	}
+	if len(importers) != 1 {
+		return ""
+	}
+	for main := range importers {
+		return main
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vulncheck
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages/packagestest"
+	"golang.org/x/vuln/internal/client"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/test"
+)
+
+// TestWorkspaceModule checks the workspace modules of the findings
+// of a workspace of two modules, a calling vuln.V and b importing
+// vuln, without calling V, and other:
+//
+//	a   b
+//	|  / \
+//	vuln  other
+func TestWorkspaceModule(t *testing.T) {
+	e := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+		{
+			Name: "example.com/a",
+			Files: map[string]interface{}{"a.go": `
+			package a
+
+			import "example.com/vuln"
+
+			func A() { vuln.V() }
+			`},
+		},
+		{
+			Name: "example.com/vuln@v1.0.0",
+			Files: map[string]interface{}{"vuln.go": `
+			package vuln
+
+			func V() {}
+
+			func W() {}
+			`},
+		},
+		{
+			Name: "example.com/other@v1.0.0",
+			Files: map[string]interface{}{"other.go": `
+			package other
+
+			func O() {}
+
+			func Safe() {}
+			`},
+		},
+	})
+	defer e.Cleanup()
+
+	// Module b shares the requirements of the exported module a,
+	// which requires all other exported modules.
+	root := e.Temp()
+	gomod, err := os.ReadFile(filepath.Join(root, "a", "go.mod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := modfile.ParseLax("go.mod", gomod, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gosum, err := os.ReadFile(filepath.Join(root, "a", "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"go.work":  fmt.Sprintf("go %s\n\nuse (\n\t./a\n\t./b\n)\n", f.Go.Version),
+		"b/go.mod": fmt.Sprintf("module example.com/b\n\ngo %s\n\nrequire (\n\texample.com/other v1.0.0\n\texample.com/vuln v1.0.0\n)\n", f.Go.Version),
+		"b/go.sum": string(gosum),
+		"b/b.go": `package b
+
+import (
+	"example.com/other"
+	"example.com/vuln"
+)
+
+func B() { vuln.W(); other.Safe() }
+`,
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The -mod flag of the exported config is not
+	// allowed in workspace mode.
+	e.Config.Dir = root
+	e.Config.Env = append(e.Config.Env, "GOFLAGS=")
+	graph := NewPackageGraph("go1.22")
+	if err := graph.LoadPackagesAndMods(e.Config, nil, []string{"./a", "./b"}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	affected := func(mod, sym string) []osv.Affected {
+		return []osv.Affected{{
+			Module:            osv.Module{Path: mod},
+			Ranges:            []osv.Range{{Type: osv.RangeTypeSemver}},
+			EcosystemSpecific: osv.EcosystemSpecific{Packages: []osv.Package{{Path: mod, Symbols: []string{sym}}}},
+		}}
+	}
+	c, err := client.NewInMemoryClient([]*osv.Entry{
+		{ID: "GO-0000-0001", Affected: affected("example.com/vuln", "V")},
+		{ID: "GO-0000-0002", Affected: affected("example.com/other", "O")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := test.NewMockHandler()
+	if err := Source(context.Background(), h, &govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}, c, graph); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, f := range h.FindingMessages {
+		level := govulncheck.ScanLevelModule
+		switch {
+		case govulncheck.IsCalled(f):
+			level = govulncheck.ScanLevelSymbol
+		case govulncheck.IsImported(f):
+			level = govulncheck.ScanLevelPackage
+		}
+		got[fmt.Sprintf("%s %s", f.OSV, level)] = f.WorkspaceModule
+	}
+	want := map[string]string{
+		// Both workspace modules import vuln,
+		// but only a calls it.
+		"GO-0000-0001 module":  "",
+		"GO-0000-0001 package": "",
+		"GO-0000-0001 symbol":  "example.com/a",
+		// Only b imports other.
+		"GO-0000-0002 module":  "example.com/b",
+		"GO-0000-0002 package": "example.com/b",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("workspace modules mismatch (-want, +got):\n%s", diff)
+	}
+}
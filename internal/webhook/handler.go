@@ -0,0 +1,200 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package webhook implements a govulncheck handler
+// reporting called vulnerabilities to a webhook.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+)
+
+// defaultTimeout bounds the time spent posting to
+// the webhook when no client is provided.
+const defaultTimeout = 30 * time.Second
+
+// Payload is the JSON body posted to the webhook.
+type Payload struct {
+	// Config is the configuration of the scan.
+	Config *govulncheck.Config `json:"config,omitempty"`
+
+	// OSV holds the entries of the vulnerabilities of Findings.
+	OSV []*osv.Entry `json:"osv"`
+
+	// Findings are the called findings of the scan.
+	Findings []*govulncheck.Finding `json:"findings"`
+}
+
+// handler is a Handler that forwards messages to an underlying
+// handler and holds called findings until Flush, where they are
+// posted to the webhook.
+type handler struct {
+	h      govulncheck.Handler
+	url    string
+	auth   string
+	client *http.Client
+
+	cfg      *govulncheck.Config
+	osvs     map[string]*osv.Entry
+	findings []*govulncheck.Finding
+	mu       sync.Mutex // guards findings, as they may be concurrent
+}
+
+// NewHandler returns a handler that forwards messages to h and, on
+// Flush, posts the called findings as a Payload to url in a single
+// request. If auth is not empty, it is sent as the value of the
+// Authorization header. If client is nil, a client timing out after
+// defaultTimeout is used.
+//
+// No request is made during the scan, so a slow or failing webhook
+// does not hold up the scan. Errors posting to the webhook are
+// returned by Flush after flushing h.
+func NewHandler(h govulncheck.Handler, url, auth string, client *http.Client) govulncheck.Handler {
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &handler{
+		h:      h,
+		url:    url,
+		auth:   auth,
+		client: client,
+		osvs:   make(map[string]*osv.Entry),
+	}
+}
+
+func (w *handler) Config(config *govulncheck.Config) error {
+	w.cfg = config
+	return w.h.Config(config)
+}
+
+func (w *handler) SBOM(sbom *govulncheck.SBOM) error {
+	return w.h.SBOM(sbom)
+}
+
+func (w *handler) Progress(progress *govulncheck.Progress) error {
+	return w.h.Progress(progress)
+}
+
+func (w *handler) OSV(entry *osv.Entry) error {
+	w.osvs[entry.ID] = entry
+	return w.h.OSV(entry)
+}
+
+// Finding forwards finding to the underlying handler and
+// keeps it for the webhook if the vulnerability is called.
+func (w *handler) Finding(finding *govulncheck.Finding) error {
+	if govulncheck.IsCalled(finding) {
+		w.mu.Lock()
+		w.findings = append(w.findings, finding)
+		w.mu.Unlock()
+	}
+	return w.h.Finding(finding)
+}
+
+// Concurrent reports whether the underlying handler is concurrent.
+func (w *handler) Concurrent() bool {
+	return govulncheck.Concurrent(w.h)
+}
+
+// Streaming reports whether the underlying handler is streaming.
+func (w *handler) Streaming() bool {
+	return govulncheck.Streaming(w.h)
+}
+
+// Flush flushes the underlying handler, if it supports flushing,
+// and then posts the called findings to the webhook, if any. The
+// error of flushing the underlying handler, which may carry an exit
+// code, like that of the text output when vulnerabilities are found,
+// is returned as is unless posting fails.
+func (w *handler) Flush() error {
+	var err error
+	if f, ok := w.h.(interface{ Flush() error }); ok {
+		err = f.Flush()
+	}
+	if len(w.findings) > 0 {
+		if perr := w.post(); perr != nil {
+			err = flushError(perr, err)
+		}
+	}
+	w.findings = nil
+	return err
+}
+
+// postError is the error of posting to the webhook after the
+// underlying handler was flushed with err, which is kept so that
+// its exit code, if any, can be recovered with errors.As.
+type postError struct {
+	post error
+	err  error
+}
+
+// flushError returns the error of Flush when posting failed with post
+// and flushing the underlying handler returned err.
+func flushError(post, err error) error {
+	if err == nil {
+		return post
+	}
+	return &postError{post: post, err: err}
+}
+
+func (e *postError) Error() string {
+	return e.post.Error()
+}
+
+func (e *postError) Unwrap() []error {
+	return []error{e.post, e.err}
+}
+
+func (w *handler) Failure(f *govulncheck.Failure) error {
+	return govulncheck.Fail(w.h, f)
+}
+
+// post sends the findings held by w to the webhook, sorted by
+// their identity so that the payload does not depend on the
+// order in which findings were delivered.
+func (w *handler) post() error {
+	sort.SliceStable(w.findings, func(i, j int) bool {
+		return govulncheck.Identity(w.findings[i], govulncheck.ScanLevelSymbol) < govulncheck.Identity(w.findings[j], govulncheck.ScanLevelSymbol)
+	})
+	p := Payload{Config: w.cfg, Findings: w.findings}
+	seen := make(map[string]bool)
+	for _, f := range w.findings {
+		if e := w.osvs[f.OSV]; e != nil && !seen[f.OSV] {
+			seen[f.OSV] = true
+			p.OSV = append(p.OSV, e)
+		}
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.auth != "" {
+		req.Header.Set("Authorization", w.auth)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // allow reuse of the connection
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: posting findings to %s: %s", w.url, resp.Status)
+	}
+	return nil
+}
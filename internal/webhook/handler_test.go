@@ -0,0 +1,164 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/vuln/internal/govulncheck"
+	"golang.org/x/vuln/internal/osv"
+	"golang.org/x/vuln/internal/sarif"
+)
+
+func TestHandler(t *testing.T) {
+	var (
+		gotAuth    string
+		gotPayload Payload
+		requests   int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		gotAuth = r.Header.Get("Authorization")
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("want Content-Type application/json; got %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&gotPayload); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	h := NewHandler(govulncheck.NewJSONHandler(&buf), srv.URL, "Bearer token", srv.Client())
+	called := &govulncheck.Finding{
+		OSV:   "GO-0000-0001",
+		Trace: []*govulncheck.Frame{{Module: "m", Package: "m/p", Function: "F"}},
+	}
+	imported := &govulncheck.Finding{
+		OSV:   "GO-0000-0002",
+		Trace: []*govulncheck.Frame{{Module: "m", Package: "m/p"}},
+	}
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"GO-0000-0001", "GO-0000-0002"} {
+		if err := h.OSV(&osv.Entry{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []*govulncheck.Finding{called, imported, called} {
+		if err := h.Finding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if requests != 0 {
+		t.Fatalf("want no requests before Flush; got %d", requests)
+	}
+	if err := h.(interface{ Flush() error }).Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests != 1 {
+		t.Errorf("want 1 request; got %d", requests)
+	}
+	if gotAuth != "Bearer token" {
+		t.Errorf("want Authorization %q; got %q", "Bearer token", gotAuth)
+	}
+	want := Payload{
+		Config:   &govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol},
+		OSV:      []*osv.Entry{{ID: "GO-0000-0001"}},
+		Findings: []*govulncheck.Finding{called, called},
+	}
+	if diff := cmp.Diff(want, gotPayload); diff != "" {
+		t.Errorf("payload mismatch (-want, +got):\n%s", diff)
+	}
+	// All messages still reach the underlying handler.
+	if n := strings.Count(buf.String(), `"finding"`); n != 3 {
+		t.Errorf("want 3 findings forwarded; got %d", n)
+	}
+}
+
+func TestHandlerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	h := NewHandler(govulncheck.NewJSONHandler(&bytes.Buffer{}), srv.URL, "", srv.Client())
+	f := &govulncheck.Finding{
+		OSV:   "GO-0000-0001",
+		Trace: []*govulncheck.Frame{{Module: "m", Package: "m/p", Function: "F"}},
+	}
+	if err := h.Finding(f); err != nil {
+		t.Fatalf("want no error during the scan; got %v", err)
+	}
+	err := h.(interface{ Flush() error }).Flush()
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("want 401 error on Flush; got %v", err)
+	}
+}
+
+func TestHandlerConcurrent(t *testing.T) {
+	var gotPayload Payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&gotPayload); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	if h := NewHandler(govulncheck.NewJSONHandler(io.Discard), srv.URL, "", srv.Client()); govulncheck.Concurrent(h) {
+		t.Error("want the handler to be concurrent only if the underlying handler is")
+	}
+	h := NewHandler(sarif.NewHandler(io.Discard), srv.URL, "", srv.Client())
+	if !govulncheck.Concurrent(h) {
+		t.Fatal("want a concurrent handler")
+	}
+	if err := h.Config(&govulncheck.Config{ScanLevel: govulncheck.ScanLevelSymbol}); err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for i := range 20 {
+		id := fmt.Sprintf("GO-0000-%04d", i)
+		if err := h.OSV(&osv.Entry{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, id)
+	}
+	var wg sync.WaitGroup
+	for i := range want {
+		id := want[len(want)-1-i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.Finding(&govulncheck.Finding{
+				OSV:   id,
+				Trace: []*govulncheck.Frame{{Module: "m", Package: "m/p", Function: "F"}},
+			})
+		}()
+	}
+	wg.Wait()
+	if err := h.(interface{ Flush() error }).Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Findings are posted in the same order, however delivered.
+	var got []string
+	for _, f := range gotPayload.Findings {
+		got = append(got, f.OSV)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("findings mismatch (-want, +got):\n%s", diff)
+	}
+}